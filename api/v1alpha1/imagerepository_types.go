@@ -33,8 +33,48 @@ type ImageRepositorySpec struct {
 	// Notifications defines configuration for image repository notifications.
 	// +optional
 	Notifications []Notifications `json:"notifications,omitempty"`
+
+	// NudgeTargets lists names of Components in the same namespace that build from this image.
+	// Their rebuild is requested when the image repository url or credentials change.
+	// +optional
+	NudgeTargets []string `json:"nudgeTargets,omitempty"`
+
+	// Signing records the cosign public key the images in the repository are signed with.
+	// The key is published in a ConfigMap, so verification policies could be generated from it.
+	// +optional
+	Signing *SigningConfiguration `json:"signing,omitempty"`
+
+	// Access defines permissions to the image repository required by existing accounts of the Quay organization.
+	// +optional
+	Access *ImageAccess `json:"access,omitempty"`
+}
+
+// ImageAccess defines permissions required by existing accounts of the Quay organization.
+type ImageAccess struct {
+	// Robots lists existing robot accounts, e.g. organization wide robot accounts used by pipelines for SBOM upload,
+	// that need at least the given role in the image repository.
+	// +optional
+	Robots []RobotAccess `json:"robots,omitempty"`
 }
 
+// RobotAccess describes minimum role of existing robot account in the image repository.
+type RobotAccess struct {
+	// Name of the robot account, the organization prefix is optional, i.e. my_robot or my-org+my_robot.
+	Name string `json:"name"`
+	// Role the robot account needs at least, "read" by default. Higher roles granted otherwise are kept.
+	// +optional
+	Role RobotAccessRole `json:"role,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=read;write;admin
+type RobotAccessRole string
+
+const (
+	RobotAccessRoleRead  RobotAccessRole = "read"
+	RobotAccessRoleWrite RobotAccessRole = "write"
+	RobotAccessRoleAdmin RobotAccessRole = "admin"
+)
+
 // ImageParameters describes requested image repository configuration.
 type ImageParameters struct {
 	// Name of the image within configured Quay organization.
@@ -46,9 +86,77 @@ type ImageParameters struct {
 
 	// Visibility defines whether the image is publicly visible.
 	// Allowed values are public and private.
-	// "public" is the default.
+	// The default is configured in the controller, "public" unless changed.
 	// +optional
 	Visibility ImageVisibility `json:"visibility,omitempty"`
+
+	// Kind of the repository in Quay.
+	// Allowed values are image, for container images, and application, e.g. for Helm charts.
+	// Defaults to image. This field cannot be changed after the resource creation.
+	// +optional
+	Kind ImageRepositoryKind `json:"kind,omitempty"`
+
+	// Shared allows several ImageRepository objects to manage the same image repository.
+	// Each of them gets own robot accounts and secrets.
+	// Has effect only if set on all ImageRepository objects that point to the image repository.
+	// +optional
+	Shared bool `json:"shared,omitempty"`
+
+	// ReadmeRef defines markdown content that is kept in sync with the image repository description in Quay.
+	// +optional
+	ReadmeRef *ReadmeReference `json:"readmeRef,omitempty"`
+
+	// Description is a short summary shown in Quay instead of the default image repository description.
+	// Changes are propagated to Quay. Ignored if readmeRef is set.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// TrackLatest enables periodic lookup of the most recently pushed tag,
+	// that is shown in status.image.latestTag and status.image.latestDigest.
+	// The tags are checked on a slow resync to limit Quay API load.
+	// +optional
+	TrackLatest bool `json:"trackLatest,omitempty"`
+
+	// VisibilitySchedule switches the image repository visibility for a limited window,
+	// e.g. to make it public for a release. Visibility is reverted once the window ends.
+	// +optional
+	VisibilitySchedule *VisibilitySchedule `json:"visibilitySchedule,omitempty"`
+}
+
+// VisibilitySchedule defines window of temporary visibility.
+// The window ends at End, or Duration after the start. Exactly one of them must be set.
+type VisibilitySchedule struct {
+	// Visibility of the image repository within the window.
+	Visibility ImageVisibility `json:"visibility"`
+	// Start of the window. The window starts once the schedule is observed, if not set.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+	// End of the window.
+	// +optional
+	End *metav1.Time `json:"end,omitempty"`
+	// Duration of the window, e.g. 4h.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ReadmeReference points to the image repository readme content.
+// Exactly one of the fields must be set.
+type ReadmeReference struct {
+	// Inline holds the readme markdown directly.
+	// +optional
+	Inline string `json:"inline,omitempty"`
+
+	// ConfigMapKeyRef selects a key of a ConfigMap in the ImageRepository namespace that holds the readme markdown.
+	// +optional
+	ConfigMapKeyRef *ConfigMapKeyReference `json:"configMapKeyRef,omitempty"`
+}
+
+// ConfigMapKeyReference selects a key of a ConfigMap in the same namespace.
+type ConfigMapKeyReference struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+	// Key within the ConfigMap data.
+	Key string `json:"key"`
 }
 
 // +kubebuilder:validation:Enum=public;private
@@ -59,13 +167,145 @@ const (
 	ImageVisibilityPrivate ImageVisibility = "private"
 )
 
+// +kubebuilder:validation:Enum=image;application
+type ImageRepositoryKind string
+
+const (
+	ImageRepositoryKindImage       ImageRepositoryKind = "image"
+	ImageRepositoryKindApplication ImageRepositoryKind = "application"
+)
+
 type ImageCredentials struct {
 	// RegenerateToken defines a request to refresh image accessing credentials.
 	// Refreshes both, push and pull tokens.
 	// The field gets cleared after the refresh.
 	RegenerateToken *bool `json:"regenerate-token,omitempty"`
+
+	// Deprovision requests removal of push and pull robot accounts and secrets, while the image repository is kept.
+	// Setting it back to false provisions new credentials.
+	// +optional
+	Deprovision bool `json:"deprovision,omitempty"`
+
+	// ExternalConsumers requests additional secrets with credentials in other namespaces, e.g. for GitOps tools.
+	// Each consumer gets dedicated robot account.
+	// The target namespace must allow it, see image-controller.appstudio.redhat.com/allowed-consumers annotation.
+	// +optional
+	ExternalConsumers []ExternalConsumer `json:"externalConsumers,omitempty"`
+
+	// ExistingPushSecretRef references dockerconfigjson Secret in the ImageRepository namespace with push credentials
+	// managed outside of the controller, e.g. synced from Vault by external-secrets operator.
+	// No push robot account is created, the Secret is linked to the build pipeline service account, but never modified.
+	// Must be set upon the ImageRepository creation.
+	// +optional
+	ExistingPushSecretRef *SecretReference `json:"existingPushSecretRef,omitempty"`
+
+	// ExistingPullSecretRef references externally managed dockerconfigjson Secret with pull credentials,
+	// used instead of the generated pull robot account and Secret of ImageRepositories linked to a Component.
+	// Must be set upon the ImageRepository creation.
+	// +optional
+	ExistingPullSecretRef *SecretReference `json:"existingPullSecretRef,omitempty"`
+
+	// AdditionalAccounts grants access to the image repository to existing Quay users or robot accounts,
+	// e.g. for teams working with the images outside of the cluster.
+	// Access of accounts removed from the list is revoked.
+	// +optional
+	AdditionalAccounts []AdditionalAccount `json:"additionalAccounts,omitempty"`
+
+	// PerServiceAccountSecrets requests dedicated pull secret for each linked Application service account,
+	// instead of one pull secret shared by all of them. Applies to ImageRepositories linked to a Component.
+	// +optional
+	PerServiceAccountSecrets *PerServiceAccountSecrets `json:"perServiceAccountSecrets,omitempty"`
+
+	// SecretLinking overrides the cluster default of which service account lists the push and pull secrets are linked to.
+	// +optional
+	SecretLinking *SecretLinking `json:"secretLinking,omitempty"`
+}
+
+// SecretLinking defines service account lists the generated secrets are linked to.
+// Fields that are not set are taken from the cluster default.
+type SecretLinking struct {
+	// PushSecret defines lists of the build pipeline service account the push secret is linked to.
+	// +optional
+	PushSecret PushSecretLinking `json:"pushSecret,omitempty"`
+	// PullSecret defines service accounts the pull secret of ImageRepository linked to a Component is linked to.
+	// +optional
+	PullSecret PullSecretLinking `json:"pullSecret,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=all;secrets;none
+type PushSecretLinking string
+
+const (
+	// PushSecretLinkingAll links the push secret to both, secrets and imagePullSecrets lists.
+	PushSecretLinkingAll PushSecretLinking = "all"
+	// PushSecretLinkingSecrets links the push secret to secrets list only.
+	PushSecretLinkingSecrets PushSecretLinking = "secrets"
+	PushSecretLinkingNone    PushSecretLinking = "none"
+)
+
+// +kubebuilder:validation:Enum=application;all;none
+type PullSecretLinking string
+
+const (
+	// PullSecretLinkingApplication links the pull secret to imagePullSecrets of the Application service accounts.
+	PullSecretLinkingApplication PullSecretLinking = "application"
+	// PullSecretLinkingAll links the pull secret to imagePullSecrets of the Application service accounts
+	// and service accounts of the Component, i.e. labelled with appstudio.redhat.com/component label.
+	PullSecretLinkingAll  PullSecretLinking = "all"
+	PullSecretLinkingNone PullSecretLinking = "none"
+)
+
+// PerServiceAccountSecrets configures pull secrets dedicated to service accounts.
+type PerServiceAccountSecrets struct {
+	// SharedRobotAccount makes the secrets hold credentials of the shared pull robot account,
+	// so the access of one service account cannot be revoked independently.
+	// By default each service account gets its own pull robot account.
+	// +optional
+	SharedRobotAccount bool `json:"sharedRobotAccount,omitempty"`
+}
+
+// AdditionalAccount describes existing Quay account, that is granted access to the image repository.
+type AdditionalAccount struct {
+	// Name of the Quay user, or full name of the robot account, e.g. my-org+my_robot.
+	Name string `json:"name"`
+	// Role of the account in the image repository, "read" by default.
+	// +optional
+	Role AdditionalAccountRole `json:"role,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=read;write
+type AdditionalAccountRole string
+
+const (
+	AdditionalAccountRoleRead  AdditionalAccountRole = "read"
+	AdditionalAccountRoleWrite AdditionalAccountRole = "write"
+)
+
+// SecretReference points to a Secret in the same namespace.
+type SecretReference struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+}
+
+// ExternalConsumer describes secret requested in other namespace.
+type ExternalConsumer struct {
+	// Namespace where the secret is created.
+	Namespace string `json:"namespace"`
+	// SecretName is name of the created dockerconfig secret.
+	SecretName string `json:"secretName"`
+	// Role defines access of the consumer to the image repository.
+	// Only "pull" is allowed, which is the default.
+	// +optional
+	Role ExternalConsumerRole `json:"role,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=pull
+type ExternalConsumerRole string
+
+const (
+	ExternalConsumerRolePull ExternalConsumerRole = "pull"
+)
+
 type Notifications struct {
 	Title string `json:"title,omitempty"`
 	// +kubebuilder:validation:Enum=repo_push
@@ -95,6 +335,30 @@ type NotificationConfig struct {
 	// Webhook is the URL to send notifications to.
 	// +optional
 	Url string `json:"url,omitempty"`
+	// SecretRef selects a key of a Secret in the ImageRepository namespace, e.g. with a webhook token.
+	// The value replaces {secret} placeholder in the webhook url, so credentials are not stored in the ImageRepository.
+	// The Secret must have appstudio.redhat.com/image-notification-secret label set to "true".
+	// +optional
+	SecretRef *SecretKeyReference `json:"secretRef,omitempty"`
+}
+
+// SecretKeyReference selects a key of a Secret in the same namespace.
+type SecretKeyReference struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+	// Key within the Secret data.
+	Key string `json:"key"`
+}
+
+// SigningConfiguration describes how the images in the repository are signed.
+type SigningConfiguration struct {
+	// PublicKeySecretRef selects a key of a Secret in the ImageRepository namespace with PEM encoded cosign public key.
+	PublicKeySecretRef SecretKeyReference `json:"publicKeySecretRef"`
+
+	// PublishToQuay adds the public key fingerprint to the image repository description in Quay,
+	// so the key could be verified also by consumers without access to the cluster.
+	// +optional
+	PublishToQuay bool `json:"publishToQuay,omitempty"`
 }
 
 // ImageRepositoryStatus defines the observed state of ImageRepository
@@ -118,8 +382,124 @@ type ImageRepositoryStatus struct {
 	// Notifications shows the status of the notifications configuration.
 	// +optional
 	Notifications []NotificationStatus `json:"notifications,omitempty"`
+
+	// Conditions represent the latest available observations of the image repository state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Provision shows information about failed provision attempts.
+	// +optional
+	Provision ProvisionStatus `json:"provision,omitempty"`
+
+	// LastFailureCorrelationID is the correlation ID of the last failed reconcile.
+	// It's logged by the controller as reconcileID and sent to Quay in X-Request-Id header.
+	// +optional
+	LastFailureCorrelationID string `json:"lastFailureCorrelationId,omitempty"`
+
+	// NudgeRevision identifies the image repository url and credentials, dependent Components were last nudged about.
+	// +optional
+	NudgeRevision string `json:"nudgeRevision,omitempty"`
+
+	// Signing shows the published cosign public key.
+	// +optional
+	Signing *SigningStatus `json:"signing,omitempty"`
+
+	// Operations shows the last provisioning, credentials and cleanup operations done by the controller, the newest last.
+	// +optional
+	Operations []OperationRecord `json:"operations,omitempty"`
+
+	// Access shows permissions granted to the image repository by the operator.
+	// +optional
+	Access *AccessStatus `json:"access,omitempty"`
+
+	// Archive shows progress of the image repository archive into the archive organization on deletion.
+	// +optional
+	Archive *ArchiveStatus `json:"archive,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=Succeeded;Failed
+type OperationResult string
+
+const (
+	OperationResultSucceeded OperationResult = "Succeeded"
+	OperationResultFailed    OperationResult = "Failed"
+)
+
+// OperationRecord shows an operation done by the controller on the image repository.
+type OperationRecord struct {
+	// Operation is the name of the operation, e.g. Provision.
+	Operation string `json:"operation"`
+	// Result shows whether the operation succeeded.
+	Result OperationResult `json:"result"`
+	// Timestamp shows when the operation finished.
+	Timestamp metav1.Time `json:"timestamp"`
+	// CorrelationID is the correlation ID of the reconcile that did the operation, see LastFailureCorrelationID.
+	// +optional
+	CorrelationID string `json:"correlationId,omitempty"`
+	// Message shows the error of a failed operation.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SigningStatus describes the published cosign public key.
+type SigningStatus struct {
+	// PublicKeyFingerprint is hex encoded sha256 digest of the DER encoded public key.
+	PublicKeyFingerprint string `json:"publicKeyFingerprint,omitempty"`
+
+	// ConfigMapName is the name of the ConfigMap the public key is published in.
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// PublishedToQuay shows whether the public key fingerprint is in the image repository description.
+	// +optional
+	PublishedToQuay bool `json:"publishedToQuay,omitempty"`
+}
+
+// ArchiveStatus shows progress of the image repository archive.
+type ArchiveStatus struct {
+	// Repository is the name of the archive repository in the archive organization.
+	Repository string `json:"repository"`
+
+	// Steps lists archive steps already done, so they are not repeated when the archive is retried.
+	// +optional
+	Steps []string `json:"steps,omitempty"`
+}
+
+// ProvisionStatus shows information about failed provision attempts.
+type ProvisionStatus struct {
+	// Attempts is the number of failed provision attempts.
+	Attempts int `json:"attempts,omitempty"`
+
+	// LastErrorClass shows whether the last error is "transient", so provision is retried, or "permanent".
+	LastErrorClass ProvisionErrorClass `json:"lastErrorClass,omitempty"`
+
+	// LastError is the error of the last failed provision attempt.
+	LastError string `json:"lastError,omitempty"`
+
+	// StartTimestamp shows when the current provision attempt started.
+	// Present only if provision timeout is configured in the controller.
+	// +optional
+	StartTimestamp *metav1.Time `json:"startTimestamp,omitempty"`
+}
+
+type ProvisionErrorClass string
+
+const (
+	ProvisionErrorClassTransient ProvisionErrorClass = "transient"
+	ProvisionErrorClassPermanent ProvisionErrorClass = "permanent"
+)
+
+// +kubebuilder:validation:Enum=Provision;RegenerateToken;Reprovision;LegacyAdoption
+type CredentialsRotationReason string
+
+const (
+	CredentialsRotationReasonProvision       CredentialsRotationReason = "Provision"
+	CredentialsRotationReasonRegenerateToken CredentialsRotationReason = "RegenerateToken"
+	CredentialsRotationReasonReprovision     CredentialsRotationReason = "Reprovision"
+	CredentialsRotationReasonLegacyAdoption  CredentialsRotationReason = "LegacyAdoption"
+)
+
 type ImageRepositoryState string
 
 const (
@@ -127,6 +507,48 @@ const (
 	ImageRepositoryStateFailed ImageRepositoryState = "failed"
 )
 
+const (
+	// ConditionTypeSimulated is set when the controller runs in global dry-run mode.
+	// It means that the changes shown in the status were not applied to Quay nor to the cluster.
+	ConditionTypeSimulated = "Simulated"
+
+	// ConditionTypePendingQuota is set on a private ImageRepository that waits for Quay organization plan
+	// to allow more private repositories. The image repository is created once the quota allows it.
+	ConditionTypePendingQuota = "PendingQuota"
+
+	// ConditionTypeCredentialsRemoved is set when push and pull credentials were removed on request,
+	// see ImageCredentials.Deprovision.
+	ConditionTypeCredentialsRemoved = "CredentialsRemoved"
+
+	// ConditionTypeDuplicateOf is set on an ImageRepository that points to the same image repository
+	// as an older ImageRepository object. The condition message contains name of the older object.
+	ConditionTypeDuplicateOf = "DuplicateOf"
+
+	// ConditionTypeVisibilityDrift is set when the image repository visibility was changed directly in Quay,
+	// e.g. in Quay UI. The condition reason tells whether the change was accepted or reverted.
+	ConditionTypeVisibilityDrift = "VisibilityDrift"
+
+	// ConditionTypePushBlocked is set when Quay rejects pushes into the image repository,
+	// e.g. because it was made read-only by quota enforcement. The condition reason tells the repository state.
+	ConditionTypePushBlocked = "PushBlocked"
+
+	// ConditionTypeVisibilityScheduled is set when spec.image.visibilitySchedule is defined.
+	// It's true while the scheduled visibility is applied, the condition reason tells the window state.
+	ConditionTypeVisibilityScheduled = "VisibilityScheduled"
+
+	// ConditionTypeOwnershipConflict is set when a secret with the name of a generated secret exists,
+	// is not managed by the controller and cannot be updated. The condition message contains the secret name.
+	ConditionTypeOwnershipConflict = "OwnershipConflict"
+
+	// ConditionTypeApplicationMismatch is set when the Component given by the component label
+	// doesn't belong to the Application given by the application label. The image repository is not provisioned then.
+	ConditionTypeApplicationMismatch = "ApplicationMismatch"
+
+	// ConditionTypeComponentMissing is set when the Component given by the component label doesn't exist.
+	// The provision is retried once the Component is created.
+	ConditionTypeComponentMissing = "ComponentMissing"
+)
+
 // ImageStatus shows actual generated image repository parameters.
 type ImageStatus struct {
 	// URL is the full image repository url to push into / pull from.
@@ -135,6 +557,66 @@ type ImageStatus struct {
 	// Visibility shows actual generated image repository visibility.
 	// +kubebuilder:validation:Enum=public;private
 	Visibility ImageVisibility `json:"visibility,omitempty"`
+
+	// Kind shows actual kind of the repository in Quay.
+	// +optional
+	Kind ImageRepositoryKind `json:"kind,omitempty"`
+
+	// ReadmeDigest is sha256 digest of the readme content last pushed into the image repository description.
+	// +optional
+	ReadmeDigest string `json:"readmeDigest,omitempty"`
+
+	// RequestedName is present only if the requested image repository name was too long and got shortened.
+	// Holds the original requested name.
+	RequestedName string `json:"requested-name,omitempty"`
+
+	// LatestTag is the most recently pushed tag, present only if spec.image.trackLatest is set.
+	// +optional
+	LatestTag string `json:"latestTag,omitempty"`
+
+	// LatestDigest is the manifest digest of the latest tag, the image could be pinned by url@latestDigest.
+	// +optional
+	LatestDigest string `json:"latestDigest,omitempty"`
+
+	// LatestTagCheckTimestamp shows when the latest tag was checked last time.
+	// +optional
+	LatestTagCheckTimestamp *metav1.Time `json:"latestTagCheckTimestamp,omitempty"`
+
+	// VisibilityCheckTimestamp shows when the visibility was compared with the image repository in Quay last time.
+	// +optional
+	VisibilityCheckTimestamp *metav1.Time `json:"visibilityCheckTimestamp,omitempty"`
+
+	// StateCheckTimestamp shows when the image repository state was checked in Quay last time.
+	// +optional
+	StateCheckTimestamp *metav1.Time `json:"stateCheckTimestamp,omitempty"`
+
+	// VisibilitySchedule shows the resolved window of the requested visibility schedule.
+	// +optional
+	VisibilitySchedule *VisibilityScheduleStatus `json:"visibilitySchedule,omitempty"`
+}
+
+// VisibilityScheduleStatus shows the resolved window of temporary visibility.
+type VisibilityScheduleStatus struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+}
+
+// CredentialsRotationProgress shows partial completion of a token rotation request.
+type CredentialsRotationProgress struct {
+	// Generation is the ImageRepository generation, token rotation request of which is in progress.
+	Generation int64 `json:"generation"`
+
+	// Rotated lists the credentials rotated so far: push, pull, externalConsumers or serviceAccountSecrets.
+	// +optional
+	Rotated []string `json:"rotated,omitempty"`
+
+	// Pending lists the credentials not rotated yet.
+	// +optional
+	Pending []string `json:"pending,omitempty"`
+
+	// Message tells why the rotation didn't finish.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // CredentialsStatus shows information about generated image repository credentials.
@@ -142,6 +624,20 @@ type CredentialsStatus struct {
 	// GenerationTime shows timestamp when the current credentials were generated.
 	GenerationTimestamp *metav1.Time `json:"generationTimestamp,omitempty"`
 
+	// RotationReason shows what triggered generation of the current credentials.
+	// +optional
+	RotationReason CredentialsRotationReason `json:"rotationReason,omitempty"`
+
+	// RotationObservedGeneration is the ImageRepository generation, token rotation request of which was handled last time.
+	// A repeated reconcile of the same request doesn't rotate the credentials again.
+	// +optional
+	RotationObservedGeneration int64 `json:"rotationObservedGeneration,omitempty"`
+
+	// RotationProgress shows credentials already rotated by the token rotation request in progress,
+	// e.g. when the rotation was rate limited by Quay. The resumed rotation skips them.
+	// +optional
+	RotationProgress *CredentialsRotationProgress `json:"rotationProgress,omitempty"`
+
 	// PushSecretName holds name of the dockerconfig secret with credentials to push (and pull) into the generated repository.
 	PushSecretName string `json:"push-secret,omitempty"`
 
@@ -156,20 +652,130 @@ type CredentialsStatus struct {
 	// PullRobotAccountName is present only if ImageRepository has labels that connect it to Application and Component.
 	// Holds name of the quay robot account with real (pull only) permissions from the generated repository.
 	PullRobotAccountName string `json:"pull-robot-account,omitempty"`
+
+	// RobotAccountNames holds names of all quay robot accounts created for the generated repository.
+	// The robot accounts are deleted together with the image repository.
+	// +optional
+	RobotAccountNames []string `json:"robot-accounts,omitempty"`
+
+	// AdoptedRobotAccountNames holds names of existing robot accounts taken over on provision,
+	// that were not created by the controller. They are not deleted together with the image repository.
+	// +optional
+	AdoptedRobotAccountNames []string `json:"adopted-robot-accounts,omitempty"`
+
+	// ExternalConsumers shows provisioned secrets in other namespaces.
+	// +optional
+	ExternalConsumers []ExternalConsumerStatus `json:"externalConsumers,omitempty"`
+
+	// AdditionalAccounts shows accounts granted access to the image repository,
+	// so the access could be revoked once they are removed from spec.
+	// +optional
+	AdditionalAccounts []AdditionalAccountStatus `json:"additionalAccounts,omitempty"`
+
+	// TemporaryPullCredentials shows short-lived pull credentials issued on request,
+	// they are revoked once expired.
+	// +optional
+	TemporaryPullCredentials *TemporaryCredentialsStatus `json:"temporaryPullCredentials,omitempty"`
+
+	// ServiceAccountSecrets shows pull secrets dedicated to service accounts.
+	// +optional
+	ServiceAccountSecrets []ServiceAccountSecretStatus `json:"serviceAccountSecrets,omitempty"`
+
+	// RetiredRobotAccounts shows robot accounts replaced or taken over by the reown request,
+	// they are deleted at the retire time.
+	// +optional
+	RetiredRobotAccounts *RetiredRobotAccountsStatus `json:"retiredRobotAccounts,omitempty"`
+}
+
+// AccessStatus shows permissions granted to the image repository by the operator,
+// so they could be revoked once they are removed from spec.
+type AccessStatus struct {
+	// Robots shows robot accounts granted a role in the image repository.
+	// Robot accounts that already had the required role are not listed.
+	// +optional
+	Robots []RobotAccessStatus `json:"robots,omitempty"`
+}
+
+// RobotAccessStatus shows robot account granted a role in the image repository.
+type RobotAccessStatus struct {
+	// Name is the full name of the robot account.
+	Name string          `json:"name"`
+	Role RobotAccessRole `json:"role"`
+	// PreviousRole is the role the robot account had before the operator granted the role, it's restored on revocation.
+	// Empty if the robot account had no access.
+	// +optional
+	PreviousRole RobotAccessRole `json:"previousRole,omitempty"`
+}
+
+// AdditionalAccountStatus shows Quay account granted access to the image repository.
+type AdditionalAccountStatus struct {
+	Name string                `json:"name"`
+	Role AdditionalAccountRole `json:"role"`
+}
+
+// ExternalConsumerStatus shows provisioned secret in other namespace.
+type ExternalConsumerStatus struct {
+	Namespace  string `json:"namespace"`
+	SecretName string `json:"secretName"`
+	// RobotAccountName holds name of the quay robot account dedicated to the consumer.
+	RobotAccountName string `json:"robotAccountName"`
+}
+
+// ServiceAccountSecretStatus shows pull secret dedicated to the service account.
+type ServiceAccountSecretStatus struct {
+	ServiceAccountName string `json:"serviceAccountName"`
+	SecretName         string `json:"secretName"`
+	// RobotAccountName holds name of the quay robot account dedicated to the service account, if requested.
+	// +optional
+	RobotAccountName string `json:"robotAccountName,omitempty"`
+}
+
+// RetiredRobotAccountsStatus shows robot accounts scheduled for deletion.
+type RetiredRobotAccountsStatus struct {
+	// Names holds names of the retired quay robot accounts.
+	Names []string `json:"names"`
+	// RetireTime shows when the robot accounts are deleted, so credentials in use elsewhere could be replaced meanwhile.
+	RetireTime metav1.Time `json:"retireTime"`
+}
+
+// TemporaryCredentialsStatus shows issued temporary credentials.
+type TemporaryCredentialsStatus struct {
+	// SecretName holds name of the dockerconfig secret with the temporary credentials.
+	SecretName string `json:"secretName"`
+	// RobotAccountName holds name of the quay robot account dedicated to the temporary credentials.
+	RobotAccountName string `json:"robotAccountName"`
+	// ExpirationTimestamp shows when the robot account and the secret are deleted.
+	ExpirationTimestamp metav1.Time `json:"expirationTimestamp"`
+	// FieldManager shows the field manager that set the request annotation, as recorded by the API server in the managed fields.
+	// It's chosen by the client, so it's not an authenticated identity of the requester.
+	// +optional
+	FieldManager string `json:"fieldManager,omitempty"`
 }
 
 // NotificationStatus shows the status of the notification configuration.
 type NotificationStatus struct {
 	Title string `json:"title,omitempty"`
 	UUID  string `json:"uuid,omitempty"`
+	// ValidationError is set if the webhook target is not reachable, the notification is not created in Quay then.
+	// +optional
+	ValidationError string `json:"validationError,omitempty"`
+	// ObservedGeneration is the generation at which the webhook target was found not reachable.
+	// The target is checked again only once the spec is changed.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 
 // ImageRepository is the Schema for the imagerepositories API
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
 // +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.image.url"
 // +kubebuilder:printcolumn:name="Visibility",type="string",JSONPath=".status.image.visibility"
+// +kubebuilder:printcolumn:name="Credentials",type="date",JSONPath=".status.credentials.generationTimestamp",description="Age of the current credentials"
+// +kubebuilder:printcolumn:name="Component",type="string",JSONPath=".metadata.labels.appstudio\\.redhat\\.com/component"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type ImageRepository struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
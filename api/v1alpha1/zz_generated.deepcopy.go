@@ -22,9 +22,120 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessStatus) DeepCopyInto(out *AccessStatus) {
+	*out = *in
+	if in.Robots != nil {
+		in, out := &in.Robots, &out.Robots
+		*out = make([]RobotAccessStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessStatus.
+func (in *AccessStatus) DeepCopy() *AccessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalAccount) DeepCopyInto(out *AdditionalAccount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalAccount.
+func (in *AdditionalAccount) DeepCopy() *AdditionalAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchiveStatus) DeepCopyInto(out *ArchiveStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchiveStatus.
+func (in *ArchiveStatus) DeepCopy() *ArchiveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchiveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalAccountStatus) DeepCopyInto(out *AdditionalAccountStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalAccountStatus.
+func (in *AdditionalAccountStatus) DeepCopy() *AdditionalAccountStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalAccountStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigMapKeyReference) DeepCopyInto(out *ConfigMapKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigMapKeyReference.
+func (in *ConfigMapKeyReference) DeepCopy() *ConfigMapKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigMapKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationProgress) DeepCopyInto(out *CredentialsRotationProgress) {
+	*out = *in
+	if in.Rotated != nil {
+		in, out := &in.Rotated, &out.Rotated
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationProgress.
+func (in *CredentialsRotationProgress) DeepCopy() *CredentialsRotationProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
 	*out = *in
@@ -32,6 +143,46 @@ func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
 		in, out := &in.GenerationTimestamp, &out.GenerationTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.RotationProgress != nil {
+		in, out := &in.RotationProgress, &out.RotationProgress
+		*out = new(CredentialsRotationProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RobotAccountNames != nil {
+		in, out := &in.RobotAccountNames, &out.RobotAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdoptedRobotAccountNames != nil {
+		in, out := &in.AdoptedRobotAccountNames, &out.AdoptedRobotAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExternalConsumers != nil {
+		in, out := &in.ExternalConsumers, &out.ExternalConsumers
+		*out = make([]ExternalConsumerStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalAccounts != nil {
+		in, out := &in.AdditionalAccounts, &out.AdditionalAccounts
+		*out = make([]AdditionalAccountStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.TemporaryPullCredentials != nil {
+		in, out := &in.TemporaryPullCredentials, &out.TemporaryPullCredentials
+		*out = new(TemporaryCredentialsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountSecrets != nil {
+		in, out := &in.ServiceAccountSecrets, &out.ServiceAccountSecrets
+		*out = make([]ServiceAccountSecretStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetiredRobotAccounts != nil {
+		in, out := &in.RetiredRobotAccounts, &out.RetiredRobotAccounts
+		*out = new(RetiredRobotAccountsStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
@@ -44,6 +195,56 @@ func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalConsumer) DeepCopyInto(out *ExternalConsumer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalConsumer.
+func (in *ExternalConsumer) DeepCopy() *ExternalConsumer {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalConsumer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalConsumerStatus) DeepCopyInto(out *ExternalConsumerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalConsumerStatus.
+func (in *ExternalConsumerStatus) DeepCopy() *ExternalConsumerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalConsumerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageAccess) DeepCopyInto(out *ImageAccess) {
+	*out = *in
+	if in.Robots != nil {
+		in, out := &in.Robots, &out.Robots
+		*out = make([]RobotAccess, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageAccess.
+func (in *ImageAccess) DeepCopy() *ImageAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageCredentials) DeepCopyInto(out *ImageCredentials) {
 	*out = *in
@@ -52,6 +253,36 @@ func (in *ImageCredentials) DeepCopyInto(out *ImageCredentials) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ExternalConsumers != nil {
+		in, out := &in.ExternalConsumers, &out.ExternalConsumers
+		*out = make([]ExternalConsumer, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExistingPushSecretRef != nil {
+		in, out := &in.ExistingPushSecretRef, &out.ExistingPushSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.ExistingPullSecretRef != nil {
+		in, out := &in.ExistingPullSecretRef, &out.ExistingPullSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+	if in.AdditionalAccounts != nil {
+		in, out := &in.AdditionalAccounts, &out.AdditionalAccounts
+		*out = make([]AdditionalAccount, len(*in))
+		copy(*out, *in)
+	}
+	if in.PerServiceAccountSecrets != nil {
+		in, out := &in.PerServiceAccountSecrets, &out.PerServiceAccountSecrets
+		*out = new(PerServiceAccountSecrets)
+		**out = **in
+	}
+	if in.SecretLinking != nil {
+		in, out := &in.SecretLinking, &out.SecretLinking
+		*out = new(SecretLinking)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageCredentials.
@@ -67,6 +298,16 @@ func (in *ImageCredentials) DeepCopy() *ImageCredentials {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageParameters) DeepCopyInto(out *ImageParameters) {
 	*out = *in
+	if in.ReadmeRef != nil {
+		in, out := &in.ReadmeRef, &out.ReadmeRef
+		*out = new(ReadmeReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VisibilitySchedule != nil {
+		in, out := &in.VisibilitySchedule, &out.VisibilitySchedule
+		*out = new(VisibilitySchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageParameters.
@@ -141,7 +382,7 @@ func (in *ImageRepositoryList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageRepositorySpec) DeepCopyInto(out *ImageRepositorySpec) {
 	*out = *in
-	out.Image = in.Image
+	in.Image.DeepCopyInto(&out.Image)
 	if in.Credentials != nil {
 		in, out := &in.Credentials, &out.Credentials
 		*out = new(ImageCredentials)
@@ -150,8 +391,25 @@ func (in *ImageRepositorySpec) DeepCopyInto(out *ImageRepositorySpec) {
 	if in.Notifications != nil {
 		in, out := &in.Notifications, &out.Notifications
 		*out = make([]Notifications, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NudgeTargets != nil {
+		in, out := &in.NudgeTargets, &out.NudgeTargets
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(SigningConfiguration)
+		**out = **in
+	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(ImageAccess)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRepositorySpec.
@@ -167,13 +425,43 @@ func (in *ImageRepositorySpec) DeepCopy() *ImageRepositorySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageRepositoryStatus) DeepCopyInto(out *ImageRepositoryStatus) {
 	*out = *in
-	out.Image = in.Image
+	in.Image.DeepCopyInto(&out.Image)
 	in.Credentials.DeepCopyInto(&out.Credentials)
 	if in.Notifications != nil {
 		in, out := &in.Notifications, &out.Notifications
 		*out = make([]NotificationStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Provision.DeepCopyInto(&out.Provision)
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(SigningStatus)
+		**out = **in
+	}
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]OperationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(AccessStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(ArchiveStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageRepositoryStatus.
@@ -189,6 +477,23 @@ func (in *ImageRepositoryStatus) DeepCopy() *ImageRepositoryStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageStatus) DeepCopyInto(out *ImageStatus) {
 	*out = *in
+	if in.LatestTagCheckTimestamp != nil {
+		in, out := &in.LatestTagCheckTimestamp, &out.LatestTagCheckTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.VisibilityCheckTimestamp != nil {
+		in, out := &in.VisibilityCheckTimestamp, &out.VisibilityCheckTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.StateCheckTimestamp != nil {
+		in, out := &in.StateCheckTimestamp, &out.StateCheckTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.VisibilitySchedule != nil {
+		in, out := &in.VisibilitySchedule, &out.VisibilitySchedule
+		*out = new(VisibilityScheduleStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStatus.
@@ -204,6 +509,11 @@ func (in *ImageStatus) DeepCopy() *ImageStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NotificationConfig) DeepCopyInto(out *NotificationConfig) {
 	*out = *in
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationConfig.
@@ -234,7 +544,7 @@ func (in *NotificationStatus) DeepCopy() *NotificationStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Notifications) DeepCopyInto(out *Notifications) {
 	*out = *in
-	out.Config = in.Config
+	in.Config.DeepCopyInto(&out.Config)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Notifications.
@@ -246,3 +556,276 @@ func (in *Notifications) DeepCopy() *Notifications {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationRecord) DeepCopyInto(out *OperationRecord) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationRecord.
+func (in *OperationRecord) DeepCopy() *OperationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerServiceAccountSecrets) DeepCopyInto(out *PerServiceAccountSecrets) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerServiceAccountSecrets.
+func (in *PerServiceAccountSecrets) DeepCopy() *PerServiceAccountSecrets {
+	if in == nil {
+		return nil
+	}
+	out := new(PerServiceAccountSecrets)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
+	*out = *in
+	if in.StartTimestamp != nil {
+		in, out := &in.StartTimestamp, &out.StartTimestamp
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStatus.
+func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadmeReference) DeepCopyInto(out *ReadmeReference) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(ConfigMapKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadmeReference.
+func (in *ReadmeReference) DeepCopy() *ReadmeReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadmeReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetiredRobotAccountsStatus) DeepCopyInto(out *RetiredRobotAccountsStatus) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.RetireTime.DeepCopyInto(&out.RetireTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetiredRobotAccountsStatus.
+func (in *RetiredRobotAccountsStatus) DeepCopy() *RetiredRobotAccountsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RetiredRobotAccountsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RobotAccess) DeepCopyInto(out *RobotAccess) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RobotAccess.
+func (in *RobotAccess) DeepCopy() *RobotAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(RobotAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RobotAccessStatus) DeepCopyInto(out *RobotAccessStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RobotAccessStatus.
+func (in *RobotAccessStatus) DeepCopy() *RobotAccessStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RobotAccessStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretLinking) DeepCopyInto(out *SecretLinking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretLinking.
+func (in *SecretLinking) DeepCopy() *SecretLinking {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretLinking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceAccountSecretStatus) DeepCopyInto(out *ServiceAccountSecretStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceAccountSecretStatus.
+func (in *ServiceAccountSecretStatus) DeepCopy() *ServiceAccountSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceAccountSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningConfiguration) DeepCopyInto(out *SigningConfiguration) {
+	*out = *in
+	out.PublicKeySecretRef = in.PublicKeySecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningConfiguration.
+func (in *SigningConfiguration) DeepCopy() *SigningConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SigningStatus) DeepCopyInto(out *SigningStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SigningStatus.
+func (in *SigningStatus) DeepCopy() *SigningStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SigningStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemporaryCredentialsStatus) DeepCopyInto(out *TemporaryCredentialsStatus) {
+	*out = *in
+	in.ExpirationTimestamp.DeepCopyInto(&out.ExpirationTimestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemporaryCredentialsStatus.
+func (in *TemporaryCredentialsStatus) DeepCopy() *TemporaryCredentialsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TemporaryCredentialsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VisibilitySchedule) DeepCopyInto(out *VisibilitySchedule) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VisibilitySchedule.
+func (in *VisibilitySchedule) DeepCopy() *VisibilitySchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(VisibilitySchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VisibilityScheduleStatus) DeepCopyInto(out *VisibilityScheduleStatus) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VisibilityScheduleStatus.
+func (in *VisibilityScheduleStatus) DeepCopy() *VisibilityScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VisibilityScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// fake-quay serves in-memory fake of the Quay API, so the controller could be run locally without a Quay organization.
+// The state is lost on exit.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	uberzapcore "go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/konflux-ci/image-controller/pkg/quay/quaytest"
+)
+
+func main() {
+	var bindAddress string
+	var token string
+	var pageSize int
+	var rateLimit int
+	var rateLimitWindow time.Duration
+	flag.StringVar(&bindAddress, "bind-address", "127.0.0.1:8088", "The address the fake Quay API binds to.")
+	flag.StringVar(&token, "token", "", "The only accepted bearer token, any token is accepted if not set.")
+	flag.IntVar(&pageSize, "page-size", quaytest.DefaultPageSize, "Number of items in one page of paginated results.")
+	flag.IntVar(&rateLimit, "rate-limit", 0, "Number of requests allowed in rate-limit-window, rate limiting is disabled if zero.")
+	flag.DurationVar(&rateLimitWindow, "rate-limit-window", time.Minute, "Duration of the rate limit window.")
+	zapOpts := zap.Options{
+		TimeEncoder: uberzapcore.ISO8601TimeEncoder,
+		ZapOpts:     []uberzap.Option{uberzap.WithCaller(true)},
+	}
+	zapOpts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOpts)))
+	setupLog := ctrl.Log.WithName("setup")
+
+	fakeQuay := quaytest.NewFakeQuay()
+	fakeQuay.Token = token
+	fakeQuay.PageSize = pageSize
+	fakeQuay.SetRateLimit(rateLimit, rateLimitWindow)
+
+	server := &http.Server{
+		Addr:              bindAddress,
+		Handler:           fakeQuay,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			setupLog.Error(err, "failed to shut down fake Quay API")
+		}
+	}()
+
+	setupLog.Info("starting fake Quay API", "url", "http://"+bindAddress+quaytest.APIPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		setupLog.Error(err, "problem running fake Quay API")
+		os.Exit(1)
+	}
+}
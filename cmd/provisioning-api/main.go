@@ -0,0 +1,115 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// provisioning-api serves the REST provisioning API for clients outside of Kubernetes.
+// Requests are translated into ImageRepository objects, which are then provisioned by the image controller.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	uberzap "go.uber.org/zap"
+	uberzapcore "go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/provisioning"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(imagerepositoryv1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	var bindAddress string
+	var tlsCertPath string
+	var tlsKeyPath string
+	flag.StringVar(&bindAddress, "bind-address", ":8443", "The address the provisioning API binds to.")
+	flag.StringVar(&tlsCertPath, "tls-cert-path", "",
+		"Path to the TLS certificate of the provisioning API. Plain HTTP is served if not set, which is meant only for local development.")
+	flag.StringVar(&tlsKeyPath, "tls-key-path", "", "Path to the TLS private key of the provisioning API.")
+	zapOpts := zap.Options{
+		TimeEncoder: uberzapcore.ISO8601TimeEncoder,
+		ZapOpts:     []uberzap.Option{uberzap.WithCaller(true)},
+	}
+	zapOpts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOpts)))
+	setupLog := ctrl.Log.WithName("setup")
+
+	if (tlsCertPath == "") != (tlsKeyPath == "") {
+		setupLog.Error(nil, "tls-cert-path and tls-key-path flags must be set together")
+		os.Exit(1)
+	}
+
+	kubeClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		setupLog.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(provisioning.ImageRepositoriesPathPrefix,
+		provisioning.NewImageRepositoriesHandler(kubeClient, provisioning.NewKubernetesReviewer(kubeClient), ctrl.Log))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{
+		Addr:              bindAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			setupLog.Error(err, "failed to shut down provisioning API")
+		}
+	}()
+
+	setupLog.Info("starting provisioning API", "address", bindAddress, "tls", tlsCertPath != "")
+	if tlsCertPath != "" {
+		err = server.ListenAndServeTLS(tlsCertPath, tlsKeyPath)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		setupLog.Error(err, "problem running provisioning API")
+		os.Exit(1)
+	}
+}
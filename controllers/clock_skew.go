@@ -0,0 +1,43 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+)
+
+// clockSkewTolerance is how much the clock of the controller may differ from clocks of other replicas and of the API server.
+// Timestamps in the status might be recorded by a former leader on other node, so they are compared with this tolerance.
+const clockSkewTolerance = 2 * time.Minute
+
+// elapsedSince returns time elapsed since the timestamp recorded possibly by other replica.
+// A timestamp in the future comes from a clock that is ahead, it's treated as just recorded,
+// so periodic checks are not postponed by the skew.
+func elapsedSince(timestamp time.Time) time.Duration {
+	elapsed := time.Since(timestamp)
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// isTimeoutExceeded checks whether the timeout has elapsed since the timestamp recorded possibly by other replica.
+// The timeout is extended by clockSkewTolerance, so a timestamp recorded by a clock that is behind doesn't trigger
+// the timeout too early.
+func isTimeoutExceeded(timestamp time.Time, timeout time.Duration) bool {
+	return elapsedSince(timestamp) > timeout+clockSkewTolerance
+}
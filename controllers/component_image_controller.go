@@ -18,9 +18,9 @@ package controllers
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -35,6 +35,8 @@ import (
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/go-logr/logr"
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
 	l "github.com/konflux-ci/image-controller/pkg/logs"
 	"github.com/konflux-ci/image-controller/pkg/metrics"
 	"github.com/konflux-ci/image-controller/pkg/quay"
@@ -42,8 +44,15 @@ import (
 )
 
 const (
-	ImageAnnotationName         = "image.redhat.com/image"
-	GenerateImageAnnotationName = "image.redhat.com/generate"
+	// Deprecated: the annotation names are kept for backward compatibility, use pkg/annotations instead.
+	ImageAnnotationName         = string(annotations.Image)
+	GenerateImageAnnotationName = string(annotations.GenerateImage)
+
+	// Optional overrides of the generated image repository parameters.
+	// Have effect only on the image repository creation, except visibility that could be changed later.
+	ImageNameAnnotationName       = string(annotations.ImageName)
+	ImageVisibilityAnnotationName = string(annotations.ImageVisibility)
+	SkipProvisionAnnotationName   = string(annotations.SkipProvision)
 
 	ImageRepositoryComponentFinalizer = "image-controller.appstudio.openshift.io/image-repository"
 
@@ -58,12 +67,22 @@ type GenerateRepositoryOpts struct {
 }
 
 // ImageRepositoryStatus defines the structure of the Repository information being exposed to external systems.
+// Use ParseImageRepositoryStatus to read it, so annotations written by older versions are handled.
 type ImageRepositoryStatus struct {
 	Image      string `json:"image,omitempty"`
 	Visibility string `json:"visibility,omitempty"`
 	Secret     string `json:"secret,omitempty"`
 
 	Message string `json:"message,omitempty"`
+
+	// Version of the annotation schema, see ImageRepositoryStatusVersion.
+	Version int `json:"version,omitempty"`
+	// Stage of the provision that failed, present only together with Message.
+	Stage ProvisionStage `json:"stage,omitempty"`
+	// ErrorClass tells whether requesting the provision again could help, present only together with Stage.
+	ErrorClass imagerepositoryv1alpha1.ProvisionErrorClass `json:"errorClass,omitempty"`
+	// RetryCount is the number of provision requests that failed in a row before this one.
+	RetryCount int `json:"retryCount,omitempty"`
 }
 
 // ComponentReconciler reconciles a Controller object
@@ -73,24 +92,58 @@ type ComponentReconciler struct {
 
 	BuildQuayClient  func(logr.Logger) quay.QuayService
 	QuayOrganization string
+
+	// ClusterID is recorded in created image repositories, see ImageRepositoryReconciler.ClusterID
+	ClusterID string
+	// RegistryHost is used in generated secrets and image annotation, see ImageRepositoryReconciler.RegistryHost
+	RegistryHost string
+
+	// RepositoryPathTemplate defines layout of generated image repository names, see ImageRepositoryReconciler.RepositoryPathTemplate
+	RepositoryPathTemplate RepositoryPathTemplate
+
+	// AdminNamespaces may manage image repositories of other namespaces, see ImageRepositoryReconciler.AdminNamespaces
+	AdminNamespaces []string
+
+	// ReservedRepositoryNames cannot be provisioned, see ImageRepositoryReconciler.ReservedRepositoryNames
+	ReservedRepositoryNames *ReservedRepositoryNames
+
+	// PullSecretExportLabels and PullSecretExportAnnotations are added to generated pull secrets,
+	// see ImageRepositoryReconciler.PullSecretExportLabels
+	PullSecretExportLabels      map[string]string
+	PullSecretExportAnnotations map[string]string
+
+	// DryRun makes the reconciler only simulate changes, Client is expected to send writes as dry-run requests,
+	// see ImageRepositoryReconciler.DryRun
+	DryRun bool
+
+	imageRepositoryIndexer *imageRepositoryIndexer
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ComponentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := r.getImageRepositoryIndexer().register(context.Background(), mgr.GetFieldIndexer(), mgr.GetCache()); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&appstudioredhatcomv1alpha1.Component{}).
 		Complete(r)
 }
 
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=components,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=imagerepositories,verbs=get;list;update
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
 	log := ctrllog.FromContext(ctx).WithName("ComponentImageRepository")
+	ctx, log = withCorrelationID(ctx, log)
 	ctx = ctrllog.IntoContext(ctx, log)
 	reconcileStartTime := time.Now()
+	defer func() {
+		metrics.ObserveReconcileDuration("component", req.Namespace, req.Name, getCorrelationID(ctx), time.Since(reconcileStartTime))
+		metrics.ObserveReconcileResult("component", reconcileErr)
+	}()
 
 	// Fetch the Component instance
 	component := &appstudioredhatcomv1alpha1.Component{}
@@ -113,35 +166,23 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		delete(metrics.RepositoryTimesForMetrics, componentIdForMetrics)
 
 		if controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
-			pushRobotAccountName, pullRobotAccountName := generateRobotAccountsNames(component)
+			// Must be done before the finalizer removal, otherwise the ImageRepositories are garbage collected
+			if err := r.detachKeptImageRepositories(ctx, component); err != nil {
+				return ctrl.Result{}, err
+			}
 
 			quayClient := r.BuildQuayClient(log)
-			isPushRobotAccountDeleted, err := quayClient.DeleteRobotAccount(r.QuayOrganization, pushRobotAccountName)
-			if err != nil {
-				log.Error(err, "failed to delete push robot account", l.Action, l.ActionDelete, l.Audit, "true")
-				// Do not block Component deletion if failed to delete robot account
-			}
-			if isPushRobotAccountDeleted {
-				log.Info(fmt.Sprintf("Deleted push robot account %s", pushRobotAccountName), l.Action, l.ActionDelete)
-			}
+			quayClient.SetCorrelationID(getCorrelationID(ctx))
+			quayClient.SetRequestObserver(metrics.QuayRequestObserver("component", component.Namespace))
 
-			isPullRobotAccountDeleted, err := quayClient.DeleteRobotAccount(r.QuayOrganization, pullRobotAccountName)
-			if err != nil {
-				log.Error(err, "failed to delete pull robot account", l.Action, l.ActionDelete, l.Audit, "true")
-				// Do not block Component deletion if failed to delete robot account
-			}
-			if isPullRobotAccountDeleted {
-				log.Info(fmt.Sprintf("Deleted pull robot account %s", pullRobotAccountName), l.Action, l.ActionDelete)
-			}
+			// Image repositories created through ImageRepository objects are cleaned up by their finalizer,
+			// unless the ImageRepository was removed without running it.
+			r.cleanupOrphanedImageRepositories(ctx, component, quayClient)
 
-			imageRepo := generateRepositoryName(component)
-			isRepoDeleted, err := quayClient.DeleteRepository(r.QuayOrganization, imageRepo)
-			if err != nil {
-				log.Error(err, "failed to delete image repository", l.Action, l.ActionDelete, l.Audit, "true")
-				// Do not block Component deletion if failed to delete image repository
-			}
-			if isRepoDeleted {
-				log.Info(fmt.Sprintf("Deleted image repository %s", imageRepo), l.Action, l.ActionDelete)
+			_, isLegacyRepository := annotations.Image.Get(component)
+			_, hasProvenance := annotations.ImageRepositories.Get(component)
+			if isLegacyRepository || !hasProvenance {
+				r.deleteLegacyImageRepository(ctx, component, quayClient)
 			}
 
 			if err := r.Client.Get(ctx, req.NamespacedName, component); err != nil {
@@ -163,7 +204,11 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	generateRepositoryOptsStr, exists := component.Annotations[GenerateImageAnnotationName]
+	if annotations.OptOut.IsTrue(component) {
+		return ctrl.Result{}, r.cleanupOptedOutComponent(ctx, component)
+	}
+
+	generateRepositoryOptsStr, exists := annotations.GenerateImage.Get(component)
 	if !exists {
 		// Nothing to do
 		return ctrl.Result{}, nil
@@ -176,33 +221,47 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		if generateRepositoryOptsStr == "true" {
 			requestRepositoryOpts.Visibility = "public"
 		} else {
-			message := fmt.Sprintf("invalid JSON in %s annotation", GenerateImageAnnotationName)
-			return ctrl.Result{}, r.reportError(ctx, component, message)
+			message := fmt.Sprintf("invalid JSON in %s annotation", annotations.GenerateImage)
+			return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, message)
 		}
 	}
 
+	if err := annotations.ImageVisibility.Validate(component); err != nil {
+		return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, err.Error())
+	}
+	if visibility, exists := annotations.ImageVisibility.Get(component); exists {
+		requestRepositoryOpts.Visibility = visibility
+	}
+
 	// Validate image repository creation options
 	if !(requestRepositoryOpts.Visibility == "public" || requestRepositoryOpts.Visibility == "private") {
-		message := fmt.Sprintf("invalid value: %s in visibility field in %s annotation", requestRepositoryOpts.Visibility, GenerateImageAnnotationName)
-		return ctrl.Result{}, r.reportError(ctx, component, message)
+		message := fmt.Sprintf("invalid value: %s in visibility field in %s annotation", requestRepositoryOpts.Visibility, annotations.GenerateImage)
+		return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, message)
 	}
 
 	setMetricsTime(componentIdForMetrics, reconcileStartTime)
 
 	imageRepositoryExists := false
 	repositoryInfo := ImageRepositoryStatus{}
-	repositoryInfoStr, imageAnnotationExist := component.Annotations[ImageAnnotationName]
+	failedAttempts := 0
+	repositoryInfoStr, imageAnnotationExist := annotations.Image.Get(component)
 	if imageAnnotationExist {
-		if err := json.Unmarshal([]byte(repositoryInfoStr), &repositoryInfo); err == nil {
+		if parsedRepositoryInfo, err := ParseImageRepositoryStatus(repositoryInfoStr); err == nil {
+			repositoryInfo = *parsedRepositoryInfo
 			imageRepositoryExists = repositoryInfo.Image != "" && repositoryInfo.Secret != ""
-			repositoryInfo.Message = ""
+			failedAttempts = repositoryInfo.failedAttempts()
+			repositoryInfo.clearFailure()
 		} else {
 			// Image repository info annotation contains invalid JSON.
 			// This means that the annotation was edited manually.
-			repositoryInfo.Message = "Invalid image status annotation"
+			repositoryInfo.setFailure(ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, "Invalid image status annotation", 0)
 		}
 	}
 
+	if !imageRepositoryExists && annotations.SkipProvision.IsTrue(component) {
+		return ctrl.Result{}, r.reportSkippedProvision(ctx, component)
+	}
+
 	// Do something only if no error has been detected before
 	if repositoryInfo.Message == "" {
 		if imageRepositoryExists {
@@ -210,44 +269,60 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			if repositoryInfo.Visibility != requestRepositoryOpts.Visibility {
 				// quay.io/org/reposito/ryName
 				imageUrlParts := strings.SplitN(repositoryInfo.Image, "/", 3)
-				if len(imageUrlParts) > 2 {
+				if len(imageUrlParts) > 2 && !isImageRepositoryNameAllowed(imageUrlParts[2], component.Namespace, r.AdminNamespaces) {
+					log.Info("visibility of image repository of other namespace is not changed", "ImageRepositoryName", imageUrlParts[2], l.Audit, "true")
+					repositoryInfo.setFailure(ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, "Image repository belongs to other namespace", failedAttempts)
+				} else if len(imageUrlParts) > 2 {
 					repositoryName := imageUrlParts[2]
 					quayClient := r.BuildQuayClient(log)
+					quayClient.SetCorrelationID(getCorrelationID(ctx))
+					quayClient.SetRequestObserver(metrics.QuayRequestObserver("component", component.Namespace))
 					if err := quayClient.ChangeRepositoryVisibility(r.QuayOrganization, repositoryName, requestRepositoryOpts.Visibility); err == nil {
 						repositoryInfo.Visibility = requestRepositoryOpts.Visibility
 					} else {
 						if err.Error() == "payment required" {
 							log.Info("failed to make image repository private due to quay plan limit", l.Audit, "true")
-							repositoryInfo.Message = "Quay organization plan doesn't allow private image repositories"
+							repositoryInfo.setFailure(ProvisionStageVisibilityUpdate, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, "Quay organization plan doesn't allow private image repositories", failedAttempts)
 						} else {
 							log.Error(err, "failed to change image repository visibility")
 							return ctrl.Result{}, err
 						}
 					}
 				} else {
-					repositoryInfo.Message = "Invalid image url"
+					repositoryInfo.setFailure(ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, "Invalid image url", failedAttempts)
 				}
 			}
 		} else {
 			// Image repository doesn't exist, create it.
+			imageRepositoryName, err := getComponentRepositoryName(component, r.RepositoryPathTemplate)
+			if err != nil {
+				return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, err.Error())
+			}
+			if err := r.ReservedRepositoryNames.Check(component.Namespace, imageRepositoryName); err != nil {
+				log.Info("image repository name is reserved", "ImageRepositoryName", imageRepositoryName, "Error", err.Error())
+				metrics.ReservedRepositoryNameRejectionsMetric.WithLabelValues(component.Namespace).Inc()
+				return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageValidation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, err.Error())
+			}
 			quayClient := r.BuildQuayClient(log)
-			repo, pushRobotAccount, pullRobotAccount, err := r.generateImageRepository(ctx, quayClient, component, requestRepositoryOpts)
+			quayClient.SetCorrelationID(getCorrelationID(ctx))
+			quayClient.SetRequestObserver(metrics.QuayRequestObserver("component", component.Namespace))
+			repo, pushRobotAccount, pullRobotAccount, err := r.generateImageRepository(ctx, quayClient, component, imageRepositoryName, requestRepositoryOpts)
 			if err != nil {
 				if err.Error() == "payment required" {
 					log.Info("failed to create private image repository due to quay plan limit", l.Audit, "true")
-					repositoryInfo.Message = "Quay organization plan doesn't allow private image repositories"
+					repositoryInfo.setFailure(ProvisionStageRepositoryCreation, imagerepositoryv1alpha1.ProvisionErrorClassPermanent, "Quay organization plan doesn't allow private image repositories", failedAttempts)
 				} else {
 					log.Error(err, "Error in the repository generation process", l.Audit, "true")
-					return ctrl.Result{}, r.reportError(ctx, component, "failed to generate image repository")
+					return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageRepositoryCreation, getProvisionErrorClass(err), "failed to generate image repository")
 				}
 			} else {
 				if repo == nil || pushRobotAccount == nil || pullRobotAccount == nil {
 					log.Error(nil, "Unknown error in the repository generation process", l.Audit, "true")
-					return ctrl.Result{}, r.reportError(ctx, component, "failed to generate image repository: unknown error")
+					return ctrl.Result{}, r.reportError(ctx, component, ProvisionStageRepositoryCreation, imagerepositoryv1alpha1.ProvisionErrorClassTransient, "failed to generate image repository: unknown error")
 				}
 				log.Info(fmt.Sprintf("Prepared image repository %s for Component", repo.Name), l.Action, l.ActionAdd)
 
-				imageURL := fmt.Sprintf("quay.io/%s/%s", r.QuayOrganization, repo.Name)
+				imageURL := getImageURL(r.RegistryHost, r.QuayOrganization, repo.Name)
 
 				// Create secrets with the repository credentials
 				pushSecretName := component.Name
@@ -273,6 +348,7 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			}
 		}
 	}
+	repositoryInfo.Version = ImageRepositoryStatusVersion
 	repositoryInfoBytes, _ := json.Marshal(repositoryInfo)
 
 	// Update component with the generated data and add finalizer
@@ -281,8 +357,8 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, fmt.Errorf("error reading component: %w", err)
 	}
 	if component.ObjectMeta.DeletionTimestamp.IsZero() {
-		component.Annotations[ImageAnnotationName] = string(repositoryInfoBytes)
-		delete(component.Annotations, GenerateImageAnnotationName)
+		annotations.Image.Set(component, string(repositoryInfoBytes))
+		annotations.GenerateImage.Remove(component)
 
 		if repositoryInfo.Image != "" && !controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
 			controllerutil.AddFinalizer(component, ImageRepositoryComponentFinalizer)
@@ -295,7 +371,7 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		log.Info("Component updated successfully", l.Action, l.ActionUpdate)
 
 		r.waitComponentUpdateInCache(ctx, req.NamespacedName, func(component *appstudioredhatcomv1alpha1.Component) bool {
-			_, exists := component.Annotations[GenerateImageAnnotationName]
+			_, exists := annotations.GenerateImage.Get(component)
 			return !exists
 		})
 	}
@@ -307,14 +383,89 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return ctrl.Result{}, nil
 }
 
-func (r *ComponentReconciler) reportError(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, messsage string) error {
+// deleteLegacyImageRepository deletes robot accounts and image repository provisioned for the Component by the generate annotation.
+// Failures are only logged, so the Component deletion is not blocked.
+func (r *ComponentReconciler) deleteLegacyImageRepository(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, quayClient quay.QuayService) {
+	log := ctrllog.FromContext(ctx)
+
+	pushRobotAccountName, pullRobotAccountName := generateRobotAccountsNames(component)
+
+	isPushRobotAccountDeleted, err := quayClient.DeleteRobotAccount(r.QuayOrganization, pushRobotAccountName)
+	if err != nil {
+		log.Error(err, "failed to delete push robot account", l.Action, l.ActionDelete, l.Audit, "true")
+		// Do not block Component deletion if failed to delete robot account
+	}
+	if isPushRobotAccountDeleted {
+		log.Info(fmt.Sprintf("Deleted push robot account %s", pushRobotAccountName), l.Action, l.ActionDelete)
+	}
+
+	isPullRobotAccountDeleted, err := quayClient.DeleteRobotAccount(r.QuayOrganization, pullRobotAccountName)
+	if err != nil {
+		log.Error(err, "failed to delete pull robot account", l.Action, l.ActionDelete, l.Audit, "true")
+		// Do not block Component deletion if failed to delete robot account
+	}
+	if isPullRobotAccountDeleted {
+		log.Info(fmt.Sprintf("Deleted pull robot account %s", pullRobotAccountName), l.Action, l.ActionDelete)
+	}
+
+	imageRepo := getProvisionedRepositoryName(component, r.RegistryHost, r.QuayOrganization, r.RepositoryPathTemplate)
+	if isImageRepositoryNameAllowed(imageRepo, component.Namespace, r.AdminNamespaces) {
+		isRepoDeleted, err := quayClient.DeleteRepository(r.QuayOrganization, imageRepo)
+		if err != nil {
+			log.Error(err, "failed to delete image repository", l.Action, l.ActionDelete, l.Audit, "true")
+			// Do not block Component deletion if failed to delete image repository
+		}
+		if isRepoDeleted {
+			log.Info(fmt.Sprintf("Deleted image repository %s", imageRepo), l.Action, l.ActionDelete)
+		}
+	} else {
+		log.Info("image repository of other namespace is not deleted", "ImageRepositoryName", imageRepo, l.Audit, "true")
+	}
+}
+
+// reportSkippedProvision reflects in the image annotation that the provision was skipped on user request.
+// The generate annotation is kept, so the provision is done once the skip annotation is removed.
+func (r *ComponentReconciler) reportSkippedProvision(ctx context.Context, component *appstudioredhatcomv1alpha1.Component) error {
+	log := ctrllog.FromContext(ctx)
+
+	messageBytes, _ := json.Marshal(&ImageRepositoryStatus{
+		Message: fmt.Sprintf("Image repository provision is skipped due to %s annotation", annotations.SkipProvision),
+		Version: ImageRepositoryStatusVersion,
+	})
+	if imageAnnotation, _ := annotations.Image.Get(component); imageAnnotation == string(messageBytes) {
+		return nil
+	}
+
+	annotations.Image.Set(component, string(messageBytes))
+	if err := r.Client.Update(ctx, component); err != nil {
+		log.Error(err, "failed to update component", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Image repository provision skipped", l.Action, l.ActionUpdate)
+
+	componentIdForMetrics := getComponentIdForMetrics(component)
+	delete(metrics.RepositoryTimesForMetrics, componentIdForMetrics)
+	return nil
+}
+
+// reportError records the failed provision in the image annotation and removes the generate annotation,
+// so the provision is retried only when requested again.
+func (r *ComponentReconciler) reportError(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, stage ProvisionStage, errorClass imagerepositoryv1alpha1.ProvisionErrorClass, messsage string) error {
 	lookUpKey := types.NamespacedName{Name: component.Name, Namespace: component.Namespace}
 	if err := r.Client.Get(ctx, lookUpKey, component); err != nil {
 		return err
 	}
-	messageBytes, _ := json.Marshal(&ImageRepositoryStatus{Message: messsage})
-	component.Annotations[ImageAnnotationName] = string(messageBytes)
-	delete(component.Annotations, GenerateImageAnnotationName)
+	failedAttempts := 0
+	if imageAnnotation, exists := annotations.Image.Get(component); exists {
+		if previousRepositoryInfo, err := ParseImageRepositoryStatus(imageAnnotation); err == nil {
+			failedAttempts = previousRepositoryInfo.failedAttempts()
+		}
+	}
+	repositoryInfo := ImageRepositoryStatus{Version: ImageRepositoryStatusVersion}
+	repositoryInfo.setFailure(stage, errorClass, messsage, failedAttempts)
+	messageBytes, _ := json.Marshal(&repositoryInfo)
+	annotations.Image.Set(component, string(messageBytes))
+	annotations.GenerateImage.Remove(component)
 
 	componentIdForMetrics := getComponentIdForMetrics(component)
 	// remove component from metrics map, permanent error
@@ -363,7 +514,11 @@ func (r *ComponentReconciler) waitComponentUpdateInCache(ctx context.Context, co
 func (r *ComponentReconciler) ensureRobotAccountSecret(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, robotAccount *quay.RobotAccount, secretName, imageURL string) (map[string]string, error) {
 	log := ctrllog.FromContext(ctx)
 
-	robotAccountSecret := generateSecret(component, robotAccount, secretName, imageURL)
+	robotAccountSecret, err := generateSecret(component, robotAccount, secretName, imageURL)
+	if err != nil {
+		log.Error(err, "refusing to write invalid robot account secret", "SecretName", secretName, l.Audit, "true")
+		return nil, err
+	}
 	secretData := robotAccountSecret.StringData
 
 	robotAccountSecretKey := types.NamespacedName{Namespace: robotAccountSecret.Namespace, Name: robotAccountSecret.Name}
@@ -399,6 +554,11 @@ func (r *ComponentReconciler) ensureComponentPullSecret(ctx context.Context, com
 			log.Error(err, fmt.Sprintf("failed to get pull secret: %v", pullSecretKey), l.Action, l.ActionView)
 			return err
 		}
+		secretData, err := generateDockerconfigSecretData(imageURL, robotAccount)
+		if err != nil {
+			log.Error(err, "refusing to write invalid pull secret", "SecretName", secretName, l.Audit, "true")
+			return err
+		}
 
 		pullSecret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -409,8 +569,9 @@ func (r *ComponentReconciler) ensureComponentPullSecret(ctx context.Context, com
 				},
 			},
 			Type:       corev1.SecretTypeDockerConfigJson,
-			StringData: generateDockerconfigSecretData(imageURL, robotAccount),
+			StringData: secretData,
 		}
+		setSecretExportMetadata(pullSecret, r.PullSecretExportLabels, r.PullSecretExportAnnotations)
 
 		if err := controllerutil.SetOwnerReference(component, pullSecret, r.Scheme); err != nil {
 			log.Error(err, "failed to set owner for pull secret")
@@ -433,14 +594,49 @@ func generateRobotAccountsNames(component *appstudioredhatcomv1alpha1.Component)
 	return pushRobotAccountName, pullRobotAccountName
 }
 
-func generateRepositoryName(component *appstudioredhatcomv1alpha1.Component) string {
-	return component.Namespace + "/" + component.Spec.Application + "/" + component.Name
+func generateRepositoryName(component *appstudioredhatcomv1alpha1.Component, pathTemplate RepositoryPathTemplate) string {
+	return pathTemplate.Render(component.Namespace, component.Spec.Application, component.Name)
+}
+
+var imageRepositoryNameRegexp = regexp.MustCompile(`^[a-z0-9][.a-z0-9_-]*(/[a-z0-9][.a-z0-9_-]*)*$`)
+
+// getComponentRepositoryName returns image repository name requested for the Component.
+// Custom name from the image name annotation is always prefixed with the Component namespace.
+// Too long names are shortened to fit Quay limit.
+func getComponentRepositoryName(component *appstudioredhatcomv1alpha1.Component, pathTemplate RepositoryPathTemplate) (string, error) {
+	customName, exists := annotations.ImageName.Get(component)
+	if !exists {
+		return shortenImageRepositoryName(generateRepositoryName(component, pathTemplate), QuayRepositoryNameMaxLength), nil
+	}
+
+	imageRepositoryName := strings.TrimPrefix(strings.TrimSpace(customName), "/")
+	if !strings.HasPrefix(imageRepositoryName, component.Namespace+"/") {
+		imageRepositoryName = component.Namespace + "/" + imageRepositoryName
+	}
+	if !imageRepositoryNameRegexp.MatchString(imageRepositoryName) {
+		return "", fmt.Errorf("invalid value: %s in %s annotation", customName, annotations.ImageName)
+	}
+	return shortenImageRepositoryName(imageRepositoryName, QuayRepositoryNameMaxLength), nil
+}
+
+// getProvisionedRepositoryName returns name of the image repository recorded in the image annotation.
+// Falls back to the default name if the annotation is missing or invalid.
+func getProvisionedRepositoryName(component *appstudioredhatcomv1alpha1.Component, registryHost, quayOrganization string, pathTemplate RepositoryPathTemplate) string {
+	repositoryInfo := ImageRepositoryStatus{}
+	imageAnnotation, _ := annotations.Image.Get(component)
+	if err := json.Unmarshal([]byte(imageAnnotation), &repositoryInfo); err == nil {
+		if imageRepositoryName, found := getImageRepositoryNameFromURL(repositoryInfo.Image, registryHost, quayOrganization); found {
+			return imageRepositoryName
+		}
+	}
+	return shortenImageRepositoryName(generateRepositoryName(component, pathTemplate), QuayRepositoryNameMaxLength)
 }
 
 func (r *ComponentReconciler) generateImageRepository(
 	ctx context.Context,
 	quayClient quay.QuayService,
 	component *appstudioredhatcomv1alpha1.Component,
+	imageRepositoryName string,
 	opts *GenerateRepositoryOpts,
 ) (
 	*quay.Repository,
@@ -450,11 +646,10 @@ func (r *ComponentReconciler) generateImageRepository(
 ) {
 	log := ctrllog.FromContext(ctx)
 
-	imageRepositoryName := generateRepositoryName(component)
 	repo, err := quayClient.CreateRepository(quay.RepositoryRequest{
 		Namespace:   r.QuayOrganization,
 		Visibility:  opts.Visibility,
-		Description: "AppStudio repository for the user",
+		Description: getRepositoryOwnership(r.ClusterID, component).Description(imageRepositoryDescription),
 		Repository:  imageRepositoryName,
 	})
 	if err != nil {
@@ -464,7 +659,8 @@ func (r *ComponentReconciler) generateImageRepository(
 
 	pushRobotAccountName, pullRobotAccountName := generateRobotAccountsNames(component)
 
-	pushRobotAccount, err := quayClient.CreateRobotAccount(r.QuayOrganization, pushRobotAccountName)
+	pushRobotAccount, err := quayClient.CreateRobotAccountWithDescription(r.QuayOrganization, pushRobotAccountName,
+		getRobotAccountRequest(r.ClusterID, component, fmt.Sprintf("Push robot account of Component %s/%s", component.Namespace, component.Name)))
 	if err != nil {
 		log.Error(err, fmt.Sprintf("failed to create robot account %s", pushRobotAccountName), l.Action, l.ActionAdd, l.Audit, "true")
 		return nil, nil, nil, err
@@ -475,7 +671,8 @@ func (r *ComponentReconciler) generateImageRepository(
 		return nil, nil, nil, err
 	}
 
-	pullRobotAccount, err := quayClient.CreateRobotAccount(r.QuayOrganization, pullRobotAccountName)
+	pullRobotAccount, err := quayClient.CreateRobotAccountWithDescription(r.QuayOrganization, pullRobotAccountName,
+		getRobotAccountRequest(r.ClusterID, component, fmt.Sprintf("Pull robot account of Component %s/%s", component.Namespace, component.Name)))
 	if err != nil {
 		log.Error(err, fmt.Sprintf("failed to create robot account %s", pullRobotAccountName), l.Action, l.ActionAdd, l.Audit, "true")
 		return nil, nil, nil, err
@@ -490,7 +687,11 @@ func (r *ComponentReconciler) generateImageRepository(
 }
 
 // generateSecret dumps the robot account token into a Secret for future consumption.
-func generateSecret(c *appstudioredhatcomv1alpha1.Component, robotAccount *quay.RobotAccount, secretName, quayImageURL string) *corev1.Secret {
+func generateSecret(c *appstudioredhatcomv1alpha1.Component, robotAccount *quay.RobotAccount, secretName, quayImageURL string) (*corev1.Secret, error) {
+	secretData, err := generateDockerconfigSecretData(quayImageURL, robotAccount)
+	if err != nil {
+		return nil, err
+	}
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
@@ -505,16 +706,8 @@ func generateSecret(c *appstudioredhatcomv1alpha1.Component, robotAccount *quay.
 			},
 		},
 		Type:       corev1.SecretTypeDockerConfigJson,
-		StringData: generateDockerconfigSecretData(quayImageURL, robotAccount),
-	}
-}
-
-func generateDockerconfigSecretData(quayImageURL string, robotAccount *quay.RobotAccount) map[string]string {
-	secretData := map[string]string{}
-	authString := fmt.Sprintf("%s:%s", robotAccount.Name, robotAccount.Token)
-	secretData[corev1.DockerConfigJsonKey] = fmt.Sprintf(`{"auths":{"%s":{"auth":"%s"}}}`,
-		quayImageURL, base64.StdEncoding.EncodeToString([]byte(authString)))
-	return secretData
+		StringData: secretData,
+	}, nil
 }
 
 func getComponentIdForMetrics(component *appstudioredhatcomv1alpha1.Component) string {
@@ -60,7 +60,7 @@ func TestGenerateRemoteImage(t *testing.T) {
 		BuildQuayClient:  func(logr.Logger) quay.QuayService { return quayClient },
 		QuayOrganization: "redhat-user-workloads",
 	}
-	createdRepository, pushRobotAccount, pullRobotAccount, err := r.generateImageRepository(context.TODO(), quayClient, &testComponent, &GenerateRepositoryOpts{Visibility: "public"})
+	createdRepository, pushRobotAccount, pullRobotAccount, err := r.generateImageRepository(context.TODO(), quayClient, &testComponent, generateRepositoryName(&testComponent, ""), &GenerateRepositoryOpts{Visibility: "public"})
 
 	if err != nil {
 		t.Errorf("Error generating repository and setting up robot account, Expected nil, got %v", err)
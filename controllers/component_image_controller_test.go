@@ -29,6 +29,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
 	"github.com/konflux-ci/image-controller/pkg/quay"
 )
 
@@ -434,7 +435,12 @@ var _ = Describe("Component image controller", func() {
 
 			Eventually(func() bool { return isCreateRepositoryInvoked }, timeout, interval).Should(BeTrue())
 
-			expectedValue, _ := json.Marshal(&ImageRepositoryStatus{Message: "failed to generate image repository"})
+			expectedValue, _ := json.Marshal(&ImageRepositoryStatus{
+				Message:    "failed to generate image repository",
+				Version:    ImageRepositoryStatusVersion,
+				Stage:      ProvisionStageRepositoryCreation,
+				ErrorClass: imagerepositoryv1alpha1.ProvisionErrorClassTransient,
+			})
 			waitComponentAnnotationWithValue(resourceKey, ImageAnnotationName, string(expectedValue))
 		})
 
@@ -0,0 +1,413 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/go-logr/logr"
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestGetComponentRepositoryName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expectedName string
+		expectErr    bool
+	}{
+		{
+			name:         "Should use default name",
+			expectedName: "test-ns/my-app/my-component",
+		},
+		{
+			name:         "Should prefix custom name with namespace",
+			annotations:  map[string]string{ImageNameAnnotationName: "custom/name"},
+			expectedName: "test-ns/custom/name",
+		},
+		{
+			name:         "Should not prefix custom name with namespace twice",
+			annotations:  map[string]string{ImageNameAnnotationName: "/test-ns/custom-name"},
+			expectedName: "test-ns/custom-name",
+		},
+		{
+			name:        "Should reject invalid custom name",
+			annotations: map[string]string{ImageNameAnnotationName: "Custom_Name!"},
+			expectErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			component := &appstudioredhatcomv1alpha1.Component{
+				ObjectMeta: v1.ObjectMeta{Name: "my-component", Namespace: "test-ns", Annotations: tc.annotations},
+				Spec:       appstudioredhatcomv1alpha1.ComponentSpec{Application: "my-app"},
+			}
+
+			imageRepositoryName, err := getComponentRepositoryName(component, "")
+
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, but got image repository name %s", imageRepositoryName)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Expected no error, but got %v", err)
+			}
+			if imageRepositoryName != tc.expectedName {
+				t.Errorf("Expected image repository name %s, but got %s", tc.expectedName, imageRepositoryName)
+			}
+		})
+	}
+}
+
+func TestGetProvisionedRepositoryName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		annotations  map[string]string
+		expectedName string
+	}{
+		{
+			name:         "Should read name from image annotation",
+			annotations:  map[string]string{ImageAnnotationName: `{"image":"quay.io/test-org/test-ns/custom-name","visibility":"public"}`},
+			expectedName: "test-ns/custom-name",
+		},
+		{
+			name:         "Should fall back to default name if image annotation is missing",
+			expectedName: "test-ns/my-app/my-component",
+		},
+		{
+			name:         "Should fall back to default name if image annotation is invalid",
+			annotations:  map[string]string{ImageAnnotationName: `{"image":`},
+			expectedName: "test-ns/my-app/my-component",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			component := &appstudioredhatcomv1alpha1.Component{
+				ObjectMeta: v1.ObjectMeta{Name: "my-component", Namespace: "test-ns", Annotations: tc.annotations},
+				Spec:       appstudioredhatcomv1alpha1.ComponentSpec{Application: "my-app"},
+			}
+
+			if imageRepositoryName := getProvisionedRepositoryName(component, "", "test-org", ""); imageRepositoryName != tc.expectedName {
+				t.Errorf("Expected image repository name %s, but got %s", tc.expectedName, imageRepositoryName)
+			}
+		})
+	}
+}
+
+func TestDetachKeptImageRepositories(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	component := &appstudioredhatcomv1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{Name: "my-component", Namespace: "test-ns", UID: "component-uid"},
+	}
+	newImageRepository := func(name string, keep bool) *imagerepositoryv1alpha1.ImageRepository {
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: "test-ns",
+				Labels:    map[string]string{ApplicationNameLabelName: "my-app", ComponentNameLabelName: "my-component", "custom": "label"},
+				OwnerReferences: []v1.OwnerReference{
+					{APIVersion: "appstudio.redhat.com/v1alpha1", Kind: "Component", Name: "my-component", UID: "component-uid"},
+				},
+			},
+		}
+		if keep {
+			annotations.KeepOnComponentDeletion.Set(imageRepository, "true")
+		}
+		return imageRepository
+	}
+	notOwnedImageRepository := newImageRepository("not-owned", true)
+	notOwnedImageRepository.OwnerReferences[0].UID = "other-uid"
+	fakeClient := newTestClientBuilder(scheme).WithObjects(
+		component, newImageRepository("kept", true), newImageRepository("deleted", false), notOwnedImageRepository,
+	).Build()
+
+	r := &ComponentReconciler{Client: fakeClient}
+	ctx := context.TODO()
+	isDetached := func(name string) bool {
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: name}, imageRepository); err != nil {
+			t.Fatal(err)
+		}
+		if imageRepository.Labels["custom"] != "label" {
+			t.Errorf("expected other labels of %s to be kept, got %v", name, imageRepository.Labels)
+		}
+		return len(imageRepository.OwnerReferences) == 0 && !isComponentLinked(imageRepository)
+	}
+
+	if err := r.detachKeptImageRepositories(ctx, component); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isDetached("kept") {
+		t.Errorf("expected image repository with keep annotation to be detached")
+	}
+	if isDetached("deleted") || isDetached("not-owned") {
+		t.Errorf("expected other image repositories not to be detached")
+	}
+
+	// Keep annotation on the Component keeps all its image repositories
+	annotations.KeepOnComponentDeletion.Set(component, "true")
+	if err := r.detachKeptImageRepositories(ctx, component); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isDetached("deleted") {
+		t.Errorf("expected all component image repositories to be detached")
+	}
+	if isDetached("not-owned") {
+		t.Errorf("expected image repository of other component not to be detached")
+	}
+}
+
+func TestParseImageRepositoryStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected *ImageRepositoryStatus
+		wantErr  bool
+	}{
+		{
+			name:     "annotation written before the schema was versioned",
+			value:    `{"image":"quay.io/org/ns/app/comp","visibility":"public","secret":"comp"}`,
+			expected: &ImageRepositoryStatus{Image: "quay.io/org/ns/app/comp", Visibility: "public", Secret: "comp", Version: 1},
+		},
+		{
+			name:  "annotation with failed provision",
+			value: `{"message":"failed to generate image repository","version":2,"stage":"RepositoryCreation","errorClass":"transient","retryCount":1}`,
+			expected: &ImageRepositoryStatus{
+				Message:    "failed to generate image repository",
+				Version:    2,
+				Stage:      ProvisionStageRepositoryCreation,
+				ErrorClass: imagerepositoryv1alpha1.ProvisionErrorClassTransient,
+				RetryCount: 1,
+			},
+		},
+		{
+			name:     "annotation of newer version keeps known fields",
+			value:    `{"image":"quay.io/org/ns/app/comp","secret":"comp","version":3,"newField":"value"}`,
+			expected: &ImageRepositoryStatus{Image: "quay.io/org/ns/app/comp", Secret: "comp", Version: 3},
+		},
+		{
+			name:    "invalid annotation",
+			value:   `{"image"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repositoryInfo, err := ParseImageRepositoryStatus(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", repositoryInfo)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if *repositoryInfo != *tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, repositoryInfo)
+			}
+		})
+	}
+}
+
+func TestReportErrorCountsFailedRequests(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	component := &appstudioredhatcomv1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "my-component",
+			Namespace:   "test-ns",
+			Annotations: map[string]string{string(annotations.GenerateImage): "true"},
+		},
+	}
+	fakeClient := newTestClientBuilder(scheme).WithObjects(component).Build()
+	r := &ComponentReconciler{Client: fakeClient, Scheme: scheme}
+
+	for expectedRetryCount := 0; expectedRetryCount < 2; expectedRetryCount++ {
+		if err := r.reportError(context.TODO(), component, ProvisionStageRepositoryCreation, imagerepositoryv1alpha1.ProvisionErrorClassTransient, "failed to generate image repository"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		imageAnnotation, _ := annotations.Image.Get(component)
+		repositoryInfo, err := ParseImageRepositoryStatus(imageAnnotation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := ImageRepositoryStatus{
+			Message:    "failed to generate image repository",
+			Version:    ImageRepositoryStatusVersion,
+			Stage:      ProvisionStageRepositoryCreation,
+			ErrorClass: imagerepositoryv1alpha1.ProvisionErrorClassTransient,
+			RetryCount: expectedRetryCount,
+		}
+		if *repositoryInfo != expected {
+			t.Errorf("Expected %v, got %v", expected, repositoryInfo)
+		}
+		if _, exists := annotations.GenerateImage.Get(component); exists {
+			t.Errorf("Expected generate annotation to be removed")
+		}
+	}
+}
+
+func TestCleanupOptedOutComponent(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	componentOwner := []v1.OwnerReference{{APIVersion: "appstudio.redhat.com/v1alpha1", Kind: "Component", Name: "my-component", UID: "component-uid"}}
+	component := &appstudioredhatcomv1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "my-component",
+			Namespace: "test-ns",
+			UID:       "component-uid",
+			Annotations: map[string]string{
+				string(annotations.OptOut): "true",
+				string(annotations.Image):  `{"image":"quay.io/test-org/test-ns/my-app/my-component","visibility":"public","secret":"my-component","version":2}`,
+			},
+			Finalizers: []string{ImageRepositoryComponentFinalizer},
+		},
+		Spec: appstudioredhatcomv1alpha1.ComponentSpec{Application: "my-app"},
+	}
+	pushSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "my-component", Namespace: "test-ns", OwnerReferences: componentOwner}}
+	pullSecret := &corev1.Secret{ObjectMeta: v1.ObjectMeta{Name: "my-component-pull", Namespace: "test-ns", OwnerReferences: componentOwner}}
+	ownedImageRepository := &imagerepositoryv1alpha1.ImageRepository{ObjectMeta: v1.ObjectMeta{Name: "owned", Namespace: "test-ns", OwnerReferences: componentOwner}}
+	otherImageRepository := &imagerepositoryv1alpha1.ImageRepository{ObjectMeta: v1.ObjectMeta{Name: "other", Namespace: "test-ns"}}
+	fakeClient := newTestClientBuilder(scheme).WithObjects(component, pushSecret, pullSecret, ownedImageRepository, otherImageRepository).Build()
+
+	quay.ResetTestQuayClient()
+	defer quay.ResetTestQuayClient()
+	deletedRepositories := []string{}
+	quay.DeleteRepositoryFunc = func(organization, imageRepository string) (bool, error) {
+		deletedRepositories = append(deletedRepositories, imageRepository)
+		return true, nil
+	}
+	deletedRobotAccounts := []string{}
+	quay.DeleteRobotAccountFunc = func(organization, robotName string) (bool, error) {
+		deletedRobotAccounts = append(deletedRobotAccounts, robotName)
+		return true, nil
+	}
+	r := &ComponentReconciler{Client: fakeClient, Scheme: scheme, QuayOrganization: quay.TestQuayOrg,
+		BuildQuayClient: func(logr.Logger) quay.QuayService { return quay.TestQuayClient{} }}
+	ctx := context.TODO()
+
+	if err := r.cleanupOptedOutComponent(ctx, component); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(deletedRepositories, []string{"test-ns/my-app/my-component"}) {
+		t.Errorf("expected provisioned image repository to be deleted, got %v", deletedRepositories)
+	}
+	if !slices.Equal(deletedRobotAccounts, []string{"test-nsmy-appmy-component", "test-nsmy-appmy-component-pull"}) {
+		t.Errorf("expected component robot accounts to be deleted, got %v", deletedRobotAccounts)
+	}
+	for _, secret := range []*corev1.Secret{pushSecret, pullSecret} {
+		if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: secret.Name}, &corev1.Secret{}); !errors.IsNotFound(err) {
+			t.Errorf("expected secret %s to be deleted, got %v", secret.Name, err)
+		}
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: "owned"}, &imagerepositoryv1alpha1.ImageRepository{}); !errors.IsNotFound(err) {
+		t.Errorf("expected image repository owned by component to be deleted, got %v", err)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: "other"}, &imagerepositoryv1alpha1.ImageRepository{}); err != nil {
+		t.Errorf("expected other image repository to be kept, got %v", err)
+	}
+
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: "my-component"}, component); err != nil {
+		t.Fatal(err)
+	}
+	imageAnnotation, _ := annotations.Image.Get(component)
+	repositoryInfo, err := ParseImageRepositoryStatus(imageAnnotation)
+	if err != nil || repositoryInfo.Image != "" || repositoryInfo.Message != getOptOutMessage() {
+		t.Errorf("expected opt-out message in image annotation, got %s", imageAnnotation)
+	}
+	if len(component.Finalizers) != 0 {
+		t.Errorf("expected finalizer to be removed, got %v", component.Finalizers)
+	}
+
+	// Nothing is deleted again
+	deletedRepositories = nil
+	if err := r.cleanupOptedOutComponent(ctx, component); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deletedRepositories) != 0 {
+		t.Errorf("expected no image repository deletion, got %v", deletedRepositories)
+	}
+}
+
+func TestReservedRepositoryNameRejectedForComponent(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	component := &appstudioredhatcomv1alpha1.Component{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "my-component",
+			Namespace: "test-ns",
+			Annotations: map[string]string{
+				string(annotations.GenerateImage): `{"visibility":"public"}`,
+				string(annotations.ImageName):     "openshift",
+			},
+		},
+		Spec: appstudioredhatcomv1alpha1.ComponentSpec{Application: "my-app"},
+	}
+	fakeClient := newTestClientBuilder(scheme).WithObjects(component).Build()
+
+	quay.ResetTestQuayClient()
+	defer quay.ResetTestQuayClient()
+	quay.CreateRepositoryFunc = func(repository quay.RepositoryRequest) (*quay.Repository, error) {
+		t.Errorf("reserved image repository %s must not be created", repository.Repository)
+		return &quay.Repository{Name: repository.Repository}, nil
+	}
+	reservedNames, err := NewReservedRepositoryNames([]string{"openshift"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &ComponentReconciler{Client: fakeClient, Scheme: scheme, QuayOrganization: quay.TestQuayOrg, ReservedRepositoryNames: reservedNames,
+		BuildQuayClient: func(logr.Logger) quay.QuayService { return quay.TestQuayClient{} }}
+	ctx := context.TODO()
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test-ns", Name: "my-component"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "test-ns", Name: "my-component"}, component); err != nil {
+		t.Fatal(err)
+	}
+	imageAnnotation, _ := annotations.Image.Get(component)
+	repositoryInfo, err := ParseImageRepositoryStatus(imageAnnotation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repositoryInfo.Image != "" || repositoryInfo.Stage != ProvisionStageValidation || repositoryInfo.ErrorClass != imagerepositoryv1alpha1.ProvisionErrorClassPermanent ||
+		repositoryInfo.Message != "image repository name test-ns/openshift is reserved, openshift matches reserved name openshift" {
+		t.Errorf("expected reserved name rejection in image annotation, got %s", imageAnnotation)
+	}
+	if _, exists := annotations.GenerateImage.Get(component); exists {
+		t.Errorf("expected generate annotation to be removed")
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+func getOptOutMessage() string {
+	return fmt.Sprintf("Image repository provision is disabled by %s annotation", annotations.OptOut)
+}
+
+// cleanupOptedOutComponent removes everything provisioned for the Component that opted out of image repositories.
+// ImageRepositories owned by the Component are deleted, their finalizers clean up the Quay resources.
+// Image repository provisioned by the generate annotation is deleted together with its robot accounts and secrets.
+// The generate annotation is kept, so the provision is done once the opt-out annotation is removed.
+func (r *ComponentReconciler) cleanupOptedOutComponent(ctx context.Context, component *appstudioredhatcomv1alpha1.Component) error {
+	log := ctrllog.FromContext(ctx).WithName("OptOut")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList, client.InNamespace(component.Namespace)); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return err
+	}
+	isComponentOwner := func(ownerReference metav1.OwnerReference) bool { return ownerReference.UID == component.UID }
+	for _, imageRepository := range imageRepositoryList.Items {
+		if !slices.ContainsFunc(imageRepository.OwnerReferences, isComponentOwner) || !imageRepository.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Client.Delete(ctx, &imageRepository); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete image repository of opted out component", "ImageRepositoryName", imageRepository.Name, l.Action, l.ActionDelete)
+			return err
+		}
+		log.Info("Deleted image repository of opted out component", "ImageRepositoryName", imageRepository.Name, l.Action, l.ActionDelete, l.Audit, "true")
+	}
+
+	_, isGenerateRequested := annotations.GenerateImage.Get(component)
+	imageAnnotation, isLegacyRepository := annotations.Image.Get(component)
+	if !isGenerateRequested && !isLegacyRepository && !controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
+		return nil
+	}
+
+	if repositoryInfo, err := ParseImageRepositoryStatus(imageAnnotation); err == nil && repositoryInfo.Image != "" {
+		quayClient := r.BuildQuayClient(log)
+		quayClient.SetCorrelationID(getCorrelationID(ctx))
+		quayClient.SetRequestObserver(metrics.QuayRequestObserver("component", component.Namespace))
+		r.deleteLegacyImageRepository(ctx, component, quayClient)
+
+		for _, secretName := range []string{repositoryInfo.Secret, component.Name + "-pull"} {
+			if secretName == "" {
+				continue
+			}
+			if err := r.deleteComponentSecret(ctx, component, secretName); err != nil {
+				return err
+			}
+		}
+	}
+
+	hadFinalizer := controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer)
+	if isLegacyRepository || isGenerateRequested {
+		messageBytes, _ := json.Marshal(&ImageRepositoryStatus{Message: getOptOutMessage(), Version: ImageRepositoryStatusVersion})
+		annotations.Image.Set(component, string(messageBytes))
+	}
+	// Provenance is removed by finalizers of the deleted ImageRepositories, the Component is reconciled again then
+	if _, hasProvenance := annotations.ImageRepositories.Get(component); !hasProvenance {
+		controllerutil.RemoveFinalizer(component, ImageRepositoryComponentFinalizer)
+	}
+	if newImageAnnotation, _ := annotations.Image.Get(component); newImageAnnotation == imageAnnotation && hadFinalizer == controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
+		return nil
+	}
+	if err := r.Client.Update(ctx, component); err != nil {
+		log.Error(err, "failed to update opted out component", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Image repository provision disabled for component", l.Action, l.ActionUpdate)
+
+	delete(metrics.RepositoryTimesForMetrics, getComponentIdForMetrics(component))
+	return nil
+}
+
+// deleteComponentSecret deletes the secret, if it belongs to the Component.
+func (r *ComponentReconciler) deleteComponentSecret(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, secretName string) error {
+	log := ctrllog.FromContext(ctx)
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: component.Namespace, Name: secretName}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get secret", "SecretName", secretName, l.Action, l.ActionView)
+		return err
+	}
+	if !slices.ContainsFunc(secret.OwnerReferences, func(ownerReference metav1.OwnerReference) bool { return ownerReference.UID == component.UID }) {
+		log.Info("secret is not owned by the component, keeping it", "SecretName", secretName)
+		return nil
+	}
+	if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "failed to delete secret", "SecretName", secretName, l.Action, l.ActionDelete)
+		return err
+	}
+	log.Info("Deleted secret of opted out component", "SecretName", secretName, l.Action, l.ActionDelete)
+	return nil
+}
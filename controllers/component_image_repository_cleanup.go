@@ -0,0 +1,271 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+// ImageRepositoriesProvenanceAnnotationName holds JSON list of image repositories created for the Component
+// through ImageRepository objects, see ImageRepositoryProvenance.
+const ImageRepositoriesProvenanceAnnotationName = string(annotations.ImageRepositories)
+
+// ImageRepositoryProvenance records Quay resources created for an ImageRepository linked to a Component.
+// It's kept on the Component, so the resources could be cleaned up when the Component is deleted,
+// even if the ImageRepository was removed without running its finalizer.
+type ImageRepositoryProvenance struct {
+	ImageRepositoryName string   `json:"imageRepositoryName"`
+	ImageRepositoryUID  string   `json:"imageRepositoryUid"`
+	Repository          string   `json:"repository"`
+	RobotAccountNames   []string `json:"robotAccounts,omitempty"`
+}
+
+func getImageRepositoriesProvenance(component *appstudioredhatcomv1alpha1.Component) ([]ImageRepositoryProvenance, error) {
+	provenanceAnnotation, exists := annotations.ImageRepositories.Get(component)
+	if !exists {
+		return nil, nil
+	}
+	provenance := []ImageRepositoryProvenance{}
+	if err := json.Unmarshal([]byte(provenanceAnnotation), &provenance); err != nil {
+		return nil, err
+	}
+	return provenance, nil
+}
+
+func setImageRepositoriesProvenance(component *appstudioredhatcomv1alpha1.Component, provenance []ImageRepositoryProvenance) error {
+	if len(provenance) == 0 {
+		annotations.ImageRepositories.Remove(component)
+		return nil
+	}
+	provenanceBytes, err := json.Marshal(provenance)
+	if err != nil {
+		return err
+	}
+	annotations.ImageRepositories.Set(component, string(provenanceBytes))
+	return nil
+}
+
+// RecordComponentProvenance keeps the Component provenance annotation in sync with the Quay resources of the image repository.
+// The Component gets the image repository finalizer, so the resources could be cleaned up on the Component deletion.
+func (r *ImageRepositoryReconciler) RecordComponentProvenance(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	componentName := imageRepository.Labels[ComponentNameLabelName]
+	component := &appstudioredhatcomv1alpha1.Component{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}, component); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get component", "ComponentName", componentName, l.Action, l.ActionView)
+		return err
+	}
+	if !component.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	provenance, err := getImageRepositoriesProvenance(component)
+	if err != nil {
+		// The annotation was edited manually, start over
+		log.Info("Invalid image repositories provenance annotation, overriding it", "ComponentName", componentName)
+		provenance = nil
+	}
+	imageRepositoryProvenance := ImageRepositoryProvenance{
+		ImageRepositoryName: imageRepository.Name,
+		ImageRepositoryUID:  string(imageRepository.UID),
+		Repository:          imageRepository.Spec.Image.Name,
+		RobotAccountNames:   getDeletableRobotAccountNames(imageRepository),
+	}
+	index := slices.IndexFunc(provenance, func(p ImageRepositoryProvenance) bool { return p.ImageRepositoryName == imageRepository.Name })
+	if index >= 0 && isSameProvenance(provenance[index], imageRepositoryProvenance) && controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
+		return nil
+	}
+	if index >= 0 {
+		provenance[index] = imageRepositoryProvenance
+	} else {
+		provenance = append(provenance, imageRepositoryProvenance)
+	}
+
+	if err := setImageRepositoriesProvenance(component, provenance); err != nil {
+		log.Error(err, "failed to marshal image repositories provenance")
+		return err
+	}
+	controllerutil.AddFinalizer(component, ImageRepositoryComponentFinalizer)
+	if err := r.Client.Update(ctx, component); err != nil {
+		log.Error(err, "failed to record image repository provenance in component", "ComponentName", componentName, l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Recorded image repository provenance in component", "ComponentName", componentName, l.Action, l.ActionUpdate)
+	return nil
+}
+
+func isSameProvenance(a, b ImageRepositoryProvenance) bool {
+	return a.ImageRepositoryName == b.ImageRepositoryName && a.ImageRepositoryUID == b.ImageRepositoryUID &&
+		a.Repository == b.Repository && slices.Equal(a.RobotAccountNames, b.RobotAccountNames)
+}
+
+// forgetComponentProvenance removes the image repository from the Component provenance annotation
+// after the Quay resources were cleaned up by the ImageRepository finalizer.
+// The Component finalizer is removed together with the last provenance, unless the Component has a legacy image repository.
+// Failures are only logged, as the Component cleanup tolerates already deleted resources.
+func (r *ImageRepositoryReconciler) forgetComponentProvenance(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+	log := ctrllog.FromContext(ctx)
+
+	componentName := imageRepository.Labels[ComponentNameLabelName]
+	component := &appstudioredhatcomv1alpha1.Component{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}, component); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get component", "ComponentName", componentName, l.Action, l.ActionView)
+		}
+		return
+	}
+	provenance, err := getImageRepositoriesProvenance(component)
+	if err != nil || len(provenance) == 0 {
+		return
+	}
+	index := slices.IndexFunc(provenance, func(p ImageRepositoryProvenance) bool { return p.ImageRepositoryUID == string(imageRepository.UID) })
+	if index < 0 {
+		return
+	}
+	provenance = slices.Delete(provenance, index, index+1)
+	if err := setImageRepositoriesProvenance(component, provenance); err != nil {
+		log.Error(err, "failed to marshal image repositories provenance")
+		return
+	}
+	if _, isLegacyRepository := annotations.Image.Get(component); len(provenance) == 0 && !isLegacyRepository {
+		controllerutil.RemoveFinalizer(component, ImageRepositoryComponentFinalizer)
+	}
+	if err := r.Client.Update(ctx, component); err != nil {
+		log.Error(err, "failed to remove image repository provenance from component", "ComponentName", componentName, l.Action, l.ActionUpdate)
+		return
+	}
+	log.Info("Removed image repository provenance from component", "ComponentName", componentName, l.Action, l.ActionUpdate)
+}
+
+// cleanupOrphanedImageRepositories deletes Quay resources recorded in the Component provenance annotation
+// whose ImageRepository doesn't exist anymore, so its finalizer could not delete them.
+// The annotation may be edited by anyone who can edit the Component, so only image repositories
+// whose recorded ownership matches the ImageRepository UID are deleted. Robot accounts are deleted only if named
+// after the image repository, which must be owned by the ImageRepository or belong to the Component namespace.
+// Image repositories still used by other ImageRepository objects are kept.
+// The cleanup is best-effort, failures are only logged so the Component deletion is not blocked.
+func (r *ComponentReconciler) cleanupOrphanedImageRepositories(ctx context.Context, component *appstudioredhatcomv1alpha1.Component, quayClient quay.QuayService) {
+	log := ctrllog.FromContext(ctx)
+
+	provenance, err := getImageRepositoriesProvenance(component)
+	if err != nil {
+		log.Error(err, "failed to parse image repositories provenance annotation", l.Audit, "true")
+		return
+	}
+
+	for _, imageRepositoryProvenance := range provenance {
+		provenanceLog := log.WithValues("ImageRepositoryName", imageRepositoryProvenance.ImageRepositoryName, "ImageRepository", imageRepositoryProvenance.Repository)
+
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+		imageRepositoryKey := types.NamespacedName{Namespace: component.Namespace, Name: imageRepositoryProvenance.ImageRepositoryName}
+		if err := r.Client.Get(ctx, imageRepositoryKey, imageRepository); err == nil {
+			if string(imageRepository.UID) == imageRepositoryProvenance.ImageRepositoryUID {
+				// The ImageRepository finalizer takes care of the Quay resources
+				continue
+			}
+		} else if !errors.IsNotFound(err) {
+			provenanceLog.Error(err, "failed to get image repository", l.Action, l.ActionView)
+			continue
+		}
+
+		isOwned := false
+		if repository, err := quayClient.GetRepository(r.QuayOrganization, imageRepositoryProvenance.Repository); err == nil {
+			ownership, hasOwnership := quay.ParseRepositoryOwnership(repository.Description)
+			isOwned = hasOwnership && ownership.UID == imageRepositoryProvenance.ImageRepositoryUID
+		} else {
+			provenanceLog.Info("failed to get image repository", "Reason", err.Error(), l.Action, l.ActionView)
+		}
+		if !isOwned && !strings.HasPrefix(imageRepositoryProvenance.Repository, component.Namespace+"/") {
+			provenanceLog.Info("Image repository of other namespace wasn't created for the ImageRepository, skipping its cleanup",
+				"ImageRepositoryUID", imageRepositoryProvenance.ImageRepositoryUID, l.Audit, "true")
+			continue
+		}
+
+		provenanceLog.Info("ImageRepository is gone, cleaning up its Quay resources", l.Audit, "true")
+		for _, robotAccountName := range imageRepositoryProvenance.RobotAccountNames {
+			if !isCurrentRobotAccountName(imageRepositoryProvenance.Repository, robotAccountName, false) &&
+				!isCurrentRobotAccountName(imageRepositoryProvenance.Repository, robotAccountName, true) {
+				provenanceLog.Info("Robot account isn't named after the image repository, skipping its deletion", "RobotAccountName", robotAccountName, l.Audit, "true")
+				continue
+			}
+			isRobotAccountDeleted, err := quayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName)
+			if err != nil {
+				provenanceLog.Error(err, "failed to delete robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			}
+			if isRobotAccountDeleted {
+				provenanceLog.Info("Deleted robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete)
+			}
+		}
+
+		if !isOwned {
+			provenanceLog.Info("Image repository wasn't created for the ImageRepository, skipping its deletion", "ImageRepositoryUID", imageRepositoryProvenance.ImageRepositoryUID, l.Audit, "true")
+			continue
+		}
+		isUsed, err := r.isImageRepositoryUsed(ctx, component.Namespace, imageRepositoryProvenance.Repository)
+		if err != nil {
+			continue
+		}
+		if isUsed {
+			provenanceLog.Info("Image repository is still used by other ImageRepository, skipping its deletion")
+			continue
+		}
+		isRepositoryDeleted, err := quayClient.DeleteRepository(r.QuayOrganization, imageRepositoryProvenance.Repository)
+		if err != nil {
+			provenanceLog.Error(err, "failed to delete image repository", l.Action, l.ActionDelete, l.Audit, "true")
+		}
+		if isRepositoryDeleted {
+			provenanceLog.Info("Deleted image repository", l.Action, l.ActionDelete)
+		}
+	}
+}
+
+// isImageRepositoryUsed checks whether any ImageRepository object points to the given Quay repository.
+// Image repository names are prefixed with the namespace, so only the namespace and the admin namespaces are searched.
+func (r *ComponentReconciler) isImageRepositoryUsed(ctx context.Context, namespace, repository string) (bool, error) {
+	log := ctrllog.FromContext(ctx)
+
+	for _, searchedNamespace := range append([]string{namespace}, r.AdminNamespaces...) {
+		imageRepositories, err := r.getImageRepositoryIndexer().list(ctx, r.Client, imageNameIndexKey, repository, client.InNamespace(searchedNamespace))
+		if err != nil {
+			log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+			return false, err
+		}
+		if len(imageRepositories) != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+// detachKeptImageRepositories hands ImageRepositories of the deleted Component over to the namespace,
+// if the Component or the ImageRepository requests it by the keep on Component deletion annotation.
+// Owner reference to the Component and the Component labels are removed,
+// so the ImageRepository is not garbage collected and becomes a general purpose one.
+// The handoff is optimistic: it relies on the Component finalizer to run before the Component is gone.
+func (r *ComponentReconciler) detachKeptImageRepositories(ctx context.Context, component *appstudioredhatcomv1alpha1.Component) error {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList, client.InNamespace(component.Namespace)); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return err
+	}
+	keepAll := annotations.KeepOnComponentDeletion.IsTrue(component)
+	isComponentOwner := func(ownerReference metav1.OwnerReference) bool { return ownerReference.UID == component.UID }
+	for _, imageRepository := range imageRepositoryList.Items {
+		if !slices.ContainsFunc(imageRepository.OwnerReferences, isComponentOwner) {
+			continue
+		}
+		if !keepAll && !annotations.KeepOnComponentDeletion.IsTrue(&imageRepository) {
+			continue
+		}
+
+		imageRepository.OwnerReferences = slices.DeleteFunc(imageRepository.OwnerReferences, isComponentOwner)
+		delete(imageRepository.Labels, ApplicationNameLabelName)
+		delete(imageRepository.Labels, ComponentNameLabelName)
+		if err := r.Client.Update(ctx, &imageRepository); err != nil {
+			log.Error(err, "failed to detach image repository from component", "ImageRepositoryName", imageRepository.Name, l.Action, l.ActionUpdate)
+			return err
+		}
+		log.Info("Detached image repository from deleted component", "ImageRepositoryName", imageRepository.Name, l.Action, l.ActionUpdate, l.Audit, "true")
+	}
+	return nil
+}
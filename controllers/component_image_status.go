@@ -0,0 +1,75 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+// ImageRepositoryStatusVersion is the version of the image annotation schema written by the controller.
+// Version 1, written without the version field, has only image, visibility, secret and message.
+// Version 2 adds stage, errorClass and retryCount describing the failed provision.
+const ImageRepositoryStatusVersion = 2
+
+// ProvisionStage is the step of the legacy Component image repository provision.
+type ProvisionStage string
+
+const (
+	// ProvisionStageValidation means that the provision request or the image annotation is invalid.
+	ProvisionStageValidation ProvisionStage = "Validation"
+	// ProvisionStageRepositoryCreation means that the image repository or its robot accounts could not be created in Quay.
+	ProvisionStageRepositoryCreation ProvisionStage = "RepositoryCreation"
+	// ProvisionStageVisibilityUpdate means that the visibility of the existing image repository could not be changed.
+	ProvisionStageVisibilityUpdate ProvisionStage = "VisibilityUpdate"
+)
+
+// ParseImageRepositoryStatus reads the image annotation of a Component.
+// Annotations written before the schema was versioned get version 1. Annotations of newer versions are
+// read as far as the fields are known, so a rollback of the controller doesn't lose provisioned image repositories.
+func ParseImageRepositoryStatus(value string) (*ImageRepositoryStatus, error) {
+	repositoryInfo := &ImageRepositoryStatus{}
+	if err := json.Unmarshal([]byte(value), repositoryInfo); err != nil {
+		return nil, err
+	}
+	if repositoryInfo.Version == 0 {
+		repositoryInfo.Version = 1
+	}
+	return repositoryInfo, nil
+}
+
+// failedAttempts returns the number of failed provision requests in a row, including the recorded one.
+func (s *ImageRepositoryStatus) failedAttempts() int {
+	if s.Stage == "" {
+		return 0
+	}
+	return s.RetryCount + 1
+}
+
+// setFailure records the failed provision stage, retryCount is the number of previous failed requests.
+func (s *ImageRepositoryStatus) setFailure(stage ProvisionStage, errorClass imagerepositoryv1alpha1.ProvisionErrorClass, message string, retryCount int) {
+	s.Message = message
+	s.Stage = stage
+	s.ErrorClass = errorClass
+	s.RetryCount = retryCount
+}
+
+// clearFailure removes the recorded failure, before the provision is attempted again.
+func (s *ImageRepositoryStatus) clearFailure() {
+	s.setFailure("", "", "", 0)
+}
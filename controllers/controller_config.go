@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// ControllerConfigMapName is name of the ConfigMap with effective controller configuration
+	// in the controller namespace.
+	ControllerConfigMapName = "image-controller-config"
+
+	controllerConfigSyncInterval = time.Minute
+)
+
+// ControllerConfig is the effective controller configuration that tools need to render image URLs.
+type ControllerConfig struct {
+	QuayOrganization             string
+	RegistryHost                 string
+	DefaultVisibility            imagerepositoryv1alpha1.ImageVisibility
+	MaxImageRepositoryNameLength int
+	DryRun                       bool
+}
+
+func (c ControllerConfig) data() map[string]string {
+	return map[string]string{
+		"quayOrganization":             c.QuayOrganization,
+		"registryHost":                 c.RegistryHost,
+		"imageURLPrefix":               c.RegistryHost + "/" + c.QuayOrganization,
+		"defaultVisibility":            string(c.DefaultVisibility),
+		"maxImageRepositoryNameLength": strconv.Itoa(c.MaxImageRepositoryNameLength),
+		"dryRun":                       strconv.FormatBool(c.DryRun),
+	}
+}
+
+// ControllerConfigPublisher publishes the controller configuration in a ConfigMap.
+// The ConfigMap is periodically reverted to the effective configuration, so it's read-only for the readers.
+type ControllerConfigPublisher struct {
+	Client    client.Client
+	Namespace string
+	Config    ControllerConfig
+}
+
+// Start implements manager.Runnable
+func (p *ControllerConfigPublisher) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("ControllerConfigPublisher")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	ticker := time.NewTicker(controllerConfigSyncInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.EnsureConfigMap(ctx); err != nil {
+			log.Error(err, "failed to publish controller configuration")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnsureConfigMap creates or updates the ConfigMap with the controller configuration.
+func (p *ControllerConfigPublisher) EnsureConfigMap(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx)
+
+	data := p.Config.data()
+	configMap := &corev1.ConfigMap{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Namespace: p.Namespace, Name: ControllerConfigMapName}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get controller config map", l.Action, l.ActionView)
+			return err
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ControllerConfigMapName,
+				Namespace: p.Namespace,
+			},
+			Data: data,
+		}
+		if err := p.Client.Create(ctx, configMap); err != nil {
+			log.Error(err, "failed to create controller config map", l.Action, l.ActionAdd)
+			return err
+		}
+		log.Info("Published controller configuration", "ConfigMap", ControllerConfigMapName)
+		return nil
+	}
+
+	if reflect.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+	configMap.Data = data
+	if err := p.Client.Update(ctx, configMap); err != nil {
+		log.Error(err, "failed to update controller config map", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Updated controller configuration", "ConfigMap", ControllerConfigMapName)
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+func TestControllerConfigPublisherEnsureConfigMap(t *testing.T) {
+	scheme := newTestScheme(t)
+	fakeClient := newTestClientBuilder(scheme).Build()
+	p := &ControllerConfigPublisher{
+		Client:    fakeClient,
+		Namespace: "image-controller",
+		Config: ControllerConfig{
+			QuayOrganization:             "my-org",
+			RegistryHost:                 "quay.io",
+			DefaultVisibility:            imagerepositoryv1alpha1.ImageVisibilityPublic,
+			MaxImageRepositoryNameLength: 255,
+		},
+	}
+	ctx := context.TODO()
+	configMapKey := types.NamespacedName{Namespace: "image-controller", Name: ControllerConfigMapName}
+
+	if err := p.EnsureConfigMap(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := fakeClient.Get(ctx, configMapKey, configMap); err != nil {
+		t.Fatal(err)
+	}
+	expectedData := map[string]string{
+		"quayOrganization":             "my-org",
+		"registryHost":                 "quay.io",
+		"imageURLPrefix":               "quay.io/my-org",
+		"defaultVisibility":            "public",
+		"maxImageRepositoryNameLength": "255",
+		"dryRun":                       "false",
+	}
+	for key, value := range expectedData {
+		if configMap.Data[key] != value {
+			t.Errorf("expected %s to be %q, got %q", key, value, configMap.Data[key])
+		}
+	}
+
+	configMap.Data["quayOrganization"] = "other-org"
+	configMap.Data["extra"] = "value"
+	if err := fakeClient.Update(ctx, configMap); err != nil {
+		t.Fatal(err)
+	}
+	p.Config.QuayOrganization = "new-org"
+	if err := p.EnsureConfigMap(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fakeClient.Get(ctx, configMapKey, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data["quayOrganization"] != "new-org" || configMap.Data["imageURLPrefix"] != "quay.io/new-org" {
+		t.Errorf("expected config map to be synced with the configuration, got %v", configMap.Data)
+	}
+	if _, exists := configMap.Data["extra"]; exists {
+		t.Error("expected manual changes to be reverted")
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+type correlationIDKey struct{}
+
+// withCorrelationID makes sure the reconcile has a correlation ID in both the context and the logger.
+// controller-runtime already logs a generated ID as reconcileID, it's reused so the IDs match.
+func withCorrelationID(ctx context.Context, log logr.Logger) (context.Context, logr.Logger) {
+	correlationID := string(controller.ReconcileIDFromContext(ctx))
+	if correlationID == "" {
+		// Reconcile is not called by controller-runtime, e.g. in tests
+		correlationID = string(uuid.NewUUID())
+		log = log.WithValues("reconcileID", correlationID)
+	}
+	return context.WithValue(ctx, correlationIDKey{}, correlationID), log
+}
+
+// getCorrelationID returns correlation ID of the current reconcile, it's sent to Quay with each request.
+func getCorrelationID(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
+
+// recordFailureCorrelationID saves correlation ID of the failed reconcile in the image repository status,
+// so the related controller and Quay logs could be found during support cases.
+// Failures are only logged, the reconcile is retried anyway.
+func (r *ImageRepositoryReconciler) recordFailureCorrelationID(ctx context.Context, imageRepositoryKey types.NamespacedName) {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := r.Client.Get(ctx, imageRepositoryKey, imageRepository); err != nil {
+		return
+	}
+	correlationID := getCorrelationID(ctx)
+	if imageRepository.Status.LastFailureCorrelationID == correlationID {
+		return
+	}
+	imageRepository.Status.LastFailureCorrelationID = correlationID
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to record correlation ID of failed reconcile", l.Action, l.ActionUpdate)
+	}
+}
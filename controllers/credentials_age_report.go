@@ -0,0 +1,266 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// CredentialsAgeReportConfigMapName is name of the ConfigMap in tenant namespaces
+	// listing image repositories with aged credentials.
+	CredentialsAgeReportConfigMapName = "image-repository-credentials-age"
+	// CredentialsAgeReportLabelName marks the ConfigMaps maintained by CredentialsAgeReporter.
+	CredentialsAgeReportLabelName = "appstudio.redhat.com/credentials-age-report"
+
+	CredentialsAgeStatusNearingRotation = "NearingRotation"
+	CredentialsAgeStatusExpired         = "Expired"
+
+	credentialsAgeReportInterval = 10 * time.Minute
+)
+
+// CredentialsAgeReportEntry describes credentials of an image repository in the report ConfigMap.
+type CredentialsAgeReportEntry struct {
+	Image               string `json:"image"`
+	GenerationTimestamp string `json:"generationTimestamp"`
+	Status              string `json:"status"`
+}
+
+// CredentialsAgeReporter publishes per namespace ConfigMap with image repositories, credentials of which
+// are older than RotationWarningAge, for users without access to the metrics.
+// Credentials older than MaxAge, if set, are reported as expired.
+// The ConfigMap is removed from the namespace once there is nothing to report.
+// ConfigMaps without CredentialsAgeReportLabelName, e.g. created by the tenant, are never overwritten nor deleted.
+type CredentialsAgeReporter struct {
+	Client             client.Client
+	RotationWarningAge time.Duration
+	MaxAge             time.Duration
+
+	// reportedNamespaces holds namespaces with the report ConfigMap published by the last Report.
+	// It's nil before the first one, when the reports left by the previous run are listed by CredentialsAgeReportLabelName,
+	// so stale reports are removed also from namespaces without image repositories anymore.
+	// It avoids listing ConfigMaps of the whole cluster on each Report.
+	reportedNamespaces map[string]bool
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (p *CredentialsAgeReporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable
+func (p *CredentialsAgeReporter) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("CredentialsAgeReporter")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	ticker := time.NewTicker(credentialsAgeReportInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.Report(ctx); err != nil {
+			log.Error(err, "failed to report credentials age")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// getCredentialsAgeStatus returns status of the image repository credentials, empty if they don't need to be reported.
+// Removed credentials and externally managed push secrets are not rotated by the operator, so they are skipped.
+func (p *CredentialsAgeReporter) getCredentialsAgeStatus(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	generationTimestamp := imageRepository.Status.Credentials.GenerationTimestamp
+	if generationTimestamp == nil || isCredentialsRemoved(imageRepository) ||
+		isExistingSecret(imageRepository, imageRepository.Status.Credentials.PushSecretName) {
+		return ""
+	}
+	age := elapsedSince(generationTimestamp.Time)
+	if p.MaxAge > 0 && age > p.MaxAge {
+		return CredentialsAgeStatusExpired
+	}
+	if age > p.RotationWarningAge {
+		return CredentialsAgeStatusNearingRotation
+	}
+	return ""
+}
+
+// Report updates the report ConfigMaps according to the current credentials of all image repositories.
+func (p *CredentialsAgeReporter) Report(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := p.Client.List(ctx, imageRepositoryList); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return err
+	}
+
+	staleReportNamespaces := p.reportedNamespaces
+	if staleReportNamespaces == nil {
+		publishedReportNamespaces, err := p.getPublishedReportNamespaces(ctx)
+		if err != nil {
+			return err
+		}
+		staleReportNamespaces = publishedReportNamespaces
+	}
+
+	reports := map[string]map[string]string{}
+	for i := range imageRepositoryList.Items {
+		imageRepository := &imageRepositoryList.Items[i]
+		status := p.getCredentialsAgeStatus(imageRepository)
+		if status == "" {
+			continue
+		}
+		entry, err := json.Marshal(CredentialsAgeReportEntry{
+			Image:               imageRepository.Spec.Image.Name,
+			GenerationTimestamp: imageRepository.Status.Credentials.GenerationTimestamp.UTC().Format(time.RFC3339),
+			Status:              status,
+		})
+		if err != nil {
+			return err
+		}
+		if reports[imageRepository.Namespace] == nil {
+			reports[imageRepository.Namespace] = map[string]string{}
+		}
+		reports[imageRepository.Namespace][imageRepository.Name] = string(entry)
+	}
+
+	// Failure in one namespace doesn't block reports of the others nor the cleanup of stale ones.
+	// Namespaces that failed are kept as reported, so they are checked again by the next Report.
+	var reportErrors []error
+	reportedNamespaces := map[string]bool{}
+	for namespace, data := range reports {
+		isReported, err := p.ensureReportConfigMap(ctx, namespace, data)
+		if err != nil {
+			reportErrors = append(reportErrors, err)
+			reportedNamespaces[namespace] = true
+		} else if isReported {
+			reportedNamespaces[namespace] = true
+		}
+	}
+
+	for namespace := range staleReportNamespaces {
+		if reports[namespace] != nil {
+			continue
+		}
+		if err := p.deleteReportConfigMap(ctx, namespace); err != nil {
+			reportErrors = append(reportErrors, err)
+			reportedNamespaces[namespace] = true
+		}
+	}
+	p.reportedNamespaces = reportedNamespaces
+	return goerrors.Join(reportErrors...)
+}
+
+// getPublishedReportNamespaces returns namespaces with the report ConfigMap maintained by the reporter.
+// Only labeled ConfigMaps are listed, the label selector is evaluated by the API server, as ConfigMaps are not cached.
+func (p *CredentialsAgeReporter) getPublishedReportNamespaces(ctx context.Context) (map[string]bool, error) {
+	log := ctrllog.FromContext(ctx)
+
+	configMapList := &corev1.ConfigMapList{}
+	if err := p.Client.List(ctx, configMapList, client.MatchingLabels{CredentialsAgeReportLabelName: "true"}); err != nil {
+		log.Error(err, "failed to list credentials age report config maps", l.Action, l.ActionView)
+		return nil, err
+	}
+	namespaces := map[string]bool{}
+	for _, configMap := range configMapList.Items {
+		if configMap.Name == CredentialsAgeReportConfigMapName {
+			namespaces[configMap.Namespace] = true
+		}
+	}
+	return namespaces, nil
+}
+
+// deleteReportConfigMap deletes the report ConfigMap from the namespace, if it's maintained by the reporter.
+func (p *CredentialsAgeReporter) deleteReportConfigMap(ctx context.Context, namespace string) error {
+	log := ctrllog.FromContext(ctx).WithValues("Namespace", namespace)
+
+	configMap := &corev1.ConfigMap{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get credentials age report config map", l.Action, l.ActionView)
+		return err
+	}
+	if configMap.Labels[CredentialsAgeReportLabelName] != "true" {
+		return nil
+	}
+	if err := p.Client.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "failed to delete credentials age report config map", l.Action, l.ActionDelete)
+		return err
+	}
+	log.Info("Deleted credentials age report", l.Action, l.ActionDelete)
+	return nil
+}
+
+// ensureReportConfigMap creates or updates the report ConfigMap in the namespace.
+// Returns false if the namespace already has a ConfigMap with the same name not maintained by the reporter, which is kept as is.
+func (p *CredentialsAgeReporter) ensureReportConfigMap(ctx context.Context, namespace string, data map[string]string) (bool, error) {
+	log := ctrllog.FromContext(ctx).WithValues("Namespace", namespace)
+
+	configMap := &corev1.ConfigMap{}
+	if err := p.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get credentials age report config map", l.Action, l.ActionView)
+			return false, err
+		}
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      CredentialsAgeReportConfigMapName,
+				Namespace: namespace,
+				Labels:    map[string]string{CredentialsAgeReportLabelName: "true"},
+			},
+			Data: data,
+		}
+		if err := p.Client.Create(ctx, configMap); err != nil {
+			log.Error(err, "failed to create credentials age report config map", l.Action, l.ActionAdd)
+			return false, err
+		}
+		log.Info("Published credentials age report", "ImageRepositories", len(data))
+		return true, nil
+	}
+
+	if configMap.Labels[CredentialsAgeReportLabelName] != "true" {
+		log.Info("credentials age report is not published, config map with the same name is not maintained by the operator", "ConfigMapName", CredentialsAgeReportConfigMapName)
+		return false, nil
+	}
+	if reflect.DeepEqual(configMap.Data, data) {
+		return true, nil
+	}
+	configMap.Data = data
+	if err := p.Client.Update(ctx, configMap); err != nil {
+		log.Error(err, "failed to update credentials age report config map", l.Action, l.ActionUpdate)
+		return false, err
+	}
+	log.Info("Updated credentials age report", "ImageRepositories", len(data))
+	return true, nil
+}
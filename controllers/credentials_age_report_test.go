@@ -0,0 +1,226 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+func TestCredentialsAgeReporterReport(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	newImageRepository := func(namespace, name string, age time.Duration) *imagerepositoryv1alpha1.ImageRepository {
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: imagerepositoryv1alpha1.ImageRepositorySpec{
+				Image: imagerepositoryv1alpha1.ImageParameters{Name: namespace + "/" + name},
+			},
+		}
+		imageRepository.Status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now().Add(-age)}
+		imageRepository.Status.Credentials.PushSecretName = name + "-image-push"
+		return imageRepository
+	}
+	removedCredentials := newImageRepository("tenant-a", "removed", 100*24*time.Hour)
+	removedCredentials.Status.Conditions = []metav1.Condition{{
+		Type:   imagerepositoryv1alpha1.ConditionTypeCredentialsRemoved,
+		Status: metav1.ConditionTrue,
+		Reason: "Deprovisioned",
+	}}
+	staleReport := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CredentialsAgeReportConfigMapName,
+			Namespace: "tenant-b",
+			Labels:    map[string]string{CredentialsAgeReportLabelName: "true"},
+		},
+		Data: map[string]string{"rotated": "{}"},
+	}
+	// ConfigMaps of tenants with the same name are not maintained by the reporter
+	tenantConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CredentialsAgeReportConfigMapName, Namespace: "tenant-c"},
+		Data:       map[string]string{"key": "tenant data"},
+	}
+	// Report left in namespace where the last image repository was deleted while the operator was down
+	orphanedReport := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CredentialsAgeReportConfigMapName,
+			Namespace: "tenant-e",
+			Labels:    map[string]string{CredentialsAgeReportLabelName: "true"},
+		},
+		Data: map[string]string{"deleted": "{}"},
+	}
+	freshTenantConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CredentialsAgeReportConfigMapName, Namespace: "tenant-d"},
+		Data:       map[string]string{"key": "tenant data"},
+	}
+
+	fakeClient := newTestClientBuilder(scheme).WithObjects(
+		newImageRepository("tenant-a", "fresh", time.Hour),
+		newImageRepository("tenant-a", "aged", 40*24*time.Hour),
+		newImageRepository("tenant-a", "expired", 100*24*time.Hour),
+		removedCredentials,
+		newImageRepository("tenant-b", "rotated", time.Hour),
+		staleReport,
+		newImageRepository("tenant-c", "aged", 40*24*time.Hour),
+		tenantConfigMap,
+		newImageRepository("tenant-d", "fresh", time.Hour),
+		freshTenantConfigMap,
+		orphanedReport,
+	).Build()
+	p := &CredentialsAgeReporter{
+		Client:             fakeClient,
+		RotationWarningAge: 30 * 24 * time.Hour,
+		MaxAge:             90 * 24 * time.Hour,
+	}
+	ctx := context.TODO()
+
+	if err := p.Report(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-a", Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		t.Fatal(err)
+	}
+	if len(configMap.Data) != 2 {
+		t.Errorf("expected aged and expired credentials to be reported, got %v", configMap.Data)
+	}
+	expectedStatuses := map[string]string{"aged": CredentialsAgeStatusNearingRotation, "expired": CredentialsAgeStatusExpired}
+	for name, expectedStatus := range expectedStatuses {
+		entry := CredentialsAgeReportEntry{}
+		if err := json.Unmarshal([]byte(configMap.Data[name]), &entry); err != nil {
+			t.Fatalf("failed to parse report entry of %s: %v", name, err)
+		}
+		if entry.Status != expectedStatus || entry.Image != "tenant-a/"+name {
+			t.Errorf("expected %s credentials to be reported as %s, got %+v", name, expectedStatus, entry)
+		}
+	}
+
+	err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-b", Name: CredentialsAgeReportConfigMapName}, configMap)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected report to be deleted from namespace without aged credentials, got %v", err)
+	}
+	err = fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-e", Name: CredentialsAgeReportConfigMapName}, configMap)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected report to be deleted from namespace without image repositories, got %v", err)
+	}
+
+	for _, tenantConfigMap := range []*corev1.ConfigMap{tenantConfigMap, freshTenantConfigMap} {
+		if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: tenantConfigMap.Namespace, Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+			t.Fatalf("expected config map of tenant to be kept, got %v", err)
+		}
+		if !reflect.DeepEqual(configMap.Data, tenantConfigMap.Data) || configMap.Labels[CredentialsAgeReportLabelName] != "" {
+			t.Errorf("expected config map of tenant not to be overwritten, got %v", configMap)
+		}
+	}
+
+	// Published report is deleted once credentials are rotated
+	for _, name := range []string{"aged", "expired"} {
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-a", Name: name}, imageRepository); err != nil {
+			t.Fatal(err)
+		}
+		imageRepository.Status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
+		if err := fakeClient.Status().Update(ctx, imageRepository); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Report(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-a", Name: CredentialsAgeReportConfigMapName}, configMap)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected report to be deleted once credentials are rotated, got %v", err)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-c", Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		t.Errorf("expected config map of tenant to be kept, got %v", err)
+	}
+}
+
+func TestCredentialsAgeReporterReportContinuesOnError(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	newAgedImageRepository := func(namespace string) *imagerepositoryv1alpha1.ImageRepository {
+		imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+			ObjectMeta: metav1.ObjectMeta{Name: "aged", Namespace: namespace},
+			Spec: imagerepositoryv1alpha1.ImageRepositorySpec{
+				Image: imagerepositoryv1alpha1.ImageParameters{Name: namespace + "/aged"},
+			},
+		}
+		imageRepository.Status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now().Add(-40 * 24 * time.Hour)}
+		return imageRepository
+	}
+	staleReport := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CredentialsAgeReportConfigMapName,
+			Namespace: "tenant-c",
+			Labels:    map[string]string{CredentialsAgeReportLabelName: "true"},
+		},
+		Data: map[string]string{"deleted": "{}"},
+	}
+
+	isCreateFailing := true
+	fakeClient := newTestClientBuilder(scheme).
+		WithObjects(newAgedImageRepository("tenant-a"), newAgedImageRepository("tenant-b"), staleReport).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if isCreateFailing && obj.GetNamespace() == "tenant-a" {
+					return fmt.Errorf("create failed")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+	p := &CredentialsAgeReporter{
+		Client:             fakeClient,
+		RotationWarningAge: 30 * 24 * time.Hour,
+	}
+	ctx := context.TODO()
+
+	if err := p.Report(ctx); err == nil {
+		t.Fatal("expected error of the failed namespace to be returned")
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-b", Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		t.Errorf("expected report to be published in other namespaces, got %v", err)
+	}
+	err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-c", Name: CredentialsAgeReportConfigMapName}, configMap)
+	if !errors.IsNotFound(err) {
+		t.Errorf("expected stale report to be deleted despite the failure, got %v", err)
+	}
+
+	// The failed namespace is retried by the next report
+	isCreateFailing = false
+	if err := p.Report(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-a", Name: CredentialsAgeReportConfigMapName}, configMap); err != nil {
+		t.Errorf("expected report to be published once the failure is gone, got %v", err)
+	}
+}
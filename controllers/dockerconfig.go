@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+var (
+	// Quay robot account names are <organization>+<name>
+	robotAccountNameRegexp  = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*(\+[a-z0-9][a-z0-9._-]*)?$`)
+	robotAccountTokenRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// InvalidDockerConfigError is returned when the docker config for a secret cannot be generated safely,
+// e.g. because Quay returned a partial robot account. The secret must not be written then.
+type InvalidDockerConfigError struct {
+	Reason string
+}
+
+func (e *InvalidDockerConfigError) Error() string {
+	return "invalid docker config: " + e.Reason
+}
+
+type dockerConfigJson struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+type dockerConfigAuth struct {
+	Auth string `json:"auth"`
+}
+
+// generateDockerconfigSecretData returns dockerconfigjson secret data with the robot account credentials for the image.
+// Both the robot account and the generated config are checked, so corrupted credentials never get into the secret.
+func generateDockerconfigSecretData(quayImageURL string, robotAccount *quay.RobotAccount) (map[string]string, error) {
+	if robotAccount == nil {
+		return nil, &InvalidDockerConfigError{Reason: "robot account is missing"}
+	}
+	if !robotAccountNameRegexp.MatchString(robotAccount.Name) {
+		return nil, &InvalidDockerConfigError{Reason: fmt.Sprintf("malformed robot account name %q", robotAccount.Name)}
+	}
+	if !robotAccountTokenRegexp.MatchString(robotAccount.Token) {
+		// Never log the token itself
+		return nil, &InvalidDockerConfigError{Reason: fmt.Sprintf("malformed token of robot account %s", robotAccount.Name)}
+	}
+	if quayImageURL == "" {
+		return nil, &InvalidDockerConfigError{Reason: "image url is missing"}
+	}
+
+	authString := fmt.Sprintf("%s:%s", robotAccount.Name, robotAccount.Token)
+	dockerConfig := dockerConfigJson{Auths: map[string]dockerConfigAuth{
+		quayImageURL: {Auth: base64.StdEncoding.EncodeToString([]byte(authString))},
+	}}
+	dockerConfigBytes, err := json.Marshal(dockerConfig)
+	if err != nil {
+		return nil, &InvalidDockerConfigError{Reason: err.Error()}
+	}
+	if err := verifyDockerConfig(dockerConfigBytes, quayImageURL, authString); err != nil {
+		return nil, err
+	}
+	return map[string]string{corev1.DockerConfigJsonKey: string(dockerConfigBytes)}, nil
+}
+
+// verifyDockerConfig parses the generated docker config back and checks it holds exactly the expected credentials.
+func verifyDockerConfig(dockerConfigBytes []byte, quayImageURL, authString string) error {
+	dockerConfig := dockerConfigJson{}
+	if err := json.Unmarshal(dockerConfigBytes, &dockerConfig); err != nil {
+		return &InvalidDockerConfigError{Reason: fmt.Sprintf("generated config cannot be parsed: %v", err)}
+	}
+	auth, found := dockerConfig.Auths[quayImageURL]
+	if !found || len(dockerConfig.Auths) != 1 {
+		return &InvalidDockerConfigError{Reason: "generated config doesn't hold the image registry"}
+	}
+	decodedAuth, err := base64.StdEncoding.DecodeString(auth.Auth)
+	if err != nil || string(decodedAuth) != authString {
+		return &InvalidDockerConfigError{Reason: "generated config doesn't hold the robot account credentials"}
+	}
+	return nil
+}
+
+// verifyExistingDockerConfigSecret checks that the externally managed secret holds credentials applicable to the image,
+// i.e. the auths entry of the image, its organization or the registry host, where Quay accepts credentials of any scope.
+func verifyExistingDockerConfigSecret(secret *corev1.Secret, quayImageURL string) error {
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return &InvalidDockerConfigError{Reason: fmt.Sprintf("secret %s is not of %s type", secret.Name, corev1.SecretTypeDockerConfigJson)}
+	}
+	dockerConfig := dockerConfigJson{}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		return &InvalidDockerConfigError{Reason: fmt.Sprintf("secret %s config cannot be parsed: %v", secret.Name, err)}
+	}
+	for registry, auth := range dockerConfig.Auths {
+		registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+		if registry != quayImageURL && !strings.HasPrefix(quayImageURL, registry+"/") {
+			continue
+		}
+		decodedAuth, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			continue
+		}
+		if username, token, found := strings.Cut(string(decodedAuth), ":"); found && username != "" && token != "" {
+			return nil
+		}
+	}
+	return &InvalidDockerConfigError{Reason: fmt.Sprintf("secret %s doesn't hold credentials for %s", secret.Name, quayImageURL)}
+}
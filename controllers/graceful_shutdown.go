@@ -0,0 +1,125 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// errProvisionInterrupted is returned by the provision that saved its progress because of the controller shutdown.
+var errProvisionInterrupted = errors.New("provision interrupted by controller shutdown")
+
+type shutdownSignalKey struct{}
+
+// ShutdownDrain lets in-flight reconciles finish their sequences of Quay mutations when the controller is stopped.
+// The reconcile context is canceled only after Timeout since the shutdown, otherwise a sequence could be cut off
+// between creating objects in Quay and recording them in the cluster.
+type ShutdownDrain struct {
+	Timeout time.Duration
+}
+
+// Detach returns context that is canceled Timeout after ctx is canceled.
+// The cancellation of ctx is still reported by isShuttingDown, so long sequences could save progress and stop early.
+func (d *ShutdownDrain) Detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithValue(context.WithoutCancel(ctx), shutdownSignalKey{}, ctx.Done()))
+	go func() {
+		select {
+		case <-drainCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		timer := time.NewTimer(d.Timeout)
+		defer timer.Stop()
+		select {
+		case <-drainCtx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	return drainCtx, cancel
+}
+
+// isShuttingDown checks whether the controller is being stopped while the reconcile is drained.
+func isShuttingDown(ctx context.Context) bool {
+	shutdownSignal, isDrained := ctx.Value(shutdownSignalKey{}).(<-chan struct{})
+	if !isDrained {
+		return false
+	}
+	select {
+	case <-shutdownSignal:
+		return true
+	default:
+		return false
+	}
+}
+
+// isProvisionInterrupted checks whether the provision stopped because of the controller shutdown, so it's not a failed attempt.
+func isProvisionInterrupted(err error) bool {
+	return errors.Is(err, errProvisionInterrupted)
+}
+
+// saveInterruptedProvision records robot accounts and secrets created so far by the provision, if the controller is being stopped.
+// The resumed provision reuses the recorded robot accounts instead of creating new ones, and a rollback of the attempt deletes them.
+// Returns errProvisionInterrupted if the progress is saved, so the provision must not continue.
+func (r *ImageRepositoryReconciler) saveInterruptedProvision(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, pushCredentialsInfo, pullCredentialsInfo *imageRepositoryAccessData) error {
+	if !isShuttingDown(ctx) {
+		return nil
+	}
+	log := ctrllog.FromContext(ctx)
+
+	if pushCredentialsInfo != nil {
+		imageRepository.Status.Credentials.PushRobotAccountName = pushCredentialsInfo.RobotAccountName
+		imageRepository.Status.Credentials.PushSecretName = pushCredentialsInfo.SecretName
+	}
+	if pullCredentialsInfo != nil {
+		imageRepository.Status.Credentials.PullRobotAccountName = pullCredentialsInfo.RobotAccountName
+		imageRepository.Status.Credentials.PullSecretName = pullCredentialsInfo.SecretName
+	}
+	imageRepository.Status.Message = "Provision was interrupted by controller shutdown, it is resumed after restart"
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to save progress of interrupted provision", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Saved progress of provision interrupted by controller shutdown")
+	return errProvisionInterrupted
+}
+
+// getInterruptedProvisionRobotAccountName returns the robot account created by the interrupted provision of the image repository,
+// empty if there is none.
+func getInterruptedProvisionRobotAccountName(imageRepository *imagerepositoryv1alpha1.ImageRepository, isPullOnly bool) string {
+	if controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) {
+		return ""
+	}
+	robotAccountName := imageRepository.Status.Credentials.PushRobotAccountName
+	if isPullOnly {
+		robotAccountName = imageRepository.Status.Credentials.PullRobotAccountName
+	}
+	// The image repository name could have changed since, e.g. by spec update
+	if !strings.HasPrefix(robotAccountName, getRobotAccountNamePrefix(imageRepository.Spec.Image.Name)+"_") {
+		return ""
+	}
+	return robotAccountName
+}
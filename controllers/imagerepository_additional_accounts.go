@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const additionalAccountsRevokedEventReason = "AdditionalAccountsRevoked"
+
+// SyncAdditionalAccounts grants the requested additional accounts access to the image repository
+// and revokes access of accounts that were granted before, but are not requested anymore.
+// Granted accounts are tracked in status, so only access given by the operator is ever revoked.
+func (r *ImageRepositoryReconciler) SyncAdditionalAccounts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("SyncAdditionalAccounts")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	requestedNames := []string{}
+	requestedRoles := map[string]imagerepositoryv1alpha1.AdditionalAccountRole{}
+	if imageRepository.Spec.Credentials != nil {
+		for _, account := range imageRepository.Spec.Credentials.AdditionalAccounts {
+			name := strings.TrimSpace(account.Name)
+			if name == "" {
+				continue
+			}
+			role := account.Role
+			if role == "" {
+				role = imagerepositoryv1alpha1.AdditionalAccountRoleRead
+			}
+			if _, exists := requestedRoles[name]; !exists {
+				requestedNames = append(requestedNames, name)
+			}
+			requestedRoles[name] = role
+		}
+	}
+
+	imageRepositoryName := imageRepository.Spec.Image.Name
+	accountsStatus := []imagerepositoryv1alpha1.AdditionalAccountStatus{}
+	revokedNames := []string{}
+	for i, grantedAccount := range imageRepository.Status.Credentials.AdditionalAccounts {
+		if _, isRequested := requestedRoles[grantedAccount.Name]; isRequested {
+			accountsStatus = append(accountsStatus, grantedAccount)
+			continue
+		}
+		if _, err := r.QuayClient.DeleteRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, grantedAccount.Name); err != nil {
+			log.Error(err, "failed to revoke access of additional account", "AccountName", grantedAccount.Name, l.Action, l.ActionDelete, l.Audit, "true")
+			// Save progress, so the accounts not processed yet are revoked on the next reconcile
+			accountsStatus = append(accountsStatus, imageRepository.Status.Credentials.AdditionalAccounts[i:]...)
+			r.recordRevokedAdditionalAccounts(imageRepository, revokedNames)
+			_ = r.saveAdditionalAccounts(ctx, imageRepository, accountsStatus)
+			return err
+		}
+		log.Info("Revoked access of additional account", "AccountName", grantedAccount.Name, l.Action, l.ActionDelete, l.Audit, "true")
+		revokedNames = append(revokedNames, grantedAccount.Name)
+	}
+	r.recordRevokedAdditionalAccounts(imageRepository, revokedNames)
+
+	for _, name := range requestedNames {
+		role := requestedRoles[name]
+		grantedIndex := -1
+		for i, grantedAccount := range accountsStatus {
+			if grantedAccount.Name == name {
+				grantedIndex = i
+				break
+			}
+		}
+		if grantedIndex >= 0 && accountsStatus[grantedIndex].Role == role {
+			continue
+		}
+
+		if err := r.QuayClient.SetRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, name, string(role)); err != nil {
+			log.Error(err, "failed to grant access to additional account", "AccountName", name, "Role", role, l.Action, l.ActionUpdate, l.Audit, "true")
+			_ = r.saveAdditionalAccounts(ctx, imageRepository, accountsStatus)
+			return err
+		}
+		log.Info("Granted access to additional account", "AccountName", name, "Role", role, l.Action, l.ActionUpdate, l.Audit, "true")
+		if grantedIndex >= 0 {
+			accountsStatus[grantedIndex].Role = role
+		} else {
+			accountsStatus = append(accountsStatus, imagerepositoryv1alpha1.AdditionalAccountStatus{Name: name, Role: role})
+		}
+	}
+
+	return r.saveAdditionalAccounts(ctx, imageRepository, accountsStatus)
+}
+
+// saveAdditionalAccounts records accounts granted access to the image repository, if changed.
+func (r *ImageRepositoryReconciler) saveAdditionalAccounts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, accountsStatus []imagerepositoryv1alpha1.AdditionalAccountStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	if len(accountsStatus) == 0 {
+		accountsStatus = nil
+	}
+	if reflect.DeepEqual(accountsStatus, imageRepository.Status.Credentials.AdditionalAccounts) {
+		return nil
+	}
+
+	imageRepository.Status.Credentials.AdditionalAccounts = accountsStatus
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update additional accounts status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// recordRevokedAdditionalAccounts emits single event summarizing accounts which lost access to the image repository.
+func (r *ImageRepositoryReconciler) recordRevokedAdditionalAccounts(imageRepository *imagerepositoryv1alpha1.ImageRepository, revokedNames []string) {
+	if r.EventRecorder == nil || r.DryRun || len(revokedNames) == 0 {
+		return
+	}
+	r.EventRecorder.Eventf(imageRepository, corev1.EventTypeNormal, additionalAccountsRevokedEventReason,
+		"Revoked access of %d account(s) to the image repository: %s", len(revokedNames), strings.Join(revokedNames, ", "))
+}
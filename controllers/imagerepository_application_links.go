@@ -0,0 +1,134 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// getImageRepositoryApplications returns all Applications the Component linked image repository belongs to,
+// i.e. the Application from the label followed by the additional Applications from the annotation.
+func getImageRepositoryApplications(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	applications := []string{}
+	if applicationName := imageRepository.Labels[ApplicationNameLabelName]; applicationName != "" {
+		applications = append(applications, applicationName)
+	}
+	additionalApplications, _ := annotations.Applications.Get(imageRepository)
+	for _, applicationName := range strings.Split(additionalApplications, ",") {
+		applicationName = strings.TrimSpace(applicationName)
+		if applicationName != "" && !slices.Contains(applications, applicationName) {
+			applications = append(applications, applicationName)
+		}
+	}
+	return applications
+}
+
+// SyncApplicationPullSecretLinks links the pull secret to service accounts of all Applications the image repository belongs to.
+// Application service accounts are recognized by the Application label.
+// With all pull secret linking, service accounts of the Component, recognized by the Component label, get the pull secret too.
+// The pull secret is unlinked from service accounts it shouldn't be linked to anymore according to the linking policy,
+// and from all service accounts if they get dedicated pull secrets, see SyncServiceAccountPullSecrets.
+func (r *ImageRepositoryReconciler) SyncApplicationPullSecretLinks(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ApplicationPullSecretLinks")
+
+	pullSecretName := imageRepository.Status.Credentials.PullSecretName
+	if pullSecretName == "" {
+		return nil
+	}
+	applications := getImageRepositoryApplications(imageRepository)
+	componentName := imageRepository.Labels[ComponentNameLabelName]
+	linking := r.getSecretLinking(imageRepository).PullSecret
+
+	serviceAccountList := &corev1.ServiceAccountList{}
+	if err := r.Client.List(ctx, serviceAccountList, client.InNamespace(imageRepository.Namespace)); err != nil {
+		log.Error(err, "failed to list application service accounts", l.Action, l.ActionView)
+		return err
+	}
+	isPullSecret := func(ref corev1.LocalObjectReference) bool { return ref.Name == pullSecretName }
+	for _, serviceAccount := range serviceAccountList.Items {
+		applicationName := serviceAccount.Labels[ApplicationNameLabelName]
+		isComponentServiceAccount := componentName != "" && serviceAccount.Labels[ComponentNameLabelName] == componentName
+		if applicationName == "" && !isComponentServiceAccount {
+			continue
+		}
+		isLinked := slices.ContainsFunc(serviceAccount.ImagePullSecrets, isPullSecret)
+		shouldBeLinked := false
+		switch linking {
+		case imagerepositoryv1alpha1.PullSecretLinkingApplication:
+			shouldBeLinked = slices.Contains(applications, applicationName)
+		case imagerepositoryv1alpha1.PullSecretLinkingAll:
+			shouldBeLinked = slices.Contains(applications, applicationName) || isComponentServiceAccount
+		}
+		shouldBeLinked = shouldBeLinked && !isPerServiceAccountSecretsEnabled(imageRepository)
+		if isLinked == shouldBeLinked {
+			continue
+		}
+
+		if shouldBeLinked {
+			serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: pullSecretName})
+		} else {
+			serviceAccount.ImagePullSecrets = slices.DeleteFunc(serviceAccount.ImagePullSecrets, isPullSecret)
+		}
+		if err := r.Client.Update(ctx, &serviceAccount); err != nil {
+			log.Error(err, "failed to update application service account", "ServiceAccountName", serviceAccount.Name, l.Action, l.ActionUpdate)
+			return err
+		}
+		if shouldBeLinked {
+			log.Info("Linked pull secret to application service account", "ServiceAccountName", serviceAccount.Name, "ApplicationName", applicationName, l.Action, l.ActionUpdate)
+		} else {
+			log.Info("Unlinked pull secret from application service account", "ServiceAccountName", serviceAccount.Name, "ApplicationName", applicationName, l.Action, l.ActionUpdate)
+		}
+	}
+	return nil
+}
+
+// mapApplicationServiceAccountToImageRepositories returns requests for all Component linked ImageRepository objects
+// that belong to the Application or the Component of the given service account,
+// so the pull secret is linked to newly created service accounts.
+func (r *ImageRepositoryReconciler) mapApplicationServiceAccountToImageRepositories(ctx context.Context, obj client.Object) []reconcile.Request {
+	applicationName := obj.GetLabels()[ApplicationNameLabelName]
+	componentName := obj.GetLabels()[ComponentNameLabelName]
+	if applicationName == "" && componentName == "" {
+		return nil
+	}
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, imageRepository := range imageRepositoryList.Items {
+		if !isComponentLinked(&imageRepository) {
+			continue
+		}
+		if slices.Contains(getImageRepositoryApplications(&imageRepository), applicationName) || imageRepository.Labels[ComponentNameLabelName] == componentName {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: imageRepository.Namespace, Name: imageRepository.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	// archiveSyncCheckInterval is how often the archive mirror is checked while it's being synced.
+	archiveSyncCheckInterval = 30 * time.Second
+	// archiveTimeout limits how long the deletion waits for the archive, the image repository is kept if it takes longer.
+	archiveTimeout = time.Hour
+	// archiveMirrorSyncInterval is set for the archive mirror, only the first sync matters as the source is deleted afterwards.
+	archiveMirrorSyncInterval = 24 * 60 * 60
+
+	archiveStepRepositoryCreated = "repositoryCreated"
+	archiveStepMirrorConfigured  = "mirrorConfigured"
+	archiveStepSyncRequested     = "syncRequested"
+	archiveStepArchived          = "archived"
+)
+
+// shouldArchiveImageRepository checks whether the image repository should be moved into ArchiveOrganization instead of being deleted.
+// ArchiveOnDeletion might be overridden by the archive-on-deletion annotation.
+func (r *ImageRepositoryReconciler) shouldArchiveImageRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	if r.ArchiveOrganization == "" || imageRepository.Status.Image.URL == "" {
+		return false
+	}
+	archive := r.ArchiveOnDeletion
+	if value, exists := annotations.ArchiveOnDeletion.Get(imageRepository); exists && annotations.ArchiveOnDeletion.Validate(imageRepository) == nil {
+		archive = value == "true"
+	}
+	if !archive {
+		return false
+	}
+	// Image repository used by other ImageRepository is not deleted, so there is nothing to archive
+	return !r.isUsedByOtherImageRepository(ctx, imageRepository)
+}
+
+// getArchiveRepositoryName returns name of the image repository in ArchiveOrganization.
+// The deletion time is appended, so image repositories recreated under the same name don't collide in the archive.
+func getArchiveRepositoryName(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	return fmt.Sprintf("%s-%s", imageRepository.Spec.Image.Name, imageRepository.DeletionTimestamp.UTC().Format("20060102150405"))
+}
+
+// ArchiveImageRepository preserves the image repository in ArchiveOrganization before it's deleted.
+// Quay cannot move image repositories between organizations, so the archive repository is created as a mirror
+// of the image repository and switched back to a normal repository once all tags are synced.
+// Done steps are recorded in the status, so an interrupted archive continues where it stopped.
+// Returns whether the image repository is archived and could be deleted,
+// and interval after which the archive should be checked again while the sync is in progress.
+// If the sync fails or takes longer than archiveTimeout, the image repository is kept, so no history is lost.
+func (r *ImageRepositoryReconciler) ArchiveImageRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (bool, time.Duration) {
+	log := ctrllog.FromContext(ctx).WithName("RepositoryArchive")
+
+	archiveRepositoryName := getArchiveRepositoryName(imageRepository)
+	log = log.WithValues("ArchiveOrganization", r.ArchiveOrganization, "ArchiveRepository", archiveRepositoryName)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	if isArchiveStepDone(imageRepository, archiveStepArchived) {
+		return true, 0
+	}
+	if isTimeoutExceeded(imageRepository.DeletionTimestamp.Time, archiveTimeout) {
+		log.Info("Image repository archive is not finished in time, keeping the image repository", "Timeout", archiveTimeout.String(), l.Audit, "true")
+		return false, 0
+	}
+
+	if r.DryRun {
+		// Nothing is synced in dry-run mode, the calls are only simulated
+		if err := r.startArchiveMirror(ctx, imageRepository, archiveRepositoryName); err != nil {
+			return false, 0
+		}
+		return true, 0
+	}
+
+	if !isArchiveStepDone(imageRepository, archiveStepSyncRequested) {
+		if err := r.startArchiveMirror(ctx, imageRepository, archiveRepositoryName); err != nil {
+			return false, archiveSyncCheckInterval
+		}
+		log.Info("Started archive of image repository", l.Action, l.ActionAdd, l.Audit, "true")
+		return false, archiveSyncCheckInterval
+	}
+
+	mirror, err := r.QuayClient.GetRepositoryMirror(r.ArchiveOrganization, archiveRepositoryName)
+	if err != nil {
+		log.Error(err, "failed to get archive mirror", l.Action, l.ActionView)
+		return false, archiveSyncCheckInterval
+	}
+	if mirror == nil {
+		log.Info("Archive mirror is gone, keeping the image repository", l.Audit, "true")
+		return false, 0
+	}
+
+	switch mirror.SyncStatus {
+	case quay.RepositoryMirrorSyncSuccess:
+		// Stop mirroring of the image repository that is going to be deleted
+		if err := r.QuayClient.ChangeRepositoryState(r.ArchiveOrganization, archiveRepositoryName, quay.RepositoryStateNormal); err != nil {
+			log.Error(err, "failed to finish archive mirror", l.Action, l.ActionUpdate)
+			return false, archiveSyncCheckInterval
+		}
+		if err := r.recordArchiveStep(ctx, imageRepository, archiveRepositoryName, archiveStepArchived); err != nil {
+			return false, archiveSyncCheckInterval
+		}
+		log.Info("Archived image repository", l.Action, l.ActionUpdate, l.Audit, "true")
+		return true, 0
+	case quay.RepositoryMirrorSyncFailed:
+		log.Info("Image repository archive failed, keeping the image repository", l.Audit, "true")
+		return false, 0
+	}
+	return false, archiveSyncCheckInterval
+}
+
+// startArchiveMirror creates the archive repository mirroring all tags of the image repository and requests its sync.
+// The push robot account of the image repository is used to pull from it, as the image repository might be private.
+// Steps done by a former attempt are skipped.
+func (r *ImageRepositoryReconciler) startArchiveMirror(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, archiveRepositoryName string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if !isArchiveStepDone(imageRepository, archiveStepRepositoryCreated) {
+		if _, err := r.QuayClient.CreateRepository(quay.RepositoryRequest{
+			Namespace:   r.ArchiveOrganization,
+			Repository:  archiveRepositoryName,
+			Visibility:  string(imagerepositoryv1alpha1.ImageVisibilityPrivate),
+			Description: fmt.Sprintf("Archive of %s", imageRepository.Status.Image.URL),
+		}); err != nil {
+			log.Error(err, "failed to create archive repository", l.Action, l.ActionAdd, l.Audit, "true")
+			return err
+		}
+		if err := r.recordArchiveStep(ctx, imageRepository, archiveRepositoryName, archiveStepRepositoryCreated); err != nil {
+			return err
+		}
+	}
+
+	if !isArchiveStepDone(imageRepository, archiveStepMirrorConfigured) {
+		if err := r.QuayClient.ChangeRepositoryState(r.ArchiveOrganization, archiveRepositoryName, quay.RepositoryStateMirror); err != nil {
+			log.Error(err, "failed to switch archive repository to mirror", l.Action, l.ActionUpdate)
+			return err
+		}
+
+		mirror := quay.RepositoryMirror{
+			IsEnabled:              true,
+			ExternalReference:      imageRepository.Status.Image.URL,
+			SyncInterval:           archiveMirrorSyncInterval,
+			SyncStartDate:          time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			RobotUsername:          r.ArchiveRobotAccountName,
+			RootRule:               quay.RepositoryMirrorRule{RuleKind: "tag_glob_csv", RuleValue: []string{"*"}},
+			ExternalRegistryConfig: map[string]interface{}{},
+		}
+		if robotAccountName := imageRepository.Status.Credentials.PushRobotAccountName; robotAccountName != "" {
+			robotAccount, err := r.QuayClient.GetRobotAccount(r.QuayOrganization, robotAccountName)
+			if err != nil {
+				log.Error(err, "failed to get robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionView)
+				return err
+			}
+			mirror.ExternalRegistryUsername = robotAccount.Name
+			mirror.ExternalRegistryPassword = robotAccount.Token
+		}
+		if err := r.QuayClient.CreateRepositoryMirror(r.ArchiveOrganization, archiveRepositoryName, mirror); err != nil {
+			log.Error(err, "failed to configure archive mirror", l.Action, l.ActionAdd)
+			return err
+		}
+		if err := r.recordArchiveStep(ctx, imageRepository, archiveRepositoryName, archiveStepMirrorConfigured); err != nil {
+			return err
+		}
+	}
+
+	if err := r.QuayClient.SyncRepositoryMirror(r.ArchiveOrganization, archiveRepositoryName); err != nil {
+		log.Error(err, "failed to request archive mirror sync", l.Action, l.ActionUpdate)
+		return err
+	}
+	return r.recordArchiveStep(ctx, imageRepository, archiveRepositoryName, archiveStepSyncRequested)
+}
+
+// isArchiveStepDone checks whether the archive step was recorded in the status.
+func isArchiveStepDone(imageRepository *imagerepositoryv1alpha1.ImageRepository, step string) bool {
+	archiveStatus := imageRepository.Status.Archive
+	return archiveStatus != nil && archiveStatus.Repository == getArchiveRepositoryName(imageRepository) && slices.Contains(archiveStatus.Steps, step)
+}
+
+// recordArchiveStep saves the done archive step in the status.
+func (r *ImageRepositoryReconciler) recordArchiveStep(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, archiveRepositoryName, step string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if r.DryRun {
+		// Simulated steps must not be skipped once the controller leaves dry-run mode
+		return nil
+	}
+	if imageRepository.Status.Archive == nil || imageRepository.Status.Archive.Repository != archiveRepositoryName {
+		imageRepository.Status.Archive = &imagerepositoryv1alpha1.ArchiveStatus{Repository: archiveRepositoryName}
+	}
+	imageRepository.Status.Archive.Steps = append(imageRepository.Status.Archive.Steps, step)
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to record archive progress", "Step", step, l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
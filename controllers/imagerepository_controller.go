@@ -19,26 +19,40 @@ package controllers
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/admin"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	"github.com/konflux-ci/image-controller/pkg/features"
 	l "github.com/konflux-ci/image-controller/pkg/logs"
 	"github.com/konflux-ci/image-controller/pkg/metrics"
 	"github.com/konflux-ci/image-controller/pkg/quay"
 	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -47,7 +61,25 @@ const (
 	ImageRepositoryFinalizer = "appstudio.openshift.io/image-repository"
 
 	buildPipelineServiceAccountName = "appstudio-pipeline"
-	updateComponentAnnotationName   = "image-controller.appstudio.redhat.com/update-component-image"
+	updateComponentAnnotationName   = string(annotations.UpdateComponentImage)
+	// robotAccountsAnnotationName holds comma separated list of all robot accounts created for the image repository.
+	robotAccountsAnnotationName = "image-controller.appstudio.redhat.com/robot-accounts"
+
+	invalidNotificationsMessagePrefix = "invalid notifications configuration: "
+
+	imageRepositoryDescription = "AppStudio repository for the user"
+
+	// Quay doesn't allow longer repository names
+	QuayRepositoryNameMaxLength = 255
+	// imageRepositoryNameHashLength is length of the hash suffix of shortened image repository names
+	imageRepositoryNameHashLength = 10
+
+	// retryProvisionAnnotationName set to "true" resets failed provision attempts, so the provision is retried.
+	retryProvisionAnnotationName = string(annotations.RetryProvision)
+	defaultMaxProvisionAttempts  = 10
+
+	repositoryTokenSecretPrefix = "quay-repository-token-"
+	repositoryTokenSecretKey    = "token"
 )
 
 // ImageRepositoryReconciler reconciles a ImageRepository object
@@ -58,15 +90,161 @@ type ImageRepositoryReconciler struct {
 	QuayClient       quay.QuayService
 	BuildQuayClient  func(logr.Logger) quay.QuayService
 	QuayOrganization string
+
+	// RegistryHost is the registry hostname used in generated secrets and status.image.url, DefaultRegistryHost if not set.
+	// It differs from the Quay API host e.g. in air-gapped deployments, where Quay is exposed under an internal hostname.
+	RegistryHost string
+
+	// ClusterID identifies the cluster in ownership information recorded in created image repositories,
+	// so leaked image repositories could be traced back when several clusters share one Quay organization.
+	ClusterID string
+
+	// PullSecretExportLabels and PullSecretExportAnnotations are added to generated pull secrets,
+	// so the secrets could be synced into remote clusters where the application is deployed.
+	PullSecretExportLabels      map[string]string
+	PullSecretExportAnnotations map[string]string
+
+	// MaxImageRepositoryNameLength limits image repository name length, Quay limit is used if not set.
+	// If ShortenLongImageRepositoryNames is set, longer names are shortened, otherwise provision fails.
+	MaxImageRepositoryNameLength    int
+	ShortenLongImageRepositoryNames bool
+
+	// RepositoryPathTemplate defines layout of generated image repository names, see RepositoryPathTemplate.
+	RepositoryPathTemplate RepositoryPathTemplate
+	// DescriptionTemplate defines description of image repositories that don't request one, see RepositoryDescriptionTemplate.
+	DescriptionTemplate RepositoryDescriptionTemplate
+
+	// MaxProvisionAttempts is the number of failed provision attempts after which the image repository becomes failed.
+	MaxProvisionAttempts int
+	// ProvisionTimeout, if set, limits duration of a provision attempt, a retry after a failed attempt starts a new one.
+	// Robot accounts and secrets of a timed out attempt are deleted and the provision starts from scratch.
+	ProvisionTimeout time.Duration
+
+	// ProvisionFairness, if set, shares provisions among namespaces, so one namespace cannot starve others.
+	ProvisionFairness *ProvisionFairness
+
+	// ShutdownDrain, if set, lets in-flight reconciles finish when the controller is stopped,
+	// while provisions save their progress and are resumed after restart.
+	ShutdownDrain *ShutdownDrain
+
+	// DefaultVisibility is used for image repositories that don't request visibility, public if not set.
+	DefaultVisibility imagerepositoryv1alpha1.ImageVisibility
+	// DefaultSecretLinking defines service account lists the generated secrets are linked to,
+	// if the ImageRepository doesn't override it, see getSecretLinking.
+	DefaultSecretLinking imagerepositoryv1alpha1.SecretLinking
+	// WaitForPrivateRepositoriesQuota makes private image repositories wait in a queue when the Quay plan limit is reached,
+	// instead of failing the provision.
+	WaitForPrivateRepositoriesQuota bool
+
+	// StrictSecretUnlink makes the deletion verify, bypassing the cache, that no service account in the namespace references
+	// the removed secrets before the finalizer is removed, see verifySecretsUnlinked.
+	StrictSecretUnlink bool
+	// APIReader reads objects bypassing the cache, the client is used if not set.
+	APIReader client.Reader
+
+	// DryRun makes the reconciler only simulate changes.
+	// Client is expected to send all cluster writes as server side dry-run requests, see client.NewDryRunClient,
+	// and BuildQuayClient is expected to return a client that doesn't change Quay state, see quay.DryRunQuayClient.
+	DryRun bool
+	// PersistingClient, in dry-run mode, actually persists changes. It's used only to record the Simulated condition.
+	PersistingClient client.Client
+
+	// SyncStates, if set, records result of each reconcile for the admin endpoint.
+	SyncStates *admin.SyncStateTracker
+
+	// RepositoryTokensNamespace enables least privilege mode if set.
+	// In the mode, repository scoped Quay tokens are read from this namespace and used for day-2 operations,
+	// while the organization token is reserved for creation and deletion, see quay.RepositoryScopedQuayClient.
+	RepositoryTokensNamespace string
+	BuildRepositoryQuayClient func(l logr.Logger, token string) quay.QuayService
+
+	// WebhookValidationClient, if set, is used to check that webhook notification targets are reachable
+	// before the notifications are created in Quay, so broken configuration is not accepted silently.
+	WebhookValidationClient *http.Client
+	// WebhookAllowlist, if set, restricts webhook notification targets.
+	// Notifications with other targets are not created in Quay and already created ones are deleted.
+	WebhookAllowlist *WebhookAllowlist
+	// ReservedRepositoryNames, if set, lists image repository names that cannot be provisioned.
+	ReservedRepositoryNames *ReservedRepositoryNames
+	// MaxNotifications, if positive, limits number of notifications created in Quay for one image repository.
+	// Notifications over the limit and duplicates of other notifications are reported in status instead.
+	MaxNotifications int
+	// AdminNamespaces may adopt image repositories of other namespaces.
+	// Objects in other namespaces manage only image repositories with their namespace prefix.
+	AdminNamespaces []string
+	// MaintenanceGrants authorizes maintenance requests, e.g. manifest deletion by the DeleteManifest annotation
+	// or the ReownRobotAccounts annotation. Maintenance requests are rejected if nil.
+	MaintenanceGrants *MaintenanceGrants
+	// VisibilityDriftPolicy, if set, defines how visibility changed directly in Quay is handled,
+	// see VisibilityDriftPolicyObserve and VisibilityDriftPolicyEnforce.
+	VisibilityDriftPolicy string
+	// ArchiveOrganization, if set, is the Quay organization where image repositories are preserved instead of being deleted,
+	// for all image repositories if ArchiveOnDeletion is set, otherwise only for ones with archive-on-deletion annotation.
+	// ArchiveRobotAccountName is the robot account of ArchiveOrganization that Quay uses to push archived tags.
+	ArchiveOrganization     string
+	ArchiveRobotAccountName string
+	ArchiveOnDeletion       bool
+	// DiscoverNudgeTargets enables nudging of Components referenced by build-nudges-ref of the linked Component,
+	// in addition to spec.nudgeTargets.
+	DiscoverNudgeTargets bool
+	// FeatureGates switches capabilities that are being rolled out, nil means the defaults.
+	FeatureGates *features.FeatureGates
+	// EventRecorder, if set, reports notable changes of image repositories, e.g. revoked access, as events.
+	EventRecorder record.EventRecorder
+	// PushNotifications, if set, receives push notifications of Quay, so the latest tag is looked up on push
+	// instead of periodically.
+	PushNotifications *PushNotifications
+
+	imageRepositoryIndexer *imageRepositoryIndexer
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ImageRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&imagerepositoryv1alpha1.ImageRepository{}).
+	if err := r.getImageRepositoryIndexer().register(context.Background(), mgr.GetFieldIndexer(), mgr.GetCache()); err != nil {
+		return err
+	}
+	forOptions := []builder.ForOption{}
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr)
+	if r.ProvisionFairness != nil {
+		forOptions = append(forOptions, builder.WithPredicates(r.ProvisionFairness.Predicate()))
+		// Postponed provisions are queued again once it's their turn
+		controllerBuilder = controllerBuilder.WatchesRawSource(&source.Channel{Source: r.ProvisionFairness.events}, &handler.EnqueueRequestForObject{})
+	}
+	if r.PushNotifications != nil {
+		// The latest tag is looked up on push
+		controllerBuilder = controllerBuilder.WatchesRawSource(&source.Channel{Source: r.PushNotifications.events},
+			handler.EnqueueRequestsFromMapFunc(r.mapPushNotificationToImageRepositories))
+	}
+	return controllerBuilder.
+		For(&imagerepositoryv1alpha1.ImageRepository{}, forOptions...).
+		// Duplicates wait for the original ImageRepository, so they have to be notified about its changes
+		Watches(&imagerepositoryv1alpha1.ImageRepository{}, handler.EnqueueRequestsFromMapFunc(r.mapToImageRepositoriesWithSameName)).
+		// Pull secrets are linked to service accounts of Applications, also to ones created after the provision
+		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(r.mapApplicationServiceAccountToImageRepositories)).
+		// Provision failed because of missing Component is retried once the Component is created
+		Watches(&appstudioredhatcomv1alpha1.Component{}, handler.EnqueueRequestsFromMapFunc(r.mapCreatedComponentToImageRepositories),
+			builder.WithPredicates(componentCreatedPredicate)).
 		Complete(r)
 }
 
+// mapToImageRepositoriesWithSameName returns requests for all other ImageRepository objects
+// that point to the same image repository as the given object.
+func (r *ImageRepositoryReconciler) mapToImageRepositoriesWithSameName(ctx context.Context, obj client.Object) []reconcile.Request {
+	imageRepository, ok := obj.(*imagerepositoryv1alpha1.ImageRepository)
+	if !ok {
+		return nil
+	}
+	siblings, err := r.listImageRepositoriesWithSameName(ctx, imageRepository)
+	if err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(siblings))
+	for _, sibling := range siblings {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: sibling.Namespace, Name: sibling.Name}})
+	}
+	return requests
+}
+
 func setMetricsTime(idForMetrics string, reconcileStartTime time.Time) {
 	_, timeRecorded := metrics.RepositoryTimesForMetrics[idForMetrics]
 	if !timeRecorded {
@@ -77,39 +255,125 @@ func setMetricsTime(idForMetrics string, reconcileStartTime time.Time) {
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=imagerepositories,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=imagerepositories/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=appstudio.redhat.com,resources=imagerepositories/finalizers,verbs=update
-//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=components,verbs=get;list;watch
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=components,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;create;update;delete
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
-func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, reconcileErr error) {
 	log := ctrllog.FromContext(ctx).WithName("ImageRepository")
+	ctx, log = withCorrelationID(ctx, log)
 	ctx = ctrllog.IntoContext(ctx, log)
+
+	if r.ShutdownDrain != nil {
+		if ctx.Err() != nil {
+			// The controller is being stopped, do not start new sequences of Quay mutations
+			return ctrl.Result{}, nil
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = r.ShutdownDrain.Detach(ctx)
+		defer cancel()
+	}
+
+	// Let other namespaces provision first, if this namespace already had its turn.
+	// A postponed reconcile does nothing, so it's not observed in metrics.
+	if r.ProvisionFairness != nil && !r.ProvisionFairness.Admit(req.NamespacedName) {
+		return ctrl.Result{RequeueAfter: provisionFairnessRetryInterval}, nil
+	}
+
 	reconcileStartTime := time.Now()
+	defer func() {
+		metrics.ObserveReconcileDuration("imagerepository", req.Namespace, req.Name, getCorrelationID(ctx), time.Since(reconcileStartTime))
+		metrics.ObserveReconcileResult("imagerepository", reconcileErr)
+	}()
+
+	isObjectDeleted := false
+	if r.SyncStates != nil {
+		defer func() {
+			if isObjectDeleted {
+				r.SyncStates.Forget(req.NamespacedName)
+			} else {
+				r.SyncStates.Record(req.NamespacedName, reconcileErr)
+			}
+		}()
+	}
+
+	if r.DryRun {
+		defer r.markSimulated(ctx, req.NamespacedName)
+	}
 
 	// Fetch the image repository instance
 	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
 	err := r.Client.Get(ctx, req.NamespacedName, imageRepository)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			// The object is deleted, nothing to do
-			return ctrl.Result{}, nil
+			// The object is deleted, clean up secrets that could be left behind in the namespace
+			isObjectDeleted = true
+			return ctrl.Result{}, r.CleanupOrphanedSecrets(ctx, req.Namespace)
 		}
 		log.Error(err, "failed to get image repository", l.Action, l.ActionView)
 		return ctrl.Result{}, err
 	}
+	defer func() {
+		if reconcileErr != nil {
+			r.recordFailureCorrelationID(ctx, req.NamespacedName)
+		}
+	}()
 
 	repositoryIdForMetrics := fmt.Sprintf("%s=%s", imageRepository.Name, imageRepository.Namespace)
 
+	if !r.DryRun && meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeSimulated) != nil {
+		// The controller left dry-run mode, the simulated results must not be trusted anymore
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeSimulated)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to remove simulated condition", l.Action, l.ActionUpdate)
+			return ctrl.Result{}, err
+		}
+		log.Info("Removed simulated condition from image repository")
+		return ctrl.Result{}, nil
+	}
+
 	if !imageRepository.DeletionTimestamp.IsZero() {
 		// remove component from metrics map
 		delete(metrics.RepositoryTimesForMetrics, repositoryIdForMetrics)
+		forgetPushBlockedMetric(imageRepository)
 
 		// Reread quay token
 		r.QuayClient = r.BuildQuayClient(log)
+		r.QuayClient.SetCorrelationID(getCorrelationID(ctx))
+		r.QuayClient.SetRequestObserver(metrics.QuayRequestObserver("imagerepository", imageRepository.Namespace))
 
 		if controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) {
+			keepRepository := false
+			if r.shouldArchiveImageRepository(ctx, imageRepository) {
+				isArchived, recheckAfter := r.ArchiveImageRepository(ctx, imageRepository)
+				if recheckAfter > 0 {
+					return ctrl.Result{RequeueAfter: recheckAfter}, nil
+				}
+				keepRepository = !isArchived
+			}
+
+			// Service accounts must not be left with references to deleted secrets, so the deletion waits for the unlink
+			secretNames := getImageRepositorySecretNames(imageRepository)
+			if err := r.unlinkSecretsFromServiceAccounts(ctx, imageRepository.Namespace, secretNames); err != nil {
+				r.recordOperation(ctx, req.NamespacedName, operationCleanup, err)
+				return ctrl.Result{}, err
+			}
+			// Simulated updates are never effective, so the verification would never pass in dry-run mode
+			if r.StrictSecretUnlink && !r.DryRun {
+				if err := r.verifySecretsUnlinked(ctx, imageRepository.Namespace, secretNames); err != nil {
+					r.recordOperation(ctx, req.NamespacedName, operationCleanup, err)
+					return ctrl.Result{}, err
+				}
+			}
+
 			// Do not block deletion on failures
-			r.CleanupImageRepository(ctx, imageRepository)
+			r.CleanupImageRepository(ctx, imageRepository, keepRepository)
+			if isComponentLinked(imageRepository) {
+				r.forgetComponentProvenance(ctx, imageRepository)
+			}
 
 			controllerutil.RemoveFinalizer(imageRepository, ImageRepositoryFinalizer)
 			if err := r.Client.Update(ctx, imageRepository); err != nil {
@@ -121,6 +385,15 @@ func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, nil
 	}
 
+	if annotations.RetryProvision.IsTrue(imageRepository) {
+		if err := r.resetProvisionAttempts(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err := r.retryProvisionForCreatedComponent(ctx, imageRepository); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed {
 		provisionTime, timeRecorded := metrics.RepositoryTimesForMetrics[repositoryIdForMetrics]
 		if timeRecorded {
@@ -135,21 +408,70 @@ func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	// Reread quay token
 	r.QuayClient = r.BuildQuayClient(log)
+	r.QuayClient.SetCorrelationID(getCorrelationID(ctx))
+	r.QuayClient.SetRequestObserver(metrics.QuayRequestObserver("imagerepository", imageRepository.Namespace))
 
 	// Provision image repository if it hasn't been done yet
 	if !controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) {
+		isDuplicate, err := r.handleDuplicates(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if isDuplicate {
+			// Wait until the original ImageRepository is gone
+			return ctrl.Result{}, nil
+		}
+
+		isAdopted, err := r.AdoptLegacyComponentRepository(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if isAdopted {
+			return ctrl.Result{}, nil
+		}
+
+		if r.isProvisionTimedOut(imageRepository) {
+			timeoutErr := fmt.Errorf("provision did not finish in %s", r.ProvisionTimeout)
+			log.Error(timeoutErr, "provision of image repository timed out, rolling back")
+			if err := r.rollbackProvisionAttempt(ctx, imageRepository); err != nil {
+				return ctrl.Result{}, err
+			}
+			r.recordOperation(ctx, req.NamespacedName, operationProvision, timeoutErr)
+			return ctrl.Result{}, r.recordFailedProvisionAttempt(ctx, req.NamespacedName, timeoutErr)
+		}
+		if r.ProvisionTimeout > 0 {
+			if err := r.startProvisionAttempt(ctx, imageRepository); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+
 		setMetricsTime(repositoryIdForMetrics, reconcileStartTime)
 		if err := r.ProvisionImageRepository(ctx, imageRepository); err != nil {
+			if isProvisionInterrupted(err) {
+				return ctrl.Result{}, nil
+			}
 			log.Error(err, "provision of image repository failed")
-			return ctrl.Result{}, err
+			r.recordOperation(ctx, req.NamespacedName, operationProvision, err)
+			return ctrl.Result{}, r.recordFailedProvisionAttempt(ctx, req.NamespacedName, err)
+		}
+		if isPendingQuota(imageRepository) {
+			return ctrl.Result{RequeueAfter: pendingQuotaRetryInterval}, nil
+		}
+		if imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed {
+			// Invalid requests fail the provision without returning an error, so they are not retried
+			r.recordOperation(ctx, req.NamespacedName, operationProvision, fmt.Errorf("%s", imageRepository.Status.Message))
+			return ctrl.Result{}, nil
 		}
+		r.recordOperation(ctx, req.NamespacedName, operationProvision, nil)
 		return ctrl.Result{}, nil
 	}
 
+	// Use repository scoped token for day-2 operations, if available
+	r.QuayClient = r.withRepositoryScopedToken(ctx, imageRepository, r.QuayClient)
+
 	// Update component
 	if isComponentLinked(imageRepository) {
-		updateComponentAnnotation, updateComponentAnnotationExists := imageRepository.Annotations[updateComponentAnnotationName]
-		if updateComponentAnnotationExists && updateComponentAnnotation == "true" {
+		if annotations.UpdateComponentImage.IsTrue(imageRepository) {
 
 			componentName := imageRepository.Labels[ComponentNameLabelName]
 			component := &appstudioredhatcomv1alpha1.Component{}
@@ -171,7 +493,7 @@ func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 				return ctrl.Result{}, err
 			}
 			log.Info("Updated component's ContainerImage", "ComponentName", componentName)
-			delete(imageRepository.Annotations, updateComponentAnnotationName)
+			annotations.UpdateComponentImage.Remove(imageRepository)
 
 			if err := r.Client.Update(ctx, imageRepository); err != nil {
 				log.Error(err, "failed to update imageRepository annotation")
@@ -185,36 +507,268 @@ func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, nil
 	}
 
+	// Delete legacy secrets of adopted Component image repository after running pipelines had time to finish
+	var requeueAfter time.Duration
+	if _, exists := imageRepository.Annotations[legacySecretsAnnotationName]; exists {
+		waitTime, err := r.RetireLegacySecrets(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if waitTime == 0 {
+			return ctrl.Result{}, nil
+		}
+		requeueAfter = waitTime
+	}
+
 	// Make sure, that image repository name is the same as on creation.
 	// Do it here to avoid webhook creation.
-	imageRepositoryName := strings.TrimPrefix(imageRepository.Status.Image.URL, fmt.Sprintf("quay.io/%s/", r.QuayOrganization))
-	if imageRepository.Spec.Image.Name != imageRepositoryName {
+	imageRepositoryName, _ := getImageRepositoryNameFromURL(imageRepository.Status.Image.URL, r.RegistryHost, r.QuayOrganization)
+	if imageRepositoryName != "" && imageRepository.Spec.Image.Name != imageRepositoryName {
 		oldName := imageRepository.Spec.Image.Name
 		imageRepository.Spec.Image.Name = imageRepositoryName
 		if err := r.Client.Update(ctx, imageRepository); err != nil {
 			log.Error(err, "failed to revert image repository name", "OldName", oldName, "ExpectedName", imageRepositoryName, l.Action, l.ActionUpdate)
 			return ctrl.Result{}, err
 		}
-		log.Info("reverted image repository name", "OldName", oldName, "ExpectedName", imageRepositoryName, l.Action, l.ActionUpdate)
+		r.recordSpecRevert(ctx, imageRepository, specRevert{
+			Field:          "spec.image.name",
+			AttemptedValue: oldName,
+			EnforcedValue:  imageRepositoryName,
+			Reason:         specRevertReasonImmutable,
+			Message:        "image repository cannot be renamed after creation",
+		})
 		return ctrl.Result{}, nil
 	}
 
-	// Change image visibility if requested
-	if imageRepository.Spec.Image.Visibility != imageRepository.Status.Image.Visibility && imageRepository.Spec.Image.Visibility != "" {
+	// Make sure, that repository kind is the same as on creation, Quay doesn't allow to change it.
+	imageRepositoryKind := imageRepository.Status.Image.Kind
+	if imageRepositoryKind == "" {
+		imageRepositoryKind = imagerepositoryv1alpha1.ImageRepositoryKindImage
+	}
+	if imageRepository.Spec.Image.Kind != "" && imageRepository.Spec.Image.Kind != imageRepositoryKind {
+		oldKind := imageRepository.Spec.Image.Kind
+		imageRepository.Spec.Image.Kind = imageRepositoryKind
+		if err := r.Client.Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to revert image repository kind", "OldKind", oldKind, "ExpectedKind", imageRepositoryKind, l.Action, l.ActionUpdate)
+			return ctrl.Result{}, err
+		}
+		r.recordSpecRevert(ctx, imageRepository, specRevert{
+			Field:          "spec.image.kind",
+			AttemptedValue: string(oldKind),
+			EnforcedValue:  string(imageRepositoryKind),
+			Reason:         specRevertReasonImmutable,
+			Message:        "Quay doesn't allow to change repository kind",
+		})
+		return ctrl.Result{}, nil
+	}
+
+	// Make sure, that the provisioned credentials keep using the same externally managed secrets
+	if reverts := revertExistingSecretRefs(imageRepository); len(reverts) > 0 {
+		if err := r.Client.Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to revert existing secret references", l.Action, l.ActionUpdate)
+			return ctrl.Result{}, err
+		}
+		for _, revert := range reverts {
+			r.recordSpecRevert(ctx, imageRepository, revert)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Change image visibility if requested, the requested visibility is applied after an active visibility window
+	// and isn't applied over visibility changed in Quay until the spec changes
+	if imageRepository.Spec.Image.Visibility != imageRepository.Status.Image.Visibility && imageRepository.Spec.Image.Visibility != "" &&
+		!isVisibilityScheduleActive(imageRepository) && !isVisibilityDriftObserved(imageRepository) {
 		if err := r.ChangeImageRepositoryVisibility(ctx, imageRepository); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
 	}
 
-	if imageRepository.Spec.Credentials != nil {
+	// Remove or restore push and pull credentials if requested, the image repository is kept
+	if isDeprovisionRequested(imageRepository) != isCredentialsRemoved(imageRepository) {
+		if isDeprovisionRequested(imageRepository) {
+			return ctrl.Result{}, r.DeprovisionCredentials(ctx, imageRepository)
+		}
+		return ctrl.Result{}, r.ReprovisionCredentials(ctx, imageRepository)
+	}
+
+	if imageRepository.Spec.Credentials != nil && !isCredentialsRemoved(imageRepository) {
 		// Rotate credentials if requested
 		regenerateToken := imageRepository.Spec.Credentials.RegenerateToken
 		if regenerateToken != nil && *regenerateToken {
-			if err := r.RegenerateImageRepositoryCredentials(ctx, imageRepository); err != nil {
-				return ctrl.Result{}, err
+			err := r.RegenerateImageRepositoryCredentials(ctx, imageRepository)
+			r.recordOperation(ctx, req.NamespacedName, operationRegenerateCredentials, err)
+			if retryAfter, isRateLimited := quay.GetRetryAfter(err); isRateLimited {
+				// The request is kept and retried when Quay allows it, instead of backing off on error
+				if retryAfter <= 0 {
+					retryAfter = rateLimitedRetryInterval
+				}
+				log.Info("Token rotation was rate limited by Quay, retrying later", "RetryAfter", retryAfter)
+				return ctrl.Result{RequeueAfter: retryAfter}, nil
 			}
-			return ctrl.Result{}, nil
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep repository notifications in sync with the requested ones
+	if len(imageRepository.Spec.Notifications) > 0 || len(imageRepository.Status.Notifications) > 0 {
+		if err := r.SyncNotifications(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep secrets for external consumers in sync with the requested ones
+	if (imageRepository.Spec.Credentials != nil && len(imageRepository.Spec.Credentials.ExternalConsumers) > 0) || len(imageRepository.Status.Credentials.ExternalConsumers) > 0 {
+		if err := r.SyncExternalConsumers(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep access of additional accounts in sync with the requested ones
+	if (imageRepository.Spec.Credentials != nil && len(imageRepository.Spec.Credentials.AdditionalAccounts) > 0) || len(imageRepository.Status.Credentials.AdditionalAccounts) > 0 {
+		if err := r.SyncAdditionalAccounts(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep required roles of robot accounts of the organization, e.g. shared by pipelines
+	if (imageRepository.Spec.Access != nil && len(imageRepository.Spec.Access.Robots) > 0) || imageRepository.Status.Access != nil {
+		if err := r.SyncRobotAccess(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Delete manifest requested e.g. by a garbage collector
+	if _, isRequested := annotations.DeleteManifest.Get(imageRepository); isRequested {
+		if err := r.HandleManifestDeletionRequest(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Move robot accounts named by former naming schemes under the current one, requested by an admin
+	if annotations.ReownRobotAccounts.IsTrue(imageRepository) {
+		if err := r.HandleRobotAccountsReownRequest(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if imageRepository.Status.Credentials.RetiredRobotAccounts != nil {
+		recheckAfter, err := r.RetireRobotAccounts(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Issue requested temporary pull credentials and revoke expired ones
+	if _, isRequested := annotations.TemporaryPullCredentials.Get(imageRepository); isRequested || imageRepository.Status.Credentials.TemporaryPullCredentials != nil ||
+		strings.HasPrefix(imageRepository.Status.Message, invalidTemporaryCredentialsMessagePrefix) {
+		recheckAfter, err := r.SyncTemporaryPullCredentials(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Record created Quay resources in the Component, so they could be cleaned up even if the ImageRepository finalizer doesn't run
+	if isComponentLinked(imageRepository) {
+		if err := r.RecordComponentProvenance(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep push secret linked to the build pipeline service account according to the linking policy
+	if !isCredentialsRemoved(imageRepository) {
+		if err := r.SyncPushSecretLinks(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep pull secret linked to service accounts of all Applications the image repository belongs to
+	if isComponentLinked(imageRepository) && !isCredentialsRemoved(imageRepository) {
+		if err := r.SyncApplicationPullSecretLinks(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep pull secrets dedicated to Application service accounts in sync, if requested
+	if isComponentLinked(imageRepository) && !isCredentialsRemoved(imageRepository) &&
+		(isPerServiceAccountSecretsEnabled(imageRepository) || len(imageRepository.Status.Credentials.ServiceAccountSecrets) > 0) {
+		if err := r.SyncServiceAccountPullSecrets(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep the signing public key published
+	if imageRepository.Spec.Signing != nil || imageRepository.Status.Signing != nil || strings.HasPrefix(imageRepository.Status.Message, invalidSigningMessagePrefix) {
+		if err := r.SyncSigning(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Keep image repository description in sync with the requested readme or description
+	if r.isDescriptionSyncNeeded(imageRepository) {
+		recheckAfter, err := r.SyncReadme(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Keep the latest pushed tag in the status if requested
+	if imageRepository.Spec.Image.TrackLatest || imageRepository.Status.Image.LatestTagCheckTimestamp != nil {
+		recheckAfter, err := r.SyncLatestTag(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Apply scheduled visibility within its window and revert it afterwards
+	if imageRepository.Spec.Image.VisibilitySchedule != nil || meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityScheduled) != nil ||
+		strings.HasPrefix(imageRepository.Status.Message, invalidVisibilityScheduleMessagePrefix) {
+		recheckAfter, err := r.SyncVisibilitySchedule(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Detect visibility changed directly in Quay
+	if r.VisibilityDriftPolicy != "" {
+		recheckAfter, err := r.SyncVisibilityDrift(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Detect image repository rejecting pushes because of its state in Quay
+	if r.FeatureGates.Enabled(features.PushBlockedDetection) || imageRepository.Status.Image.StateCheckTimestamp != nil {
+		recheckAfter, err := r.SyncPushBlocked(ctx, imageRepository)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if recheckAfter > 0 && (requeueAfter == 0 || recheckAfter < requeueAfter) {
+			requeueAfter = recheckAfter
+		}
+	}
+
+	// Request rebuild of dependent Components if the image url or credentials changed
+	if len(imageRepository.Spec.NudgeTargets) > 0 || (r.DiscoverNudgeTargets && isComponentLinked(imageRepository)) {
+		if err := r.SyncComponentNudges(ctx, imageRepository); err != nil {
+			return ctrl.Result{}, err
 		}
 	}
 
@@ -227,52 +781,545 @@ func (r *ImageRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	// remove component from metrics map
 	delete(metrics.RepositoryTimesForMetrics, repositoryIdForMetrics)
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// withRepositoryScopedToken returns Quay client that uses repository scoped token for day-2 operations
+// if the token for the image repository exists, otherwise the given organization client is returned.
+func (r *ImageRepositoryReconciler) withRepositoryScopedToken(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, organizationClient quay.QuayService) quay.QuayService {
+	if r.RepositoryTokensNamespace == "" || r.BuildRepositoryQuayClient == nil {
+		return organizationClient
+	}
+	log := ctrllog.FromContext(ctx)
+
+	secretName := getRepositoryTokenSecretName(imageRepository.Spec.Image.Name)
+	tokenSecret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: r.RepositoryTokensNamespace, Name: secretName}, tokenSecret); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get repository token secret, using organization token", "SecretName", secretName, l.Action, l.ActionView)
+		}
+		return organizationClient
+	}
+	token := strings.TrimSpace(string(tokenSecret.Data[repositoryTokenSecretKey]))
+	if token == "" {
+		log.Info("repository token secret has no token, using organization token", "SecretName", secretName)
+		return organizationClient
+	}
+
+	repositoryClient := r.BuildRepositoryQuayClient(log, token)
+	repositoryClient.SetCorrelationID(getCorrelationID(ctx))
+	repositoryClient.SetRequestObserver(metrics.QuayRequestObserver("imagerepository", imageRepository.Namespace))
+	return quay.NewRepositoryScopedQuayClient(organizationClient, repositoryClient, log)
+}
+
+// getRepositoryTokenSecretName returns name of the secret with repository scoped token for the given image repository.
+// Image repository name might be longer than allowed for secret name, so its hash is used.
+func getRepositoryTokenSecretName(imageRepositoryName string) string {
+	hash := sha256.Sum256([]byte(imageRepositoryName))
+	return repositoryTokenSecretPrefix + hex.EncodeToString(hash[:])[:16]
+}
+
+// recordFailedProvisionAttempt counts the failed provision attempt in the status.
+// Returns the error, so the provision is retried with backoff, while the retry budget isn't exhausted.
+// Permanent errors and exhausted budget make the image repository failed.
+func (r *ImageRepositoryReconciler) recordFailedProvisionAttempt(ctx context.Context, imageRepositoryKey types.NamespacedName, provisionErr error) error {
+	log := ctrllog.FromContext(ctx)
+
+	// Provision might modify the object in memory, record the attempt on the latest version
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := r.Client.Get(ctx, imageRepositoryKey, imageRepository); err != nil {
+		log.Error(err, "failed to get image repository", l.Action, l.ActionView)
+		return provisionErr
+	}
+
+	errorClass := getProvisionErrorClass(provisionErr)
+	imageRepository.Status.Provision.Attempts++
+	imageRepository.Status.Provision.LastErrorClass = errorClass
+	imageRepository.Status.Provision.LastError = provisionErr.Error()
+	// The retry is a new attempt, which gets its own ProvisionTimeout
+	imageRepository.Status.Provision.StartTimestamp = nil
+
+	isFailed := false
+	if errorClass == imagerepositoryv1alpha1.ProvisionErrorClassPermanent {
+		isFailed = true
+		imageRepository.Status.Message = provisionErr.Error()
+	} else if imageRepository.Status.Provision.Attempts >= r.getMaxProvisionAttempts() {
+		isFailed = true
+		imageRepository.Status.Message = fmt.Sprintf("provision failed after %d attempts, last error: %s", imageRepository.Status.Provision.Attempts, provisionErr.Error())
+	}
+	if isFailed {
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		log.Info("image repository provision failed", "Attempts", imageRepository.Status.Provision.Attempts, "ErrorClass", errorClass)
+	}
+
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository provision status", l.Action, l.ActionUpdate)
+		return provisionErr
+	}
+	if isFailed {
+		return nil
+	}
+	return provisionErr
+}
+
+// resetProvisionAttempts clears failed provision state, so the image repository provision is retried.
+// Has effect only on image repositories that haven't been provisioned.
+func (r *ImageRepositoryReconciler) resetProvisionAttempts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	annotations.RetryProvision.Remove(imageRepository)
+	if err := r.Client.Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to remove retry provision annotation", l.Action, l.ActionUpdate)
+		return err
+	}
+	if controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) {
+		log.Info("image repository is already provisioned, ignoring retry provision request")
+		return nil
+	}
+
+	imageRepository.Status.Provision = imagerepositoryv1alpha1.ProvisionStatus{}
+	if imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed {
+		imageRepository.Status.State = ""
+		imageRepository.Status.Message = ""
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to reset image repository provision status", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Reset image repository provision attempts")
+	return nil
+}
+
+// permanentProvisionErrorStatusCodes are status codes of Quay rejections that won't go away on retry:
+// invalid request, private repositories not allowed by the plan and forbidden action.
+var permanentProvisionErrorStatusCodes = []int{
+	http.StatusBadRequest,
+	http.StatusPaymentRequired,
+	http.StatusForbidden,
+}
+
+func (r *ImageRepositoryReconciler) getDefaultVisibility() imagerepositoryv1alpha1.ImageVisibility {
+	if r.DefaultVisibility == "" {
+		return imagerepositoryv1alpha1.ImageVisibilityPublic
+	}
+	return r.DefaultVisibility
+}
+
+func (r *ImageRepositoryReconciler) getMaxProvisionAttempts() int {
+	if r.MaxProvisionAttempts > 0 {
+		return r.MaxProvisionAttempts
+	}
+	return defaultMaxProvisionAttempts
+}
+
+// getProvisionErrorClass tells whether retrying the provision could help.
+// Errors caused by the request itself or by the Quay organization settings are permanent,
+// they are told by the status code of the Quay response. Other errors, e.g. unreadable responses, are transient.
+func getProvisionErrorClass(err error) imagerepositoryv1alpha1.ProvisionErrorClass {
+	if isSecretOwnershipConflict(err) {
+		return imagerepositoryv1alpha1.ProvisionErrorClassPermanent
+	}
+	if statusCode, isRejected := quay.GetStatusCode(err); isRejected && slices.Contains(permanentProvisionErrorStatusCodes, statusCode) {
+		return imagerepositoryv1alpha1.ProvisionErrorClassPermanent
+	}
+	return imagerepositoryv1alpha1.ProvisionErrorClassTransient
+}
+
+// markSimulated records in the image repository status that the changes were only simulated.
+// The condition is written by PersistingClient, as writes of the reconciler client are not persisted.
+func (r *ImageRepositoryReconciler) markSimulated(ctx context.Context, imageRepositoryKey types.NamespacedName) {
+	log := ctrllog.FromContext(ctx)
+	if r.PersistingClient == nil {
+		log.Info("changes are simulated, but there is no client to record it in the image repository status")
+		return
+	}
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := r.PersistingClient.Get(ctx, imageRepositoryKey, imageRepository); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get image repository", l.Action, l.ActionView)
+		}
+		return
+	}
+	if meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeSimulated) {
+		return
+	}
+
+	meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeSimulated,
+		Status:             metav1.ConditionTrue,
+		Reason:             "DryRun",
+		Message:            "The controller runs in dry-run mode, requested changes are not applied",
+		ObservedGeneration: imageRepository.Generation,
+	})
+	if err := r.PersistingClient.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to mark image repository as simulated", l.Action, l.ActionUpdate)
+	}
+}
+
+// handleDuplicates checks whether an older ImageRepository points to the same image repository.
+// If so, DuplicateOf condition is set and true is returned, unless both objects allow sharing the image repository.
+// Returns false if the image repository could be provisioned.
+func (r *ImageRepositoryReconciler) handleDuplicates(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (bool, error) {
+	log := ctrllog.FromContext(ctx)
+
+	siblings, err := r.listImageRepositoriesWithSameName(ctx, imageRepository)
+	if err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return false, err
+	}
+
+	var original *imagerepositoryv1alpha1.ImageRepository
+	for i := range siblings {
+		sibling := &siblings[i]
+		if sibling.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed {
+			// Failed image repository doesn't own anything
+			continue
+		}
+		if isOlderImageRepository(sibling, imageRepository) && (original == nil || isOlderImageRepository(sibling, original)) {
+			original = sibling
+		}
+	}
+
+	if original == nil {
+		if meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeDuplicateOf) == nil {
+			return false, nil
+		}
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeDuplicateOf)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to remove duplicate condition", l.Action, l.ActionUpdate)
+			return false, err
+		}
+		log.Info("Image repository is not a duplicate anymore")
+		return false, nil
+	}
+
+	isShared := imageRepository.Spec.Image.Shared && original.Spec.Image.Shared
+	condition := metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeDuplicateOf,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: imageRepository.Generation,
+	}
+	if isShared {
+		condition.Reason = "SharedImageRepository"
+		condition.Message = fmt.Sprintf("Image repository is shared with ImageRepository %s", original.Name)
+	} else {
+		condition.Reason = "DuplicateImageRepository"
+		condition.Message = fmt.Sprintf("Image repository is already managed by ImageRepository %s, set spec.image.shared in both objects to share it", original.Name)
+	}
+	if meta.SetStatusCondition(&imageRepository.Status.Conditions, condition) {
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to set duplicate condition", l.Action, l.ActionUpdate)
+			return false, err
+		}
+		log.Info("Image repository is a duplicate", "OriginalImageRepository", original.Name, "IsShared", isShared)
+	}
+
+	return !isShared, nil
+}
+
+// listImageRepositoriesWithSameName returns all other ImageRepository objects that point to the same image repository.
+// Image repository name is always prefixed with the namespace, so only the object namespace is searched.
+func (r *ImageRepositoryReconciler) listImageRepositoriesWithSameName(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) ([]imagerepositoryv1alpha1.ImageRepository, error) {
+	imageRepositories, err := r.getImageRepositoryIndexer().list(ctx, r.Client, quayRepositoryNameIndexKey,
+		r.getQuayRepositoryName(imageRepository), client.InNamespace(imageRepository.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	siblings := []imagerepositoryv1alpha1.ImageRepository{}
+	for _, item := range imageRepositories {
+		if item.Name != imageRepository.Name {
+			siblings = append(siblings, item)
+		}
+	}
+	return siblings, nil
+}
+
+// isOlderImageRepository returns true if a was created before b.
+// Objects created at the same time are ordered by name.
+func isOlderImageRepository(a, b *imagerepositoryv1alpha1.ImageRepository) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
 }
 
 func (r *ImageRepositoryReconciler) AddNotifications(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) ([]imagerepositoryv1alpha1.NotificationStatus, error) {
 	log := ctrllog.FromContext(ctx).WithName("ConfigureNotifications")
+	ctx = ctrllog.IntoContext(ctx, log)
 
 	if imageRepository.Spec.Notifications == nil {
 		// No notifications to configure
 		return nil, nil
 	}
+	if err := validateNotifications(imageRepository.Spec.Notifications); err != nil {
+		// Do not block provision, the error is reported in status message
+		log.Info("skipping invalid notifications configuration", "Reason", err.Error())
+		return nil, nil
+	}
 
 	log.Info("Configuring notifications")
 	notificationStatus := []imagerepositoryv1alpha1.NotificationStatus{}
 
-	for _, notification := range imageRepository.Spec.Notifications {
-		log.Info("Creating notification in Quay", "Title", notification.Title, "Event", notification.Event, "Method", notification.Method)
-		quayNotification, err := r.QuayClient.CreateNotification(
-			r.QuayOrganization,
-			imageRepository.Spec.Image.Name,
-			quay.Notification{
-				Title:  notification.Title,
-				Event:  string(notification.Event),
-				Method: string(notification.Method),
-				Config: quay.NotificationConfig{
-					Url: notification.Config.Url,
-				},
-				EventConfig: quay.NotificationEventConfig{},
-			})
-		if err != nil {
-			log.Error(err, "failed to create notification", "Title", notification.Title, "Event", notification.Event, "Method", notification.Method)
-			return nil, err
+	rejectedNotifications := r.getRejectedNotifications(imageRepository.Spec.Notifications)
+	for _, notification := range imageRepository.Spec.Notifications {
+		title := normalizeNotificationTitle(notification.Title)
+		if reason, isRejected := rejectedNotifications[title]; isRejected {
+			log.Info("notification rejected", "Title", title, "Reason", reason)
+			notificationStatus = append(notificationStatus, imagerepositoryv1alpha1.NotificationStatus{Title: title, ValidationError: reason})
+			continue
+		}
+		status, err := r.createNotification(ctx, imageRepository, notification)
+		if err != nil {
+			return nil, err
+		}
+		notificationStatus = append(notificationStatus, *status)
+	}
+	return notificationStatus, nil
+}
+
+// SyncNotifications makes Quay repository notifications match the requested ones.
+// Notifications are matched by UUID saved in the status, because Quay doesn't require unique titles.
+// Only notifications of the ImageRepository are changed, see getNotificationOwnerMarker.
+// Quay doesn't support notification update, so changed notifications are recreated.
+func (r *ImageRepositoryReconciler) SyncNotifications(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("SyncNotifications")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	if err := validateNotifications(imageRepository.Spec.Notifications); err != nil {
+		if imageRepository.Status.Message != err.Error() {
+			imageRepository.Status.Message = err.Error()
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+				return err
+			}
+		}
+		return nil
+	}
+	isStatusChanged := false
+	if strings.HasPrefix(imageRepository.Status.Message, invalidNotificationsMessagePrefix) {
+		imageRepository.Status.Message = ""
+		isStatusChanged = true
+	}
+
+	imageRepositoryName := imageRepository.Spec.Image.Name
+	quayNotifications, err := r.QuayClient.GetNotifications(r.QuayOrganization, imageRepositoryName)
+	if err != nil {
+		log.Error(err, "failed to get notifications", l.Action, l.ActionView)
+		return err
+	}
+	quayNotificationsByUUID := make(map[string]quay.Notification, len(quayNotifications))
+	for _, quayNotification := range quayNotifications {
+		quayNotificationsByUUID[quayNotification.UUID] = quayNotification
+	}
+
+	rejectedNotifications := r.getRejectedNotifications(imageRepository.Spec.Notifications)
+	requestedNotifications := make(map[string]imagerepositoryv1alpha1.Notifications, len(imageRepository.Spec.Notifications))
+	for _, notification := range imageRepository.Spec.Notifications {
+		title := normalizeNotificationTitle(notification.Title)
+		if _, isRejected := rejectedNotifications[title]; !isRejected {
+			requestedNotifications[title] = notification
+		}
+	}
+
+	syncedNotifications := make(map[string]imagerepositoryv1alpha1.NotificationStatus)
+	for _, notificationStatus := range imageRepository.Status.Notifications {
+		if notificationStatus.UUID == "" && notificationStatus.ValidationError == "" {
+			// Deprecated: status recorded without UUID, fall back to matching by title
+			for _, quayNotification := range quayNotifications {
+				if normalizeNotificationTitle(quayNotification.Title) == getQuayNotificationTitle(imageRepository, notificationStatus.Title) {
+					log.Info("matched notification by title, title based matching is deprecated", "Title", notificationStatus.Title, "UUID", quayNotification.UUID)
+					notificationStatus.UUID = quayNotification.UUID
+					break
+				}
+			}
+		}
+
+		title := normalizeNotificationTitle(notificationStatus.Title)
+		quayNotification, existsInQuay := quayNotificationsByUUID[notificationStatus.UUID]
+		if existsInQuay && isNotificationOfOtherOwner(imageRepository, quayNotification) {
+			// Never touch notifications of other ImageRepositories sharing the image repository
+			log.Info("notification belongs to other image repository, dropping it from status", "Title", notificationStatus.Title, "UUID", notificationStatus.UUID, "QuayTitle", quayNotification.Title)
+			existsInQuay = false
+		}
+		notification, isRequested := requestedNotifications[title]
+		_, isAlreadySynced := syncedNotifications[title]
+		if isRequested && !isAlreadySynced && existsInQuay && r.checkWebhookAllowlist(notification) == nil {
+			notificationUrl, err := r.getNotificationUrl(ctx, imageRepository, notification)
+			if _, isInvalidSecret := err.(*InvalidNotificationSecretError); err != nil && !isInvalidSecret {
+				return err
+			}
+			if err == nil && isNotificationUpToDate(quayNotification, notification, notificationUrl) {
+				syncedNotifications[title] = notificationStatus
+				continue
+			}
+		}
+
+		if existsInQuay {
+			if _, err := r.QuayClient.DeleteNotification(r.QuayOrganization, imageRepositoryName, notificationStatus.UUID); err != nil {
+				log.Error(err, "failed to delete notification", "Title", notificationStatus.Title, "UUID", notificationStatus.UUID, l.Action, l.ActionDelete)
+				return err
+			}
+			log.Info("Notification deleted", "Title", notificationStatus.Title, "UUID", notificationStatus.UUID, l.Action, l.ActionDelete)
+		}
+	}
+
+	notificationsStatus := []imagerepositoryv1alpha1.NotificationStatus{}
+	for _, notification := range imageRepository.Spec.Notifications {
+		title := normalizeNotificationTitle(notification.Title)
+		if notificationStatus, isSynced := syncedNotifications[title]; isSynced {
+			notificationsStatus = append(notificationsStatus, notificationStatus)
+			continue
+		}
+		if reason, isRejected := rejectedNotifications[title]; isRejected {
+			notificationsStatus = append(notificationsStatus, imagerepositoryv1alpha1.NotificationStatus{Title: title, ValidationError: reason})
+			continue
+		}
+		notificationStatus, err := r.createNotification(ctx, imageRepository, notification)
+		if err != nil {
+			return err
+		}
+		notificationsStatus = append(notificationsStatus, *notificationStatus)
+	}
+
+	if len(notificationsStatus) != 0 || len(imageRepository.Status.Notifications) != 0 {
+		if !reflect.DeepEqual(notificationsStatus, imageRepository.Status.Notifications) {
+			imageRepository.Status.Notifications = notificationsStatus
+			isStatusChanged = true
+		}
+	}
+	if !isStatusChanged {
+		return nil
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update notifications status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+func (r *ImageRepositoryReconciler) createNotification(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, notification imagerepositoryv1alpha1.Notifications) (*imagerepositoryv1alpha1.NotificationStatus, error) {
+	log := ctrllog.FromContext(ctx)
+
+	title := normalizeNotificationTitle(notification.Title)
+	if err := r.checkWebhookAllowlist(notification); err != nil {
+		log.Info("webhook notification target is not allowed", "Title", title, "Url", notification.Config.Url, "Reason", err.Error(), l.Audit, "true")
+		return &imagerepositoryv1alpha1.NotificationStatus{Title: title, ValidationError: err.Error()}, nil
+	}
+	notificationUrl, err := r.getNotificationUrl(ctx, imageRepository, notification)
+	if err != nil {
+		if _, isInvalidSecret := err.(*InvalidNotificationSecretError); isInvalidSecret {
+			log.Info("notification secret cannot be used", "Title", title, "Reason", err.Error())
+			return &imagerepositoryv1alpha1.NotificationStatus{Title: title, ValidationError: err.Error()}, nil
+		}
+		return nil, err
+	}
+	if notification.Method == imagerepositoryv1alpha1.NotificationMethodWebhook && r.WebhookValidationClient != nil {
+		for _, notificationStatus := range imageRepository.Status.Notifications {
+			// Targets found unreachable are not requested again until the spec changes
+			if notificationStatus.Title == title && notificationStatus.ValidationError != "" &&
+				notificationStatus.ObservedGeneration != 0 && notificationStatus.ObservedGeneration == imageRepository.Generation {
+				return &notificationStatus, nil
+			}
+		}
+		if err := r.validateWebhookTarget(ctx, notificationUrl); err != nil {
+			log.Info("webhook notification target is not reachable", "Title", title, "Url", notification.Config.Url, "Reason", err.Error())
+			return &imagerepositoryv1alpha1.NotificationStatus{Title: title, ValidationError: err.Error(), ObservedGeneration: imageRepository.Generation}, nil
+		}
+	}
+
+	log.Info("Creating notification in Quay", "Title", title, "Event", notification.Event, "Method", notification.Method)
+	var quayNotification *quay.Notification
+	err = retryAfterCreate(ctx, "CreateNotification", func() error {
+		var err error
+		quayNotification, err = r.QuayClient.CreateNotification(
+			r.QuayOrganization,
+			imageRepository.Spec.Image.Name,
+			quay.Notification{
+				Title:  getQuayNotificationTitle(imageRepository, title),
+				Event:  string(notification.Event),
+				Method: string(notification.Method),
+				Config: quay.NotificationConfig{
+					Url: notificationUrl,
+				},
+				EventConfig: quay.NotificationEventConfig{},
+			})
+		return err
+	})
+	if err != nil {
+		log.Error(err, "failed to create notification", "Title", title, "Event", notification.Event, "Method", notification.Method)
+		return nil, err
+	}
+
+	log.Info("Notification added",
+		"Title", title,
+		"Event", notification.Event,
+		"Method", notification.Method,
+		"QuayNotification", quayNotification)
+	return &imagerepositoryv1alpha1.NotificationStatus{UUID: quayNotification.UUID, Title: title}, nil
+}
+
+// checkWebhookAllowlist returns error if the notification is a webhook with target not allowed by WebhookAllowlist.
+func (r *ImageRepositoryReconciler) checkWebhookAllowlist(notification imagerepositoryv1alpha1.Notifications) error {
+	if notification.Method != imagerepositoryv1alpha1.NotificationMethodWebhook || r.WebhookAllowlist == nil {
+		return nil
+	}
+	return r.WebhookAllowlist.Check(notification.Config.Url)
+}
+
+// validateWebhookTarget sends HEAD request to the webhook URL.
+// Any response means that the target is reachable, as webhooks aren't required to support HEAD method.
+// Redirects are not followed, addresses the target may resolve to are restricted by WebhookValidationClient,
+// see WebhookValidationDialControl.
+func (r *ImageRepositoryReconciler) validateWebhookTarget(ctx context.Context, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	response, err := r.WebhookValidationClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("webhook target is not reachable: %w", err)
+	}
+	response.Body.Close()
+	return nil
+}
+
+// validateNotifications checks that all notification titles are unique after normalization.
+// It is done here to avoid webhook creation.
+func validateNotifications(notifications []imagerepositoryv1alpha1.Notifications) error {
+	titles := make(map[string]bool, len(notifications))
+	for _, notification := range notifications {
+		title := normalizeNotificationTitle(notification.Title)
+		if title == "" {
+			return fmt.Errorf("%snotification title must not be empty", invalidNotificationsMessagePrefix)
+		}
+		if titles[title] {
+			return fmt.Errorf("%snotification titles must be unique, duplicated title: %s", invalidNotificationsMessagePrefix, title)
+		}
+		titles[title] = true
+		if notification.Config.SecretRef != nil {
+			if notification.Method != imagerepositoryv1alpha1.NotificationMethodWebhook {
+				return fmt.Errorf("%ssecretRef is allowed only for webhook notifications, notification: %s", invalidNotificationsMessagePrefix, title)
+			}
+			if !strings.Contains(notification.Config.Url, notificationSecretPlaceholder) {
+				return fmt.Errorf("%swebhook url must contain %s placeholder if secretRef is set, notification: %s", invalidNotificationsMessagePrefix, notificationSecretPlaceholder, title)
+			}
 		}
-		notificationStatus = append(
-			notificationStatus,
-			imagerepositoryv1alpha1.NotificationStatus{
-				UUID:  quayNotification.UUID,
-				Title: notification.Title,
-			})
-
-		log.Info("Notification added",
-			"Title", notification.Title,
-			"Event", notification.Event,
-			"Method", notification.Method,
-			"QuayNotification", quayNotification)
 	}
-	return notificationStatus, nil
+	return nil
+}
+
+func normalizeNotificationTitle(title string) string {
+	return strings.TrimSpace(title)
+}
+
+// isNotificationUpToDate checks the Quay notification against the requested one,
+// notificationUrl is the requested url with the referenced secret injected.
+func isNotificationUpToDate(quayNotification quay.Notification, notification imagerepositoryv1alpha1.Notifications, notificationUrl string) bool {
+	return quayNotification.Event == string(notification.Event) &&
+		quayNotification.Method == string(notification.Method) &&
+		quayNotification.Config.Url == notificationUrl
 }
 
 // ProvisionImageRepository creates image repository, robot account(s) and secret(s) to access the image repository.
@@ -291,6 +1338,13 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 			if errors.IsNotFound(err) {
 				imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
 				imageRepository.Status.Message = fmt.Sprintf("Component '%s' does not exist", componentName)
+				meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+					Type:               imagerepositoryv1alpha1.ConditionTypeComponentMissing,
+					Status:             metav1.ConditionTrue,
+					Reason:             "ComponentNotFound",
+					Message:            imageRepository.Status.Message,
+					ObservedGeneration: imageRepository.Generation,
+				})
 				if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
 					log.Error(err, "failed to update image repository status")
 					return err
@@ -301,42 +1355,120 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 			log.Error(err, "failed to get component", "ComponentName", componentName)
 			return err
 		}
+		if applicationName := imageRepository.Labels[ApplicationNameLabelName]; component.Spec.Application != applicationName {
+			message := fmt.Sprintf("Component '%s' belongs to application '%s', not to application '%s' given by %s label",
+				componentName, component.Spec.Application, applicationName, ApplicationNameLabelName)
+			imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+			imageRepository.Status.Message = message
+			meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+				Type:               imagerepositoryv1alpha1.ConditionTypeApplicationMismatch,
+				Status:             metav1.ConditionTrue,
+				Reason:             "ComponentInOtherApplication",
+				Message:            message,
+				ObservedGeneration: imageRepository.Generation,
+			})
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status")
+				return err
+			}
+			log.Info("component of image repository belongs to other application", "Component", componentName, "Application", component.Spec.Application)
+			return nil
+		}
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeApplicationMismatch)
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeComponentMissing)
+		if annotations.OptOut.IsTrue(component) {
+			imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+			imageRepository.Status.Message = fmt.Sprintf("Component '%s' opted out of image repositories by %s annotation", componentName, annotations.OptOut)
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status")
+				return err
+			}
+			log.Info("attempt to create image repository related to opted out component", "Component", componentName)
+			return nil
+		}
 	}
 
-	imageRepositoryName := ""
-	if imageRepository.Spec.Image.Name == "" {
-		if isComponentLinked(imageRepository) {
-			applicationName := imageRepository.Labels[ApplicationNameLabelName]
-			componentName := imageRepository.Labels[ComponentNameLabelName]
-			imageRepositoryName = imageRepository.Namespace + "/" + applicationName + "/" + componentName
-		} else {
-			imageRepositoryName = imageRepository.Namespace + "/" + imageRepository.Name
+	requestedImageRepositoryName := getImageRepositoryName(imageRepository, r.RepositoryPathTemplate)
+	imageRepositoryName := r.getQuayRepositoryName(imageRepository)
+	if len(imageRepositoryName) > r.getMaxImageRepositoryNameLength() {
+		log.Info("image repository name is too long", "ImageRepositoryName", imageRepositoryName, "MaxLength", r.getMaxImageRepositoryNameLength())
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Message = fmt.Sprintf("image repository name is %d characters long, but maximum allowed length is %d", len(imageRepositoryName), r.getMaxImageRepositoryNameLength())
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status")
 		}
-	} else {
-		imageRepositoryName = strings.TrimPrefix(imageRepository.Spec.Image.Name, "/")
-		if !strings.HasPrefix(imageRepositoryName, imageRepository.Namespace+"/") {
-			imageRepositoryName = imageRepository.Namespace + "/" + imageRepositoryName
+		return nil
+	}
+	if err := r.ReservedRepositoryNames.Check(imageRepository.Namespace, imageRepositoryName); err != nil {
+		log.Info("image repository name is reserved", "ImageRepositoryName", imageRepositoryName, "Error", err.Error())
+		metrics.ReservedRepositoryNameRejectionsMetric.WithLabelValues(imageRepository.Namespace).Inc()
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Message = err.Error()
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status")
 		}
+		return nil
+	}
+	if imageRepositoryName != requestedImageRepositoryName {
+		log.Info("image repository name is shortened", "RequestedName", requestedImageRepositoryName, "ImageRepositoryName", imageRepositoryName)
 	}
 	imageRepository.Spec.Image.Name = imageRepositoryName
 
-	quayImageURL := fmt.Sprintf("quay.io/%s/%s", r.QuayOrganization, imageRepositoryName)
+	adoption, err := getRobotAccountsAdoption(imageRepository)
+	if err == nil {
+		err = validateRobotAccountsAdoption(imageRepository, adoption, r.QuayOrganization)
+	}
+	if err != nil {
+		log.Info("invalid robot accounts adoption", "Error", err.Error())
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Message = err.Error()
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status")
+		}
+		return nil
+	}
+
+	quayImageURL := getImageURL(r.RegistryHost, r.QuayOrganization, imageRepositoryName)
 	imageRepository.Status.Image.URL = quayImageURL
 
 	if imageRepository.Spec.Image.Visibility == "" {
-		imageRepository.Spec.Image.Visibility = imagerepositoryv1alpha1.ImageVisibilityPublic
+		imageRepository.Spec.Image.Visibility = r.getDefaultVisibility()
 	}
 	visibility := string(imageRepository.Spec.Image.Visibility)
+	if imageRepository.Spec.Image.Kind == "" {
+		imageRepository.Spec.Image.Kind = imagerepositoryv1alpha1.ImageRepositoryKindImage
+	}
+
+	if r.WaitForPrivateRepositoriesQuota && imageRepository.Spec.Image.Visibility == imagerepositoryv1alpha1.ImageVisibilityPrivate {
+		isWaiting, err := r.isWaitingInPrivateQuotaQueue(ctx, imageRepository)
+		if err != nil {
+			return err
+		}
+		if isWaiting {
+			return nil
+		}
+	}
 
 	repository, err := r.QuayClient.CreateRepository(quay.RepositoryRequest{
 		Namespace:   r.QuayOrganization,
 		Repository:  imageRepositoryName,
 		Visibility:  visibility,
-		Description: "AppStudio repository for the user",
+		Kind:        string(imageRepository.Spec.Image.Kind),
+		Description: getRepositoryOwnership(r.ClusterID, imageRepository).Description(r.getRepositorySummary(imageRepository)),
 	})
 	if err != nil {
 		log.Error(err, "failed to create image repository", l.Action, l.ActionAdd, l.Audit, "true")
+		if statusCode, _ := quay.GetStatusCode(err); statusCode == http.StatusPaymentRequired && r.WaitForPrivateRepositoriesQuota {
+			return r.setPendingQuotaCondition(ctx, imageRepository, pendingQuotaReasonQuotaExhausted,
+				"Number of private repositories exceeds current quay plan limit, the creation is retried periodically")
+		}
+		if getProvisionErrorClass(err) == imagerepositoryv1alpha1.ProvisionErrorClassTransient {
+			return err
+		}
 		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Provision.Attempts++
+		imageRepository.Status.Provision.LastErrorClass = imagerepositoryv1alpha1.ProvisionErrorClassPermanent
+		imageRepository.Status.Provision.LastError = err.Error()
 		if err.Error() == "payment required" {
 			imageRepository.Status.Message = "Number of private repositories exceeds current quay plan limit"
 		} else {
@@ -353,6 +1485,9 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 		return err
 	}
 
+	// Quay might not have propagated the new image repository yet
+	ctx = withJustCreatedRepository(ctx)
+
 	pushCredentialsInfo, err := r.ProvisionImageRepositoryAccess(ctx, imageRepository, false)
 	if err != nil {
 		return err
@@ -360,31 +1495,64 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 
 	var pullCredentialsInfo *imageRepositoryAccessData
 	if isComponentLinked(imageRepository) {
+		if err := r.saveInterruptedProvision(ctx, imageRepository, pushCredentialsInfo, nil); err != nil {
+			return err
+		}
 		pullCredentialsInfo, err = r.ProvisionImageRepositoryAccess(ctx, imageRepository, true)
 		if err != nil {
 			return err
 		}
 	}
+	if err := r.saveInterruptedProvision(ctx, imageRepository, pushCredentialsInfo, pullCredentialsInfo); err != nil {
+		return err
+	}
 
 	var notificationStatus []imagerepositoryv1alpha1.NotificationStatus
 	if notificationStatus, err = r.AddNotifications(ctx, imageRepository); err != nil {
 		return err
 	}
+	notificationsValidationErr := validateNotifications(imageRepository.Spec.Notifications)
 
 	status := imagerepositoryv1alpha1.ImageRepositoryStatus{}
 	status.State = imagerepositoryv1alpha1.ImageRepositoryStateReady
 	status.Image.URL = quayImageURL
 	status.Image.Visibility = imageRepository.Spec.Image.Visibility
+	status.Image.Kind = imageRepository.Spec.Image.Kind
+	if imageRepositoryName != requestedImageRepositoryName {
+		status.Image.RequestedName = requestedImageRepositoryName
+	}
 	status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
+	status.Credentials.RotationReason = imagerepositoryv1alpha1.CredentialsRotationReasonProvision
 	status.Credentials.PushRobotAccountName = pushCredentialsInfo.RobotAccountName
 	status.Credentials.PushSecretName = pushCredentialsInfo.SecretName
+	status.Credentials.RobotAccountNames = []string{}
+	credentialsInfos := []*imageRepositoryAccessData{pushCredentialsInfo}
 	if isComponentLinked(imageRepository) {
 		status.Credentials.PullRobotAccountName = pullCredentialsInfo.RobotAccountName
 		status.Credentials.PullSecretName = pullCredentialsInfo.SecretName
+		credentialsInfos = append(credentialsInfos, pullCredentialsInfo)
+	}
+	for _, credentialsInfo := range credentialsInfos {
+		// No robot account is created for externally managed secrets
+		if credentialsInfo.RobotAccountName == "" {
+			continue
+		}
+		if credentialsInfo.IsAdopted {
+			status.Credentials.AdoptedRobotAccountNames = append(status.Credentials.AdoptedRobotAccountNames, credentialsInfo.RobotAccountName)
+		} else {
+			status.Credentials.RobotAccountNames = append(status.Credentials.RobotAccountNames, credentialsInfo.RobotAccountName)
+		}
 	}
 	status.Notifications = notificationStatus
+	if notificationsValidationErr != nil {
+		status.Message = notificationsValidationErr.Error()
+	}
+	status.Conditions = imageRepository.Status.Conditions
+	meta.RemoveStatusCondition(&status.Conditions, imagerepositoryv1alpha1.ConditionTypePendingQuota)
 
 	imageRepository.Spec.Image.Name = imageRepositoryName
+	// Adopted robot accounts are tracked as created ones from now on
+	annotations.AdoptRobotAccounts.Remove(imageRepository)
 	controllerutil.AddFinalizer(imageRepository, ImageRepositoryFinalizer)
 	if isComponentLinked(imageRepository) {
 		if err := controllerutil.SetOwnerReference(component, imageRepository, r.Scheme); err != nil {
@@ -393,18 +1561,10 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 		}
 	}
 
-	if err := r.Client.Update(ctx, imageRepository); err != nil {
-		log.Error(err, "failed to update CR after provision")
-		return err
-	} else {
-		log.Info("Finished provision of image repository and added finalizer")
-	}
-
-	imageRepository.Status = status
-	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
-		log.Error(err, "failed to update CR status after provision")
+	if err := r.saveTrackedRobotAccounts(ctx, imageRepository, &status); err != nil {
 		return err
 	}
+	log.Info("Finished provision of image repository and added finalizer")
 
 	return nil
 }
@@ -412,6 +1572,8 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepository(ctx context.Context
 type imageRepositoryAccessData struct {
 	RobotAccountName string
 	SecretName       string
+	// IsAdopted is set if the robot account existed and was not created by the controller, so it must not be deleted
+	IsAdopted bool
 }
 
 // ProvisionImageRepositoryAccess makes existing quay image repository accessible
@@ -420,14 +1582,48 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepositoryAccess(ctx context.C
 	log := ctrllog.FromContext(ctx).WithName("ProvisionImageRepositoryAccess").WithValues("IsPullOnly", isPullOnly)
 	ctx = ctrllog.IntoContext(ctx, log)
 
+	if secretRef := getExistingSecretRef(imageRepository, isPullOnly); secretRef != nil {
+		return r.useExistingSecret(ctx, imageRepository, secretRef.Name, isPullOnly)
+	}
+
 	imageRepositoryName := imageRepository.Spec.Image.Name
 	quayImageURL := imageRepository.Status.Image.URL
 
-	robotAccountName := generateQuayRobotAccountName(imageRepositoryName, isPullOnly)
-	robotAccount, err := r.QuayClient.CreateRobotAccount(r.QuayOrganization, robotAccountName)
-	if err != nil {
-		log.Error(err, "failed to create robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionAdd, l.Audit, "true")
-		return nil, err
+	// The adoption is validated before the image repository is created
+	adoption, _ := getRobotAccountsAdoption(imageRepository)
+	robotAccountName := getAdoptedRobotAccountName(adoption, r.QuayOrganization, isPullOnly)
+	var robotAccount *quay.RobotAccount
+	var err error
+	isAdopted := false
+	if robotAccountName != "" {
+		robotAccount, err = r.getAdoptedRobotAccount(ctx, robotAccountName, adoption.RegenerateTokens)
+		if err != nil {
+			return nil, err
+		}
+		isAdopted = robotAccount != nil && !robotAccount.IsCreatedByController() &&
+			!slices.Contains(imageRepository.Status.Credentials.RobotAccountNames, robotAccountName)
+	} else {
+		robotAccountName = getInterruptedProvisionRobotAccountName(imageRepository, isPullOnly)
+		if robotAccountName == "" {
+			robotAccountName = generateQuayRobotAccountName(imageRepositoryName, isPullOnly)
+		}
+		robotAccountPurpose := "Push"
+		if isPullOnly {
+			robotAccountPurpose = "Pull"
+		}
+		robotAccountRequest := getRobotAccountRequest(r.ClusterID, imageRepository,
+			fmt.Sprintf("%s robot account of ImageRepository %s/%s", robotAccountPurpose, imageRepository.Namespace, imageRepository.Name))
+		robotAccount, err = r.QuayClient.CreateRobotAccountWithDescription(r.QuayOrganization, robotAccountName, robotAccountRequest)
+		if err != nil {
+			log.Error(err, "failed to create robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionAdd, l.Audit, "true")
+			return nil, err
+		}
+		if robotAccount != nil && robotAccount.Description != robotAccountRequest.Description {
+			// The robot account existed already, e.g. created by an interrupted provision, the description is best effort
+			if err := r.QuayClient.UpdateRobotAccountDescription(r.QuayOrganization, robotAccountName, robotAccountRequest); err != nil {
+				log.Error(err, "failed to update robot account description", "RobotAccountName", robotAccountName, l.Action, l.ActionUpdate)
+			}
+		}
 	}
 	if robotAccount == nil {
 		err := fmt.Errorf("unexpected response from Quay: robot account data object is nil")
@@ -435,7 +1631,9 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepositoryAccess(ctx context.C
 		return nil, err
 	}
 
-	err = r.QuayClient.AddPermissionsForRepositoryToRobotAccount(r.QuayOrganization, imageRepositoryName, robotAccount.Name, !isPullOnly)
+	err = retryAfterCreate(ctx, "AddPermissionsForRepositoryToRobotAccount", func() error {
+		return r.QuayClient.AddPermissionsForRepositoryToRobotAccount(r.QuayOrganization, imageRepositoryName, robotAccount.Name, !isPullOnly)
+	})
 	if err != nil {
 		log.Error(err, "failed to add permissions to robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionUpdate, l.Audit, "true")
 		return nil, err
@@ -449,22 +1647,41 @@ func (r *ImageRepositoryReconciler) ProvisionImageRepositoryAccess(ctx context.C
 	data := &imageRepositoryAccessData{
 		RobotAccountName: robotAccountName,
 		SecretName:       secretName,
+		IsAdopted:        isAdopted,
 	}
 	return data, nil
 }
 
 // RegenerateImageRepositoryCredentials rotates robot account(s) token and updates corresponding secret(s)
+// The handled request is identified by the object generation, not by timestamps, that are not comparable across
+// replicas with skewed clocks. So if clearing of the request fails, e.g. on leader failover, the credentials aren't rotated again.
 func (r *ImageRepositoryReconciler) RegenerateImageRepositoryCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
 	log := ctrllog.FromContext(ctx)
 
-	if err := r.RegenerateImageRepositoryAccessToken(ctx, imageRepository, false); err != nil {
-		return err
-	}
+	if imageRepository.Status.Credentials.RotationObservedGeneration != imageRepository.Generation {
+		// Credentials rotated by an interrupted attempt of the same request are not rotated again
+		steps := getCredentialsRotationSteps(imageRepository)
+		rotated := getRotatedCredentials(imageRepository)
+		for _, step := range steps {
+			if slices.Contains(rotated, step) {
+				continue
+			}
+			if err := r.rotateCredentials(ctx, imageRepository, step); err != nil {
+				return r.saveCredentialsRotationProgress(ctx, imageRepository, steps, rotated, err)
+			}
+			rotated = append(rotated, step)
+		}
 
-	if isComponentLinked(imageRepository) {
-		if err := r.RegenerateImageRepositoryAccessToken(ctx, imageRepository, true); err != nil {
+		imageRepository.Status.Credentials.RotationProgress = nil
+		imageRepository.Status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
+		imageRepository.Status.Credentials.RotationReason = imagerepositoryv1alpha1.CredentialsRotationReasonRegenerateToken
+		imageRepository.Status.Credentials.RotationObservedGeneration = imageRepository.Generation
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
 			return err
 		}
+	} else {
+		log.Info("Token rotation request was already handled", "Generation", imageRepository.Generation)
 	}
 
 	imageRepository.Spec.Credentials.RegenerateToken = nil
@@ -473,12 +1690,6 @@ func (r *ImageRepositoryReconciler) RegenerateImageRepositoryCredentials(ctx con
 		return err
 	}
 
-	imageRepository.Status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
-	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
-		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
-		return err
-	}
-
 	return nil
 }
 
@@ -487,6 +1698,11 @@ func (r *ImageRepositoryReconciler) RegenerateImageRepositoryAccessToken(ctx con
 	log := ctrllog.FromContext(ctx).WithName("RegenerateImageRepositoryAccessToken").WithValues("IsPullOnly", isPullOnly)
 	ctx = ctrllog.IntoContext(ctx, log)
 
+	if getExistingSecretRef(imageRepository, isPullOnly) != nil {
+		log.Info("Credentials are managed externally, token is not rotated")
+		return nil
+	}
+
 	quayImageURL := imageRepository.Status.Image.URL
 
 	robotAccountName := imageRepository.Status.Credentials.PushRobotAccountName
@@ -512,30 +1728,51 @@ func (r *ImageRepositoryReconciler) RegenerateImageRepositoryAccessToken(ctx con
 }
 
 // CleanupImageRepository deletes image repository and corresponding robot account(s).
-func (r *ImageRepositoryReconciler) CleanupImageRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+// If keepRepository is set, only the robot accounts and secrets are deleted.
+// Push and pull secrets are expected to be unlinked from service accounts already.
+func (r *ImageRepositoryReconciler) CleanupImageRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, keepRepository bool) {
 	log := ctrllog.FromContext(ctx).WithName("RepositoryCleanup")
 
-	robotAccountName := imageRepository.Status.Credentials.PushRobotAccountName
-	isRobotAccountDeleted, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName)
-	if err != nil {
-		log.Error(err, "failed to delete push robot account", l.Action, l.ActionDelete, l.Audit, "true")
-	}
-	if isRobotAccountDeleted {
-		log.Info("Deleted push robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete)
+	robotAccountNames := getDeletableRobotAccountNames(imageRepository)
+	if len(getTrackedRobotAccountNames(imageRepository)) == 0 {
+		// Legacy object without any record about created robot accounts
+		robotAccountNames = r.findLegacyRobotAccountNames(ctx, imageRepository)
 	}
-
-	if isComponentLinked(imageRepository) {
-		pullRobotAccountName := imageRepository.Status.Credentials.PullRobotAccountName
-		isPullRobotAccountDeleted, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, pullRobotAccountName)
+	for _, robotAccountName := range robotAccountNames {
+		isRobotAccountDeleted, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName)
 		if err != nil {
-			log.Error(err, "failed to delete pull robot account", l.Action, l.ActionDelete, l.Audit, "true")
+			log.Error(err, "failed to delete robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
 		}
-		if isPullRobotAccountDeleted {
-			log.Info("Deleted pull robot account", "RobotAccountName", pullRobotAccountName, l.Action, l.ActionDelete)
+		if isRobotAccountDeleted {
+			log.Info("Deleted robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete)
 		}
 	}
+	if keepRepository {
+		// Adopted robot accounts are kept, but must not keep access to the kept image repository
+		for _, robotAccountName := range imageRepository.Status.Credentials.AdoptedRobotAccountNames {
+			_ = r.revokeAdoptedRobotAccountAccess(ctrllog.IntoContext(ctx, log), imageRepository, robotAccountName)
+		}
+	}
+
+	// Secrets in other namespaces are not garbage collected by owner references
+	for _, consumerStatus := range imageRepository.Status.Credentials.ExternalConsumers {
+		consumerLog := log.WithValues("Namespace", consumerStatus.Namespace, "SecretName", consumerStatus.SecretName)
+		_ = r.deleteExternalConsumerSecret(ctrllog.IntoContext(ctx, consumerLog), imageRepository, consumerStatus)
+	}
+
+	// Adopted legacy secrets are owned by the Component, so they are not garbage collected
+	if legacySecrets := imageRepository.Annotations[legacySecretsAnnotationName]; legacySecrets != "" {
+		_ = r.deleteLegacySecrets(ctx, imageRepository, strings.Split(legacySecrets, ","))
+	}
 
 	imageRepositoryName := imageRepository.Spec.Image.Name
+	if r.isUsedByOtherImageRepository(ctx, imageRepository) {
+		return
+	}
+	if keepRepository {
+		log.Info("Keeping image repository", "ImageRepository", imageRepositoryName, l.Audit, "true")
+		return
+	}
 	isImageRepositoryDeleted, err := r.QuayClient.DeleteRepository(r.QuayOrganization, imageRepositoryName)
 	if err != nil {
 		log.Error(err, "failed to delete image repository", l.Action, l.ActionDelete, l.Audit, "true")
@@ -545,6 +1782,106 @@ func (r *ImageRepositoryReconciler) CleanupImageRepository(ctx context.Context,
 	}
 }
 
+// isUsedByOtherImageRepository checks whether the shared image repository is still used by other ImageRepository object.
+func (r *ImageRepositoryReconciler) isUsedByOtherImageRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	log := ctrllog.FromContext(ctx)
+
+	if !imageRepository.Spec.Image.Shared {
+		return false
+	}
+	siblings, err := r.listImageRepositoriesWithSameName(ctx, imageRepository)
+	if err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+	}
+	for _, sibling := range siblings {
+		if sibling.Spec.Image.Shared && controllerutil.ContainsFinalizer(&sibling, ImageRepositoryFinalizer) {
+			log.Info("Image repository is still used by other ImageRepository, skipping its deletion", "ImageRepository", imageRepository.Spec.Image.Name, "SharedWith", sibling.Name)
+			return true
+		}
+	}
+	return false
+}
+
+// getTrackedRobotAccountNames returns names of all robot accounts recorded as created for the image repository.
+// The annotation may be edited by anyone who can edit the object, so robot accounts recorded only there
+// must be named by the naming scheme of the image repository.
+func getTrackedRobotAccountNames(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	credentials := imageRepository.Status.Credentials
+	recordedNames := []string{credentials.PushRobotAccountName, credentials.PullRobotAccountName}
+	recordedNames = append(recordedNames, credentials.RobotAccountNames...)
+	for _, consumerStatus := range credentials.ExternalConsumers {
+		recordedNames = append(recordedNames, consumerStatus.RobotAccountName)
+	}
+	for _, secretStatus := range credentials.ServiceAccountSecrets {
+		recordedNames = append(recordedNames, secretStatus.RobotAccountName)
+	}
+	if credentials.TemporaryPullCredentials != nil {
+		recordedNames = append(recordedNames, credentials.TemporaryPullCredentials.RobotAccountName)
+	}
+	if robotAccountsAnnotation := imageRepository.Annotations[robotAccountsAnnotationName]; robotAccountsAnnotation != "" {
+		for _, name := range strings.Split(robotAccountsAnnotation, ",") {
+			if isImageRepositoryRobotAccountName(imageRepository, strings.TrimSpace(name)) {
+				recordedNames = append(recordedNames, name)
+			}
+		}
+	}
+
+	robotAccountNames := []string{}
+	seen := make(map[string]bool)
+	for _, name := range recordedNames {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		robotAccountNames = append(robotAccountNames, name)
+	}
+	return robotAccountNames
+}
+
+// isImageRepositoryRobotAccountName checks that the robot account is named by the naming scheme of the image repository,
+// see generateQuayRobotAccountName, or by the former naming scheme of its Component, see generateRobotAccountsNames.
+func isImageRepositoryRobotAccountName(imageRepository *imagerepositoryv1alpha1.ImageRepository, robotAccountName string) bool {
+	if imageRepository.Spec.Image.Name != "" {
+		if isCurrentRobotAccountName(imageRepository.Spec.Image.Name, robotAccountName, false) ||
+			isCurrentRobotAccountName(imageRepository.Spec.Image.Name, robotAccountName, true) {
+			return true
+		}
+	}
+	if isComponentLinked(imageRepository) {
+		legacyRobotAccountName := imageRepository.Namespace + imageRepository.Labels[ApplicationNameLabelName] + imageRepository.Labels[ComponentNameLabelName]
+		return robotAccountName == legacyRobotAccountName || robotAccountName == legacyRobotAccountName+"-pull"
+	}
+	return false
+}
+
+// findLegacyRobotAccountNames searches Quay for robot accounts of the image repository by the naming scheme.
+// Used only for objects that have no record about the created robot accounts.
+func (r *ImageRepositoryReconciler) findLegacyRobotAccountNames(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	log := ctrllog.FromContext(ctx)
+
+	if imageRepository.Spec.Image.Name == "" {
+		return nil
+	}
+
+	robotAccounts, err := r.QuayClient.GetAllRobotAccounts(r.QuayOrganization)
+	if err != nil {
+		log.Error(err, "failed to list robot accounts", l.Action, l.ActionView)
+		return nil
+	}
+
+	robotAccountNameRegexp := regexp.MustCompile("^" + regexp.QuoteMeta(getRobotAccountNamePrefix(imageRepository.Spec.Image.Name)) + "_[0-9a-f]{10}(_pull)?$")
+	robotAccountNames := []string{}
+	for _, robotAccount := range robotAccounts {
+		robotAccountName := strings.TrimPrefix(robotAccount.Name, r.QuayOrganization+"+")
+		if robotAccountNameRegexp.MatchString(robotAccountName) {
+			robotAccountNames = append(robotAccountNames, robotAccountName)
+		}
+	}
+	log.Info("Found legacy robot accounts by name", "RobotAccountNames", robotAccountNames)
+	return robotAccountNames
+}
+
 func (r *ImageRepositoryReconciler) ChangeImageRepositoryVisibility(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
 	if imageRepository.Status.Image.Visibility == imageRepository.Spec.Image.Visibility {
 		return nil
@@ -558,6 +1895,7 @@ func (r *ImageRepositoryReconciler) ChangeImageRepositoryVisibility(ctx context.
 	if err == nil {
 		imageRepository.Status.Image.Visibility = imageRepository.Spec.Image.Visibility
 		imageRepository.Status.Message = ""
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityDrift)
 		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
 			log.Error(err, "failed to update image repository name", l.Action, l.ActionUpdate)
 			return err
@@ -574,6 +1912,13 @@ func (r *ImageRepositoryReconciler) ChangeImageRepositoryVisibility(ctx context.
 			log.Error(err, "failed to update image repository", l.Action, l.ActionUpdate)
 			return err
 		}
+		r.recordSpecRevert(ctx, imageRepository, specRevert{
+			Field:          "spec.image.visibility",
+			AttemptedValue: requestedVisibility,
+			EnforcedValue:  string(imageRepository.Spec.Image.Visibility),
+			Reason:         specRevertReasonQuotaExceeded,
+			Message:        "Quay organization plan private repositories limit exceeded",
+		})
 
 		imageRepository.Status.Message = "Quay organization plan private repositories limit exceeded"
 		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
@@ -592,6 +1937,13 @@ func (r *ImageRepositoryReconciler) ChangeImageRepositoryVisibility(ctx context.
 func (r *ImageRepositoryReconciler) EnsureSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string, robotAccount *quay.RobotAccount, imageURL string, isPull bool) error {
 	log := ctrllog.FromContext(ctx).WithValues("SecretName", secretName)
 
+	// Validate before touching the secret, so a previously valid secret is never overwritten with garbage
+	secretData, err := generateDockerconfigSecretData(imageURL, robotAccount)
+	if err != nil {
+		log.Error(err, "refusing to write invalid image repository secret", l.Audit, "true")
+		return err
+	}
+
 	secret := &corev1.Secret{}
 	secretKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretName}
 	if err := r.Client.Get(ctx, secretKey, secret); err != nil {
@@ -600,60 +1952,169 @@ func (r *ImageRepositoryReconciler) EnsureSecret(ctx context.Context, imageRepos
 			return err
 		}
 
-		secret = &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      secretName,
-				Namespace: imageRepository.Namespace,
-				Labels: map[string]string{
-					InternalSecretLabelName: "true",
-				},
-			},
-			Type:       corev1.SecretTypeDockerConfigJson,
-			StringData: generateDockerconfigSecretData(imageURL, robotAccount),
+		if err := r.createImageRepositorySecret(ctx, imageRepository, secretName, secretData, isPull); err != nil {
+			return err
+		}
+		if !isPull {
+			if err := r.linkSecretToBuildPipelineServiceAccount(ctx, imageRepository.Namespace, secretName, r.getSecretLinking(imageRepository).PushSecret); err != nil {
+				return err
+			}
 		}
+		return r.clearSecretOwnershipConflict(ctx, imageRepository, secretName)
+	}
 
-		if err := controllerutil.SetOwnerReference(imageRepository, secret, r.Scheme); err != nil {
-			log.Error(err, "failed to set owner for image repository secret")
+	// Type and data of immutable secret cannot be updated
+	if conflict := getSecretUpdateConflict(secret); conflict != "" {
+		if err := r.resolveSecretUpdateConflict(ctx, imageRepository, secret, conflict, secretData, isPull); err != nil {
 			return err
 		}
+		return r.clearSecretOwnershipConflict(ctx, imageRepository, secretName)
+	}
 
-		if err := r.Client.Create(ctx, secret); err != nil {
-			log.Error(err, "failed to create image repository secret", l.Action, l.ActionAdd, l.Audit, "true")
-			return err
-		} else {
-			log.Info("Image repository secret created")
+	// Keep existing secret up to date, e.g. after token rotation
+	secret.StringData = secretData
+	if isPull {
+		setSecretExportMetadata(secret, r.PullSecretExportLabels, r.PullSecretExportAnnotations)
+	}
+	if err := r.Client.Update(ctx, secret); err != nil {
+		log.Error(err, "failed to update image repository secret", l.Action, l.ActionUpdate, l.Audit, "true")
+		return err
+	}
+	log.Info("Image repository secret updated")
+	return r.clearSecretOwnershipConflict(ctx, imageRepository, secretName)
+}
+
+// createImageRepositorySecret creates dockerconfigjson secret owned by the image repository.
+func (r *ImageRepositoryReconciler) createImageRepositorySecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string, secretData map[string]string, isPull bool) error {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secretName)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: imageRepository.Namespace,
+			Labels: map[string]string{
+				InternalSecretLabelName: "true",
+			},
+		},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		StringData: secretData,
+	}
+	if isPull {
+		setSecretExportMetadata(secret, r.PullSecretExportLabels, r.PullSecretExportAnnotations)
+	}
+
+	if err := controllerutil.SetOwnerReference(imageRepository, secret, r.Scheme); err != nil {
+		log.Error(err, "failed to set owner for image repository secret")
+		return err
+	}
+
+	if err := r.Client.Create(ctx, secret); err != nil {
+		log.Error(err, "failed to create image repository secret", l.Action, l.ActionAdd, l.Audit, "true")
+		return err
+	}
+	log.Info("Image repository secret created")
+	return nil
+}
+
+// setSecretExportMetadata adds the given labels and annotations to the secret,
+// so the secret could be picked up by a secret sync mechanism and exported into remote clusters.
+// Internal labels cannot be overridden.
+func setSecretExportMetadata(secret *corev1.Secret, labels, annotations map[string]string) {
+	if len(labels) > 0 && secret.Labels == nil {
+		secret.Labels = make(map[string]string)
+	}
+	for key, value := range labels {
+		if key == InternalSecretLabelName {
+			continue
 		}
+		secret.Labels[key] = value
+	}
 
-		if !isPull {
-			serviceAccount := &corev1.ServiceAccount{}
-			serviceAccountKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: buildPipelineServiceAccountName}
-			if err := r.Client.Get(ctx, serviceAccountKey, serviceAccount); err != nil {
-				log.Error(err, "failed to get service account", l.Action, l.ActionView)
-				return err
-			}
-			serviceAccount.Secrets = append(serviceAccount.Secrets, corev1.ObjectReference{Name: secretName})
-			serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
-			if err := r.Client.Update(ctx, serviceAccount); err != nil {
-				log.Error(err, "failed to update service account", l.Action, l.ActionUpdate)
-				return err
-			}
+	if len(annotations) > 0 && secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	for key, value := range annotations {
+		secret.Annotations[key] = value
+	}
+}
+
+// getImageRepositoryName returns normalized image repository name within the configured Quay organization.
+// The name is always prefixed with the ImageRepository namespace.
+// If the name is not requested, it's generated from the path template.
+func getImageRepositoryName(imageRepository *imagerepositoryv1alpha1.ImageRepository, pathTemplate RepositoryPathTemplate) string {
+	if imageRepository.Spec.Image.Name == "" {
+		if isComponentLinked(imageRepository) {
+			applicationName := imageRepository.Labels[ApplicationNameLabelName]
+			componentName := imageRepository.Labels[ComponentNameLabelName]
+			return pathTemplate.Render(imageRepository.Namespace, applicationName, componentName)
 		}
+		return pathTemplate.Render(imageRepository.Namespace, "", imageRepository.Name)
 	}
-	return nil
+
+	imageRepositoryName := strings.TrimPrefix(imageRepository.Spec.Image.Name, "/")
+	if !strings.HasPrefix(imageRepositoryName, imageRepository.Namespace+"/") {
+		imageRepositoryName = imageRepository.Namespace + "/" + imageRepositoryName
+	}
+	return imageRepositoryName
+}
+
+// getRepositoryOwnership returns ownership information to record in the image repository created for the given object.
+func getRepositoryOwnership(clusterID string, owner client.Object) quay.RepositoryOwnership {
+	return quay.RepositoryOwnership{
+		ManagedBy: quay.OwnershipManagedBy,
+		ClusterID: clusterID,
+		Namespace: owner.GetNamespace(),
+		UID:       string(owner.GetUID()),
+	}
+}
+
+// getRobotAccountRequest returns provenance information to record in the robot account created for the given object,
+// so Quay admins could tell where the robot account came from. The summary describes the purpose of the robot account.
+func getRobotAccountRequest(clusterID string, owner client.Object, summary string) quay.RobotAccountRequest {
+	description := summary
+	if clusterID != "" {
+		description = fmt.Sprintf("%s in cluster %s", summary, clusterID)
+	}
+	metadata := getRepositoryOwnership(clusterID, owner).Metadata()
+	metadata["name"] = owner.GetName()
+	return quay.RobotAccountRequest{Description: description, UnstructuredMetadata: metadata}
+}
+
+// getQuayRepositoryName returns name of the image repository in Quay.
+// It's the normalized requested name, shortened to fit the configured limit if allowed.
+func (r *ImageRepositoryReconciler) getQuayRepositoryName(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	imageRepositoryName := getImageRepositoryName(imageRepository, r.RepositoryPathTemplate)
+	if r.ShortenLongImageRepositoryNames {
+		return shortenImageRepositoryName(imageRepositoryName, r.getMaxImageRepositoryNameLength())
+	}
+	return imageRepositoryName
+}
+
+func (r *ImageRepositoryReconciler) getMaxImageRepositoryNameLength() int {
+	if r.MaxImageRepositoryNameLength > 0 {
+		return r.MaxImageRepositoryNameLength
+	}
+	return QuayRepositoryNameMaxLength
+}
+
+// shortenImageRepositoryName replaces the end of too long image repository name with hash of the whole name.
+// The result is the same for the same name, while the hash prevents collisions between names with common beginning.
+// Name segments start with alphanumeric character, so the namespace prefix is preserved if maxLength is big enough.
+func shortenImageRepositoryName(imageRepositoryName string, maxLength int) string {
+	if len(imageRepositoryName) <= maxLength {
+		return imageRepositoryName
+	}
+	hash := sha256.Sum256([]byte(imageRepositoryName))
+	suffix := "-" + hex.EncodeToString(hash[:])[:imageRepositoryNameHashLength]
+	prefix := strings.TrimRight(imageRepositoryName[:maxLength-len(suffix)], "/._-")
+	return prefix + suffix
 }
 
 // generateQuayRobotAccountName generates valid robot account name for given image repository name.
 func generateQuayRobotAccountName(imageRepositoryName string, isPullOnly bool) string {
 	// Robot account name must match ^[a-z][a-z0-9_]{1,254}$
 
-	imageNamePrefix := imageRepositoryName
-	if len(imageNamePrefix) > 220 {
-		imageNamePrefix = imageNamePrefix[:220]
-	}
-	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, "/", "_")
-	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, ".", "_")
-	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, "-", "_")
-
+	imageNamePrefix := getRobotAccountNamePrefix(imageRepositoryName)
 	randomSuffix := getRandomString(10)
 
 	robotAccountName := fmt.Sprintf("%s_%s", imageNamePrefix, randomSuffix)
@@ -663,6 +2124,18 @@ func generateQuayRobotAccountName(imageRepositoryName string, isPullOnly bool) s
 	return robotAccountName
 }
 
+// getRobotAccountNamePrefix returns robot account name prefix for given image repository name.
+func getRobotAccountNamePrefix(imageRepositoryName string) string {
+	imageNamePrefix := imageRepositoryName
+	if len(imageNamePrefix) > 220 {
+		imageNamePrefix = imageNamePrefix[:220]
+	}
+	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, "/", "_")
+	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, ".", "_")
+	imageNamePrefix = strings.ReplaceAll(imageNamePrefix, "-", "_")
+	return imageNamePrefix
+}
+
 func getSecretName(imageRepository *imagerepositoryv1alpha1.ImageRepository, isPullOnly bool) string {
 	secretName := imageRepository.Name
 	if len(secretName) > 220 {
@@ -27,9 +27,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 
 	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
 )
 
 var _ = Describe("Image repository controller", func() {
@@ -594,6 +596,106 @@ var _ = Describe("Image repository controller", func() {
 
 	})
 
+	Context("Image repository credentials", func() {
+
+		BeforeEach(func() {
+			quay.ResetTestQuayClient()
+			deleteImageRepository(resourceKey)
+		})
+
+		It("should record failed provision if robot account of other image repository requested for adoption", func() {
+			createImageRepository(imageRepositoryConfig{
+				Annotations: map[string]string{string(annotations.AdoptRobotAccounts): `{"pull": "other_namespace_other_image_pull"}`},
+			})
+			defer deleteImageRepository(resourceKey)
+
+			imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+			Eventually(func() bool {
+				imageRepository = getImageRepository(resourceKey)
+				return imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed
+			}, timeout, interval).Should(BeTrue())
+			Expect(imageRepository.Status.Message).To(ContainSubstring("doesn't belong to image repository"))
+			Expect(imageRepository.Status.Operations).To(HaveLen(1))
+			Expect(imageRepository.Status.Operations[0].Operation).To(Equal(operationProvision))
+			Expect(imageRepository.Status.Operations[0].Result).To(Equal(imagerepositoryv1alpha1.OperationResultFailed))
+			Expect(imageRepository.Status.Operations[0].Message).To(Equal(imageRepository.Status.Message))
+		})
+
+		It("should provision and remove external consumer secret", func() {
+			consumerNamespace := "consumer-namespace"
+			createNamespaceWithAnnotations(consumerNamespace, map[string]string{AllowedConsumersAnnotationName: defaultNamespace})
+			consumerSecretKey := types.NamespacedName{Namespace: consumerNamespace, Name: "image-repository-pull"}
+
+			createImageRepository(imageRepositoryConfig{})
+			defer deleteImageRepository(resourceKey)
+			Eventually(func() bool {
+				return getImageRepository(resourceKey).Status.State == imagerepositoryv1alpha1.ImageRepositoryStateReady
+			}, timeout, interval).Should(BeTrue())
+
+			imageRepository := getImageRepository(resourceKey)
+			imageRepository.Spec.Credentials = &imagerepositoryv1alpha1.ImageCredentials{
+				ExternalConsumers: []imagerepositoryv1alpha1.ExternalConsumer{{Namespace: consumerSecretKey.Namespace, SecretName: consumerSecretKey.Name}},
+			}
+			Expect(k8sClient.Update(ctx, imageRepository)).To(Succeed())
+
+			consumerSecret := waitSecretExist(consumerSecretKey)
+			Expect(consumerSecret.Type).To(Equal(corev1.SecretTypeDockerConfigJson))
+			Expect(consumerSecret.Annotations[externalSecretOwnerAnnotationName]).To(Equal(defaultNamespace + "/" + defaultImageRepositoryName))
+
+			consumerRobotAccountName := ""
+			Eventually(func() bool {
+				imageRepository = getImageRepository(resourceKey)
+				if len(imageRepository.Status.Credentials.ExternalConsumers) != 1 {
+					return false
+				}
+				consumerRobotAccountName = imageRepository.Status.Credentials.ExternalConsumers[0].RobotAccountName
+				return consumerRobotAccountName != ""
+			}, timeout, interval).Should(BeTrue())
+			Expect(imageRepository.Annotations[robotAccountsAnnotationName]).To(ContainSubstring(consumerRobotAccountName))
+
+			isDeleteConsumerRobotAccountInvoked := false
+			quay.DeleteRobotAccountFunc = func(organization, robotName string) (bool, error) {
+				if robotName == consumerRobotAccountName {
+					isDeleteConsumerRobotAccountInvoked = true
+				}
+				return true, nil
+			}
+
+			imageRepository.Spec.Credentials.ExternalConsumers = nil
+			Expect(k8sClient.Update(ctx, imageRepository)).To(Succeed())
+
+			waitSecretGone(consumerSecretKey)
+			Eventually(func() bool { return isDeleteConsumerRobotAccountInvoked }, timeout, interval).Should(BeTrue())
+			Eventually(func() bool {
+				imageRepository = getImageRepository(resourceKey)
+				return len(imageRepository.Status.Credentials.ExternalConsumers) == 0 &&
+					!strings.Contains(imageRepository.Annotations[robotAccountsAnnotationName], consumerRobotAccountName)
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("should not provision external consumer secret in namespace that doesn't allow it", func() {
+			consumerNamespace := "not-allowing-consumer-namespace"
+			createNamespace(consumerNamespace)
+			consumerSecretKey := types.NamespacedName{Namespace: consumerNamespace, Name: "image-repository-pull"}
+
+			createImageRepository(imageRepositoryConfig{
+				Credentials: &imagerepositoryv1alpha1.ImageCredentials{
+					ExternalConsumers: []imagerepositoryv1alpha1.ExternalConsumer{{Namespace: consumerSecretKey.Namespace, SecretName: consumerSecretKey.Name}},
+				},
+			})
+			defer deleteImageRepository(resourceKey)
+
+			Eventually(func() bool {
+				imageRepository := getImageRepository(resourceKey)
+				return imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateReady &&
+					strings.HasPrefix(imageRepository.Status.Message, invalidExternalConsumerMessagePrefix)
+			}, timeout, interval).Should(BeTrue())
+			Consistently(func() bool {
+				return k8sErrors.IsNotFound(k8sClient.Get(ctx, consumerSecretKey, &corev1.Secret{}))
+			}, ensureTimeout, interval).Should(BeTrue())
+		})
+	})
+
 	Context("Image repository error scenarios", func() {
 
 		BeforeEach(func() {
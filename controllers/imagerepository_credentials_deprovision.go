@@ -0,0 +1,178 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const credentialsRemovedReasonDeprovisioned = "Deprovisioned"
+
+func isDeprovisionRequested(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return imageRepository.Spec.Credentials != nil && imageRepository.Spec.Credentials.Deprovision
+}
+
+func isCredentialsRemoved(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeCredentialsRemoved)
+}
+
+// DeprovisionCredentials deletes push and pull robot accounts and secrets of the image repository,
+// while the image repository and its images are kept.
+// The secrets are unlinked from all service accounts in the namespace.
+func (r *ImageRepositoryReconciler) DeprovisionCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("DeprovisionCredentials")
+
+	credentials := imageRepository.Status.Credentials
+	robotAccountNames := []string{credentials.PushRobotAccountName, credentials.PullRobotAccountName}
+	for _, secretStatus := range credentials.ServiceAccountSecrets {
+		robotAccountNames = append(robotAccountNames, secretStatus.RobotAccountName)
+	}
+	for _, robotAccountName := range robotAccountNames {
+		if robotAccountName == "" {
+			continue
+		}
+		if slices.Contains(credentials.AdoptedRobotAccountNames, robotAccountName) {
+			if err := r.revokeAdoptedRobotAccountAccess(ctx, imageRepository, robotAccountName); err != nil {
+				return err
+			}
+			continue
+		}
+		isRobotAccountDeleted, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName)
+		if err != nil {
+			log.Error(err, "failed to delete robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			return err
+		}
+		if isRobotAccountDeleted {
+			log.Info("Deleted robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete)
+		}
+	}
+
+	secretNames := getImageRepositorySecretNames(imageRepository)
+	if err := r.unlinkSecretsFromServiceAccounts(ctx, imageRepository.Namespace, secretNames); err != nil {
+		return err
+	}
+	for _, secretName := range secretNames {
+		if isExistingSecret(imageRepository, secretName) {
+			// Externally managed secrets are only unlinked
+			continue
+		}
+		secret := &corev1.Secret{}
+		secret.Name = secretName
+		secret.Namespace = imageRepository.Namespace
+		if err := r.Client.Delete(ctx, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "failed to delete image repository secret", "SecretName", secretName, l.Action, l.ActionDelete)
+			return err
+		}
+		log.Info("Deleted image repository secret", "SecretName", secretName, l.Action, l.ActionDelete)
+	}
+
+	status := imageRepository.Status.DeepCopy()
+	status.Credentials.PushRobotAccountName = ""
+	status.Credentials.PullRobotAccountName = ""
+	status.Credentials.ServiceAccountSecrets = nil
+	status.Credentials.AdoptedRobotAccountNames = nil
+	status.Credentials.RobotAccountNames = slices.DeleteFunc(status.Credentials.RobotAccountNames, func(robotAccountName string) bool {
+		return slices.Contains(robotAccountNames, robotAccountName)
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeCredentialsRemoved,
+		Status:             metav1.ConditionTrue,
+		Reason:             credentialsRemovedReasonDeprovisioned,
+		Message:            "Push and pull credentials were removed on request, the image repository is kept",
+		ObservedGeneration: imageRepository.Generation,
+	})
+
+	if err := r.saveTrackedRobotAccounts(ctx, imageRepository, status); err != nil {
+		return err
+	}
+	log.Info("Image repository credentials deprovisioned", l.Audit, "true")
+	return nil
+}
+
+// ReprovisionCredentials creates push and pull robot accounts and secrets again,
+// after they were removed by DeprovisionCredentials.
+func (r *ImageRepositoryReconciler) ReprovisionCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ReprovisionCredentials")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	status := imageRepository.Status.DeepCopy()
+	pushCredentialsInfo, err := r.ProvisionImageRepositoryAccess(ctx, imageRepository, false)
+	if err != nil {
+		return err
+	}
+	status.Credentials.PushRobotAccountName = pushCredentialsInfo.RobotAccountName
+	status.Credentials.PushSecretName = pushCredentialsInfo.SecretName
+	robotAccountNames := []string{pushCredentialsInfo.RobotAccountName}
+	if isComponentLinked(imageRepository) {
+		pullCredentialsInfo, err := r.ProvisionImageRepositoryAccess(ctx, imageRepository, true)
+		if err != nil {
+			// The push robot account isn't recorded anywhere yet, so it would leak
+			if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, pushCredentialsInfo.RobotAccountName); err != nil {
+				log.Error(err, "failed to delete push robot account of failed reprovision", "RobotAccountName", pushCredentialsInfo.RobotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			}
+			return err
+		}
+		status.Credentials.PullRobotAccountName = pullCredentialsInfo.RobotAccountName
+		status.Credentials.PullSecretName = pullCredentialsInfo.SecretName
+		robotAccountNames = append(robotAccountNames, pullCredentialsInfo.RobotAccountName)
+	}
+	for _, robotAccountName := range robotAccountNames {
+		if robotAccountName != "" && !slices.Contains(status.Credentials.RobotAccountNames, robotAccountName) {
+			status.Credentials.RobotAccountNames = append(status.Credentials.RobotAccountNames, robotAccountName)
+		}
+	}
+	status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
+	status.Credentials.RotationReason = imagerepositoryv1alpha1.CredentialsRotationReasonReprovision
+	meta.RemoveStatusCondition(&status.Conditions, imagerepositoryv1alpha1.ConditionTypeCredentialsRemoved)
+
+	if err := r.saveTrackedRobotAccounts(ctx, imageRepository, status); err != nil {
+		return err
+	}
+	log.Info("Image repository credentials reprovisioned", l.Audit, "true")
+	return nil
+}
+
+// getImageRepositorySecretNames returns names of push and pull secrets of the image repository,
+// both generated and externally managed ones.
+func getImageRepositorySecretNames(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	secretNames := []string{}
+	credentials := imageRepository.Status.Credentials
+	candidateNames := append([]string{credentials.PushSecretName, credentials.PullSecretName}, getExistingSecretNames(imageRepository)...)
+	for _, secretStatus := range credentials.ServiceAccountSecrets {
+		candidateNames = append(candidateNames, secretStatus.SecretName)
+	}
+	for _, secretName := range candidateNames {
+		if secretName != "" && !slices.Contains(secretNames, secretName) {
+			secretNames = append(secretNames, secretName)
+		}
+	}
+	return secretNames
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	credentialsRotationStepPush                  = "push"
+	credentialsRotationStepPull                  = "pull"
+	credentialsRotationStepExternalConsumers     = "externalConsumers"
+	credentialsRotationStepServiceAccountSecrets = "serviceAccountSecrets"
+
+	// rateLimitedRetryInterval is used when Quay rate limits a request without telling when to retry.
+	rateLimitedRetryInterval = time.Minute
+)
+
+// getCredentialsRotationSteps returns credentials of the image repository rotated by token rotation request, in rotation order.
+func getCredentialsRotationSteps(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	steps := []string{credentialsRotationStepPush}
+	if isComponentLinked(imageRepository) {
+		steps = append(steps, credentialsRotationStepPull)
+	}
+	return append(steps, credentialsRotationStepExternalConsumers, credentialsRotationStepServiceAccountSecrets)
+}
+
+// getRotatedCredentials returns credentials already rotated by the current token rotation request.
+// Progress of former requests is ignored.
+func getRotatedCredentials(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	progress := imageRepository.Status.Credentials.RotationProgress
+	if progress == nil || progress.Generation != imageRepository.Generation {
+		return []string{}
+	}
+	return slices.Clone(progress.Rotated)
+}
+
+// rotateCredentials rotates the given credentials of the image repository.
+func (r *ImageRepositoryReconciler) rotateCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, step string) error {
+	switch step {
+	case credentialsRotationStepPush:
+		return r.RegenerateImageRepositoryAccessToken(ctx, imageRepository, false)
+	case credentialsRotationStepPull:
+		return r.RegenerateImageRepositoryAccessToken(ctx, imageRepository, true)
+	case credentialsRotationStepExternalConsumers:
+		return r.RegenerateExternalConsumersCredentials(ctx, imageRepository)
+	case credentialsRotationStepServiceAccountSecrets:
+		return r.RegenerateServiceAccountPullSecretsCredentials(ctx, imageRepository)
+	}
+	return nil
+}
+
+// saveCredentialsRotationProgress records the credentials rotated so far by the interrupted token rotation request,
+// so the rotated ones are not rotated again when the request is retried. Returns the rotation error.
+func (r *ImageRepositoryReconciler) saveCredentialsRotationProgress(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, steps, rotated []string, rotationErr error) error {
+	log := ctrllog.FromContext(ctx)
+
+	if len(rotated) == 0 && imageRepository.Status.Credentials.RotationProgress == nil {
+		if _, isRateLimited := quay.GetRetryAfter(rotationErr); !isRateLimited {
+			// Nothing done yet, the request is simply retried
+			return rotationErr
+		}
+	}
+	pending := []string{}
+	for _, step := range steps {
+		if !slices.Contains(rotated, step) {
+			pending = append(pending, step)
+		}
+	}
+	imageRepository.Status.Credentials.RotationProgress = &imagerepositoryv1alpha1.CredentialsRotationProgress{
+		Generation: imageRepository.Generation,
+		Rotated:    rotated,
+		Pending:    pending,
+		Message:    rotationErr.Error(),
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to save credentials rotation progress", l.Action, l.ActionUpdate)
+		return rotationErr
+	}
+	log.Info("Saved credentials rotation progress", "Rotated", rotated, "Pending", pending)
+	return rotationErr
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// getExistingSecretRef returns reference to the externally managed push or pull secret, nil if the secret is generated.
+func getExistingSecretRef(imageRepository *imagerepositoryv1alpha1.ImageRepository, isPullOnly bool) *imagerepositoryv1alpha1.SecretReference {
+	if imageRepository.Spec.Credentials == nil {
+		return nil
+	}
+	if isPullOnly {
+		return imageRepository.Spec.Credentials.ExistingPullSecretRef
+	}
+	return imageRepository.Spec.Credentials.ExistingPushSecretRef
+}
+
+// getExistingSecretNames returns names of all externally managed secrets of the image repository.
+func getExistingSecretNames(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	secretNames := []string{}
+	for _, isPullOnly := range []bool{false, true} {
+		if secretRef := getExistingSecretRef(imageRepository, isPullOnly); secretRef != nil {
+			secretNames = append(secretNames, secretRef.Name)
+		}
+	}
+	return secretNames
+}
+
+// isExistingSecret checks whether the secret of the image repository is managed externally, so it must not be modified.
+func isExistingSecret(imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string) bool {
+	return slices.Contains(getExistingSecretNames(imageRepository), secretName)
+}
+
+// revertExistingSecretRefs restores references to the externally managed secrets the provisioned image repository uses.
+// Changing the references later would leave the service accounts linked to the former secrets.
+// Credentials that are not provisioned, e.g. removed by deprovision request, may be changed.
+// The spec is changed in place, returns the reverted changes.
+func revertExistingSecretRefs(imageRepository *imagerepositoryv1alpha1.ImageRepository) []specRevert {
+	if imageRepository.Status.State != imagerepositoryv1alpha1.ImageRepositoryStateReady {
+		return nil
+	}
+
+	reverts := []specRevert{}
+	for _, isPullOnly := range []bool{false, true} {
+		field, provisionedSecretName := "spec.credentials.existingPushSecretRef", imageRepository.Status.Credentials.PushSecretName
+		if isPullOnly {
+			field, provisionedSecretName = "spec.credentials.existingPullSecretRef", imageRepository.Status.Credentials.PullSecretName
+		}
+		if provisionedSecretName == "" {
+			continue
+		}
+		enforcedSecretName := ""
+		if provisionedSecretName != getSecretName(imageRepository, isPullOnly) {
+			enforcedSecretName = provisionedSecretName
+		}
+		attemptedSecretName := ""
+		if secretRef := getExistingSecretRef(imageRepository, isPullOnly); secretRef != nil {
+			attemptedSecretName = secretRef.Name
+		}
+		if attemptedSecretName == enforcedSecretName {
+			continue
+		}
+
+		var enforcedSecretRef *imagerepositoryv1alpha1.SecretReference
+		if enforcedSecretName != "" {
+			enforcedSecretRef = &imagerepositoryv1alpha1.SecretReference{Name: enforcedSecretName}
+		}
+		if imageRepository.Spec.Credentials == nil {
+			imageRepository.Spec.Credentials = &imagerepositoryv1alpha1.ImageCredentials{}
+		}
+		if isPullOnly {
+			imageRepository.Spec.Credentials.ExistingPullSecretRef = enforcedSecretRef
+		} else {
+			imageRepository.Spec.Credentials.ExistingPushSecretRef = enforcedSecretRef
+		}
+		reverts = append(reverts, specRevert{
+			Field:          field,
+			AttemptedValue: attemptedSecretName,
+			EnforcedValue:  enforcedSecretName,
+			Reason:         specRevertReasonImmutable,
+			Message:        "existing secret cannot be changed after provision, remove the credentials by spec.credentials.deprovision first",
+		})
+	}
+	return reverts
+}
+
+// useExistingSecret makes the image repository accessible with the externally managed secret instead of a robot account.
+// The secret is only verified and linked to the build pipeline service account, its content is never touched,
+// so the source of the secret, e.g. external-secrets operator, stays the only writer.
+func (r *ImageRepositoryReconciler) useExistingSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string, isPullOnly bool) (*imageRepositoryAccessData, error) {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secretName)
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretName}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			// The secret might not be synced yet, the provision is retried
+			return nil, fmt.Errorf("existing secret %s does not exist", secretName)
+		}
+		log.Error(err, "failed to get existing secret", l.Action, l.ActionView)
+		return nil, err
+	}
+	if err := verifyExistingDockerConfigSecret(secret, imageRepository.Status.Image.URL); err != nil {
+		log.Error(err, "existing secret cannot be used for the image repository")
+		return nil, err
+	}
+
+	if !isPullOnly {
+		if err := r.linkSecretToBuildPipelineServiceAccount(ctx, imageRepository.Namespace, secretName, r.getSecretLinking(imageRepository).PushSecret); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("Using existing secret instead of generated credentials", l.Audit, "true")
+
+	return &imageRepositoryAccessData{SecretName: secretName}, nil
+}
+
+// linkSecretToBuildPipelineServiceAccount links the push secret to the build pipeline service account according to the linking policy.
+func (r *ImageRepositoryReconciler) linkSecretToBuildPipelineServiceAccount(ctx context.Context, namespace, secretName string, linking imagerepositoryv1alpha1.PushSecretLinking) error {
+	log := ctrllog.FromContext(ctx)
+
+	if linking == imagerepositoryv1alpha1.PushSecretLinkingNone {
+		return nil
+	}
+	serviceAccount := &corev1.ServiceAccount{}
+	serviceAccountKey := types.NamespacedName{Namespace: namespace, Name: buildPipelineServiceAccountName}
+	if err := r.Client.Get(ctx, serviceAccountKey, serviceAccount); err != nil {
+		log.Error(err, "failed to get service account", l.Action, l.ActionView)
+		return err
+	}
+
+	if !setPushSecretLinks(serviceAccount, secretName, linking) {
+		return nil
+	}
+	if err := r.Client.Update(ctx, serviceAccount); err != nil {
+		log.Error(err, "failed to update service account", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
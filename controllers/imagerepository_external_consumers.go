@@ -0,0 +1,355 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	// AllowedConsumersAnnotationName is set on a Namespace to allow creation of external consumer secrets in it.
+	// Holds comma separated list of namespaces, ImageRepositories of which may create the secrets, or "*" for all namespaces.
+	AllowedConsumersAnnotationName = string(annotations.AllowedConsumers)
+
+	// externalSecretOwnerAnnotationName tracks ImageRepository that created the secret in other namespace,
+	// because owner references cannot point to objects in other namespaces.
+	externalSecretOwnerAnnotationName = "image-controller.appstudio.redhat.com/owner"
+
+	invalidExternalConsumerMessagePrefix = "invalid external consumer: "
+)
+
+// SyncExternalConsumers makes secrets in other namespaces match the requested external consumers.
+// Each consumer gets dedicated pull only robot account, so the access could be revoked independently.
+func (r *ImageRepositoryReconciler) SyncExternalConsumers(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("SyncExternalConsumers")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	requestedConsumers := []imagerepositoryv1alpha1.ExternalConsumer{}
+	if imageRepository.Spec.Credentials != nil {
+		requestedConsumers = imageRepository.Spec.Credentials.ExternalConsumers
+	}
+	requested := map[string]imagerepositoryv1alpha1.ExternalConsumer{}
+	for _, consumer := range requestedConsumers {
+		requested[getExternalConsumerKey(consumer.Namespace, consumer.SecretName)] = consumer
+	}
+
+	consumersStatus := []imagerepositoryv1alpha1.ExternalConsumerStatus{}
+	isProvisioned := map[string]bool{}
+	removedRobotAccountNames := map[string]bool{}
+	invalidConsumerMessages := []string{}
+	for _, consumerStatus := range imageRepository.Status.Credentials.ExternalConsumers {
+		key := getExternalConsumerKey(consumerStatus.Namespace, consumerStatus.SecretName)
+		if consumer, isRequested := requested[key]; isRequested {
+			// The allowance of the namespace could be withdrawn or the secret taken over anytime, the access is revoked then
+			invalidConsumerMessage, err := r.validateExternalConsumer(ctx, imageRepository, consumer)
+			if err != nil {
+				_ = r.saveExternalConsumers(ctx, imageRepository, consumersStatus, removedRobotAccountNames, imageRepository.Status.Message)
+				return err
+			}
+			isProvisioned[key] = true
+			if invalidConsumerMessage == "" {
+				consumersStatus = append(consumersStatus, consumerStatus)
+				continue
+			}
+			log.Info("revoking external consumer that is not valid anymore", "Namespace", consumer.Namespace, "SecretName", consumer.SecretName, "Reason", invalidConsumerMessage, l.Audit, "true")
+			invalidConsumerMessages = append(invalidConsumerMessages, invalidConsumerMessage)
+		}
+		if err := r.deleteExternalConsumer(ctx, imageRepository, consumerStatus); err != nil {
+			// Save progress, so provisioned consumers are not provisioned again
+			_ = r.saveExternalConsumers(ctx, imageRepository, consumersStatus, removedRobotAccountNames, imageRepository.Status.Message)
+			return err
+		}
+		removedRobotAccountNames[consumerStatus.RobotAccountName] = true
+	}
+
+	for _, consumer := range requestedConsumers {
+		key := getExternalConsumerKey(consumer.Namespace, consumer.SecretName)
+		if isProvisioned[key] {
+			continue
+		}
+		isProvisioned[key] = true
+
+		invalidConsumerMessage, err := r.validateExternalConsumer(ctx, imageRepository, consumer)
+		if err != nil {
+			_ = r.saveExternalConsumers(ctx, imageRepository, consumersStatus, removedRobotAccountNames, imageRepository.Status.Message)
+			return err
+		}
+		if invalidConsumerMessage != "" {
+			log.Info("skipping invalid external consumer", "Namespace", consumer.Namespace, "SecretName", consumer.SecretName, "Reason", invalidConsumerMessage)
+			invalidConsumerMessages = append(invalidConsumerMessages, invalidConsumerMessage)
+			continue
+		}
+
+		consumerStatus, err := r.provisionExternalConsumer(ctx, imageRepository, consumer)
+		if err != nil {
+			_ = r.saveExternalConsumers(ctx, imageRepository, consumersStatus, removedRobotAccountNames, imageRepository.Status.Message)
+			return err
+		}
+		consumersStatus = append(consumersStatus, *consumerStatus)
+	}
+
+	message := imageRepository.Status.Message
+	if len(invalidConsumerMessages) > 0 {
+		message = invalidExternalConsumerMessagePrefix + strings.Join(invalidConsumerMessages, "; ")
+	} else if strings.HasPrefix(message, invalidExternalConsumerMessagePrefix) {
+		message = ""
+	}
+	return r.saveExternalConsumers(ctx, imageRepository, consumersStatus, removedRobotAccountNames, message)
+}
+
+// saveExternalConsumers records provisioned external consumers and their robot accounts, if changed.
+func (r *ImageRepositoryReconciler) saveExternalConsumers(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumersStatus []imagerepositoryv1alpha1.ExternalConsumerStatus, removedRobotAccountNames map[string]bool, message string) error {
+	log := ctrllog.FromContext(ctx)
+
+	addedRobotAccountNames := []string{}
+	for _, consumerStatus := range consumersStatus {
+		addedRobotAccountNames = append(addedRobotAccountNames, consumerStatus.RobotAccountName)
+	}
+	if len(consumersStatus) == 0 {
+		consumersStatus = nil
+	}
+
+	isConsumersChanged := !reflect.DeepEqual(consumersStatus, imageRepository.Status.Credentials.ExternalConsumers)
+	if !isConsumersChanged && message == imageRepository.Status.Message {
+		return nil
+	}
+
+	status := imageRepository.Status.DeepCopy()
+	status.Message = message
+	if isConsumersChanged {
+		status.Credentials.RobotAccountNames = getUpdatedTrackedRobotAccountNames(imageRepository, removedRobotAccountNames, addedRobotAccountNames)
+		status.Credentials.ExternalConsumers = consumersStatus
+		return r.saveTrackedRobotAccounts(ctx, imageRepository, status)
+	}
+	imageRepository.Status = *status
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update external consumers status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// validateExternalConsumer returns message describing why the consumer cannot be provisioned, or empty string if it can.
+func (r *ImageRepositoryReconciler) validateExternalConsumer(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumer imagerepositoryv1alpha1.ExternalConsumer) (string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	if consumer.Namespace == "" || consumer.SecretName == "" {
+		return "namespace and secretName must be set", nil
+	}
+	if consumer.Role != "" && consumer.Role != imagerepositoryv1alpha1.ExternalConsumerRolePull {
+		return fmt.Sprintf("role %s of %s/%s is not supported", consumer.Role, consumer.Namespace, consumer.SecretName), nil
+	}
+
+	if consumer.Namespace != imageRepository.Namespace {
+		namespace := &corev1.Namespace{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: consumer.Namespace}, namespace); err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Sprintf("namespace %s doesn't exist", consumer.Namespace), nil
+			}
+			log.Error(err, "failed to get namespace", "Namespace", consumer.Namespace, l.Action, l.ActionView)
+			return "", err
+		}
+		if !isConsumerNamespaceAllowed(namespace, imageRepository.Namespace) {
+			return fmt.Sprintf("namespace %s doesn't allow secrets from %s namespace", consumer.Namespace, imageRepository.Namespace), nil
+		}
+	}
+
+	// Never take over secrets created by someone else
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: consumer.Namespace, Name: consumer.SecretName}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		log.Error(err, "failed to get external consumer secret", "Namespace", consumer.Namespace, "SecretName", consumer.SecretName, l.Action, l.ActionView)
+		return "", err
+	}
+	if secret.Annotations[externalSecretOwnerAnnotationName] != getExternalSecretOwner(imageRepository) {
+		return fmt.Sprintf("secret %s already exists in %s namespace", consumer.SecretName, consumer.Namespace), nil
+	}
+	return "", nil
+}
+
+// isConsumerNamespaceAllowed checks that the namespace allows creation of external consumer secrets from the source namespace.
+func isConsumerNamespaceAllowed(namespace *corev1.Namespace, sourceNamespace string) bool {
+	allowedConsumers, _ := annotations.AllowedConsumers.Get(namespace)
+	for _, allowedNamespace := range strings.Split(allowedConsumers, ",") {
+		allowedNamespace = strings.TrimSpace(allowedNamespace)
+		if allowedNamespace == "*" || allowedNamespace == sourceNamespace {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ImageRepositoryReconciler) provisionExternalConsumer(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumer imagerepositoryv1alpha1.ExternalConsumer) (*imagerepositoryv1alpha1.ExternalConsumerStatus, error) {
+	log := ctrllog.FromContext(ctx).WithValues("Namespace", consumer.Namespace, "SecretName", consumer.SecretName)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	robotAccount, robotAccountName, err := r.createRepositoryRobotAccount(ctx, imageRepository,
+		fmt.Sprintf("Pull robot account of ImageRepository %s/%s for namespace %s", imageRepository.Namespace, imageRepository.Name, consumer.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	consumerStatus := &imagerepositoryv1alpha1.ExternalConsumerStatus{
+		Namespace:        consumer.Namespace,
+		SecretName:       consumer.SecretName,
+		RobotAccountName: robotAccountName,
+	}
+
+	if err := r.ensureExternalConsumerSecret(ctx, imageRepository, *consumerStatus, robotAccount); err != nil {
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, err
+	}
+	log.Info("Provisioned external consumer", "RobotAccountName", robotAccountName, l.Action, l.ActionAdd)
+	return consumerStatus, nil
+}
+
+// ensureExternalConsumerSecret creates or updates the consumer secret with the robot account credentials.
+// Secret owned by someone else is never overwritten, e.g. if it was recreated in the consumer namespace meanwhile.
+func (r *ImageRepositoryReconciler) ensureExternalConsumerSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumerStatus imagerepositoryv1alpha1.ExternalConsumerStatus, robotAccount *quay.RobotAccount) error {
+	log := ctrllog.FromContext(ctx)
+
+	secretData, err := generateDockerconfigSecretData(imageRepository.Status.Image.URL, robotAccount)
+	if err != nil {
+		log.Error(err, "refusing to write invalid external consumer secret", l.Audit, "true")
+		return err
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Namespace: consumerStatus.Namespace, Name: consumerStatus.SecretName}
+	if err := r.Client.Get(ctx, secretKey, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get external consumer secret", l.Action, l.ActionView)
+			return err
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      consumerStatus.SecretName,
+				Namespace: consumerStatus.Namespace,
+				Labels: map[string]string{
+					InternalSecretLabelName: "true",
+				},
+				Annotations: map[string]string{
+					externalSecretOwnerAnnotationName: getExternalSecretOwner(imageRepository),
+				},
+			},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			StringData: secretData,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			log.Error(err, "failed to create external consumer secret", l.Action, l.ActionAdd, l.Audit, "true")
+			return err
+		}
+		log.Info("External consumer secret created")
+		return nil
+	}
+
+	if secret.Annotations[externalSecretOwnerAnnotationName] != getExternalSecretOwner(imageRepository) {
+		err := fmt.Errorf("secret %s already exists in %s namespace and is not owned by the image repository", consumerStatus.SecretName, consumerStatus.Namespace)
+		log.Error(err, "refusing to overwrite external consumer secret", l.Audit, "true")
+		return err
+	}
+	secret.StringData = secretData
+	if err := r.Client.Update(ctx, secret); err != nil {
+		log.Error(err, "failed to update external consumer secret", l.Action, l.ActionUpdate, l.Audit, "true")
+		return err
+	}
+	log.Info("External consumer secret updated")
+	return nil
+}
+
+// RegenerateExternalConsumersCredentials rotates tokens of all external consumer robot accounts and updates their secrets.
+func (r *ImageRepositoryReconciler) RegenerateExternalConsumersCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	for _, consumerStatus := range imageRepository.Status.Credentials.ExternalConsumers {
+		consumerLog := log.WithValues("Namespace", consumerStatus.Namespace, "SecretName", consumerStatus.SecretName)
+		robotAccount, err := r.QuayClient.RegenerateRobotAccountToken(r.QuayOrganization, consumerStatus.RobotAccountName)
+		if err != nil {
+			consumerLog.Error(err, "failed to refresh external consumer robot account token")
+			return err
+		}
+		if err := r.ensureExternalConsumerSecret(ctrllog.IntoContext(ctx, consumerLog), imageRepository, consumerStatus, robotAccount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExternalConsumer revokes the consumer access by deleting its robot account and secret.
+func (r *ImageRepositoryReconciler) deleteExternalConsumer(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumerStatus imagerepositoryv1alpha1.ExternalConsumerStatus) error {
+	log := ctrllog.FromContext(ctx).WithValues("Namespace", consumerStatus.Namespace, "SecretName", consumerStatus.SecretName)
+
+	if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, consumerStatus.RobotAccountName); err != nil {
+		log.Error(err, "failed to delete robot account", "RobotAccountName", consumerStatus.RobotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	if err := r.deleteExternalConsumerSecret(ctrllog.IntoContext(ctx, log), imageRepository, consumerStatus); err != nil {
+		return err
+	}
+	log.Info("Deleted external consumer", l.Action, l.ActionDelete)
+	return nil
+}
+
+// deleteExternalConsumerSecret deletes the consumer secret, unless it was taken over by someone else.
+func (r *ImageRepositoryReconciler) deleteExternalConsumerSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, consumerStatus imagerepositoryv1alpha1.ExternalConsumerStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Namespace: consumerStatus.Namespace, Name: consumerStatus.SecretName}
+	if err := r.Client.Get(ctx, secretKey, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get external consumer secret", l.Action, l.ActionView)
+		return err
+	}
+	if secret.Annotations[externalSecretOwnerAnnotationName] != getExternalSecretOwner(imageRepository) {
+		log.Info("external consumer secret is not owned by the image repository, keeping it")
+		return nil
+	}
+	if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "failed to delete external consumer secret", l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	log.Info("External consumer secret deleted")
+	return nil
+}
+
+// getExternalSecretOwner returns value of the owner annotation of secrets created for the image repository.
+func getExternalSecretOwner(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	return imageRepository.Namespace + "/" + imageRepository.Name
+}
+
+func getExternalConsumerKey(namespace, secretName string) string {
+	return namespace + "/" + secretName
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+const (
+	// quayRepositoryNameIndexKey indexes ImageRepository objects by name of the image repository in Quay.
+	quayRepositoryNameIndexKey = "quayRepositoryName"
+	// pendingQuotaIndexKey indexes ImageRepository objects waiting for private repositories quota, see isPendingQuota.
+	pendingQuotaIndexKey = "pendingQuota"
+	// imageNameIndexKey indexes ImageRepository objects by the provisioned image repository name.
+	imageNameIndexKey = "spec.image.name"
+)
+
+// imageRepositoryIndexer looks up ImageRepository objects by indexed values.
+// The client reads ImageRepository objects directly from the API server, so lookups done on watch events
+// and on each provision use indexes of the manager cache, which is filled by the controller watch anyway.
+type imageRepositoryIndexer struct {
+	// cache is the indexed manager cache, nil until the indexes are registered,
+	// then the objects are listed by the client and filtered by the index functions.
+	cache   client.Reader
+	indexes map[string]client.IndexerFunc
+}
+
+// register adds the indexes to the manager cache.
+func (i *imageRepositoryIndexer) register(ctx context.Context, indexer client.FieldIndexer, cache client.Reader) error {
+	for key, indexFunc := range i.indexes {
+		if err := indexer.IndexField(ctx, &imagerepositoryv1alpha1.ImageRepository{}, key, indexFunc); err != nil {
+			return err
+		}
+	}
+	i.cache = cache
+	return nil
+}
+
+// list returns ImageRepository objects with the given value of the index.
+func (i *imageRepositoryIndexer) list(ctx context.Context, c client.Reader, key, value string, opts ...client.ListOption) ([]imagerepositoryv1alpha1.ImageRepository, error) {
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if i.cache != nil {
+		if err := i.cache.List(ctx, imageRepositoryList, append(opts, client.MatchingFields{key: value})...); err != nil {
+			return nil, err
+		}
+		return imageRepositoryList.Items, nil
+	}
+
+	if err := c.List(ctx, imageRepositoryList, opts...); err != nil {
+		return nil, err
+	}
+	imageRepositories := []imagerepositoryv1alpha1.ImageRepository{}
+	for _, imageRepository := range imageRepositoryList.Items {
+		if slices.Contains(i.indexes[key](&imageRepository), value) {
+			imageRepositories = append(imageRepositories, imageRepository)
+		}
+	}
+	return imageRepositories, nil
+}
+
+// getImageRepositoryIndexer returns indexer of ImageRepository objects used by the reconciler.
+func (r *ImageRepositoryReconciler) getImageRepositoryIndexer() *imageRepositoryIndexer {
+	if r.imageRepositoryIndexer == nil {
+		r.imageRepositoryIndexer = &imageRepositoryIndexer{
+			indexes: map[string]client.IndexerFunc{
+				quayRepositoryNameIndexKey: func(obj client.Object) []string {
+					return []string{r.getQuayRepositoryName(obj.(*imagerepositoryv1alpha1.ImageRepository))}
+				},
+				pendingQuotaIndexKey: func(obj client.Object) []string {
+					if isPendingQuota(obj.(*imagerepositoryv1alpha1.ImageRepository)) {
+						return []string{"true"}
+					}
+					return nil
+				},
+			},
+		}
+	}
+	return r.imageRepositoryIndexer
+}
+
+// getImageRepositoryIndexer returns indexer of ImageRepository objects used by the reconciler.
+func (r *ComponentReconciler) getImageRepositoryIndexer() *imageRepositoryIndexer {
+	if r.imageRepositoryIndexer == nil {
+		r.imageRepositoryIndexer = &imageRepositoryIndexer{
+			indexes: map[string]client.IndexerFunc{
+				imageNameIndexKey: func(obj client.Object) []string {
+					return []string{obj.(*imagerepositoryv1alpha1.ImageRepository).Spec.Image.Name}
+				},
+			},
+		}
+	}
+	return r.imageRepositoryIndexer
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	"github.com/konflux-ci/image-controller/pkg/features"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// latestTagSyncInterval is how often the latest tag is looked up in Quay if pushes are not watched.
+const latestTagSyncInterval = 30 * time.Minute
+
+// SyncLatestTag records the most recently pushed tag and its digest in the image repository status.
+// If push notifications are received, Quay is asked only after a push, otherwise at most once per latestTagSyncInterval.
+// Other reconciles reuse the recorded values.
+// If tracking is disabled, the recorded values are removed.
+// Returns interval after which the latest tag should be checked again, zero if it's not needed.
+func (r *ImageRepositoryReconciler) SyncLatestTag(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (recheckAfter time.Duration, err error) {
+	log := ctrllog.FromContext(ctx)
+
+	imageStatus := &imageRepository.Status.Image
+	if !imageRepository.Spec.Image.TrackLatest {
+		if imageStatus.LatestTag == "" && imageStatus.LatestDigest == "" && imageStatus.LatestTagCheckTimestamp == nil {
+			return 0, nil
+		}
+		imageStatus.LatestTag = ""
+		imageStatus.LatestDigest = ""
+		imageStatus.LatestTagCheckTimestamp = nil
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	if r.PushNotifications != nil {
+		if !r.PushNotifications.takePushed(r.QuayOrganization, imageRepository.Spec.Image.Name) && imageStatus.LatestTagCheckTimestamp != nil {
+			return 0, nil
+		}
+		defer func() {
+			if err != nil {
+				// Look up the push again on retry
+				r.PushNotifications.markPushed(r.QuayOrganization, imageRepository.Spec.Image.Name)
+			}
+		}()
+	} else if imageStatus.LatestTagCheckTimestamp != nil {
+		if sinceLastCheck := elapsedSince(imageStatus.LatestTagCheckTimestamp.Time); sinceLastCheck < latestTagSyncInterval {
+			return latestTagSyncInterval - sinceLastCheck, nil
+		}
+	}
+
+	// Quay returns the most recent tags first, so the first page is enough
+	tags, _, err := r.QuayClient.GetTagsFromPage(r.QuayOrganization, imageRepository.Spec.Image.Name, 1)
+	if err != nil {
+		log.Error(err, "failed to get image repository tags", l.Action, l.ActionView)
+		return 0, err
+	}
+	now := time.Now()
+	latestTag := getLatestTag(tags, now)
+	if latestTag.Name != imageStatus.LatestTag || latestTag.ManifestDigest != imageStatus.LatestDigest {
+		log.Info("Latest tag changed", "Tag", latestTag.Name, "Digest", latestTag.ManifestDigest)
+	}
+	if latestTag.ManifestDigest != "" && latestTag.ManifestDigest != imageStatus.LatestDigest {
+		// Labeled before the digest is recorded, so failed labeling is retried on the next reconcile
+		if err := r.labelManifest(ctx, imageRepository, latestTag.ManifestDigest); err != nil {
+			return 0, err
+		}
+	}
+	imageStatus.LatestTag = latestTag.Name
+	imageStatus.LatestDigest = latestTag.ManifestDigest
+	imageStatus.LatestTagCheckTimestamp = &metav1.Time{Time: now}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return 0, err
+	}
+	if r.PushNotifications != nil {
+		return 0, nil
+	}
+	return latestTagSyncInterval, nil
+}
+
+// labelManifest attaches labels from the manifest-labels annotation to the newly pushed manifest.
+// Labels already present on the manifest are skipped, as Quay would add a duplicate instead of replacing them.
+func (r *ImageRepositoryReconciler) labelManifest(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, manifestDigest string) error {
+	log := ctrllog.FromContext(ctx).WithValues("Digest", manifestDigest)
+
+	if !r.FeatureGates.Enabled(features.ManifestLabels) {
+		return nil
+	}
+	value, exists := annotations.ManifestLabels.Get(imageRepository)
+	if !exists {
+		return nil
+	}
+	if err := annotations.ManifestLabels.Validate(imageRepository); err != nil {
+		log.Error(err, "invalid manifest labels annotation, the manifest is not labeled")
+		return nil
+	}
+	labels := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &labels); err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	existingLabels, err := r.QuayClient.ListManifestLabels(r.QuayOrganization, imageRepository.Spec.Image.Name, manifestDigest)
+	if err != nil {
+		log.Error(err, "failed to list manifest labels", l.Action, l.ActionView)
+		return err
+	}
+	existing := make(map[string]bool, len(existingLabels))
+	for _, label := range existingLabels {
+		existing[label.Key+"="+label.Value] = true
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	added := []string{}
+	for _, key := range keys {
+		if existing[key+"="+labels[key]] {
+			continue
+		}
+		if err := r.QuayClient.AddManifestLabel(r.QuayOrganization, imageRepository.Spec.Image.Name, manifestDigest, key, labels[key]); err != nil {
+			log.Error(err, "failed to add manifest label", "Key", key, l.Action, l.ActionAdd)
+			return err
+		}
+		added = append(added, key)
+	}
+	if len(added) > 0 {
+		log.Info("Labeled latest manifest", "Labels", added, l.Action, l.ActionAdd)
+	}
+	return nil
+}
+
+// getLatestTag returns the most recently pushed tag that is not expired yet, empty tag if there is none.
+func getLatestTag(tags []quay.Tag, now time.Time) quay.Tag {
+	latestTag := quay.Tag{}
+	for _, tag := range tags {
+		if tag.EndTS != 0 && tag.EndTS <= now.Unix() {
+			continue
+		}
+		if latestTag.Name == "" || tag.StartTS > latestTag.StartTS {
+			latestTag = tag
+		}
+	}
+	return latestTag
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+const (
+	// AdoptLegacyComponentAnnotationName holds name of the Component, image repository of which
+	// was provisioned by the legacy annotation flow and should be adopted by the ImageRepository.
+	// Not needed for ImageRepository objects linked to the Component by labels, they adopt it automatically.
+	AdoptLegacyComponentAnnotationName = string(annotations.AdoptLegacyComponent)
+
+	// legacySecretsAnnotationName holds comma separated list of the adopted legacy secrets to be deleted
+	// after legacySecretsRetireTimeAnnotationName, so running pipelines could finish with the old secret names.
+	legacySecretsAnnotationName           = "image-controller.appstudio.redhat.com/legacy-secrets"
+	legacySecretsRetireTimeAnnotationName = "image-controller.appstudio.redhat.com/legacy-secrets-retire-time"
+
+	legacySecretsRetirementDelay = 24 * time.Hour
+)
+
+// getLegacyComponentName returns name of the Component the image repository of which could be adopted.
+func getLegacyComponentName(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	if componentName, _ := annotations.AdoptLegacyComponent.Get(imageRepository); componentName != "" {
+		return componentName
+	}
+	if isComponentLinked(imageRepository) {
+		return imageRepository.Labels[ComponentNameLabelName]
+	}
+	return ""
+}
+
+// getLegacyRepositoryInfo returns the image repository provisioned for the Component by the legacy flow, if any.
+func getLegacyRepositoryInfo(component *appstudioredhatcomv1alpha1.Component) (ImageRepositoryStatus, bool) {
+	if !controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
+		return ImageRepositoryStatus{}, false
+	}
+	repositoryInfo := ImageRepositoryStatus{}
+	imageAnnotation, _ := annotations.Image.Get(component)
+	if err := json.Unmarshal([]byte(imageAnnotation), &repositoryInfo); err != nil {
+		return ImageRepositoryStatus{}, false
+	}
+	if repositoryInfo.Image == "" || repositoryInfo.Secret == "" {
+		return ImageRepositoryStatus{}, false
+	}
+	return repositoryInfo, true
+}
+
+// AdoptLegacyComponentRepository takes over image repository provisioned by the legacy Component flow.
+// The image repository and robot accounts are kept, so the legacy secrets stay valid until they are retired.
+// Returns false if there is nothing to adopt and the image repository should be provisioned as usual.
+func (r *ImageRepositoryReconciler) AdoptLegacyComponentRepository(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (bool, error) {
+	componentName := getLegacyComponentName(imageRepository)
+	if componentName == "" {
+		return false, nil
+	}
+	log := ctrllog.FromContext(ctx).WithName("LegacyComponentAdoption").WithValues("ComponentName", componentName)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	component := &appstudioredhatcomv1alpha1.Component{}
+	componentKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}
+	if err := r.Client.Get(ctx, componentKey, component); err != nil {
+		if errors.IsNotFound(err) {
+			// Provision reports missing Component of linked image repositories
+			return false, nil
+		}
+		log.Error(err, "failed to get component", l.Action, l.ActionView)
+		return false, err
+	}
+	repositoryInfo, exists := getLegacyRepositoryInfo(component)
+	if !exists {
+		return false, nil
+	}
+
+	imageRepositoryName, found := getImageRepositoryNameFromURL(repositoryInfo.Image, r.RegistryHost, r.QuayOrganization)
+	if !found {
+		log.Info("legacy image repository is not in the configured organization", "Image", repositoryInfo.Image)
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Message = fmt.Sprintf("cannot adopt image repository %s of Component '%s' from other organization", repositoryInfo.Image, componentName)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return true, err
+		}
+		return true, nil
+	}
+	if !isImageRepositoryNameAllowed(imageRepositoryName, imageRepository.Namespace, r.AdminNamespaces) {
+		log.Info("legacy image repository belongs to other namespace", "Image", repositoryInfo.Image, l.Audit, "true")
+		imageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+		imageRepository.Status.Message = fmt.Sprintf("cannot adopt image repository %s of Component '%s' from other namespace", repositoryInfo.Image, componentName)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return true, err
+		}
+		return true, nil
+	}
+
+	pushRobotAccountName, pullRobotAccountName := generateRobotAccountsNames(component)
+	pushRobotAccount, err := r.QuayClient.GetRobotAccount(r.QuayOrganization, pushRobotAccountName)
+	if err != nil {
+		log.Error(err, "failed to get legacy robot account", "RobotAccountName", pushRobotAccountName, l.Action, l.ActionView)
+		return true, err
+	}
+	pullRobotAccount, err := r.QuayClient.GetRobotAccount(r.QuayOrganization, pullRobotAccountName)
+	if err != nil {
+		log.Error(err, "failed to get legacy robot account", "RobotAccountName", pullRobotAccountName, l.Action, l.ActionView)
+		return true, err
+	}
+
+	imageRepository.Spec.Image.Name = imageRepositoryName
+	if imageRepository.Spec.Image.Visibility == "" {
+		imageRepository.Spec.Image.Visibility = imagerepositoryv1alpha1.ImageVisibility(repositoryInfo.Visibility)
+	}
+	imageRepository.Status.Image.URL = repositoryInfo.Image
+
+	pushSecretName := getSecretName(imageRepository, false)
+	if err := r.EnsureSecret(ctx, imageRepository, pushSecretName, pushRobotAccount, repositoryInfo.Image, false); err != nil {
+		return true, err
+	}
+	pullSecretName := getSecretName(imageRepository, true)
+	if isComponentLinked(imageRepository) {
+		if err := r.EnsureSecret(ctx, imageRepository, pullSecretName, pullRobotAccount, repositoryInfo.Image, true); err != nil {
+			return true, err
+		}
+	}
+
+	status := imagerepositoryv1alpha1.ImageRepositoryStatus{}
+	status.State = imagerepositoryv1alpha1.ImageRepositoryStateReady
+	status.Image.URL = repositoryInfo.Image
+	status.Image.Visibility = imagerepositoryv1alpha1.ImageVisibility(repositoryInfo.Visibility)
+	status.Credentials.GenerationTimestamp = &metav1.Time{Time: time.Now()}
+	status.Credentials.RotationReason = imagerepositoryv1alpha1.CredentialsRotationReasonLegacyAdoption
+	status.Credentials.PushRobotAccountName = pushRobotAccountName
+	status.Credentials.PushSecretName = pushSecretName
+	if isComponentLinked(imageRepository) {
+		status.Credentials.PullRobotAccountName = pullRobotAccountName
+		status.Credentials.PullSecretName = pullSecretName
+	}
+	// The pull robot account is tracked even if not used, so it's deleted together with the image repository
+	status.Credentials.RobotAccountNames = []string{pushRobotAccountName, pullRobotAccountName}
+	status.Conditions = imageRepository.Status.Conditions
+
+	if imageRepository.Annotations == nil {
+		imageRepository.Annotations = make(map[string]string)
+	}
+	imageRepository.Annotations[legacySecretsAnnotationName] = strings.Join([]string{repositoryInfo.Secret, repositoryInfo.Secret + "-pull"}, ",")
+	imageRepository.Annotations[legacySecretsRetireTimeAnnotationName] = time.Now().Add(legacySecretsRetirementDelay).UTC().Format(time.RFC3339)
+	annotations.AdoptLegacyComponent.Remove(imageRepository)
+	controllerutil.AddFinalizer(imageRepository, ImageRepositoryFinalizer)
+	if err := controllerutil.SetOwnerReference(component, imageRepository, r.Scheme); err != nil {
+		log.Error(err, "failed to set component as owner")
+		// Do not brake adoption because of failed owner reference
+	}
+	if err := r.saveTrackedRobotAccounts(ctx, imageRepository, &status); err != nil {
+		return true, err
+	}
+	log.Info("Adopted legacy image repository of the Component", "ImageRepository", imageRepositoryName, l.Action, l.ActionUpdate, l.Audit, "true")
+
+	return true, r.releaseLegacyComponent(ctx, componentKey)
+}
+
+// releaseLegacyComponent removes the legacy image repository finalizer and annotation from the Component,
+// so the Component controller doesn't delete the adopted image repository.
+func (r *ImageRepositoryReconciler) releaseLegacyComponent(ctx context.Context, componentKey types.NamespacedName) error {
+	log := ctrllog.FromContext(ctx)
+
+	component := &appstudioredhatcomv1alpha1.Component{}
+	if err := r.Client.Get(ctx, componentKey, component); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get component", l.Action, l.ActionView)
+		return err
+	}
+	_, annotationExists := annotations.Image.Get(component)
+	if !annotationExists && !controllerutil.ContainsFinalizer(component, ImageRepositoryComponentFinalizer) {
+		return nil
+	}
+
+	annotations.Image.Remove(component)
+	controllerutil.RemoveFinalizer(component, ImageRepositoryComponentFinalizer)
+	if err := r.Client.Update(ctx, component); err != nil {
+		log.Error(err, "failed to release legacy component", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Removed legacy image repository finalizer and annotation from the Component", l.Action, l.ActionUpdate)
+	return nil
+}
+
+// RetireLegacySecrets deletes the adopted legacy secrets once running pipelines had time to finish.
+// Returns time to wait for the retirement, if it's not due yet.
+func (r *ImageRepositoryReconciler) RetireLegacySecrets(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("RetireLegacySecrets")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	// Finish adoption if it was interrupted
+	if componentName := imageRepository.Labels[ComponentNameLabelName]; componentName != "" {
+		componentKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}
+		if err := r.releaseLegacyComponent(ctx, componentKey); err != nil {
+			return 0, err
+		}
+	}
+
+	if retireTime, err := time.Parse(time.RFC3339, imageRepository.Annotations[legacySecretsRetireTimeAnnotationName]); err == nil {
+		if waitTime := time.Until(retireTime); waitTime > 0 {
+			return waitTime, nil
+		}
+	}
+
+	legacySecretNames := strings.Split(imageRepository.Annotations[legacySecretsAnnotationName], ",")
+	if err := r.deleteLegacySecrets(ctx, imageRepository, legacySecretNames); err != nil {
+		return 0, err
+	}
+
+	delete(imageRepository.Annotations, legacySecretsAnnotationName)
+	delete(imageRepository.Annotations, legacySecretsRetireTimeAnnotationName)
+	if err := r.Client.Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to remove legacy secrets annotations", l.Action, l.ActionUpdate)
+		return 0, err
+	}
+	log.Info("Retired legacy secrets", "SecretNames", legacySecretNames)
+	return 0, nil
+}
+
+// deleteLegacySecrets deletes the given secrets and unlinks them from service accounts.
+func (r *ImageRepositoryReconciler) deleteLegacySecrets(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretNames []string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if err := r.unlinkSecretsFromServiceAccounts(ctx, imageRepository.Namespace, secretNames); err != nil {
+		return err
+	}
+
+	for _, secretName := range secretNames {
+		if secretName == "" {
+			continue
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: imageRepository.Namespace},
+		}
+		if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete legacy secret", "SecretName", secretName, l.Action, l.ActionDelete)
+			return err
+		}
+	}
+	return nil
+}
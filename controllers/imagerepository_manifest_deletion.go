@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	manifestDeletedEventReason          = "ManifestDeleted"
+	manifestDeletionRejectedEventReason = "ManifestDeletionRejected"
+)
+
+// HandleManifestDeletionRequest deletes the manifest requested by the DeleteManifest annotation, e.g. with a leaked secret,
+// if the deletion of the manifest is granted in MaintenanceGrants.
+// The result is reported by an event and recorded in the operation history, the annotation and the grant are removed.
+// Failed Quay calls keep the annotation, so the deletion is retried.
+func (r *ImageRepositoryReconciler) HandleManifestDeletionRequest(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ManifestDeletion")
+
+	manifestDigest, _ := annotations.DeleteManifest.Get(imageRepository)
+	log = log.WithValues("ManifestDigest", manifestDigest)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	rejectionErr := annotations.DeleteManifest.Validate(imageRepository)
+	if rejectionErr == nil {
+		var err error
+		if rejectionErr, err = r.MaintenanceGrants.validateMaintenanceGrant(ctx, imageRepository, operationDeleteManifest, manifestDigest); err != nil {
+			return err
+		}
+	}
+	if rejectionErr != nil {
+		log.Info("Rejected manifest deletion request", "Reason", rejectionErr.Error(), l.Audit, "true")
+		r.recordAuditEvent(imageRepository, corev1.EventTypeWarning, manifestDeletionRejectedEventReason,
+			"Rejected deletion of manifest %s: %s", manifestDigest, rejectionErr.Error())
+	} else {
+		deletedTagNames, err := r.QuayClient.DeleteManifestByDigest(r.QuayOrganization, imageRepository.Spec.Image.Name, manifestDigest)
+		if err != nil {
+			log.Error(err, "failed to delete manifest", "DeletedTags", deletedTagNames, l.Action, l.ActionDelete, l.Audit, "true")
+			return err
+		}
+		log.Info("Deleted manifest", "DeletedTags", deletedTagNames, l.Action, l.ActionDelete, l.Audit, "true")
+		r.recordAuditEvent(imageRepository, corev1.EventTypeNormal, manifestDeletedEventReason,
+			"Deleted manifest %s granted in %s ConfigMap, removed tags: %s", manifestDigest, MaintenanceGrantsConfigMapName, strings.Join(deletedTagNames, ", "))
+		// The grant is used up, so the same request set again is rejected
+		if err := r.MaintenanceGrants.revoke(ctx, imageRepository, operationDeleteManifest); err != nil {
+			return err
+		}
+	}
+
+	annotations.DeleteManifest.Remove(imageRepository)
+	if err := r.Client.Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to remove manifest deletion request annotation", l.Action, l.ActionUpdate)
+		return err
+	}
+	imageRepository.Status.Operations = appendOperationRecord(imageRepository.Status.Operations, newOperationRecord(ctx, operationDeleteManifest, rejectionErr))
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to record manifest deletion in image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+// isWaitingForComponent checks whether provision of the image repository failed because its Component doesn't exist.
+func isWaitingForComponent(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return !controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) &&
+		imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed &&
+		meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeComponentMissing)
+}
+
+// componentCreatedPredicate passes only Component creation events, including the initial ones on controller start,
+// so Components created while the controller was down are noticed as well.
+var componentCreatedPredicate = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return true },
+	UpdateFunc:  func(e event.UpdateEvent) bool { return false },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+	GenericFunc: func(e event.GenericEvent) bool { return false },
+}
+
+// mapCreatedComponentToImageRepositories returns requests for ImageRepositories in the namespace of the created Component
+// that wait for the Component.
+// Only ImageRepositories labeled with the Component name are listed, so creation of Components in namespaces
+// with many ImageRepositories doesn't fetch all of them from the API server.
+func (r *ImageRepositoryReconciler) mapCreatedComponentToImageRepositories(ctx context.Context, obj client.Object) []reconcile.Request {
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList, client.InNamespace(obj.GetNamespace()), client.MatchingLabels{ComponentNameLabelName: obj.GetName()}); err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, imageRepository := range imageRepositoryList.Items {
+		if isWaitingForComponent(&imageRepository) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: imageRepository.Namespace, Name: imageRepository.Name}})
+		}
+	}
+	return requests
+}
+
+// retryProvisionForCreatedComponent resets the failed provision of the image repository, if the missing Component exists now.
+// The reset image repository is provisioned in the same reconcile.
+func (r *ImageRepositoryReconciler) retryProvisionForCreatedComponent(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	if !isWaitingForComponent(imageRepository) {
+		return nil
+	}
+	log := ctrllog.FromContext(ctx)
+
+	componentName := imageRepository.Labels[ComponentNameLabelName]
+	component := &appstudioredhatcomv1alpha1.Component{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}, component); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get component", "ComponentName", componentName, l.Action, l.ActionView)
+		return err
+	}
+
+	meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeComponentMissing)
+	imageRepository.Status.Provision = imagerepositoryv1alpha1.ProvisionStatus{}
+	imageRepository.Status.State = ""
+	imageRepository.Status.Message = ""
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to reset image repository provision status", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Component of image repository has been created, retrying provision", "ComponentName", componentName)
+	return nil
+}
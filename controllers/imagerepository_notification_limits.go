@@ -0,0 +1,56 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+// getRejectedNotifications returns reasons of requested notifications that must not be created in Quay, by normalized title.
+// Notifications with the same event, method and config as a preceding one are duplicates,
+// notifications over MaxNotifications are rejected in the requested order.
+func (r *ImageRepositoryReconciler) getRejectedNotifications(notifications []imagerepositoryv1alpha1.Notifications) map[string]string {
+	rejected := make(map[string]string)
+	accepted := []imagerepositoryv1alpha1.Notifications{}
+	for _, notification := range notifications {
+		title := normalizeNotificationTitle(notification.Title)
+		if duplicatedTitle := findIdenticalNotification(accepted, notification); duplicatedTitle != "" {
+			rejected[title] = fmt.Sprintf("notification is identical to notification %s", duplicatedTitle)
+			continue
+		}
+		if r.MaxNotifications > 0 && len(accepted) >= r.MaxNotifications {
+			rejected[title] = fmt.Sprintf("maximum number of %d notifications per image repository exceeded", r.MaxNotifications)
+			continue
+		}
+		accepted = append(accepted, notification)
+	}
+	return rejected
+}
+
+// findIdenticalNotification returns normalized title of the notification with the same body, empty if there is none.
+func findIdenticalNotification(notifications []imagerepositoryv1alpha1.Notifications, notification imagerepositoryv1alpha1.Notifications) string {
+	for _, existingNotification := range notifications {
+		if existingNotification.Event == notification.Event && existingNotification.Method == notification.Method &&
+			reflect.DeepEqual(existingNotification.Config, notification.Config) {
+			return normalizeNotificationTitle(existingNotification.Title)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// notificationOwnerMarkerRegexp matches the title prefix of notifications created for shared image repositories.
+var notificationOwnerMarkerRegexp = regexp.MustCompile(`^\[[a-z0-9.-]+/[a-z0-9.-]+\] `)
+
+// getNotificationOwnerMarker returns the title prefix identifying notifications of the ImageRepository,
+// empty if the image repository is not shared.
+// Shared image repositories get notifications of several ImageRepositories, so each of them manages only the ones with its marker.
+func getNotificationOwnerMarker(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	if !imageRepository.Spec.Image.Shared {
+		return ""
+	}
+	return fmt.Sprintf("[%s/%s] ", imageRepository.Namespace, imageRepository.Name)
+}
+
+// getQuayNotificationTitle returns title of the notification in Quay, i.e. the normalized title with the owner marker.
+func getQuayNotificationTitle(imageRepository *imagerepositoryv1alpha1.ImageRepository, title string) string {
+	return getNotificationOwnerMarker(imageRepository) + normalizeNotificationTitle(title)
+}
+
+// isNotificationOfOtherOwner checks the Quay notification has been created for other ImageRepository of the shared image repository.
+// Notifications without any owner marker, e.g. created before the image repository was shared, are not considered foreign.
+func isNotificationOfOtherOwner(imageRepository *imagerepositoryv1alpha1.ImageRepository, quayNotification quay.Notification) bool {
+	ownerMarker := getNotificationOwnerMarker(imageRepository)
+	if ownerMarker == "" {
+		return false
+	}
+	marker := notificationOwnerMarkerRegexp.FindString(quayNotification.Title)
+	return marker != "" && marker != ownerMarker
+}
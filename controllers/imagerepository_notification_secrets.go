@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	neturl "net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// NotificationSecretLabelName must be set to "true" on Secrets referenced by notifications,
+	// so anyone allowed to edit ImageRepository objects can't send other Secrets of the namespace to arbitrary webhooks.
+	NotificationSecretLabelName = "appstudio.redhat.com/image-notification-secret"
+
+	// notificationSecretPlaceholder is replaced in webhook url with the value of the referenced secret key.
+	notificationSecretPlaceholder = "{secret}"
+)
+
+// InvalidNotificationSecretError is returned when the secret referenced by a notification cannot be used,
+// e.g. it doesn't exist yet. The notification is not created in Quay then.
+type InvalidNotificationSecretError struct {
+	Reason string
+}
+
+func (e *InvalidNotificationSecretError) Error() string {
+	return "invalid notification secret: " + e.Reason
+}
+
+// getNotificationUrl returns the url to be configured in Quay for the notification.
+// If the notification references a secret, the placeholder in the url is replaced with the query escaped secret value.
+// Only secrets labeled with NotificationSecretLabelName could be referenced.
+// The returned url might contain credentials, so it must not be logged or saved into the ImageRepository.
+func (r *ImageRepositoryReconciler) getNotificationUrl(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, notification imagerepositoryv1alpha1.Notifications) (string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	secretRef := notification.Config.SecretRef
+	if secretRef == nil {
+		return notification.Config.Url, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretRef.Name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", &InvalidNotificationSecretError{Reason: fmt.Sprintf("secret %s not found", secretRef.Name)}
+		}
+		log.Error(err, "failed to get notification secret", "SecretName", secretRef.Name, l.Action, l.ActionView)
+		return "", err
+	}
+	if secret.Labels[NotificationSecretLabelName] != "true" {
+		return "", &InvalidNotificationSecretError{Reason: fmt.Sprintf("secret %s is not labeled with %s=true", secretRef.Name, NotificationSecretLabelName)}
+	}
+	value, exists := secret.Data[secretRef.Key]
+	if !exists || len(value) == 0 {
+		return "", &InvalidNotificationSecretError{Reason: fmt.Sprintf("key %s not found in secret %s", secretRef.Key, secretRef.Name)}
+	}
+	return strings.ReplaceAll(notification.Config.Url, notificationSecretPlaceholder, neturl.QueryEscape(string(value))), nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+// getNudgeRevision identifies the image repository url and credentials generation,
+// dependent Components are nudged whenever it changes.
+func getNudgeRevision(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	credentialsGeneration := ""
+	if generationTimestamp := imageRepository.Status.Credentials.GenerationTimestamp; generationTimestamp != nil {
+		credentialsGeneration = generationTimestamp.UTC().Format(time.RFC3339)
+	}
+	hash := sha256.Sum256([]byte(imageRepository.Status.Image.URL + "\n" + credentialsGeneration))
+	return hex.EncodeToString(hash[:])[:16]
+}
+
+// getNudgeTargets returns names of Components that build from the image repository.
+// These are the Components from spec.nudgeTargets and, if DiscoverNudgeTargets is set,
+// the Components referenced by build-nudges-ref of the linked Component.
+func (r *ImageRepositoryReconciler) getNudgeTargets(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) ([]string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	nudgeTargets := []string{}
+	addNudgeTarget := func(componentName string) {
+		if componentName = strings.TrimSpace(componentName); componentName != "" && !slices.Contains(nudgeTargets, componentName) {
+			nudgeTargets = append(nudgeTargets, componentName)
+		}
+	}
+	for _, componentName := range imageRepository.Spec.NudgeTargets {
+		addNudgeTarget(componentName)
+	}
+
+	if r.DiscoverNudgeTargets && isComponentLinked(imageRepository) {
+		componentName := imageRepository.Labels[ComponentNameLabelName]
+		component := &appstudioredhatcomv1alpha1.Component{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}, component); err != nil {
+			if !errors.IsNotFound(err) {
+				log.Error(err, "failed to get component", "ComponentName", componentName, l.Action, l.ActionView)
+				return nil, err
+			}
+		} else {
+			for _, nudgedComponentName := range component.Spec.BuildNudgesRef {
+				addNudgeTarget(nudgedComponentName)
+			}
+		}
+	}
+	return nudgeTargets, nil
+}
+
+// SyncComponentNudges requests rebuild of dependent Components when the image repository url or credentials change,
+// so images built from this image repository don't keep references to the old ones.
+// The rebuild is requested by the build request annotation, that is handled by the build-service.
+// The first sync only records the current revision, as there is nothing to rebuild for a new image repository.
+func (r *ImageRepositoryReconciler) SyncComponentNudges(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ComponentNudges")
+
+	nudgeRevision := getNudgeRevision(imageRepository)
+	if imageRepository.Status.NudgeRevision == nudgeRevision {
+		return nil
+	}
+
+	if imageRepository.Status.NudgeRevision != "" {
+		nudgeTargets, err := r.getNudgeTargets(ctx, imageRepository)
+		if err != nil {
+			return err
+		}
+		for _, componentName := range nudgeTargets {
+			component := &appstudioredhatcomv1alpha1.Component{}
+			if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: componentName}, component); err != nil {
+				if errors.IsNotFound(err) {
+					log.Info("nudged component does not exist", "ComponentName", componentName)
+					continue
+				}
+				log.Error(err, "failed to get component", "ComponentName", componentName, l.Action, l.ActionView)
+				return err
+			}
+			annotations.BuildRequest.Set(component, annotations.BuildRequestTriggerPaCBuild)
+			if err := r.Client.Update(ctx, component); err != nil {
+				log.Error(err, "failed to request component rebuild", "ComponentName", componentName, l.Action, l.ActionUpdate)
+				return err
+			}
+			log.Info("Requested rebuild of dependent component", "ComponentName", componentName, l.Action, l.ActionUpdate)
+		}
+	}
+
+	imageRepository.Status.NudgeRevision = nudgeRevision
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository nudge revision", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	operationProvision             = "Provision"
+	operationRegenerateCredentials = "RegenerateCredentials"
+	operationCleanup               = "Cleanup"
+	operationDeleteManifest        = "DeleteManifest"
+	operationReownRobotAccounts    = "ReownRobotAccounts"
+
+	// maxOperationRecords caps the operation history kept in the status, so the object stays small.
+	maxOperationRecords = 10
+	// maxOperationMessageLength caps error messages in the operation history, the full error is in the logs.
+	maxOperationMessageLength = 256
+)
+
+// recordOperation appends the operation result to the operation history of the image repository.
+// The history is recorded on the latest version of the object, failures are only logged.
+func (r *ImageRepositoryReconciler) recordOperation(ctx context.Context, imageRepositoryKey types.NamespacedName, operation string, operationErr error) {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := r.Client.Get(ctx, imageRepositoryKey, imageRepository); err != nil {
+		log.Error(err, "failed to get image repository", l.Action, l.ActionView)
+		return
+	}
+
+	imageRepository.Status.Operations = appendOperationRecord(imageRepository.Status.Operations, newOperationRecord(ctx, operation, operationErr))
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to record operation in image repository status", "Operation", operation, l.Action, l.ActionUpdate)
+	}
+}
+
+func newOperationRecord(ctx context.Context, operation string, operationErr error) imagerepositoryv1alpha1.OperationRecord {
+	record := imagerepositoryv1alpha1.OperationRecord{
+		Operation:     operation,
+		Result:        imagerepositoryv1alpha1.OperationResultSucceeded,
+		Timestamp:     metav1.NewTime(time.Now().Truncate(time.Second)),
+		CorrelationID: getCorrelationID(ctx),
+	}
+	if operationErr != nil {
+		record.Result = imagerepositoryv1alpha1.OperationResultFailed
+		record.Message = operationErr.Error()
+		if len(record.Message) > maxOperationMessageLength {
+			record.Message = record.Message[:maxOperationMessageLength]
+		}
+	}
+	return record
+}
+
+// appendOperationRecord adds the record to the history and drops the oldest records over maxOperationRecords.
+func appendOperationRecord(records []imagerepositoryv1alpha1.OperationRecord, record imagerepositoryv1alpha1.OperationRecord) []imagerepositoryv1alpha1.OperationRecord {
+	records = append(records, record)
+	if len(records) > maxOperationRecords {
+		records = records[len(records)-maxOperationRecords:]
+	}
+	return records
+}
+
+// recordAuditEvent emits event about an action requested by users, e.g. issued credentials.
+func (r *ImageRepositoryReconciler) recordAuditEvent(imageRepository *imagerepositoryv1alpha1.ImageRepository, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.EventRecorder == nil || r.DryRun {
+		return
+	}
+	r.EventRecorder.Eventf(imageRepository, eventType, reason, messageFmt, args...)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+)
+
+// CleanupOrphanedSecrets deletes generated image repository secrets in the namespace,
+// that are left behind by already deleted ImageRepository objects, e.g. because owner references were stripped.
+// The secrets are also unlinked from all service accounts in the namespace.
+func (r *ImageRepositoryReconciler) CleanupOrphanedSecrets(ctx context.Context, namespace string) error {
+	log := ctrllog.FromContext(ctx).WithName("CleanupOrphanedSecrets")
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return err
+	}
+	existingImageRepositories := make(map[string]bool, len(imageRepositoryList.Items))
+	usedSecretNames := make(map[string]bool)
+	for _, imageRepository := range imageRepositoryList.Items {
+		existingImageRepositories[imageRepository.Name] = true
+		usedSecretNames[getSecretName(&imageRepository, false)] = true
+		usedSecretNames[getSecretName(&imageRepository, true)] = true
+		usedSecretNames[imageRepository.Status.Credentials.PushSecretName] = true
+		usedSecretNames[imageRepository.Status.Credentials.PullSecretName] = true
+	}
+
+	secretList := &corev1.SecretList{}
+	if err := r.Client.List(ctx, secretList, client.InNamespace(namespace), client.MatchingLabels{InternalSecretLabelName: "true"}); err != nil {
+		log.Error(err, "failed to list generated secrets", l.Action, l.ActionView)
+		return err
+	}
+	orphanedSecretNames := []string{}
+	for _, secret := range secretList.Items {
+		if !usedSecretNames[secret.Name] && isOrphanedImageRepositorySecret(&secret, existingImageRepositories) {
+			orphanedSecretNames = append(orphanedSecretNames, secret.Name)
+		}
+	}
+	if len(orphanedSecretNames) == 0 {
+		return nil
+	}
+
+	if err := r.unlinkSecretsFromServiceAccounts(ctx, namespace, orphanedSecretNames); err != nil {
+		return err
+	}
+	for _, secretName := range orphanedSecretNames {
+		secret := &corev1.Secret{}
+		secret.Name = secretName
+		secret.Namespace = namespace
+		if err := r.Client.Delete(ctx, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "failed to delete orphaned secret", "SecretName", secretName, l.Action, l.ActionDelete)
+			return err
+		}
+		metrics.OrphanedSecretsDeletedMetric.Inc()
+		log.Info("Deleted orphaned image repository secret", "SecretName", secretName, l.Action, l.ActionDelete)
+	}
+	return nil
+}
+
+// isOrphanedImageRepositorySecret checks that the generated secret was named after an ImageRepository
+// and isn't owned by anything else than ImageRepository objects that don't exist anymore.
+// External consumer secrets have no owner references, as they are owned by ImageRepository of other namespace,
+// so they are never cleaned up here, see ensureExternalConsumerSecret.
+func isOrphanedImageRepositorySecret(secret *corev1.Secret, existingImageRepositories map[string]bool) bool {
+	if !strings.HasSuffix(secret.Name, "-image-push") && !strings.HasSuffix(secret.Name, "-image-pull") {
+		return false
+	}
+	if _, isExternalConsumerSecret := secret.Annotations[externalSecretOwnerAnnotationName]; isExternalConsumerSecret {
+		return false
+	}
+	for _, ownerReference := range secret.OwnerReferences {
+		if ownerReference.Kind != "ImageRepository" || existingImageRepositories[ownerReference.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// unlinkSecretsFromServiceAccounts removes references to the given secrets from all service accounts in the namespace.
+// Updates are retried on conflicts, e.g. when other controllers link their secrets at the same time.
+func (r *ImageRepositoryReconciler) unlinkSecretsFromServiceAccounts(ctx context.Context, namespace string, secretNames []string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if len(secretNames) == 0 {
+		return nil
+	}
+	serviceAccountList := &corev1.ServiceAccountList{}
+	if err := r.Client.List(ctx, serviceAccountList, client.InNamespace(namespace)); err != nil {
+		log.Error(err, "failed to list service accounts", l.Action, l.ActionView)
+		return err
+	}
+	for i := range serviceAccountList.Items {
+		serviceAccount := &serviceAccountList.Items[i]
+		isRefreshNeeded := false
+		isUpdated := false
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			if isRefreshNeeded {
+				if err := r.Client.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount); err != nil {
+					if errors.IsNotFound(err) {
+						isUpdated = false
+						return nil
+					}
+					return err
+				}
+			}
+			isRefreshNeeded = true
+
+			isUpdated = removeSecretReferences(serviceAccount, secretNames)
+			if !isUpdated {
+				return nil
+			}
+			return r.Client.Update(ctx, serviceAccount)
+		})
+		if err != nil {
+			log.Error(err, "failed to unlink secrets from service account", "ServiceAccountName", serviceAccount.Name, l.Action, l.ActionUpdate)
+			return err
+		}
+		if isUpdated {
+			log.Info("Unlinked secrets from service account", "ServiceAccountName", serviceAccount.Name, "SecretNames", secretNames, l.Action, l.ActionUpdate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+)
+
+const postCreateMaxRetries = 3
+
+// postCreateRetryInitialBackoff is doubled after each retry. It's a variable to allow shorter waits in tests.
+var postCreateRetryInitialBackoff = time.Second
+
+type justCreatedRepositoryKey struct{}
+
+// withJustCreatedRepository marks the context of the provision steps following creation of the image repository in Quay.
+func withJustCreatedRepository(ctx context.Context) context.Context {
+	return context.WithValue(ctx, justCreatedRepositoryKey{}, true)
+}
+
+func isJustCreatedRepository(ctx context.Context) bool {
+	justCreated, _ := ctx.Value(justCreatedRepositoryKey{}).(bool)
+	return justCreated
+}
+
+// isNotFoundQuayError checks whether Quay responded that the requested resource doesn't exist.
+func isNotFoundQuayError(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "status code: 404") || strings.Contains(message, "not found")
+}
+
+// retryAfterCreate calls the Quay operation and retries it with backoff while it fails with not found error,
+// but only if the image repository has just been created.
+// Quay might not have propagated newly created image repository yet, so the follow-up calls occasionally fail with 404,
+// which would otherwise fail the whole provision. The retries are counted in quay_post_create_retries_total metric.
+func retryAfterCreate(ctx context.Context, operation string, quayOperation func() error) error {
+	log := ctrllog.FromContext(ctx)
+
+	err := quayOperation()
+	if !isJustCreatedRepository(ctx) {
+		return err
+	}
+	backoff := postCreateRetryInitialBackoff
+	for retry := 1; retry <= postCreateMaxRetries && err != nil && isNotFoundQuayError(err); retry++ {
+		log.Info("Quay resource not found right after image repository creation, retrying", "Operation", operation, "Retry", retry, "Backoff", backoff.String())
+		metrics.QuayPostCreateRetriesMetric.WithLabelValues(operation).Inc()
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = quayOperation()
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// pendingQuotaRetryInterval is how often the first image repository in the queue retries the creation,
+	// as there is no way to get notified when the organization private repositories quota is freed.
+	pendingQuotaRetryInterval = time.Minute
+
+	pendingQuotaReasonQuotaExhausted = "QuotaExhausted"
+	pendingQuotaReasonQueued         = "Queued"
+)
+
+// isWaitingInPrivateQuotaQueue checks whether private image repository creation has to wait
+// for image repositories that are waiting for the private repositories quota longer.
+// The queue is shared by all namespaces and ordered by creation time, so that freed quota is used fairly.
+func (r *ImageRepositoryReconciler) isWaitingInPrivateQuotaQueue(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (bool, error) {
+	log := ctrllog.FromContext(ctx)
+
+	pendingImageRepositories, err := r.getImageRepositoryIndexer().list(ctx, r.Client, pendingQuotaIndexKey, "true")
+	if err != nil {
+		log.Error(err, "failed to list image repositories waiting for quota", l.Action, l.ActionView)
+		return false, err
+	}
+	for i := range pendingImageRepositories {
+		pending := &pendingImageRepositories[i]
+		if pending.Namespace == imageRepository.Namespace && pending.Name == imageRepository.Name {
+			continue
+		}
+		if isEarlierInPrivateQuotaQueue(pending, imageRepository) {
+			return true, r.setPendingQuotaCondition(ctx, imageRepository, pendingQuotaReasonQueued,
+				"Waiting for older image repositories to get private repositories quota")
+		}
+	}
+	return false, nil
+}
+
+// setPendingQuotaCondition marks the image repository as waiting for private repositories quota.
+func (r *ImageRepositoryReconciler) setPendingQuotaCondition(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, reason, message string) error {
+	log := ctrllog.FromContext(ctx)
+
+	condition := metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypePendingQuota,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: imageRepository.Generation,
+	}
+	if !meta.SetStatusCondition(&imageRepository.Status.Conditions, condition) {
+		return nil
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to set pending quota condition", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Image repository is waiting for private repositories quota", "Reason", reason)
+	return nil
+}
+
+func isPendingQuota(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePendingQuota)
+}
+
+func isEarlierInPrivateQuotaQueue(a, b *imagerepositoryv1alpha1.ImageRepository) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
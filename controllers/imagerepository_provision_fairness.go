@@ -0,0 +1,212 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+// provisionFairnessRetryInterval is how long a postponed provision waits before it's checked again,
+// in case its release has been dropped because the controller didn't keep up with the events.
+const provisionFairnessRetryInterval = 5 * time.Minute
+
+// ProvisionFairness shares provisions of image repositories among namespaces in round-robin fashion,
+// so a namespace creating thousands of ImageRepository objects doesn't delay provisions of other namespaces.
+// The controller workqueue is FIFO and cannot be replaced, so waiting ImageRepositories are tracked from watch events,
+// before they get to the queue. A provision out of turn is parked and queued again by an event
+// once its namespace gets the turn, so postponed provisions don't cycle through the queue.
+// In each round, a namespace with waiting provisions gets as many provisions as its weight, 1 by default.
+type ProvisionFairness struct {
+	weights map[string]int
+	// events queues parked ImageRepositories once it's their turn
+	events chan event.GenericEvent
+
+	lock sync.Mutex
+	// waiting holds not yet provisioned ImageRepositories that have been queued since their last reconcile, by namespace
+	waiting map[string]map[types.NamespacedName]bool
+	// parked holds waiting ImageRepositories postponed by Admit, by namespace
+	parked map[string]map[types.NamespacedName]bool
+	// served holds number of provisions of the namespace in the current round
+	served map[string]int
+}
+
+// NewProvisionFairness parses weights of namespaces, given as namespace to positive number map.
+func NewProvisionFairness(weights map[string]string) (*ProvisionFairness, error) {
+	fairness := &ProvisionFairness{
+		weights: map[string]int{},
+		events:  make(chan event.GenericEvent, 1000),
+		waiting: map[string]map[types.NamespacedName]bool{},
+		parked:  map[string]map[types.NamespacedName]bool{},
+		served:  map[string]int{},
+	}
+	for namespace, weightValue := range weights {
+		weight, err := strconv.Atoi(weightValue)
+		if err != nil || weight < 1 {
+			return nil, fmt.Errorf("invalid provision weight %s of namespace %s, positive number expected", weightValue, namespace)
+		}
+		fairness.weights[namespace] = weight
+	}
+	return fairness, nil
+}
+
+// Admit checks whether it's the turn of the ImageRepository namespace to provision, counting the provision if it is.
+// ImageRepositories that don't wait for provision are always admitted.
+// Not admitted ImageRepository is parked until its namespace gets the turn.
+func (f *ProvisionFairness) Admit(key types.NamespacedName) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if !f.waiting[key.Namespace][key] {
+		return true
+	}
+	if f.served[key.Namespace] >= f.getWeight(key.Namespace) {
+		if !f.isRoundCompletedLocked() {
+			// Other namespace hasn't had its turn in this round yet
+			if f.parked[key.Namespace] == nil {
+				f.parked[key.Namespace] = map[types.NamespacedName]bool{}
+			}
+			f.parked[key.Namespace][key] = true
+			return false
+		}
+		// All waiting namespaces had their turn, start a new round
+		f.served = map[string]int{}
+	}
+	f.served[key.Namespace]++
+	// The ImageRepository is being reconciled, it waits again only if a new event comes
+	f.forgetLocked(key)
+	f.releaseLocked()
+	return true
+}
+
+// isRoundCompletedLocked returns true if all namespaces with waiting provisions had their turn in the current round.
+func (f *ProvisionFairness) isRoundCompletedLocked() bool {
+	for namespace := range f.waiting {
+		if f.served[namespace] < f.getWeight(namespace) {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseLocked queues parked ImageRepositories of namespaces that have the turn now,
+// as many as the namespace could provision, so the rest stays parked.
+func (f *ProvisionFairness) releaseLocked() {
+	isRoundCompleted := f.isRoundCompletedLocked()
+	for namespace, parked := range f.parked {
+		turns := f.getWeight(namespace) - f.served[namespace]
+		if isRoundCompleted {
+			turns = f.getWeight(namespace)
+		}
+		for key := range parked {
+			if turns <= 0 {
+				break
+			}
+			select {
+			case f.events <- event.GenericEvent{Object: &imagerepositoryv1alpha1.ImageRepository{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}}:
+			default:
+				// The retry interval of the postponed provision applies
+			}
+			delete(parked, key)
+			turns--
+		}
+		if len(parked) == 0 {
+			delete(f.parked, namespace)
+		}
+	}
+}
+
+// Predicate returns predicate that tracks waiting ImageRepositories from watch events, it never filters out any event.
+func (f *ProvisionFairness) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			f.track(e.Object)
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			f.track(e.ObjectNew)
+			return true
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			f.forget(client.ObjectKeyFromObject(e.Object))
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			f.track(e.Object)
+			return true
+		},
+	}
+}
+
+// track records the ImageRepository as waiting, as the event queues it, if it's not provisioned, failed or being deleted yet.
+// Forgets it otherwise.
+func (f *ProvisionFairness) track(obj client.Object) {
+	imageRepository, ok := obj.(*imagerepositoryv1alpha1.ImageRepository)
+	if !ok {
+		return
+	}
+	key := client.ObjectKeyFromObject(imageRepository)
+	if controllerutil.ContainsFinalizer(imageRepository, ImageRepositoryFinalizer) || imageRepository.DeletionTimestamp != nil ||
+		imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed {
+		f.forget(key)
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.waiting[key.Namespace] == nil {
+		f.waiting[key.Namespace] = map[types.NamespacedName]bool{}
+	}
+	f.waiting[key.Namespace][key] = true
+}
+
+// forget stops tracking the ImageRepository, its namespace might not wait anymore, so others could get the turn.
+func (f *ProvisionFairness) forget(key types.NamespacedName) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.forgetLocked(key)
+	f.releaseLocked()
+}
+
+func (f *ProvisionFairness) forgetLocked(key types.NamespacedName) {
+	delete(f.waiting[key.Namespace], key)
+	if len(f.waiting[key.Namespace]) == 0 {
+		delete(f.waiting, key.Namespace)
+	}
+	delete(f.parked[key.Namespace], key)
+	if len(f.parked[key.Namespace]) == 0 {
+		delete(f.parked, key.Namespace)
+	}
+}
+
+func (f *ProvisionFairness) getWeight(namespace string) int {
+	if weight, exists := f.weights[namespace]; exists {
+		return weight
+	}
+	return 1
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// startProvisionAttempt records when the provision attempt started, so it could be rolled back after ProvisionTimeout.
+// The attempt spans reconciles until it succeeds or fails, the start time is reset when the failed attempt is recorded.
+// Time spent waiting for private repositories quota is not counted.
+func (r *ImageRepositoryReconciler) startProvisionAttempt(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	if imageRepository.Status.Provision.StartTimestamp != nil && !isPendingQuota(imageRepository) {
+		return nil
+	}
+	imageRepository.Status.Provision.StartTimestamp = &metav1.Time{Time: time.Now()}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to record provision start", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// isProvisionTimedOut checks whether the provision attempt takes longer than ProvisionTimeout.
+func (r *ImageRepositoryReconciler) isProvisionTimedOut(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	startTimestamp := imageRepository.Status.Provision.StartTimestamp
+	if r.ProvisionTimeout <= 0 || startTimestamp == nil || isPendingQuota(imageRepository) {
+		return false
+	}
+	return isTimeoutExceeded(startTimestamp.Time, r.ProvisionTimeout)
+}
+
+// rollbackProvisionAttempt deletes robot accounts and secrets created by the timed out provision attempt,
+// so the next attempt starts from scratch. The image repository itself is kept, as it might have existed before.
+// Robot accounts are found in Quay by the naming scheme, adopted ones and ones tracked by other ImageRepository objects are kept.
+func (r *ImageRepositoryReconciler) rollbackProvisionAttempt(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ProvisionRollback")
+
+	imageRepositoryName := r.getQuayRepositoryName(imageRepository)
+	robotAccounts, err := r.QuayClient.GetAllRobotAccounts(r.QuayOrganization)
+	if err != nil {
+		log.Error(err, "failed to list robot accounts", l.Action, l.ActionView)
+		return err
+	}
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := r.Client.List(ctx, imageRepositoryList); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return err
+	}
+	trackedRobotAccountNames := []string{}
+	for _, otherImageRepository := range imageRepositoryList.Items {
+		if otherImageRepository.UID != imageRepository.UID {
+			trackedRobotAccountNames = append(trackedRobotAccountNames, getTrackedRobotAccountNames(&otherImageRepository)...)
+		}
+	}
+	// Adopted robot accounts existed before the provision
+	if adoption, _ := getRobotAccountsAdoption(imageRepository); adoption != nil {
+		for _, isPullOnly := range []bool{false, true} {
+			trackedRobotAccountNames = append(trackedRobotAccountNames, getAdoptedRobotAccountName(adoption, r.QuayOrganization, isPullOnly))
+		}
+	}
+
+	robotAccountNameRegexp := regexp.MustCompile("^" + regexp.QuoteMeta(getRobotAccountNamePrefix(imageRepositoryName)) + "_[0-9a-f]{10}(_pull)?$")
+	for _, robotAccount := range robotAccounts {
+		robotAccountName := strings.TrimPrefix(robotAccount.Name, r.QuayOrganization+"+")
+		if !robotAccountNameRegexp.MatchString(robotAccountName) || slices.Contains(trackedRobotAccountNames, robotAccountName) {
+			continue
+		}
+		if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName); err != nil {
+			log.Error(err, "failed to delete robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			return err
+		}
+		log.Info("Deleted robot account of timed out provision", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+	}
+
+	for _, isPullOnly := range []bool{false, true} {
+		secretName := getSecretName(imageRepository, isPullOnly)
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretName}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "failed to get secret", "SecretName", secretName, l.Action, l.ActionView)
+			return err
+		}
+		isOwnedByImageRepository := func(ownerReference metav1.OwnerReference) bool { return ownerReference.UID == imageRepository.UID }
+		if !slices.ContainsFunc(secret.OwnerReferences, isOwnedByImageRepository) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete secret", "SecretName", secretName, l.Action, l.ActionDelete)
+			return err
+		}
+		log.Info("Deleted secret of timed out provision", "SecretName", secretName, l.Action, l.ActionDelete)
+	}
+
+	imageRepository.Status.Provision.StartTimestamp = nil
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to reset provision start", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
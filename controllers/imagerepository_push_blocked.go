@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/features"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	pushBlockedReasonReadOnly = "ReadOnly"
+	pushBlockedReasonMirror   = "Mirror"
+
+	// pushBlockedSyncInterval is how often the image repository state is checked in Quay.
+	pushBlockedSyncInterval = 30 * time.Minute
+)
+
+// getPushBlockedReason returns the PushBlocked condition reason and message for the Quay repository state,
+// empty reason if pushes are accepted.
+func getPushBlockedReason(state string) (string, string) {
+	switch state {
+	case quay.RepositoryStateReadOnly:
+		return pushBlockedReasonReadOnly, "Image repository is read-only in Quay, e.g. because of quota enforcement, pushes are rejected"
+	case quay.RepositoryStateMirror:
+		return pushBlockedReasonMirror, "Image repository is a mirror in Quay, pushes are rejected"
+	}
+	return "", ""
+}
+
+// SyncPushBlocked detects image repository that doesn't accept pushes because of its state in Quay,
+// so builds don't fail without an explanation. The state is reported by PushBlocked condition and
+// image_repository_push_blocked metric, both are removed once the image repository accepts pushes again.
+// Quay is asked at most once per pushBlockedSyncInterval.
+// Returns interval after which the state should be checked again, zero if it's not needed.
+func (r *ImageRepositoryReconciler) SyncPushBlocked(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("PushBlocked")
+
+	imageStatus := &imageRepository.Status.Image
+	if !r.FeatureGates.Enabled(features.PushBlockedDetection) {
+		if imageStatus.StateCheckTimestamp == nil {
+			return 0, nil
+		}
+		imageStatus.StateCheckTimestamp = nil
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePushBlocked)
+		forgetPushBlockedMetric(imageRepository)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	if imageStatus.StateCheckTimestamp != nil {
+		if sinceLastCheck := elapsedSince(imageStatus.StateCheckTimestamp.Time); sinceLastCheck < pushBlockedSyncInterval {
+			return pushBlockedSyncInterval - sinceLastCheck, nil
+		}
+	}
+
+	repository, err := r.QuayClient.GetRepository(r.QuayOrganization, imageRepository.Spec.Image.Name)
+	if err != nil {
+		log.Error(err, "failed to get image repository state", l.Action, l.ActionView)
+		return 0, err
+	}
+	imageStatus.StateCheckTimestamp = &metav1.Time{Time: time.Now()}
+
+	reason, message := getPushBlockedReason(repository.State)
+	forgetPushBlockedMetric(imageRepository)
+	if reason == "" {
+		if meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePushBlocked) != nil {
+			log.Info("Image repository accepts pushes again", "State", repository.State, l.Audit, "true")
+			meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePushBlocked)
+		}
+	} else {
+		if !meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePushBlocked) {
+			log.Info("Image repository doesn't accept pushes", "State", repository.State, l.Audit, "true")
+		}
+		meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+			Type:               imagerepositoryv1alpha1.ConditionTypePushBlocked,
+			Status:             metav1.ConditionTrue,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: imageRepository.Generation,
+		})
+		metrics.ImageRepositoryPushBlockedMetric.WithLabelValues(imageRepository.Namespace, imageRepository.Name, reason).Set(1)
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return 0, err
+	}
+	return pushBlockedSyncInterval, nil
+}
+
+// forgetPushBlockedMetric removes the image repository from image_repository_push_blocked metric.
+func forgetPushBlockedMetric(imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+	metrics.ImageRepositoryPushBlockedMetric.DeletePartialMatch(prometheus.Labels{"namespace": imageRepository.Namespace, "name": imageRepository.Name})
+}
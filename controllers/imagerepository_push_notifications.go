@@ -0,0 +1,146 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"sync"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// PushNotificationsEndpointPath is the path where repo_push notifications of Quay are received.
+	PushNotificationsEndpointPath = "/quay/push-notifications"
+
+	maxPushNotificationBodySize = 64 * 1024
+)
+
+// quayPushNotification is the part of Quay repo_push notification payload identifying the pushed image repository.
+type quayPushNotification struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// PushNotifications receives repo_push notifications of Quay, so the latest tag of the pushed image repositories
+// is looked up right after the push instead of periodically.
+// Quay webhooks cannot set headers, so requests are authenticated with the token query parameter.
+type PushNotifications struct {
+	token  string
+	events chan event.GenericEvent
+	log    logr.Logger
+
+	mutex sync.Mutex
+	// pushed holds Quay image repositories, as organization/name, pushed since their latest tag was looked up.
+	// Only pushes of repositories tracked by an ImageRepository are held, so the map is bounded by their count.
+	pushed map[string]bool
+}
+
+func NewPushNotifications(token string, log logr.Logger) *PushNotifications {
+	return &PushNotifications{
+		token:  token,
+		events: make(chan event.GenericEvent, 100),
+		log:    log.WithName("PushNotifications"),
+		pushed: map[string]bool{},
+	}
+}
+
+func (p *PushNotifications) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if p.token == "" || subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(p.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPushNotificationBodySize)
+	notification := &quayPushNotification{}
+	if err := json.NewDecoder(r.Body).Decode(notification); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if goerrors.As(err, &maxBytesErr) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid push notification", http.StatusBadRequest)
+		return
+	}
+	if notification.Namespace == "" || notification.Name == "" {
+		http.Error(w, "namespace and name of the pushed repository are required", http.StatusBadRequest)
+		return
+	}
+
+	// The object only carries the pushed Quay image repository, it's mapped to ImageRepository objects by the watch,
+	// which also marks the push, once it's known the repository belongs to an ImageRepository
+	pushedRepository := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: notification.Namespace, Name: notification.Name}}
+	select {
+	case p.events <- event.GenericEvent{Object: pushedRepository}:
+	case <-r.Context().Done():
+		http.Error(w, "push notification not processed", http.StatusServiceUnavailable)
+		return
+	}
+	p.log.V(1).Info("Received push notification", "QuayOrganization", notification.Namespace, "QuayRepository", notification.Name)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (p *PushNotifications) markPushed(quayOrganization, quayRepository string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.pushed[quayOrganization+"/"+quayRepository] = true
+}
+
+// takePushed returns whether the Quay image repository has been pushed since the last call.
+func (p *PushNotifications) takePushed(quayOrganization, quayRepository string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	key := quayOrganization + "/" + quayRepository
+	pushed := p.pushed[key]
+	delete(p.pushed, key)
+	return pushed
+}
+
+// mapPushNotificationToImageRepositories returns requests for ImageRepository objects of the pushed Quay image repository.
+// The push is marked only if there is an ImageRepository tracking the latest tag of the Quay image repository.
+func (r *ImageRepositoryReconciler) mapPushNotificationToImageRepositories(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetNamespace() != r.QuayOrganization {
+		return nil
+	}
+	imageRepositories, err := r.getImageRepositoryIndexer().list(ctx, r.Client, quayRepositoryNameIndexKey, obj.GetName())
+	if err != nil {
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, imageRepository := range imageRepositories {
+		if imageRepository.Spec.Image.TrackLatest {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: imageRepository.Namespace, Name: imageRepository.Name}})
+		}
+	}
+	if len(requests) > 0 && r.PushNotifications != nil {
+		r.PushNotifications.markPushed(obj.GetNamespace(), obj.GetName())
+	}
+	return requests
+}
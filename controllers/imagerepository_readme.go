@@ -0,0 +1,168 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	invalidReadmeMessagePrefix = "invalid readme configuration: "
+
+	// readmeSyncInterval is how often readme taken from a ConfigMap is checked for changes.
+	// ConfigMaps are not watched, as it would require caching all ConfigMaps in the cluster.
+	readmeSyncInterval = 10 * time.Minute
+)
+
+// SyncReadme keeps the image repository description in Quay in sync with the requested readme or description.
+// The ownership lines are kept at the end of the description,
+// preceded by the signing public key fingerprint if it's requested to be published in Quay.
+// If the readme is removed from the spec, the default description, rendered from DescriptionTemplate if set, is restored.
+// Returns interval after which the readme should be checked again, zero if it's not needed.
+func (r *ImageRepositoryReconciler) SyncReadme(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx)
+
+	var recheckAfter time.Duration
+	readmeRef := imageRepository.Spec.Image.ReadmeRef
+	if readmeRef != nil && readmeRef.ConfigMapKeyRef != nil {
+		recheckAfter = readmeSyncInterval
+	}
+
+	readme, err := r.getReadme(ctx, imageRepository)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), invalidReadmeMessagePrefix) {
+			return 0, err
+		}
+		if imageRepository.Status.Message != err.Error() {
+			imageRepository.Status.Message = err.Error()
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+				return 0, err
+			}
+		}
+		return recheckAfter, nil
+	}
+
+	summary := r.getRepositorySummary(imageRepository)
+	digest := ""
+	if readmeRef != nil {
+		summary = readme
+	}
+	signingFingerprint := getQuaySigningFingerprint(imageRepository)
+	if signingFingerprint != "" {
+		summary = fmt.Sprintf("%s\n\n%s: %s", strings.TrimRight(summary, "\n"), signingFingerprintDescriptionKey, signingFingerprint)
+	}
+	if readmeRef != nil || imageRepository.Spec.Image.Description != "" || signingFingerprint != "" || r.DescriptionTemplate != "" {
+		digest = getReadmeDigest(summary)
+	}
+
+	if digest != imageRepository.Status.Image.ReadmeDigest {
+		imageRepositoryName := imageRepository.Spec.Image.Name
+		description := getRepositoryOwnership(r.ClusterID, imageRepository).Description(summary)
+		if err := r.QuayClient.UpdateRepositoryDescription(r.QuayOrganization, imageRepositoryName, description); err != nil {
+			log.Error(err, "failed to update image repository description", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		log.Info("Updated image repository description", "ReadmeDigest", digest, l.Action, l.ActionUpdate)
+		imageRepository.Status.Image.ReadmeDigest = digest
+		if imageRepository.Status.Signing != nil {
+			imageRepository.Status.Signing.PublishedToQuay = signingFingerprint != ""
+		}
+	} else if !strings.HasPrefix(imageRepository.Status.Message, invalidReadmeMessagePrefix) {
+		return recheckAfter, nil
+	}
+
+	if strings.HasPrefix(imageRepository.Status.Message, invalidReadmeMessagePrefix) {
+		imageRepository.Status.Message = ""
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return 0, err
+	}
+	return recheckAfter, nil
+}
+
+// isDescriptionSyncNeeded checks whether the image repository description in Quay differs from the static default one,
+// or did before, so it has to be kept in sync.
+func (r *ImageRepositoryReconciler) isDescriptionSyncNeeded(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return imageRepository.Spec.Image.ReadmeRef != nil || imageRepository.Spec.Image.Description != "" ||
+		imageRepository.Status.Image.ReadmeDigest != "" || getQuaySigningFingerprint(imageRepository) != "" ||
+		r.DescriptionTemplate != ""
+}
+
+// getRepositorySummary returns the requested image repository description,
+// the one rendered from the description template if not requested.
+func (r *ImageRepositoryReconciler) getRepositorySummary(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	if description := strings.TrimSpace(imageRepository.Spec.Image.Description); description != "" {
+		return description
+	}
+	componentName := ""
+	if isComponentLinked(imageRepository) {
+		componentName = imageRepository.Labels[ComponentNameLabelName]
+	}
+	return r.DescriptionTemplate.Render(imageRepository.Namespace, imageRepository.Name, componentName,
+		imageRepository.Labels[ApplicationNameLabelName], r.ClusterID)
+}
+
+// getReadme returns the requested readme content.
+// Errors caused by wrong configuration are prefixed with invalidReadmeMessagePrefix.
+func (r *ImageRepositoryReconciler) getReadme(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (string, error) {
+	readmeRef := imageRepository.Spec.Image.ReadmeRef
+	if readmeRef == nil {
+		return "", nil
+	}
+	if (readmeRef.Inline == "") == (readmeRef.ConfigMapKeyRef == nil) {
+		return "", fmt.Errorf("%sexactly one of inline and configMapKeyRef must be set", invalidReadmeMessagePrefix)
+	}
+	if readmeRef.ConfigMapKeyRef == nil {
+		return readmeRef.Inline, nil
+	}
+
+	log := ctrllog.FromContext(ctx)
+	configMapName := readmeRef.ConfigMapKeyRef.Name
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: configMapName}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("%sConfigMap %s not found", invalidReadmeMessagePrefix, configMapName)
+		}
+		log.Error(err, "failed to get readme ConfigMap", "ConfigMapName", configMapName, l.Action, l.ActionView)
+		return "", err
+	}
+	readme, exists := configMap.Data[readmeRef.ConfigMapKeyRef.Key]
+	if !exists {
+		return "", fmt.Errorf("%skey %s not found in ConfigMap %s", invalidReadmeMessagePrefix, readmeRef.ConfigMapKeyRef.Key, configMapName)
+	}
+	return readme, nil
+}
+
+func getReadmeDigest(readme string) string {
+	digest := sha256.Sum256([]byte(readme))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// robotAccessRoleRanks orders Quay repository roles, empty role means no access.
+var robotAccessRoleRanks = map[imagerepositoryv1alpha1.RobotAccessRole]int{
+	"": 0,
+	imagerepositoryv1alpha1.RobotAccessRoleRead:  1,
+	imagerepositoryv1alpha1.RobotAccessRoleWrite: 2,
+	imagerepositoryv1alpha1.RobotAccessRoleAdmin: 3,
+}
+
+// SyncRobotAccess makes sure the robot accounts listed in spec.access.robots have at least the required role in the image repository.
+// The current role is read from Quay, so permissions removed directly in Quay are granted again, while higher roles granted by others are kept.
+// Roles granted by the operator are tracked in status together with the previous roles, so once the robot account is removed from spec
+// the previous role is restored, or the access is revoked if it had none.
+func (r *ImageRepositoryReconciler) SyncRobotAccess(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("SyncRobotAccess")
+
+	requestedNames := []string{}
+	requestedRoles := map[string]imagerepositoryv1alpha1.RobotAccessRole{}
+	if imageRepository.Spec.Access != nil {
+		for _, robot := range imageRepository.Spec.Access.Robots {
+			name := strings.TrimSpace(robot.Name)
+			if name == "" {
+				continue
+			}
+			if !strings.Contains(name, "+") {
+				name = r.QuayOrganization + "+" + name
+			}
+			role := robot.Role
+			if role == "" {
+				role = imagerepositoryv1alpha1.RobotAccessRoleRead
+			}
+			if requestedRole, exists := requestedRoles[name]; !exists {
+				requestedNames = append(requestedNames, name)
+			} else if robotAccessRoleRanks[requestedRole] > robotAccessRoleRanks[role] {
+				continue
+			}
+			requestedRoles[name] = role
+		}
+	}
+
+	imageRepositoryName := imageRepository.Spec.Image.Name
+	grantedRobots := []imagerepositoryv1alpha1.RobotAccessStatus{}
+	if imageRepository.Status.Access != nil {
+		for i, grantedRobot := range imageRepository.Status.Access.Robots {
+			if _, isRequested := requestedRoles[grantedRobot.Name]; isRequested {
+				grantedRobots = append(grantedRobots, grantedRobot)
+				continue
+			}
+			if grantedRobot.PreviousRole != "" {
+				if err := r.QuayClient.SetRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, grantedRobot.Name, string(grantedRobot.PreviousRole)); err != nil {
+					log.Error(err, "failed to restore previous role of robot account", "RobotAccountName", grantedRobot.Name, "Role", grantedRobot.PreviousRole, l.Action, l.ActionUpdate, l.Audit, "true")
+					// Save progress, so the robot accounts not processed yet are revoked on the next reconcile
+					grantedRobots = append(grantedRobots, imageRepository.Status.Access.Robots[i:]...)
+					_ = r.saveRobotAccess(ctx, imageRepository, grantedRobots)
+					return err
+				}
+				log.Info("Restored previous role of robot account", "RobotAccountName", grantedRobot.Name, "Role", grantedRobot.PreviousRole, l.Action, l.ActionUpdate, l.Audit, "true")
+				continue
+			}
+			if _, err := r.QuayClient.DeleteRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, grantedRobot.Name); err != nil {
+				log.Error(err, "failed to revoke access of robot account", "RobotAccountName", grantedRobot.Name, l.Action, l.ActionDelete, l.Audit, "true")
+				// Save progress, so the robot accounts not processed yet are revoked on the next reconcile
+				grantedRobots = append(grantedRobots, imageRepository.Status.Access.Robots[i:]...)
+				_ = r.saveRobotAccess(ctx, imageRepository, grantedRobots)
+				return err
+			}
+			log.Info("Revoked access of robot account", "RobotAccountName", grantedRobot.Name, l.Action, l.ActionDelete, l.Audit, "true")
+		}
+	}
+
+	for _, name := range requestedNames {
+		role := requestedRoles[name]
+		currentRole, err := r.QuayClient.GetRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, name)
+		if err != nil {
+			log.Error(err, "failed to get robot account permission", "RobotAccountName", name, l.Action, l.ActionView)
+			_ = r.saveRobotAccess(ctx, imageRepository, grantedRobots)
+			return err
+		}
+
+		grantedIndex := -1
+		for i, grantedRobot := range grantedRobots {
+			if grantedRobot.Name == name {
+				grantedIndex = i
+				break
+			}
+		}
+		isLoweredByOperator := grantedIndex >= 0 && string(grantedRobots[grantedIndex].Role) == currentRole &&
+			robotAccessRoleRanks[grantedRobots[grantedIndex].Role] > robotAccessRoleRanks[role]
+		if robotAccessRoleRanks[imagerepositoryv1alpha1.RobotAccessRole(currentRole)] >= robotAccessRoleRanks[role] && !isLoweredByOperator {
+			// Keep higher role granted by someone else
+			continue
+		}
+
+		if err := r.QuayClient.SetRepositoryUserPermission(r.QuayOrganization, imageRepositoryName, name, string(role)); err != nil {
+			log.Error(err, "failed to grant access to robot account", "RobotAccountName", name, "Role", role, l.Action, l.ActionUpdate, l.Audit, "true")
+			_ = r.saveRobotAccess(ctx, imageRepository, grantedRobots)
+			return err
+		}
+		log.Info("Granted access to robot account", "RobotAccountName", name, "Role", role, "PreviousRole", currentRole, l.Action, l.ActionUpdate, l.Audit, "true")
+		if grantedIndex >= 0 {
+			grantedRobots[grantedIndex].Role = role
+		} else {
+			grantedRobots = append(grantedRobots, imagerepositoryv1alpha1.RobotAccessStatus{Name: name, Role: role, PreviousRole: imagerepositoryv1alpha1.RobotAccessRole(currentRole)})
+		}
+	}
+
+	return r.saveRobotAccess(ctx, imageRepository, grantedRobots)
+}
+
+// saveRobotAccess records robot accounts granted access to the image repository, if changed.
+func (r *ImageRepositoryReconciler) saveRobotAccess(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, grantedRobots []imagerepositoryv1alpha1.RobotAccessStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	var accessStatus *imagerepositoryv1alpha1.AccessStatus
+	if len(grantedRobots) > 0 {
+		accessStatus = &imagerepositoryv1alpha1.AccessStatus{Robots: grantedRobots}
+	}
+	if reflect.DeepEqual(accessStatus, imageRepository.Status.Access) {
+		return nil
+	}
+
+	imageRepository.Status.Access = accessStatus
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update robot access status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// createRepositoryRobotAccount creates new pull only robot account with access to the image repository.
+// The summary describes the purpose of the robot account in its description.
+// If the permission cannot be added, the robot account is deleted, so it's not left behind untracked.
+func (r *ImageRepositoryReconciler) createRepositoryRobotAccount(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, summary string) (*quay.RobotAccount, string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepositoryName := imageRepository.Spec.Image.Name
+	robotAccountName := generateQuayRobotAccountName(imageRepositoryName, true)
+	robotAccountRequest := getRobotAccountRequest(r.ClusterID, imageRepository, summary)
+	robotAccount, err := r.QuayClient.CreateRobotAccountWithDescription(r.QuayOrganization, robotAccountName, robotAccountRequest)
+	if err != nil {
+		log.Error(err, "failed to create robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionAdd, l.Audit, "true")
+		return nil, "", err
+	}
+	if robotAccount == nil {
+		err := fmt.Errorf("unexpected response from Quay: robot account data object is nil")
+		log.Error(err, "nil robot account")
+		return nil, "", err
+	}
+	if err := r.QuayClient.AddPermissionsForRepositoryToRobotAccount(r.QuayOrganization, imageRepositoryName, robotAccount.Name, false); err != nil {
+		log.Error(err, "failed to add permissions to robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionUpdate, l.Audit, "true")
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, "", err
+	}
+	return robotAccount, robotAccountName, nil
+}
+
+// deleteRobotAccount deletes robot account created by a failed operation, the failure is only logged.
+func (r *ImageRepositoryReconciler) deleteRobotAccount(ctx context.Context, robotAccountName string) {
+	log := ctrllog.FromContext(ctx)
+	if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName); err != nil {
+		log.Error(err, "failed to delete robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+	}
+}
+
+// getUpdatedTrackedRobotAccountNames returns robot accounts tracked by the image repository
+// without the removed ones and with the added ones.
+func getUpdatedTrackedRobotAccountNames(imageRepository *imagerepositoryv1alpha1.ImageRepository, removedRobotAccountNames map[string]bool, addedRobotAccountNames []string) []string {
+	robotAccountNames := []string{}
+	for _, robotAccountName := range getTrackedRobotAccountNames(imageRepository) {
+		if !removedRobotAccountNames[robotAccountName] {
+			robotAccountNames = append(robotAccountNames, robotAccountName)
+		}
+	}
+	for _, robotAccountName := range addedRobotAccountNames {
+		if robotAccountName != "" && !slices.Contains(robotAccountNames, robotAccountName) {
+			robotAccountNames = append(robotAccountNames, robotAccountName)
+		}
+	}
+	return robotAccountNames
+}
+
+// saveTrackedRobotAccounts saves the image repository and then its new status.
+// Robot accounts of the new status are recorded also in the annotation, that is saved first,
+// so they could be cleaned up even if the status is lost.
+// Saving the object overwrites its status by the stored one, that's why the new status is passed separately.
+func (r *ImageRepositoryReconciler) saveTrackedRobotAccounts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, status *imagerepositoryv1alpha1.ImageRepositoryStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	if imageRepository.Annotations == nil {
+		imageRepository.Annotations = make(map[string]string)
+	}
+	imageRepository.Annotations[robotAccountsAnnotationName] = strings.Join(status.Credentials.RobotAccountNames, ",")
+	if err := r.Client.Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository robot accounts annotation", l.Action, l.ActionUpdate)
+		return err
+	}
+
+	imageRepository.Status = *status
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
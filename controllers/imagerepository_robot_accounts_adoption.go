@@ -0,0 +1,124 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// getRobotAccountsAdoption returns robot accounts the image repository should take over on provision.
+// Returns nil if the image repository doesn't adopt robot accounts.
+func getRobotAccountsAdoption(imageRepository *imagerepositoryv1alpha1.ImageRepository) (*annotations.RobotAccountsAdoption, error) {
+	value, exists := annotations.AdoptRobotAccounts.Get(imageRepository)
+	if !exists {
+		return nil, nil
+	}
+	return annotations.ParseRobotAccountsAdoption(value)
+}
+
+// validateRobotAccountsAdoption checks that the image repository adopts only its own robot accounts,
+// i.e. named by its naming scheme, by the former naming scheme of its Component or already tracked in its status.
+// Otherwise anyone who can create an ImageRepository could take over credentials of other image repositories.
+func validateRobotAccountsAdoption(imageRepository *imagerepositoryv1alpha1.ImageRepository, adoption *annotations.RobotAccountsAdoption, quayOrganization string) error {
+	if adoption == nil {
+		return nil
+	}
+	trackedRobotAccountNames := getTrackedRobotAccountNames(imageRepository)
+	for _, isPullOnly := range []bool{false, true} {
+		robotAccountName := getAdoptedRobotAccountName(adoption, quayOrganization, isPullOnly)
+		if robotAccountName == "" || slices.Contains(trackedRobotAccountNames, robotAccountName) || isImageRepositoryRobotAccountName(imageRepository, robotAccountName) ||
+			strings.HasPrefix(robotAccountName, getRobotAccountNamePrefix(imageRepository.Spec.Image.Name)+"_") {
+			continue
+		}
+		return fmt.Errorf("robot account %s in %s annotation doesn't belong to image repository %s, its name must start with %s_",
+			robotAccountName, annotations.AdoptRobotAccounts, imageRepository.Spec.Image.Name, getRobotAccountNamePrefix(imageRepository.Spec.Image.Name))
+	}
+	return nil
+}
+
+// getDeletableRobotAccountNames returns tracked robot accounts of the image repository that are deleted together with it.
+// Adopted robot accounts not created by the controller are kept.
+func getDeletableRobotAccountNames(imageRepository *imagerepositoryv1alpha1.ImageRepository) []string {
+	return slices.DeleteFunc(getTrackedRobotAccountNames(imageRepository), func(robotAccountName string) bool {
+		return slices.Contains(imageRepository.Status.Credentials.AdoptedRobotAccountNames, robotAccountName)
+	})
+}
+
+// revokeAdoptedRobotAccountAccess removes permission of the adopted robot account to the image repository,
+// the robot account itself is kept as it wasn't created by the controller.
+func (r *ImageRepositoryReconciler) revokeAdoptedRobotAccountAccess(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, robotAccountName string) error {
+	log := ctrllog.FromContext(ctx).WithValues("RobotAccountName", robotAccountName)
+
+	if _, err := r.QuayClient.DeleteRepositoryUserPermission(r.QuayOrganization, imageRepository.Spec.Image.Name, r.QuayOrganization+"+"+robotAccountName); err != nil {
+		log.Error(err, "failed to revoke access of adopted robot account", l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	log.Info("Revoked access of adopted robot account, the robot account is kept", l.Action, l.ActionDelete, l.Audit, "true")
+	return nil
+}
+
+// getAdoptedRobotAccountName returns short name of the adopted robot account of the given purpose,
+// the organization prefix is dropped if set. Returns empty string if the robot account is not adopted.
+func getAdoptedRobotAccountName(adoption *annotations.RobotAccountsAdoption, quayOrganization string, isPullOnly bool) string {
+	if adoption == nil {
+		return ""
+	}
+	robotAccountName := adoption.Push
+	if isPullOnly {
+		robotAccountName = adoption.Pull
+	}
+	return strings.TrimPrefix(robotAccountName, quayOrganization+"+")
+}
+
+// getAdoptedRobotAccount returns existing robot account with its token.
+// The token is regenerated if requested, otherwise the current one is read, so credentials already in use stay valid.
+func (r *ImageRepositoryReconciler) getAdoptedRobotAccount(ctx context.Context, robotAccountName string, regenerateToken bool) (*quay.RobotAccount, error) {
+	log := ctrllog.FromContext(ctx).WithValues("RobotAccountName", robotAccountName)
+
+	if regenerateToken {
+		robotAccount, err := r.QuayClient.RegenerateRobotAccountToken(r.QuayOrganization, robotAccountName)
+		if err != nil {
+			log.Error(err, "failed to regenerate token of adopted robot account", l.Action, l.ActionUpdate, l.Audit, "true")
+			return nil, err
+		}
+		log.Info("Regenerated token of adopted robot account", l.Action, l.ActionUpdate, l.Audit, "true")
+		return robotAccount, nil
+	}
+
+	robotAccount, err := r.QuayClient.GetRobotAccount(r.QuayOrganization, robotAccountName)
+	if err != nil {
+		log.Error(err, "failed to get adopted robot account", l.Action, l.ActionView)
+		return nil, err
+	}
+	if robotAccount != nil && robotAccount.Token == "" {
+		err := fmt.Errorf("token of robot account %s is not readable, set regenerateTokens in %s annotation", robotAccountName, annotations.AdoptRobotAccounts)
+		log.Error(err, "failed to read token of adopted robot account")
+		return nil, err
+	}
+	log.Info("Adopted existing robot account", l.Action, l.ActionView, l.Audit, "true")
+	return robotAccount, nil
+}
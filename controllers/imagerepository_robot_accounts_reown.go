@@ -0,0 +1,273 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	retiredRobotAccountsRetirementDelay = 24 * time.Hour
+
+	robotAccountsReownedEventReason       = "RobotAccountsReowned"
+	robotAccountsReownRejectedEventReason = "RobotAccountsReownRejected"
+	robotAccountsRetiredEventReason       = "RobotAccountsRetired"
+)
+
+// isCurrentRobotAccountName checks that the robot account is named by the current naming scheme, see generateQuayRobotAccountName.
+func isCurrentRobotAccountName(imageRepositoryName, robotAccountName string, isPullOnly bool) bool {
+	suffix := ""
+	if isPullOnly {
+		suffix = "_pull"
+	}
+	robotAccountNameRegexp := regexp.MustCompile("^" + regexp.QuoteMeta(getRobotAccountNamePrefix(imageRepositoryName)) + "_[0-9a-f]{10}" + suffix + "$")
+	return robotAccountNameRegexp.MatchString(robotAccountName)
+}
+
+// isReownableRobotAccountName checks that the robot account is named after the image repository,
+// either by its robot account name prefix or by the legacy naming scheme of Component image repositories.
+// Robot accounts named otherwise are never retired by the reown request.
+func isReownableRobotAccountName(imageRepository *imagerepositoryv1alpha1.ImageRepository, robotAccountName string) bool {
+	prefix := getRobotAccountNamePrefix(imageRepository.Spec.Image.Name)
+	return robotAccountName == prefix || strings.HasPrefix(robotAccountName, prefix+"_") ||
+		isImageRepositoryRobotAccountName(imageRepository, robotAccountName)
+}
+
+// HandleRobotAccountsReownRequest handles ReownRobotAccounts annotation, if the request is granted in MaintenanceGrants.
+// Push and pull robot accounts named by a former naming scheme are replaced by new ones and their secrets are updated.
+// Robot accounts named after the image repository, that aren't tracked by any ImageRepository,
+// but have permissions only to the image repository, are taken over.
+// Both, the replaced and the taken over robot accounts, are recorded in status and deleted after retiredRobotAccountsRetirementDelay.
+// The result is reported by an event and recorded in the operation history, the annotation and the grant are removed.
+func (r *ImageRepositoryReconciler) HandleRobotAccountsReownRequest(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ReownRobotAccounts")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	rejectionErr, err := r.MaintenanceGrants.validateMaintenanceGrant(ctx, imageRepository, operationReownRobotAccounts, "true")
+	if err != nil {
+		return err
+	}
+	if rejectionErr != nil {
+		log.Info("Rejected robot accounts reown request", "Reason", rejectionErr.Error(), l.Audit, "true")
+		r.recordAuditEvent(imageRepository, corev1.EventTypeWarning, robotAccountsReownRejectedEventReason,
+			"Rejected robot accounts reown: %s", rejectionErr.Error())
+		return r.saveReownedRobotAccounts(ctx, imageRepository, nil, nil, rejectionErr)
+	}
+
+	adoption, _ := getRobotAccountsAdoption(imageRepository)
+	credentials := &imageRepository.Status.Credentials
+	replacedRobotAccounts := map[string]string{}
+	for _, isPullOnly := range []bool{false, true} {
+		robotAccountName, secretName := credentials.PushRobotAccountName, credentials.PushSecretName
+		if isPullOnly {
+			robotAccountName, secretName = credentials.PullRobotAccountName, credentials.PullSecretName
+		}
+		if robotAccountName == "" || isCurrentRobotAccountName(imageRepository.Spec.Image.Name, robotAccountName, isPullOnly) {
+			continue
+		}
+		if getAdoptedRobotAccountName(adoption, r.QuayOrganization, isPullOnly) != "" || slices.Contains(credentials.AdoptedRobotAccountNames, robotAccountName) {
+			// Adopted robot accounts keep their names, so credentials in use elsewhere stay valid
+			continue
+		}
+		if secretName != getSecretName(imageRepository, isPullOnly) {
+			// Robot accounts of existing or legacy secrets are not managed by the image repository
+			log.Info("robot account secret is not generated by the image repository, keeping the robot account", "RobotAccountName", robotAccountName, "SecretName", secretName)
+			continue
+		}
+		if !isReownableRobotAccountName(imageRepository, robotAccountName) {
+			log.Info("robot account is not named after the image repository, keeping the robot account", "RobotAccountName", robotAccountName)
+			continue
+		}
+		accessData, err := r.ProvisionImageRepositoryAccess(ctx, imageRepository, isPullOnly)
+		if err != nil {
+			return err
+		}
+		replacedRobotAccounts[robotAccountName] = accessData.RobotAccountName
+		if isPullOnly {
+			credentials.PullRobotAccountName = accessData.RobotAccountName
+		} else {
+			credentials.PushRobotAccountName = accessData.RobotAccountName
+		}
+		log.Info("Replaced robot account named by former naming scheme", "OldRobotAccountName", robotAccountName,
+			"RobotAccountName", accessData.RobotAccountName, l.Action, l.ActionAdd, l.Audit, "true")
+	}
+
+	orphanedRobotAccountNames, err := r.findOrphanedRobotAccountNames(ctx, imageRepository)
+	if err != nil {
+		return err
+	}
+	if len(orphanedRobotAccountNames) > 0 {
+		log.Info("Took over orphaned robot accounts with access to the image repository", "RobotAccountNames", orphanedRobotAccountNames, l.Audit, "true")
+	}
+
+	retiredRobotAccountNames := orphanedRobotAccountNames
+	if credentials.RetiredRobotAccounts != nil {
+		retiredRobotAccountNames = append(retiredRobotAccountNames, credentials.RetiredRobotAccounts.Names...)
+	}
+	for oldRobotAccountName := range replacedRobotAccounts {
+		retiredRobotAccountNames = append(retiredRobotAccountNames, oldRobotAccountName)
+	}
+	slices.Sort(retiredRobotAccountNames)
+	retiredRobotAccountNames = slices.Compact(retiredRobotAccountNames)
+
+	newRobotAccountNames := []string{}
+	for _, newRobotAccountName := range replacedRobotAccounts {
+		newRobotAccountNames = append(newRobotAccountNames, newRobotAccountName)
+	}
+	r.recordAuditEvent(imageRepository, corev1.EventTypeNormal, robotAccountsReownedEventReason,
+		"Replaced %d robot account(s) named by former naming scheme and took over %d orphaned robot account(s), retiring: %s",
+		len(replacedRobotAccounts), len(orphanedRobotAccountNames), strings.Join(retiredRobotAccountNames, ", "))
+	if err := r.saveReownedRobotAccounts(ctx, imageRepository, append(newRobotAccountNames, retiredRobotAccountNames...), retiredRobotAccountNames, nil); err != nil {
+		return err
+	}
+	// The grant is used up, so the same request set again is rejected
+	return r.MaintenanceGrants.revoke(ctx, imageRepository, operationReownRobotAccounts)
+}
+
+// findOrphanedRobotAccountNames returns robot accounts named after the image repository with permissions only to the image repository,
+// that are not tracked by the ImageRepository nor by other ImageRepositories sharing the image repository.
+func (r *ImageRepositoryReconciler) findOrphanedRobotAccountNames(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) ([]string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	knownRobotAccountNames := getTrackedRobotAccountNames(imageRepository)
+	if imageRepository.Spec.Credentials != nil {
+		for _, account := range imageRepository.Spec.Credentials.AdditionalAccounts {
+			knownRobotAccountNames = append(knownRobotAccountNames, strings.TrimPrefix(account.Name, r.QuayOrganization+"+"))
+		}
+	}
+	if imageRepository.Spec.Access != nil {
+		for _, robot := range imageRepository.Spec.Access.Robots {
+			knownRobotAccountNames = append(knownRobotAccountNames, strings.TrimPrefix(robot.Name, r.QuayOrganization+"+"))
+		}
+	}
+	siblings, err := r.listImageRepositoriesWithSameName(ctx, imageRepository)
+	if err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return nil, err
+	}
+	for _, sibling := range siblings {
+		knownRobotAccountNames = append(knownRobotAccountNames, getTrackedRobotAccountNames(&sibling)...)
+	}
+
+	robotAccounts, err := r.QuayClient.GetAllRobotAccounts(r.QuayOrganization)
+	if err != nil {
+		log.Error(err, "failed to list robot accounts", l.Action, l.ActionView)
+		return nil, err
+	}
+	orphanedRobotAccountNames := []string{}
+	for _, robotAccount := range robotAccounts {
+		robotAccountName := strings.TrimPrefix(robotAccount.Name, r.QuayOrganization+"+")
+		if slices.Contains(knownRobotAccountNames, robotAccountName) || !isReownableRobotAccountName(imageRepository, robotAccountName) {
+			continue
+		}
+		// The prefix is shared with image repositories named with the image repository name as prefix, e.g. my-image-build
+		permissions, err := r.QuayClient.GetRobotAccountPermissions(r.QuayOrganization, robotAccountName)
+		if err != nil {
+			log.Error(err, "failed to get robot account permissions", "RobotAccountName", robotAccountName, l.Action, l.ActionView)
+			return nil, err
+		}
+		if len(permissions) == 0 {
+			continue
+		}
+		isOtherRepository := func(permission quay.RobotAccountPermission) bool {
+			return permission.Repository.Name != imageRepository.Spec.Image.Name
+		}
+		if !slices.ContainsFunc(permissions, isOtherRepository) {
+			orphanedRobotAccountNames = append(orphanedRobotAccountNames, robotAccountName)
+		}
+	}
+	return orphanedRobotAccountNames, nil
+}
+
+// saveReownedRobotAccounts tracks the added robot accounts, schedules retirement of the retired ones and records the operation.
+// The request annotation is removed.
+func (r *ImageRepositoryReconciler) saveReownedRobotAccounts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, addedRobotAccountNames, retiredRobotAccountNames []string, operationErr error) error {
+	status := imageRepository.Status.DeepCopy()
+	status.Credentials.RobotAccountNames = getUpdatedTrackedRobotAccountNames(imageRepository, nil, addedRobotAccountNames)
+	if len(retiredRobotAccountNames) > 0 {
+		status.Credentials.RetiredRobotAccounts = &imagerepositoryv1alpha1.RetiredRobotAccountsStatus{
+			Names:      retiredRobotAccountNames,
+			RetireTime: metav1.NewTime(time.Now().Add(retiredRobotAccountsRetirementDelay).Truncate(time.Second)),
+		}
+	}
+	status.Operations = appendOperationRecord(status.Operations, newOperationRecord(ctx, operationReownRobotAccounts, operationErr))
+	annotations.ReownRobotAccounts.Remove(imageRepository)
+	return r.saveTrackedRobotAccounts(ctx, imageRepository, status)
+}
+
+// RetireRobotAccounts deletes robot accounts retired by the reown request, once their retire time passed.
+// Returns time to wait for the retirement, if it's not due yet.
+// Retired robot accounts not named after the image repository are only untracked, they are never deleted.
+func (r *ImageRepositoryReconciler) RetireRobotAccounts(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("RetireRobotAccounts")
+
+	retired := imageRepository.Status.Credentials.RetiredRobotAccounts
+	if retired.RetireTime.IsZero() {
+		// The retirement is never done without the delay, so credentials in use elsewhere could be replaced meanwhile
+		log.Info("retired robot accounts have no retire time, scheduling the retirement", "RobotAccountNames", retired.Names)
+		retired.RetireTime = metav1.NewTime(time.Now().Add(retiredRobotAccountsRetirementDelay).Truncate(time.Second))
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		return retiredRobotAccountsRetirementDelay, nil
+	}
+	if waitTime := time.Until(retired.RetireTime.Time); waitTime > 0 {
+		return waitTime, nil
+	}
+
+	deletedRobotAccountNames := []string{}
+	for _, robotAccountName := range retired.Names {
+		if !isReownableRobotAccountName(imageRepository, robotAccountName) {
+			log.Info("refusing to delete retired robot account not named after the image repository", "RobotAccountName", robotAccountName, l.Audit, "true")
+			continue
+		}
+		if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, robotAccountName); err != nil {
+			log.Error(err, "failed to delete retired robot account", "RobotAccountName", robotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			return 0, err
+		}
+		deletedRobotAccountNames = append(deletedRobotAccountNames, robotAccountName)
+	}
+
+	removedRobotAccountNames := map[string]bool{}
+	for _, robotAccountName := range retired.Names {
+		removedRobotAccountNames[robotAccountName] = true
+	}
+	status := imageRepository.Status.DeepCopy()
+	status.Credentials.RobotAccountNames = getUpdatedTrackedRobotAccountNames(imageRepository, removedRobotAccountNames, nil)
+	status.Credentials.RetiredRobotAccounts = nil
+	if err := r.saveTrackedRobotAccounts(ctx, imageRepository, status); err != nil {
+		return 0, err
+	}
+	log.Info("Retired robot accounts", "RobotAccountNames", deletedRobotAccountNames, l.Action, l.ActionDelete, l.Audit, "true")
+	r.recordAuditEvent(imageRepository, corev1.EventTypeNormal, robotAccountsRetiredEventReason,
+		"Deleted retired robot accounts: %s", strings.Join(deletedRobotAccountNames, ", "))
+	return 0, nil
+}
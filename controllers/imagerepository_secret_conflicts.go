@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const ownershipConflictReasonSecretNotManaged = "SecretNotManaged"
+
+// secretOwnershipConflictError is returned if a secret with the name of a generated secret cannot be updated
+// and is not managed by the controller. Retrying doesn't help until the secret is removed.
+type secretOwnershipConflictError struct {
+	secretName string
+	conflict   string
+}
+
+func (e *secretOwnershipConflictError) Error() string {
+	return fmt.Sprintf("secret %s is not managed by image controller and cannot be updated: %s", e.secretName, e.conflict)
+}
+
+func isSecretOwnershipConflict(err error) bool {
+	conflictErr := &secretOwnershipConflictError{}
+	return errors.As(err, &conflictErr)
+}
+
+// getSecretUpdateConflict returns why the existing secret cannot be updated to the generated dockerconfigjson secret,
+// empty string if it can.
+func getSecretUpdateConflict(secret *corev1.Secret) string {
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		return fmt.Sprintf("type is %s instead of %s", secret.Type, corev1.SecretTypeDockerConfigJson)
+	}
+	if secret.Immutable != nil && *secret.Immutable {
+		return "secret is immutable"
+	}
+	return ""
+}
+
+// resolveSecretUpdateConflict recreates the secret, if it was created by the controller.
+// Secrets created by someone else are never deleted, OwnershipConflict condition is set instead.
+// If the secret cannot be recreated yet, e.g. the old one is still being deleted, the error is retried with backoff.
+func (r *ImageRepositoryReconciler) resolveSecretUpdateConflict(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secret *corev1.Secret, conflict string, secretData map[string]string, isPull bool) error {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secret.Name, "Conflict", conflict)
+
+	if secret.Labels[InternalSecretLabelName] != "true" {
+		return r.reportSecretOwnershipConflict(ctx, imageRepository, secret.Name, conflict)
+	}
+
+	log.Info("Recreating image repository secret that cannot be updated", l.Audit, "true")
+	if err := client.IgnoreNotFound(r.Client.Delete(ctx, secret, client.Preconditions{UID: &secret.UID})); err != nil {
+		log.Error(err, "failed to delete image repository secret", l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	return r.createImageRepositorySecret(ctx, imageRepository, secret.Name, secretData, isPull)
+}
+
+// reportSecretOwnershipConflict sets OwnershipConflict condition of the secret and returns the conflict error.
+func (r *ImageRepositoryReconciler) reportSecretOwnershipConflict(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string, conflict string) error {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secretName, "Conflict", conflict)
+
+	conflictErr := &secretOwnershipConflictError{secretName: secretName, conflict: conflict}
+	log.Error(conflictErr, "existing secret cannot be used for the image repository", l.Audit, "true")
+	meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeOwnershipConflict,
+		Status:             metav1.ConditionTrue,
+		Reason:             ownershipConflictReasonSecretNotManaged,
+		Message:            getSecretOwnershipConflictMessagePrefix(secretName) + "is not managed by image controller and cannot be updated, " + conflict,
+		ObservedGeneration: imageRepository.Generation,
+	})
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return conflictErr
+}
+
+// clearSecretOwnershipConflict removes OwnershipConflict condition of the secret, once the secret is managed by the controller.
+func (r *ImageRepositoryReconciler) clearSecretOwnershipConflict(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string) error {
+	log := ctrllog.FromContext(ctx)
+
+	condition := meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeOwnershipConflict)
+	if condition == nil || !strings.HasPrefix(condition.Message, getSecretOwnershipConflictMessagePrefix(secretName)) {
+		return nil
+	}
+	meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeOwnershipConflict)
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+func getSecretOwnershipConflictMessagePrefix(secretName string) string {
+	return fmt.Sprintf("Secret %s ", secretName)
+}
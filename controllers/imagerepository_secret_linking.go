@@ -0,0 +1,132 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// ValidateSecretLinking checks the cluster default secret linking policy, empty fields keep the built-in defaults.
+func ValidateSecretLinking(linking imagerepositoryv1alpha1.SecretLinking) error {
+	switch linking.PushSecret {
+	case "", imagerepositoryv1alpha1.PushSecretLinkingAll, imagerepositoryv1alpha1.PushSecretLinkingSecrets, imagerepositoryv1alpha1.PushSecretLinkingNone:
+	default:
+		return fmt.Errorf("invalid push secret linking '%s', must be one of: %s, %s, %s", linking.PushSecret,
+			imagerepositoryv1alpha1.PushSecretLinkingAll, imagerepositoryv1alpha1.PushSecretLinkingSecrets, imagerepositoryv1alpha1.PushSecretLinkingNone)
+	}
+	switch linking.PullSecret {
+	case "", imagerepositoryv1alpha1.PullSecretLinkingApplication, imagerepositoryv1alpha1.PullSecretLinkingAll, imagerepositoryv1alpha1.PullSecretLinkingNone:
+	default:
+		return fmt.Errorf("invalid pull secret linking '%s', must be one of: %s, %s, %s", linking.PullSecret,
+			imagerepositoryv1alpha1.PullSecretLinkingApplication, imagerepositoryv1alpha1.PullSecretLinkingAll, imagerepositoryv1alpha1.PullSecretLinkingNone)
+	}
+	return nil
+}
+
+// getSecretLinking returns the secret linking policy of the image repository.
+// Fields not set in the ImageRepository are taken from DefaultSecretLinking and then from the built-in defaults,
+// i.e. the push secret is linked to both lists of the build pipeline service account
+// and the pull secret is linked to the Application service accounts.
+func (r *ImageRepositoryReconciler) getSecretLinking(imageRepository *imagerepositoryv1alpha1.ImageRepository) imagerepositoryv1alpha1.SecretLinking {
+	linking := r.DefaultSecretLinking
+	if imageRepository.Spec.Credentials != nil && imageRepository.Spec.Credentials.SecretLinking != nil {
+		override := imageRepository.Spec.Credentials.SecretLinking
+		if override.PushSecret != "" {
+			linking.PushSecret = override.PushSecret
+		}
+		if override.PullSecret != "" {
+			linking.PullSecret = override.PullSecret
+		}
+	}
+	if linking.PushSecret == "" {
+		linking.PushSecret = imagerepositoryv1alpha1.PushSecretLinkingAll
+	}
+	if linking.PullSecret == "" {
+		linking.PullSecret = imagerepositoryv1alpha1.PullSecretLinkingApplication
+	}
+	return linking
+}
+
+// setPushSecretLinks makes lists of the service account reference the push secret as the linking policy requests.
+// Returns true if the service account has been changed.
+func setPushSecretLinks(serviceAccount *corev1.ServiceAccount, secretName string, linking imagerepositoryv1alpha1.PushSecretLinking) bool {
+	isUpdated := false
+
+	isSecret := func(ref corev1.ObjectReference) bool { return ref.Name == secretName }
+	if isLinked, shouldBeLinked := slices.ContainsFunc(serviceAccount.Secrets, isSecret), linking != imagerepositoryv1alpha1.PushSecretLinkingNone; isLinked != shouldBeLinked {
+		if shouldBeLinked {
+			serviceAccount.Secrets = append(serviceAccount.Secrets, corev1.ObjectReference{Name: secretName})
+		} else {
+			serviceAccount.Secrets = slices.DeleteFunc(serviceAccount.Secrets, isSecret)
+		}
+		isUpdated = true
+	}
+
+	isImagePullSecret := func(ref corev1.LocalObjectReference) bool { return ref.Name == secretName }
+	if isLinked, shouldBeLinked := slices.ContainsFunc(serviceAccount.ImagePullSecrets, isImagePullSecret), linking == imagerepositoryv1alpha1.PushSecretLinkingAll; isLinked != shouldBeLinked {
+		if shouldBeLinked {
+			serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+		} else {
+			serviceAccount.ImagePullSecrets = slices.DeleteFunc(serviceAccount.ImagePullSecrets, isImagePullSecret)
+		}
+		isUpdated = true
+	}
+
+	return isUpdated
+}
+
+// SyncPushSecretLinks keeps the push secret linked to the build pipeline service account according to the linking policy,
+// so links removed by someone else are restored and policy changes are applied to already provisioned image repositories.
+func (r *ImageRepositoryReconciler) SyncPushSecretLinks(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("PushSecretLinks")
+
+	pushSecretName := imageRepository.Status.Credentials.PushSecretName
+	if pushSecretName == "" {
+		return nil
+	}
+
+	serviceAccount := &corev1.ServiceAccount{}
+	serviceAccountKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: buildPipelineServiceAccountName}
+	if err := r.Client.Get(ctx, serviceAccountKey, serviceAccount); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get build pipeline service account", l.Action, l.ActionView)
+		return err
+	}
+
+	linking := r.getSecretLinking(imageRepository).PushSecret
+	if !setPushSecretLinks(serviceAccount, pushSecretName, linking) {
+		return nil
+	}
+	if err := r.Client.Update(ctx, serviceAccount); err != nil {
+		log.Error(err, "failed to update build pipeline service account", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Updated push secret links of build pipeline service account", "SecretName", pushSecretName, "Linking", linking, l.Action, l.ActionUpdate)
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+func isPerServiceAccountSecretsEnabled(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return imageRepository.Spec.Credentials != nil && imageRepository.Spec.Credentials.PerServiceAccountSecrets != nil
+}
+
+func isDedicatedServiceAccountRobotAccountsEnabled(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return isPerServiceAccountSecretsEnabled(imageRepository) && !imageRepository.Spec.Credentials.PerServiceAccountSecrets.SharedRobotAccount
+}
+
+// SyncServiceAccountPullSecrets links dedicated pull secret to each service account of Applications the image repository belongs to,
+// so no secret is mounted by more than one service account and the access of each could be revoked independently.
+// Secrets of service accounts that don't belong to the Applications anymore are deleted together with their robot accounts.
+func (r *ImageRepositoryReconciler) SyncServiceAccountPullSecrets(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("ServiceAccountPullSecrets")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	if imageRepository.Status.Credentials.PullSecretName == "" {
+		return nil
+	}
+
+	shouldBeLinked := map[string]bool{}
+	serviceAccountList := &corev1.ServiceAccountList{}
+	if isPerServiceAccountSecretsEnabled(imageRepository) {
+		if err := r.Client.List(ctx, serviceAccountList, client.InNamespace(imageRepository.Namespace), client.HasLabels{ApplicationNameLabelName}); err != nil {
+			log.Error(err, "failed to list application service accounts", l.Action, l.ActionView)
+			return err
+		}
+		applications := getImageRepositoryApplications(imageRepository)
+		for _, serviceAccount := range serviceAccountList.Items {
+			if slices.Contains(applications, serviceAccount.Labels[ApplicationNameLabelName]) {
+				shouldBeLinked[serviceAccount.Name] = true
+			}
+		}
+	}
+	isDedicatedRobotAccount := isDedicatedServiceAccountRobotAccountsEnabled(imageRepository)
+
+	secretsStatus := []imagerepositoryv1alpha1.ServiceAccountSecretStatus{}
+	removedRobotAccountNames := map[string]bool{}
+	for _, secretStatus := range imageRepository.Status.Credentials.ServiceAccountSecrets {
+		// Switching between shared and dedicated robot accounts provisions the secret again
+		if shouldBeLinked[secretStatus.ServiceAccountName] && (secretStatus.RobotAccountName != "") == isDedicatedRobotAccount {
+			secretsStatus = append(secretsStatus, secretStatus)
+			continue
+		}
+		if err := r.deleteServiceAccountPullSecret(ctx, imageRepository, secretStatus); err != nil {
+			_ = r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+			return err
+		}
+		if secretStatus.RobotAccountName != "" {
+			removedRobotAccountNames[secretStatus.RobotAccountName] = true
+		}
+	}
+	if len(shouldBeLinked) == 0 {
+		return r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+	}
+
+	var sharedSecretData []byte
+	if !isDedicatedRobotAccount {
+		sharedSecret := &corev1.Secret{}
+		sharedSecretKey := types.NamespacedName{Namespace: imageRepository.Namespace, Name: imageRepository.Status.Credentials.PullSecretName}
+		if err := r.Client.Get(ctx, sharedSecretKey, sharedSecret); err != nil {
+			log.Error(err, "failed to get pull secret", "SecretName", sharedSecretKey.Name, l.Action, l.ActionView)
+			_ = r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+			return err
+		}
+		sharedSecretData = sharedSecret.Data[corev1.DockerConfigJsonKey]
+	}
+
+	for i := range serviceAccountList.Items {
+		serviceAccount := &serviceAccountList.Items[i]
+		if !shouldBeLinked[serviceAccount.Name] {
+			continue
+		}
+
+		index := slices.IndexFunc(secretsStatus, func(s imagerepositoryv1alpha1.ServiceAccountSecretStatus) bool {
+			return s.ServiceAccountName == serviceAccount.Name
+		})
+		if index < 0 {
+			secretStatus, err := r.provisionServiceAccountPullSecret(ctx, imageRepository, serviceAccount.Name, isDedicatedRobotAccount, sharedSecretData)
+			if err != nil {
+				_ = r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+				return err
+			}
+			secretsStatus = append(secretsStatus, *secretStatus)
+			index = len(secretsStatus) - 1
+		} else if !isDedicatedRobotAccount {
+			// Keep the copy of the shared credentials up to date, e.g. after token rotation
+			if err := r.ensureServiceAccountPullSecret(ctx, imageRepository, secretsStatus[index].SecretName, sharedSecretData); err != nil {
+				_ = r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+				return err
+			}
+		}
+
+		if err := r.linkServiceAccountPullSecret(ctx, serviceAccount, secretsStatus[index].SecretName); err != nil {
+			_ = r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+			return err
+		}
+	}
+	return r.saveServiceAccountSecrets(ctx, imageRepository, secretsStatus, removedRobotAccountNames)
+}
+
+// provisionServiceAccountPullSecret creates the pull secret dedicated to the service account,
+// either with a copy of the shared pull credentials or with credentials of a new dedicated robot account.
+func (r *ImageRepositoryReconciler) provisionServiceAccountPullSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, serviceAccountName string, isDedicatedRobotAccount bool, sharedSecretData []byte) (*imagerepositoryv1alpha1.ServiceAccountSecretStatus, error) {
+	log := ctrllog.FromContext(ctx).WithValues("ServiceAccountName", serviceAccountName)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	secretStatus := &imagerepositoryv1alpha1.ServiceAccountSecretStatus{
+		ServiceAccountName: serviceAccountName,
+		SecretName:         getServiceAccountPullSecretName(imageRepository, serviceAccountName),
+	}
+	if !isDedicatedRobotAccount {
+		if err := r.ensureServiceAccountPullSecret(ctx, imageRepository, secretStatus.SecretName, sharedSecretData); err != nil {
+			return nil, err
+		}
+		log.Info("Provisioned service account pull secret", "SecretName", secretStatus.SecretName, l.Action, l.ActionAdd)
+		return secretStatus, nil
+	}
+
+	// Check before creating the robot account, EnsureSecret would update any existing secret
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretStatus.SecretName}, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get service account pull secret", "SecretName", secretStatus.SecretName, l.Action, l.ActionView)
+			return nil, err
+		}
+	} else if conflict := getServiceAccountPullSecretConflict(imageRepository, secret); conflict != "" {
+		return nil, r.reportSecretOwnershipConflict(ctx, imageRepository, secret.Name, conflict)
+	}
+
+	robotAccount, robotAccountName, err := r.createRepositoryRobotAccount(ctx, imageRepository,
+		fmt.Sprintf("Pull robot account of ImageRepository %s/%s for service account %s", imageRepository.Namespace, imageRepository.Name, serviceAccountName))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.EnsureSecret(ctx, imageRepository, secretStatus.SecretName, robotAccount, imageRepository.Status.Image.URL, true); err != nil {
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, err
+	}
+	secretStatus.RobotAccountName = robotAccountName
+	log.Info("Provisioned service account pull secret", "SecretName", secretStatus.SecretName, "RobotAccountName", robotAccountName, l.Action, l.ActionAdd)
+	return secretStatus, nil
+}
+
+// getServiceAccountPullSecretConflict returns why the existing secret is not the service account pull secret
+// created by the controller for the image repository, empty string if it is.
+func getServiceAccountPullSecretConflict(imageRepository *imagerepositoryv1alpha1.ImageRepository, secret *corev1.Secret) string {
+	if secret.Labels[InternalSecretLabelName] != "true" {
+		return fmt.Sprintf("%s label is missing", InternalSecretLabelName)
+	}
+	if !slices.ContainsFunc(secret.OwnerReferences, func(ownerReference metav1.OwnerReference) bool { return ownerReference.UID == imageRepository.UID }) {
+		return "secret is not owned by the image repository"
+	}
+	return ""
+}
+
+// ensureServiceAccountPullSecret creates or updates the service account pull secret with the shared pull credentials.
+// Secret with the same name not created by the controller for the image repository is never overwritten.
+func (r *ImageRepositoryReconciler) ensureServiceAccountPullSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretName string, secretData []byte) error {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secretName)
+
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretName}, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get service account pull secret", l.Action, l.ActionView)
+			return err
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: imageRepository.Namespace,
+				Labels: map[string]string{
+					InternalSecretLabelName: "true",
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: secretData},
+		}
+		setSecretExportMetadata(secret, r.PullSecretExportLabels, r.PullSecretExportAnnotations)
+		if err := controllerutil.SetOwnerReference(imageRepository, secret, r.Scheme); err != nil {
+			log.Error(err, "failed to set owner for service account pull secret")
+			return err
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			log.Error(err, "failed to create service account pull secret", l.Action, l.ActionAdd, l.Audit, "true")
+			return err
+		}
+		log.Info("Service account pull secret created")
+		return r.clearSecretOwnershipConflict(ctx, imageRepository, secretName)
+	}
+
+	if conflict := getServiceAccountPullSecretConflict(imageRepository, secret); conflict != "" {
+		return r.reportSecretOwnershipConflict(ctx, imageRepository, secretName, conflict)
+	}
+	if !bytes.Equal(secret.Data[corev1.DockerConfigJsonKey], secretData) {
+		secret.Data = map[string][]byte{corev1.DockerConfigJsonKey: secretData}
+		if err := r.Client.Update(ctx, secret); err != nil {
+			log.Error(err, "failed to update service account pull secret", l.Action, l.ActionUpdate, l.Audit, "true")
+			return err
+		}
+		log.Info("Service account pull secret updated")
+	}
+	return r.clearSecretOwnershipConflict(ctx, imageRepository, secretName)
+}
+
+// linkServiceAccountPullSecret links the dedicated pull secret to the service account, if it's not linked yet.
+func (r *ImageRepositoryReconciler) linkServiceAccountPullSecret(ctx context.Context, serviceAccount *corev1.ServiceAccount, secretName string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if slices.ContainsFunc(serviceAccount.ImagePullSecrets, func(ref corev1.LocalObjectReference) bool { return ref.Name == secretName }) {
+		return nil
+	}
+	serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+	if err := r.Client.Update(ctx, serviceAccount); err != nil {
+		log.Error(err, "failed to update application service account", "ServiceAccountName", serviceAccount.Name, l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Linked service account pull secret", "ServiceAccountName", serviceAccount.Name, "SecretName", secretName, l.Action, l.ActionUpdate)
+	return nil
+}
+
+// deleteServiceAccountPullSecret revokes the service account access by deleting its secret and dedicated robot account.
+func (r *ImageRepositoryReconciler) deleteServiceAccountPullSecret(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretStatus imagerepositoryv1alpha1.ServiceAccountSecretStatus) error {
+	log := ctrllog.FromContext(ctx).WithValues("ServiceAccountName", secretStatus.ServiceAccountName, "SecretName", secretStatus.SecretName)
+
+	if secretStatus.RobotAccountName != "" {
+		if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, secretStatus.RobotAccountName); err != nil {
+			log.Error(err, "failed to delete robot account", "RobotAccountName", secretStatus.RobotAccountName, l.Action, l.ActionDelete, l.Audit, "true")
+			return err
+		}
+	}
+	if err := r.unlinkSecretsFromServiceAccounts(ctx, imageRepository.Namespace, []string{secretStatus.SecretName}); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	secret.Name = secretStatus.SecretName
+	secret.Namespace = imageRepository.Namespace
+	if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+		log.Error(err, "failed to delete service account pull secret", l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	log.Info("Deleted service account pull secret", l.Action, l.ActionDelete)
+	return nil
+}
+
+// saveServiceAccountSecrets records provisioned service account pull secrets and their robot accounts, if changed.
+func (r *ImageRepositoryReconciler) saveServiceAccountSecrets(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, secretsStatus []imagerepositoryv1alpha1.ServiceAccountSecretStatus, removedRobotAccountNames map[string]bool) error {
+	if len(secretsStatus) == 0 {
+		secretsStatus = nil
+	}
+	if reflect.DeepEqual(secretsStatus, imageRepository.Status.Credentials.ServiceAccountSecrets) {
+		return nil
+	}
+
+	addedRobotAccountNames := []string{}
+	for _, secretStatus := range secretsStatus {
+		addedRobotAccountNames = append(addedRobotAccountNames, secretStatus.RobotAccountName)
+	}
+	status := imageRepository.Status.DeepCopy()
+	status.Credentials.RobotAccountNames = getUpdatedTrackedRobotAccountNames(imageRepository, removedRobotAccountNames, addedRobotAccountNames)
+	status.Credentials.ServiceAccountSecrets = secretsStatus
+	return r.saveTrackedRobotAccounts(ctx, imageRepository, status)
+}
+
+// RegenerateServiceAccountPullSecretsCredentials rotates tokens of dedicated service account robot accounts and updates their secrets.
+// Secrets with the shared pull credentials are updated on the next sync.
+func (r *ImageRepositoryReconciler) RegenerateServiceAccountPullSecretsCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	for _, secretStatus := range imageRepository.Status.Credentials.ServiceAccountSecrets {
+		if secretStatus.RobotAccountName == "" {
+			continue
+		}
+		secretLog := log.WithValues("ServiceAccountName", secretStatus.ServiceAccountName)
+		robotAccount, err := r.QuayClient.RegenerateRobotAccountToken(r.QuayOrganization, secretStatus.RobotAccountName)
+		if err != nil {
+			secretLog.Error(err, "failed to refresh service account robot account token")
+			return err
+		}
+		if err := r.EnsureSecret(ctrllog.IntoContext(ctx, secretLog), imageRepository, secretStatus.SecretName, robotAccount, imageRepository.Status.Image.URL, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getServiceAccountPullSecretName(imageRepository *imagerepositoryv1alpha1.ImageRepository, serviceAccountName string) string {
+	secretName := getSecretName(imageRepository, true) + "-" + serviceAccountName
+	if len(secretName) > 253 {
+		secretName = secretName[:253]
+	}
+	return secretName
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// SigningPublicKeyLabelName marks ConfigMaps with published cosign public keys,
+	// so verification policies could be generated by selecting them.
+	SigningPublicKeyLabelName = "appstudio.redhat.com/cosign-public-key"
+
+	SigningPublicKeyConfigMapKey   = "cosign.pub"
+	SigningImageConfigMapKey       = "image"
+	SigningFingerprintConfigMapKey = "fingerprint"
+
+	invalidSigningMessagePrefix = "invalid signing configuration: "
+
+	// signingFingerprintDescriptionKey prefixes the public key fingerprint line in the image repository description.
+	signingFingerprintDescriptionKey = "cosign-public-key-sha256"
+)
+
+// SyncSigning publishes the requested cosign public key in a ConfigMap in the image repository namespace.
+// If signing is removed from the spec, the ConfigMap is deleted.
+// Publishing the key fingerprint in Quay is done together with the description, see SyncReadme.
+func (r *ImageRepositoryReconciler) SyncSigning(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx).WithName("SyncSigning")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	if imageRepository.Spec.Signing == nil {
+		return r.unpublishSigningPublicKey(ctx, imageRepository)
+	}
+
+	publicKey, fingerprint, err := r.getSigningPublicKey(ctx, imageRepository)
+	if err == nil {
+		err = r.ensureSigningPublicKeyConfigMap(ctx, imageRepository, publicKey, fingerprint)
+	}
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), invalidSigningMessagePrefix) {
+			return err
+		}
+		if imageRepository.Status.Message != err.Error() {
+			imageRepository.Status.Message = err.Error()
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+				return err
+			}
+		}
+		return nil
+	}
+
+	signingStatus := &imagerepositoryv1alpha1.SigningStatus{
+		PublicKeyFingerprint: fingerprint,
+		ConfigMapName:        getSigningConfigMapName(imageRepository),
+	}
+	if imageRepository.Status.Signing != nil {
+		signingStatus.PublishedToQuay = imageRepository.Status.Signing.PublishedToQuay
+	}
+	isMessageCleared := strings.HasPrefix(imageRepository.Status.Message, invalidSigningMessagePrefix)
+	if !isMessageCleared && reflect.DeepEqual(signingStatus, imageRepository.Status.Signing) {
+		return nil
+	}
+
+	if isMessageCleared {
+		imageRepository.Status.Message = ""
+	}
+	imageRepository.Status.Signing = signingStatus
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// getSigningPublicKey returns the requested PEM encoded public key and its fingerprint.
+// Errors caused by wrong configuration are prefixed with invalidSigningMessagePrefix.
+func (r *ImageRepositoryReconciler) getSigningPublicKey(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (string, string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	secretRef := imageRepository.Spec.Signing.PublicKeySecretRef
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: secretRef.Name}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", "", fmt.Errorf("%sSecret %s not found", invalidSigningMessagePrefix, secretRef.Name)
+		}
+		log.Error(err, "failed to get signing public key Secret", "SecretName", secretRef.Name, l.Action, l.ActionView)
+		return "", "", err
+	}
+	publicKeyData, exists := secret.Data[secretRef.Key]
+	if !exists {
+		return "", "", fmt.Errorf("%skey %s not found in Secret %s", invalidSigningMessagePrefix, secretRef.Key, secretRef.Name)
+	}
+
+	block, _ := pem.Decode(publicKeyData)
+	if block == nil {
+		return "", "", fmt.Errorf("%skey %s in Secret %s is not PEM encoded", invalidSigningMessagePrefix, secretRef.Key, secretRef.Name)
+	}
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return "", "", fmt.Errorf("%skey %s in Secret %s is not a public key: %s", invalidSigningMessagePrefix, secretRef.Key, secretRef.Name, err.Error())
+	}
+
+	fingerprint := sha256.Sum256(block.Bytes)
+	return string(pem.EncodeToMemory(block)), hex.EncodeToString(fingerprint[:]), nil
+}
+
+// ensureSigningPublicKeyConfigMap creates or updates the ConfigMap with the published public key.
+// ConfigMap with the same name not created by the operator is never overwritten.
+func (r *ImageRepositoryReconciler) ensureSigningPublicKeyConfigMap(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, publicKey, fingerprint string) error {
+	configMapName := getSigningConfigMapName(imageRepository)
+	log := ctrllog.FromContext(ctx).WithValues("ConfigMapName", configMapName)
+
+	data := map[string]string{
+		SigningPublicKeyConfigMapKey:   publicKey,
+		SigningImageConfigMapKey:       imageRepository.Status.Image.URL,
+		SigningFingerprintConfigMapKey: fingerprint,
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: configMapName}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get signing public key ConfigMap", l.Action, l.ActionView)
+			return err
+		}
+
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      configMapName,
+				Namespace: imageRepository.Namespace,
+				Labels: map[string]string{
+					SigningPublicKeyLabelName: "true",
+				},
+			},
+			Data: data,
+		}
+		if err := controllerutil.SetOwnerReference(imageRepository, configMap, r.Scheme); err != nil {
+			log.Error(err, "failed to set owner for signing public key ConfigMap")
+			return err
+		}
+		if err := r.Client.Create(ctx, configMap); err != nil {
+			log.Error(err, "failed to create signing public key ConfigMap", l.Action, l.ActionAdd)
+			return err
+		}
+		log.Info("Published signing public key", "Fingerprint", fingerprint, l.Audit, "true")
+		return nil
+	}
+
+	if configMap.Labels[SigningPublicKeyLabelName] != "true" {
+		return fmt.Errorf("%sConfigMap %s already exists and is not managed by the image repository", invalidSigningMessagePrefix, configMapName)
+	}
+	if reflect.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+	configMap.Data = data
+	if err := r.Client.Update(ctx, configMap); err != nil {
+		log.Error(err, "failed to update signing public key ConfigMap", l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Updated published signing public key", "Fingerprint", fingerprint, l.Audit, "true")
+	return nil
+}
+
+// unpublishSigningPublicKey deletes the ConfigMap with the public key, after signing was removed from the spec.
+func (r *ImageRepositoryReconciler) unpublishSigningPublicKey(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	log := ctrllog.FromContext(ctx)
+
+	if imageRepository.Status.Signing != nil && imageRepository.Status.Signing.ConfigMapName != "" {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      imageRepository.Status.Signing.ConfigMapName,
+				Namespace: imageRepository.Namespace,
+			},
+		}
+		if err := r.Client.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete signing public key ConfigMap", "ConfigMapName", configMap.Name, l.Action, l.ActionDelete)
+			return err
+		}
+		log.Info("Deleted published signing public key", "ConfigMapName", configMap.Name, l.Audit, "true")
+	}
+
+	if strings.HasPrefix(imageRepository.Status.Message, invalidSigningMessagePrefix) {
+		imageRepository.Status.Message = ""
+	}
+	imageRepository.Status.Signing = nil
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// getQuaySigningFingerprint returns the public key fingerprint to be added to the image repository description,
+// empty if the key is not requested to be published in Quay or is not valid.
+func getQuaySigningFingerprint(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	if imageRepository.Spec.Signing == nil || !imageRepository.Spec.Signing.PublishToQuay || imageRepository.Status.Signing == nil {
+		return ""
+	}
+	return imageRepository.Status.Signing.PublicKeyFingerprint
+}
+
+func getSigningConfigMapName(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	configMapName := imageRepository.Name
+	if len(configMapName) > 220 {
+		configMapName = configMapName[:220]
+	}
+	return configMapName + "-cosign-public-key"
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+)
+
+const (
+	specRevertedEventReason = "SpecReverted"
+
+	specRevertReasonImmutable     = "Immutable"
+	specRevertReasonQuotaExceeded = "QuotaExceeded"
+)
+
+// specRevert describes a spec change of a user, that the controller has overwritten.
+type specRevert struct {
+	// Field is the path of the reverted spec field, e.g. spec.image.name
+	Field string
+	// AttemptedValue is the value set by the user, EnforcedValue is the value the field was reverted to.
+	AttemptedValue string
+	EnforcedValue  string
+	// Reason is a short machine readable cause used in the metric, Message explains it to the user.
+	Reason  string
+	Message string
+}
+
+// recordSpecRevert reports the reverted spec change by a Warning event, a log record and the reverts metric,
+// so users see why their change disappeared and admins could detect users fighting the controller.
+// Should be called once the reverted spec is saved.
+func (r *ImageRepositoryReconciler) recordSpecRevert(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, revert specRevert) {
+	log := ctrllog.FromContext(ctx)
+
+	log.Info("Reverted image repository spec change", "Field", revert.Field, "AttemptedValue", revert.AttemptedValue,
+		"EnforcedValue", revert.EnforcedValue, "Reason", revert.Reason, l.Action, l.ActionUpdate, l.Audit, "true")
+	metrics.SpecRevertsMetric.WithLabelValues(imageRepository.Namespace, revert.Field, revert.Reason).Inc()
+
+	if r.EventRecorder == nil || r.DryRun {
+		return
+	}
+	r.EventRecorder.Eventf(imageRepository, corev1.EventTypeWarning, specRevertedEventReason,
+		"Reverted %s from '%s' to '%s': %s", revert.Field, revert.AttemptedValue, revert.EnforcedValue, revert.Message)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// verifySecretsUnlinked makes sure none of the service accounts in the namespace references the given secrets.
+// Service accounts are read bypassing the cache, so links made just before the deletion, not yet seen by the cache, are found too.
+// Found references are removed and the service accounts are read again, until no reference is left or the retries are exhausted.
+func (r *ImageRepositoryReconciler) verifySecretsUnlinked(ctx context.Context, namespace string, secretNames []string) error {
+	log := ctrllog.FromContext(ctx)
+
+	if len(secretNames) == 0 {
+		return nil
+	}
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+
+	err := retry.OnError(retry.DefaultBackoff, func(error) bool { return true }, func() error {
+		serviceAccountList := &corev1.ServiceAccountList{}
+		if err := reader.List(ctx, serviceAccountList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		linkedServiceAccountNames := []string{}
+		for i := range serviceAccountList.Items {
+			serviceAccount := &serviceAccountList.Items[i]
+			if !removeSecretReferences(serviceAccount, secretNames) {
+				continue
+			}
+			linkedServiceAccountNames = append(linkedServiceAccountNames, serviceAccount.Name)
+			if err := r.Client.Update(ctx, serviceAccount); err != nil {
+				return err
+			}
+			log.Info("Unlinked secrets left in service account", "ServiceAccountName", serviceAccount.Name, "SecretNames", secretNames, l.Action, l.ActionUpdate)
+		}
+		if len(linkedServiceAccountNames) > 0 {
+			// Check again that the update is effective
+			return fmt.Errorf("secrets %v were still linked to service accounts %v", secretNames, linkedServiceAccountNames)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err, "failed to verify secrets are unlinked from service accounts", "SecretNames", secretNames, l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// removeSecretReferences removes the given secrets from secrets and image pull secrets of the service account.
+// Returns true if any reference has been removed.
+func removeSecretReferences(serviceAccount *corev1.ServiceAccount, secretNames []string) bool {
+	isUnlinked := func(name string) bool { return slices.Contains(secretNames, name) }
+	secretsCount, imagePullSecretsCount := len(serviceAccount.Secrets), len(serviceAccount.ImagePullSecrets)
+	serviceAccount.Secrets = slices.DeleteFunc(serviceAccount.Secrets, func(ref corev1.ObjectReference) bool { return isUnlinked(ref.Name) })
+	serviceAccount.ImagePullSecrets = slices.DeleteFunc(serviceAccount.ImagePullSecrets, func(ref corev1.LocalObjectReference) bool { return isUnlinked(ref.Name) })
+	return len(serviceAccount.Secrets) != secretsCount || len(serviceAccount.ImagePullSecrets) != imagePullSecretsCount
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	defaultTemporaryCredentialsTTL = time.Hour
+	maxTemporaryCredentialsTTL     = 24 * time.Hour
+
+	temporaryPullCredentialsIssuedEventReason  = "TemporaryPullCredentialsIssued"
+	temporaryPullCredentialsRevokedEventReason = "TemporaryPullCredentialsRevoked"
+
+	invalidTemporaryCredentialsMessagePrefix = "invalid temporary pull credentials request: "
+)
+
+// SyncTemporaryPullCredentials issues pull only credentials requested by the TemporaryPullCredentials annotation
+// and revokes them once expired. Only one set of temporary credentials exists at a time, a new request replaces it.
+// Returns time after which the credentials must be checked again.
+func (r *ImageRepositoryReconciler) SyncTemporaryPullCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("SyncTemporaryPullCredentials")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	temporaryCredentials := imageRepository.Status.Credentials.TemporaryPullCredentials
+	requestedTTL, isRequested, invalidRequestMessage := getRequestedTemporaryCredentialsTTL(imageRepository)
+	if invalidRequestMessage != "" {
+		if imageRepository.Status.Message != invalidRequestMessage {
+			imageRepository.Status.Message = invalidRequestMessage
+			if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+				log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+				return 0, err
+			}
+		}
+		isRequested = false
+	}
+
+	if !isRequested {
+		if temporaryCredentials == nil {
+			return 0, r.clearInvalidTemporaryCredentialsMessage(ctx, imageRepository, invalidRequestMessage)
+		}
+		if remaining := time.Until(temporaryCredentials.ExpirationTimestamp.Time); remaining > 0 {
+			return remaining, r.clearInvalidTemporaryCredentialsMessage(ctx, imageRepository, invalidRequestMessage)
+		}
+	}
+
+	removedRobotAccountName := ""
+	if temporaryCredentials != nil {
+		if err := r.revokeTemporaryPullCredentials(ctx, imageRepository, temporaryCredentials); err != nil {
+			return 0, err
+		}
+		removedRobotAccountName = temporaryCredentials.RobotAccountName
+		temporaryCredentials = nil
+	}
+
+	if isRequested {
+		var err error
+		temporaryCredentials, err = r.issueTemporaryPullCredentials(ctx, imageRepository, requestedTTL)
+		if err != nil {
+			// Save progress, so the revoked credentials are not revoked again
+			_ = r.saveTemporaryPullCredentials(ctx, imageRepository, nil, removedRobotAccountName, false)
+			return 0, err
+		}
+	}
+
+	if err := r.saveTemporaryPullCredentials(ctx, imageRepository, temporaryCredentials, removedRobotAccountName, isRequested); err != nil {
+		return 0, err
+	}
+	if temporaryCredentials == nil {
+		return 0, nil
+	}
+	return requestedTTL, nil
+}
+
+// getRequestedTemporaryCredentialsTTL returns lifetime of the requested temporary credentials, whether they are requested
+// and message describing why the request is invalid, if it is.
+func getRequestedTemporaryCredentialsTTL(imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, bool, string) {
+	value, exists := annotations.TemporaryPullCredentials.Get(imageRepository)
+	if !exists {
+		return 0, false, ""
+	}
+	if err := annotations.TemporaryPullCredentials.Validate(imageRepository); err != nil {
+		return 0, true, invalidTemporaryCredentialsMessagePrefix + err.Error()
+	}
+	if value == "true" {
+		return defaultTemporaryCredentialsTTL, true, ""
+	}
+	ttl, _ := time.ParseDuration(value)
+	if ttl > maxTemporaryCredentialsTTL {
+		return 0, true, fmt.Sprintf("%slifetime %s exceeds maximum of %s", invalidTemporaryCredentialsMessagePrefix, value, maxTemporaryCredentialsTTL)
+	}
+	return ttl, true, ""
+}
+
+// clearInvalidTemporaryCredentialsMessage removes message of a previously invalid request, once the request is fixed or removed.
+func (r *ImageRepositoryReconciler) clearInvalidTemporaryCredentialsMessage(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, invalidRequestMessage string) error {
+	if invalidRequestMessage != "" || !strings.HasPrefix(imageRepository.Status.Message, invalidTemporaryCredentialsMessagePrefix) {
+		return nil
+	}
+	imageRepository.Status.Message = ""
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
+
+// issueTemporaryPullCredentials creates dedicated pull only robot account and a secret with its credentials.
+func (r *ImageRepositoryReconciler) issueTemporaryPullCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, ttl time.Duration) (*imagerepositoryv1alpha1.TemporaryCredentialsStatus, error) {
+	log := ctrllog.FromContext(ctx)
+
+	expirationTimestamp := metav1.NewTime(time.Now().Add(ttl).Truncate(time.Second))
+	robotAccount, robotAccountName, err := r.createRepositoryRobotAccount(ctx, imageRepository,
+		fmt.Sprintf("Temporary pull robot account of ImageRepository %s/%s, expires at %s", imageRepository.Namespace, imageRepository.Name, expirationTimestamp.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return nil, err
+	}
+
+	secretName := getTemporaryPullSecretName(imageRepository)
+	secretData, err := generateDockerconfigSecretData(imageRepository.Status.Image.URL, robotAccount)
+	if err != nil {
+		log.Error(err, "refusing to write invalid temporary pull secret", l.Audit, "true")
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: imageRepository.Namespace,
+			Labels: map[string]string{
+				InternalSecretLabelName: "true",
+			},
+		},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		StringData: secretData,
+	}
+	if err := controllerutil.SetOwnerReference(imageRepository, secret, r.Scheme); err != nil {
+		log.Error(err, "failed to set owner for temporary pull secret")
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, err
+	}
+	if err := r.ensureTemporaryPullSecret(ctx, secret); err != nil {
+		r.deleteRobotAccount(ctx, robotAccountName)
+		return nil, err
+	}
+
+	// The field manager is chosen by the client, the authenticated user is recorded by the API server audit log
+	fieldManager := getAnnotationManager(imageRepository, annotations.TemporaryPullCredentials)
+	log.Info("Issued temporary pull credentials", "RobotAccountName", robotAccountName, "SecretName", secretName,
+		"ExpirationTimestamp", expirationTimestamp.UTC().Format(time.RFC3339), "FieldManager", fieldManager, l.Action, l.ActionAdd, l.Audit, "true")
+	r.recordAuditEvent(imageRepository, corev1.EventTypeNormal, temporaryPullCredentialsIssuedEventReason,
+		"Issued temporary pull credentials in secret %s, robot account %s, expiring at %s, annotation set by field manager %s",
+		secretName, robotAccount.Name, expirationTimestamp.UTC().Format(time.RFC3339), fieldManager)
+
+	return &imagerepositoryv1alpha1.TemporaryCredentialsStatus{
+		SecretName:          secretName,
+		RobotAccountName:    robotAccountName,
+		ExpirationTimestamp: expirationTimestamp,
+		FieldManager:        fieldManager,
+	}, nil
+}
+
+// ensureTemporaryPullSecret creates the secret or overwrites a left over one, e.g. if saving the status failed before.
+// Secret with the same name not created by the operator is never overwritten.
+func (r *ImageRepositoryReconciler) ensureTemporaryPullSecret(ctx context.Context, secret *corev1.Secret) error {
+	log := ctrllog.FromContext(ctx).WithValues("SecretName", secret.Name)
+
+	err := r.Client.Create(ctx, secret)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		log.Error(err, "failed to create temporary pull secret", l.Action, l.ActionAdd, l.Audit, "true")
+		return err
+	}
+
+	existingSecret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, existingSecret); err != nil {
+		log.Error(err, "failed to get temporary pull secret", l.Action, l.ActionView)
+		return err
+	}
+	if existingSecret.Labels[InternalSecretLabelName] != "true" {
+		err := fmt.Errorf("secret %s already exists and is not managed by the image repository", secret.Name)
+		log.Error(err, "refusing to overwrite temporary pull secret", l.Audit, "true")
+		return err
+	}
+	existingSecret.StringData = secret.StringData
+	if err := r.Client.Update(ctx, existingSecret); err != nil {
+		log.Error(err, "failed to update temporary pull secret", l.Action, l.ActionUpdate, l.Audit, "true")
+		return err
+	}
+	return nil
+}
+
+// revokeTemporaryPullCredentials deletes robot account and secret of the temporary credentials.
+func (r *ImageRepositoryReconciler) revokeTemporaryPullCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, temporaryCredentials *imagerepositoryv1alpha1.TemporaryCredentialsStatus) error {
+	log := ctrllog.FromContext(ctx).WithValues("RobotAccountName", temporaryCredentials.RobotAccountName, "SecretName", temporaryCredentials.SecretName)
+
+	if _, err := r.QuayClient.DeleteRobotAccount(r.QuayOrganization, temporaryCredentials.RobotAccountName); err != nil {
+		log.Error(err, "failed to delete robot account", l.Action, l.ActionDelete, l.Audit, "true")
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: imageRepository.Namespace, Name: temporaryCredentials.SecretName}, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get temporary pull secret", l.Action, l.ActionView)
+			return err
+		}
+	} else if secret.Labels[InternalSecretLabelName] == "true" {
+		if err := r.Client.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete temporary pull secret", l.Action, l.ActionDelete, l.Audit, "true")
+			return err
+		}
+	}
+
+	log.Info("Revoked temporary pull credentials", "FieldManager", temporaryCredentials.FieldManager, l.Action, l.ActionDelete, l.Audit, "true")
+	r.recordAuditEvent(imageRepository, corev1.EventTypeNormal, temporaryPullCredentialsRevokedEventReason,
+		"Revoked temporary pull credentials in secret %s, robot account %s, annotation set by field manager %s",
+		temporaryCredentials.SecretName, temporaryCredentials.RobotAccountName, temporaryCredentials.FieldManager)
+	return nil
+}
+
+// saveTemporaryPullCredentials records the temporary credentials and their robot account.
+// The request annotation is removed once handled.
+func (r *ImageRepositoryReconciler) saveTemporaryPullCredentials(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, temporaryCredentials *imagerepositoryv1alpha1.TemporaryCredentialsStatus, removedRobotAccountName string, isRequestHandled bool) error {
+	addedRobotAccountNames := []string{}
+	if temporaryCredentials != nil {
+		addedRobotAccountNames = append(addedRobotAccountNames, temporaryCredentials.RobotAccountName)
+	}
+	status := imageRepository.Status.DeepCopy()
+	status.Credentials.RobotAccountNames = getUpdatedTrackedRobotAccountNames(imageRepository, map[string]bool{removedRobotAccountName: true}, addedRobotAccountNames)
+	status.Credentials.TemporaryPullCredentials = temporaryCredentials
+	if isRequestHandled {
+		annotations.TemporaryPullCredentials.Remove(imageRepository)
+	}
+	if _, _, invalidRequestMessage := getRequestedTemporaryCredentialsTTL(imageRepository); invalidRequestMessage == "" &&
+		strings.HasPrefix(status.Message, invalidTemporaryCredentialsMessagePrefix) {
+		status.Message = ""
+	}
+	return r.saveTrackedRobotAccounts(ctx, imageRepository, status)
+}
+
+// getAnnotationManager returns the field manager that set the annotation, as recorded by the API server, or "unknown".
+func getAnnotationManager(obj metav1.Object, key annotations.Key) string {
+	field := []byte(fmt.Sprintf(`"f:%s"`, key))
+	for _, managedFields := range obj.GetManagedFields() {
+		if managedFields.FieldsV1 != nil && bytes.Contains(managedFields.FieldsV1.Raw, field) {
+			return managedFields.Manager
+		}
+	}
+	return "unknown"
+}
+
+func getTemporaryPullSecretName(imageRepository *imagerepositoryv1alpha1.ImageRepository) string {
+	return getSecretName(imageRepository, true) + "-temporary"
+}
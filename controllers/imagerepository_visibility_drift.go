@@ -0,0 +1,125 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const (
+	// VisibilityDriftPolicyObserve accepts visibility changed in Quay, status is updated to match it.
+	// The spec is kept, the requested visibility is applied again only when the spec is changed.
+	VisibilityDriftPolicyObserve = "Observe"
+	// VisibilityDriftPolicyEnforce reverts visibility changed in Quay to the one recorded in status.
+	VisibilityDriftPolicyEnforce = "Enforce"
+
+	visibilityDriftReasonObserved = "ChangedInQuay"
+	visibilityDriftReasonReverted = "Reverted"
+
+	// visibilityDriftSyncInterval is how often the visibility is compared with Quay.
+	visibilityDriftSyncInterval = 30 * time.Minute
+)
+
+// SyncVisibilityDrift detects image repository visibility changed directly in Quay and handles it according to VisibilityDriftPolicy.
+// The drift is reported by VisibilityDrift condition, which is removed once the visibility matches.
+// Quay is asked at most once per visibilityDriftSyncInterval.
+// Returns interval after which the visibility should be checked again, zero if it's not needed.
+func (r *ImageRepositoryReconciler) SyncVisibilityDrift(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("VisibilityDrift")
+
+	if r.VisibilityDriftPolicy == "" {
+		return 0, nil
+	}
+	imageStatus := &imageRepository.Status.Image
+	if imageStatus.VisibilityCheckTimestamp != nil {
+		if sinceLastCheck := elapsedSince(imageStatus.VisibilityCheckTimestamp.Time); sinceLastCheck < visibilityDriftSyncInterval {
+			return visibilityDriftSyncInterval - sinceLastCheck, nil
+		}
+	}
+
+	isPublic, err := r.QuayClient.IsRepositoryPublic(r.QuayOrganization, imageRepository.Spec.Image.Name)
+	if err != nil {
+		log.Error(err, "failed to get image repository visibility", l.Action, l.ActionView)
+		return 0, err
+	}
+	actualVisibility := imagerepositoryv1alpha1.ImageVisibilityPrivate
+	if isPublic {
+		actualVisibility = imagerepositoryv1alpha1.ImageVisibilityPublic
+	}
+	imageStatus.VisibilityCheckTimestamp = &metav1.Time{Time: time.Now()}
+
+	if actualVisibility == imageStatus.Visibility {
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityDrift)
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		return visibilityDriftSyncInterval, nil
+	}
+
+	expectedVisibility := imageStatus.Visibility
+	if r.VisibilityDriftPolicy == VisibilityDriftPolicyEnforce {
+		if err := r.QuayClient.ChangeRepositoryVisibility(r.QuayOrganization, imageRepository.Spec.Image.Name, string(expectedVisibility)); err != nil {
+			log.Error(err, "failed to revert image repository visibility", "Visibility", expectedVisibility, l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		log.Info("Reverted image repository visibility changed in Quay", "ActualVisibility", actualVisibility, "Visibility", expectedVisibility, l.Audit, "true")
+		meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+			Type:               imagerepositoryv1alpha1.ConditionTypeVisibilityDrift,
+			Status:             metav1.ConditionTrue,
+			Reason:             visibilityDriftReasonReverted,
+			Message:            fmt.Sprintf("Visibility was changed to %s in Quay and reverted to %s", actualVisibility, expectedVisibility),
+			ObservedGeneration: imageRepository.Generation,
+		})
+		if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+			log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+			return 0, err
+		}
+		return visibilityDriftSyncInterval, nil
+	}
+
+	log.Info("Image repository visibility was changed in Quay", "ActualVisibility", actualVisibility, "Visibility", expectedVisibility, l.Audit, "true")
+	imageStatus.Visibility = actualVisibility
+	meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeVisibilityDrift,
+		Status:             metav1.ConditionTrue,
+		Reason:             visibilityDriftReasonObserved,
+		Message:            fmt.Sprintf("Visibility was changed from %s to %s in Quay", expectedVisibility, actualVisibility),
+		ObservedGeneration: imageRepository.Generation,
+	})
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update image repository status", l.Action, l.ActionUpdate)
+		return 0, err
+	}
+	return visibilityDriftSyncInterval, nil
+}
+
+// isVisibilityDriftObserved checks whether visibility changed in Quay was accepted after the last spec change,
+// so the requested visibility must not be applied until the spec is changed again.
+func isVisibilityDriftObserved(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	condition := meta.FindStatusCondition(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityDrift)
+	return condition != nil && condition.Reason == visibilityDriftReasonObserved && condition.ObservedGeneration == imageRepository.Generation
+}
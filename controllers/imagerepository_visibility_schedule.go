@@ -0,0 +1,197 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	visibilityScheduleReasonPending = "Pending"
+	visibilityScheduleReasonActive  = "Active"
+	visibilityScheduleReasonEnded   = "Ended"
+	// visibilityScheduleReasonQuotaExceeded means the scheduled private visibility cannot be applied
+	// due to Quay organization plan private repositories limit, it's not retried within the window.
+	visibilityScheduleReasonQuotaExceeded = "QuotaExceeded"
+
+	invalidVisibilityScheduleMessagePrefix = "invalid visibility schedule: "
+)
+
+func isVisibilityScheduleActive(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityScheduled)
+}
+
+// SyncVisibilitySchedule applies the scheduled visibility within the requested window and reverts it to spec.image.visibility
+// once the window ends or the schedule is removed. The window state is reported by VisibilityScheduled condition.
+// Returns interval after which the schedule should be checked again, zero if it's not needed.
+func (r *ImageRepositoryReconciler) SyncVisibilitySchedule(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) (time.Duration, error) {
+	log := ctrllog.FromContext(ctx).WithName("VisibilitySchedule")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	schedule := imageRepository.Spec.Image.VisibilitySchedule
+	originalStatus := imageRepository.Status.DeepCopy()
+	isActive := isVisibilityScheduleActive(imageRepository)
+	if strings.HasPrefix(imageRepository.Status.Message, invalidVisibilityScheduleMessagePrefix) {
+		imageRepository.Status.Message = ""
+	}
+
+	if schedule == nil {
+		if isActive {
+			if err := r.revertScheduledVisibility(ctx, imageRepository); err != nil {
+				return 0, err
+			}
+		}
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityScheduled)
+		imageRepository.Status.Image.VisibilitySchedule = nil
+		return 0, r.saveVisibilitySchedule(ctx, imageRepository, originalStatus)
+	}
+
+	now := time.Now()
+	window, invalidMessage := resolveVisibilityWindow(imageRepository, now)
+	if invalidMessage != "" {
+		log.Info("invalid visibility schedule", "Reason", invalidMessage)
+		if isActive {
+			if err := r.revertScheduledVisibility(ctx, imageRepository); err != nil {
+				return 0, err
+			}
+		}
+		meta.RemoveStatusCondition(&imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypeVisibilityScheduled)
+		imageRepository.Status.Image.VisibilitySchedule = nil
+		imageRepository.Status.Message = invalidVisibilityScheduleMessagePrefix + invalidMessage
+		return 0, r.saveVisibilitySchedule(ctx, imageRepository, originalStatus)
+	}
+	imageRepository.Status.Image.VisibilitySchedule = window
+
+	condition := metav1.Condition{
+		Type:               imagerepositoryv1alpha1.ConditionTypeVisibilityScheduled,
+		ObservedGeneration: imageRepository.Generation,
+	}
+	recheckAfter := time.Duration(0)
+	switch {
+	case now.Before(window.Start.Time):
+		if isActive {
+			if err := r.revertScheduledVisibility(ctx, imageRepository); err != nil {
+				return 0, err
+			}
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = visibilityScheduleReasonPending
+		condition.Message = fmt.Sprintf("Visibility changes to %s at %s", schedule.Visibility, window.Start.UTC().Format(time.RFC3339))
+		recheckAfter = window.Start.Sub(now)
+	case now.Before(window.End.Time):
+		// Applying the scheduled visibility is not retried within the window, once it exceeded the quota
+		if current := meta.FindStatusCondition(imageRepository.Status.Conditions, condition.Type); current != nil && current.Reason == visibilityScheduleReasonQuotaExceeded {
+			return window.End.Sub(now), r.saveVisibilitySchedule(ctx, imageRepository, originalStatus)
+		}
+		if imageRepository.Status.Image.Visibility != schedule.Visibility {
+			if err := r.QuayClient.ChangeRepositoryVisibility(r.QuayOrganization, imageRepository.Spec.Image.Name, string(schedule.Visibility)); err != nil {
+				if statusCode, _ := quay.GetStatusCode(err); statusCode == http.StatusPaymentRequired {
+					log.Info("failed to apply scheduled private visibility due to quay plan limit", l.Audit, "true")
+					condition.Status = metav1.ConditionFalse
+					condition.Reason = visibilityScheduleReasonQuotaExceeded
+					condition.Message = "Quay organization plan private repositories limit exceeded"
+					meta.SetStatusCondition(&imageRepository.Status.Conditions, condition)
+					return window.End.Sub(now), r.saveVisibilitySchedule(ctx, imageRepository, originalStatus)
+				}
+				log.Error(err, "failed to apply scheduled visibility", "Visibility", schedule.Visibility, l.Action, l.ActionUpdate)
+				return 0, err
+			}
+			log.Info("Applied scheduled image repository visibility", "Visibility", schedule.Visibility, "End", window.End, l.Audit, "true")
+			imageRepository.Status.Image.Visibility = schedule.Visibility
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = visibilityScheduleReasonActive
+		condition.Message = fmt.Sprintf("Visibility is %s until %s", schedule.Visibility, window.End.UTC().Format(time.RFC3339))
+		recheckAfter = window.End.Sub(now)
+	default:
+		if isActive {
+			if err := r.revertScheduledVisibility(ctx, imageRepository); err != nil {
+				return 0, err
+			}
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = visibilityScheduleReasonEnded
+		condition.Message = fmt.Sprintf("Visibility window ended at %s", window.End.UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&imageRepository.Status.Conditions, condition)
+	return recheckAfter, r.saveVisibilitySchedule(ctx, imageRepository, originalStatus)
+}
+
+// resolveVisibilityWindow returns the window of the visibility schedule, or message describing why it's invalid.
+// A window without start begins once it's observed, the resolved start is kept until the schedule is removed.
+func resolveVisibilityWindow(imageRepository *imagerepositoryv1alpha1.ImageRepository, now time.Time) (*imagerepositoryv1alpha1.VisibilityScheduleStatus, string) {
+	schedule := imageRepository.Spec.Image.VisibilitySchedule
+	if schedule.Visibility != imagerepositoryv1alpha1.ImageVisibilityPublic && schedule.Visibility != imagerepositoryv1alpha1.ImageVisibilityPrivate {
+		return nil, fmt.Sprintf("visibility must be %s or %s", imagerepositoryv1alpha1.ImageVisibilityPublic, imagerepositoryv1alpha1.ImageVisibilityPrivate)
+	}
+	if (schedule.End == nil) == (schedule.Duration == nil) {
+		return nil, "exactly one of end and duration must be set"
+	}
+
+	start := metav1.NewTime(now.Truncate(time.Second))
+	if schedule.Start != nil {
+		start = *schedule.Start
+	} else if resolved := imageRepository.Status.Image.VisibilitySchedule; resolved != nil {
+		start = resolved.Start
+	}
+	var end metav1.Time
+	if schedule.End != nil {
+		end = *schedule.End
+	} else {
+		end = metav1.NewTime(start.Add(schedule.Duration.Duration))
+	}
+	if !end.After(start.Time) {
+		return nil, "the window must end after it starts"
+	}
+	return &imagerepositoryv1alpha1.VisibilityScheduleStatus{Start: start, End: end}, ""
+}
+
+// revertScheduledVisibility changes the image repository visibility back to the one requested in spec.
+// If the revert to private exceeds Quay organization plan private repositories limit,
+// the spec takes the scheduled visibility as on any other visibility change, see ChangeImageRepositoryVisibility.
+func (r *ImageRepositoryReconciler) revertScheduledVisibility(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository) error {
+	if imageRepository.Spec.Image.Visibility == "" {
+		imageRepository.Spec.Image.Visibility = r.getDefaultVisibility()
+	}
+	return r.ChangeImageRepositoryVisibility(ctx, imageRepository)
+}
+
+func (r *ImageRepositoryReconciler) saveVisibilitySchedule(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, originalStatus *imagerepositoryv1alpha1.ImageRepositoryStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	if reflect.DeepEqual(originalStatus, &imageRepository.Status) {
+		return nil
+	}
+	if err := r.Client.Status().Update(ctx, imageRepository); err != nil {
+		log.Error(err, "failed to update visibility schedule status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
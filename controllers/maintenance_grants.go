@@ -0,0 +1,111 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// MaintenanceGrantsConfigMapName is name of the ConfigMap in the controller namespace that authorizes maintenance requests,
+// e.g. manifest deletion. Request annotations could be set by anyone who can edit the ImageRepository,
+// while the ConfigMap is writable only by subjects bound to the maintenance grants editor role.
+const MaintenanceGrantsConfigMapName = "image-controller-maintenance-grants"
+
+// MaintenanceGrants authorizes maintenance requests of ImageRepository objects by grants in MaintenanceGrantsConfigMapName ConfigMap.
+// Each grant is a key <namespace>_<name>_<operation>, e.g. test-ns_my-image_DeleteManifest, with the requested value,
+// e.g. the manifest digest. Object names cannot contain "_", so the key is unambiguous.
+// A grant is removed once its request is handled, so it authorizes exactly one request.
+type MaintenanceGrants struct {
+	Client    client.Client
+	Namespace string
+}
+
+func getMaintenanceGrantKey(imageRepository *imagerepositoryv1alpha1.ImageRepository, operation string) string {
+	return fmt.Sprintf("%s_%s_%s", imageRepository.Namespace, imageRepository.Name, operation)
+}
+
+// getGrant returns the granted value of the operation for the image repository, false if the operation is not granted.
+func (g *MaintenanceGrants) getGrant(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, operation string) (string, bool, error) {
+	log := ctrllog.FromContext(ctx)
+
+	configMap := &corev1.ConfigMap{}
+	if err := g.Client.Get(ctx, types.NamespacedName{Namespace: g.Namespace, Name: MaintenanceGrantsConfigMapName}, configMap); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		log.Error(err, "failed to get maintenance grants", l.Action, l.ActionView)
+		return "", false, err
+	}
+	grantedValue, isGranted := configMap.Data[getMaintenanceGrantKey(imageRepository, operation)]
+	return grantedValue, isGranted, nil
+}
+
+// validateMaintenanceGrant returns error describing why the request with the given value is not granted, nil if it's granted.
+// The request is granted only for the exact value, so a grant cannot be reused for other requests.
+func (g *MaintenanceGrants) validateMaintenanceGrant(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, operation, value string) (rejectionErr error, err error) {
+	if g == nil {
+		return errors.New("maintenance requests are not enabled"), nil
+	}
+	grantedValue, isGranted, err := g.getGrant(ctx, imageRepository, operation)
+	if err != nil {
+		return nil, err
+	}
+	grantKey := getMaintenanceGrantKey(imageRepository, operation)
+	if !isGranted {
+		return fmt.Errorf("%s is not granted in %s ConfigMap", grantKey, MaintenanceGrantsConfigMapName), nil
+	}
+	if grantedValue != value {
+		return fmt.Errorf("%s is granted for %q, not for %q", grantKey, grantedValue, value), nil
+	}
+	return nil, nil
+}
+
+// revoke removes the grant of the handled request.
+func (g *MaintenanceGrants) revoke(ctx context.Context, imageRepository *imagerepositoryv1alpha1.ImageRepository, operation string) error {
+	log := ctrllog.FromContext(ctx)
+
+	grantKey := getMaintenanceGrantKey(imageRepository, operation)
+	configMap := &corev1.ConfigMap{}
+	if err := g.Client.Get(ctx, types.NamespacedName{Namespace: g.Namespace, Name: MaintenanceGrantsConfigMapName}, configMap); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		log.Error(err, "failed to get maintenance grants", l.Action, l.ActionView)
+		return err
+	}
+	if _, isGranted := configMap.Data[grantKey]; !isGranted {
+		return nil
+	}
+	delete(configMap.Data, grantKey)
+	if err := g.Client.Update(ctx, configMap); err != nil {
+		log.Error(err, "failed to remove maintenance grant", "Grant", grantKey, l.Action, l.ActionUpdate)
+		return err
+	}
+	log.Info("Removed maintenance grant of handled request", "Grant", grantKey, l.Action, l.ActionUpdate, l.Audit, "true")
+	return nil
+}
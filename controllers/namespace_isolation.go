@@ -0,0 +1,117 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+)
+
+// isImageRepositoryNameAllowed checks that the image repository name belongs to the namespace,
+// so a tenant cannot take over image repository of other namespace.
+// Objects in admin namespaces may manage image repositories of any namespace.
+func isImageRepositoryNameAllowed(imageRepositoryName, namespace string, adminNamespaces []string) bool {
+	if slices.Contains(adminNamespaces, namespace) {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimPrefix(imageRepositoryName, "/"), namespace+"/")
+}
+
+// NamespaceIsolationAuditor reports existing image repositories outside of the namespace of their owner,
+// e.g. adopted or provisioned before the namespace prefix was enforced.
+// The audit is done once on start, the reported image repositories are not modified.
+type NamespaceIsolationAuditor struct {
+	Client           client.Client
+	QuayOrganization string
+	RegistryHost     string
+	AdminNamespaces  []string
+}
+
+// Start implements manager.Runnable
+func (a *NamespaceIsolationAuditor) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("NamespaceIsolationAuditor")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	violations, err := a.Audit(ctx)
+	if err != nil {
+		log.Error(err, "failed to audit image repositories namespaces")
+		return nil
+	}
+	log.Info("Image repositories namespaces audited", "Violations", violations)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so the audit is reported only once.
+func (a *NamespaceIsolationAuditor) NeedLeaderElection() bool {
+	return true
+}
+
+// Audit logs all ImageRepositories and legacy Components that manage image repository of other namespace.
+// Returns number of found violations.
+func (a *NamespaceIsolationAuditor) Audit(ctx context.Context) (int, error) {
+	log := ctrllog.FromContext(ctx)
+	violations := 0
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := a.Client.List(ctx, imageRepositoryList); err != nil {
+		log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		return 0, err
+	}
+	for _, imageRepository := range imageRepositoryList.Items {
+		imageRepositoryName, found := getImageRepositoryNameFromURL(imageRepository.Status.Image.URL, a.RegistryHost, a.QuayOrganization)
+		if !found || isImageRepositoryNameAllowed(imageRepositoryName, imageRepository.Namespace, a.AdminNamespaces) {
+			continue
+		}
+		violations++
+		log.Info("image repository belongs to other namespace", "ImageRepository", imageRepository.Name,
+			"Namespace", imageRepository.Namespace, "Image", imageRepository.Status.Image.URL, l.Audit, "true")
+	}
+
+	componentList := &appstudioredhatcomv1alpha1.ComponentList{}
+	if err := a.Client.List(ctx, componentList); err != nil {
+		log.Error(err, "failed to list components", l.Action, l.ActionView)
+		return 0, err
+	}
+	for _, component := range componentList.Items {
+		imageAnnotation, exists := annotations.Image.Get(&component)
+		if !exists {
+			continue
+		}
+		repositoryInfo := ImageRepositoryStatus{}
+		if err := json.Unmarshal([]byte(imageAnnotation), &repositoryInfo); err != nil {
+			continue
+		}
+		imageRepositoryName, found := getImageRepositoryNameFromURL(repositoryInfo.Image, a.RegistryHost, a.QuayOrganization)
+		if !found || isImageRepositoryNameAllowed(imageRepositoryName, component.Namespace, a.AdminNamespaces) {
+			continue
+		}
+		violations++
+		log.Info("component image repository belongs to other namespace", "ComponentName", component.Name,
+			"Namespace", component.Namespace, "Image", repositoryInfo.Image, l.Audit, "true")
+	}
+	return violations, nil
+}
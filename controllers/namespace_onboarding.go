@@ -0,0 +1,366 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/yaml"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/metrics"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	// AdditionalUsersConfigMapName is name of the ConfigMap in a tenant namespace with Quay users added to the namespace team.
+	AdditionalUsersConfigMapName = "image-controller-additional-users"
+	// AdditionalUsersConfigMapKey is the only key of the additional users ConfigMap, it holds whitespace separated Quay user names.
+	AdditionalUsersConfigMapKey = "quay.io"
+
+	NamespaceOnboardingStateReady  = "ready"
+	NamespaceOnboardingStateFailed = "failed"
+
+	// namespaceOnboardingRetryInterval is how often invalid additional users ConfigMap is checked again,
+	// ConfigMaps are not watched, as it would require caching all ConfigMaps in the cluster.
+	namespaceOnboardingRetryInterval = 5 * time.Minute
+)
+
+var quayUserNameRegexp = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+
+// NamespaceOnboardingConfig is the content of the file set by --namespace-onboarding-config flag.
+type NamespaceOnboardingConfig struct {
+	// NamespaceSelector selects the tenant namespaces to bootstrap, it must not be empty.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	// ImageRepositories are created in each onboarded namespace.
+	ImageRepositories []ImageRepositoryTemplate `json:"imageRepositories,omitempty"`
+}
+
+// ImageRepositoryTemplate is an ImageRepository pre-created in onboarded namespaces.
+type ImageRepositoryTemplate struct {
+	// Name is the name of the ImageRepository object.
+	Name string `json:"name"`
+	// ImageName is spec.image.name of the ImageRepository, the object name is used if empty.
+	ImageName string `json:"imageName,omitempty"`
+	// Visibility is spec.image.visibility of the ImageRepository, the controller default is used if empty.
+	Visibility imagerepositoryv1alpha1.ImageVisibility `json:"visibility,omitempty"`
+}
+
+// NamespaceOnboardingStatus is the value of the onboarding status annotation of an onboarded Namespace.
+type NamespaceOnboardingStatus struct {
+	State string `json:"state"`
+	// Team is the Quay team of the namespace.
+	Team string `json:"team,omitempty"`
+	// ImageRepositories are names of ImageRepositories created from the templates.
+	// An ImageRepository is created only once, so it's not recreated once deleted by the tenant.
+	ImageRepositories []string `json:"imageRepositories,omitempty"`
+	Message           string   `json:"message,omitempty"`
+}
+
+// LoadNamespaceOnboardingConfig reads and validates namespace onboarding config file.
+func LoadNamespaceOnboardingConfig(configPath string) (*NamespaceOnboardingConfig, error) {
+	/* #nosec the path is set by the controller administrator */
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace onboarding config: %w", err)
+	}
+
+	config := &NamespaceOnboardingConfig{}
+	if err := yaml.UnmarshalStrict(configContent, config); err != nil {
+		return nil, fmt.Errorf("failed to parse namespace onboarding config: %w", err)
+	}
+
+	if len(config.NamespaceSelector.MatchLabels) == 0 && len(config.NamespaceSelector.MatchExpressions) == 0 {
+		return nil, fmt.Errorf("namespace selector must not be empty")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(&config.NamespaceSelector); err != nil {
+		return nil, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	names := map[string]bool{}
+	for i, template := range config.ImageRepositories {
+		if errs := validation.IsDNS1123Subdomain(template.Name); len(errs) > 0 {
+			return nil, fmt.Errorf("image repository template %d has invalid name %q: %s", i, template.Name, strings.Join(errs, ", "))
+		}
+		if names[template.Name] {
+			return nil, fmt.Errorf("image repository template %d has duplicated name %q", i, template.Name)
+		}
+		names[template.Name] = true
+		switch template.Visibility {
+		case "", imagerepositoryv1alpha1.ImageVisibilityPublic, imagerepositoryv1alpha1.ImageVisibilityPrivate:
+		default:
+			return nil, fmt.Errorf("image repository template %d has invalid visibility %q, must be public or private", i, template.Visibility)
+		}
+	}
+	return config, nil
+}
+
+// getNamespaceTeamName returns name of the Quay team of the namespace.
+// Quay team names may contain only lowercase letters and digits, so dashes are dropped
+// and a short hash of the namespace name is appended, as different namespaces might drop to the same name, e.g. a-b and ab.
+func getNamespaceTeamName(namespace string) string {
+	namespaceHash := sha256.Sum256([]byte(namespace))
+	return strings.ReplaceAll(namespace, "-", "") + "team" + hex.EncodeToString(namespaceHash[:])[:8]
+}
+
+func getNamespaceTeamDescription(namespace string) string {
+	return fmt.Sprintf("Team of %s namespace", namespace)
+}
+
+// NamespaceOnboardingReconciler bootstraps new tenant namespaces, so a tenant doesn't need to request each piece separately:
+// it creates the Quay team of the namespace with users from the additional users ConfigMap
+// and the configured default ImageRepositories. The result is reported in the onboarding status annotation.
+type NamespaceOnboardingReconciler struct {
+	Client client.Client
+
+	BuildQuayClient  func(logr.Logger) quay.QuayService
+	QuayOrganization string
+	Config           *NamespaceOnboardingConfig
+}
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups=appstudio.redhat.com,resources=imagerepositories,verbs=get;create
+
+// SetupWithManager sets up the controller with the Manager, only namespaces matching the selector are reconciled.
+func (r *NamespaceOnboardingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	selector, err := metav1.LabelSelectorAsSelector(&r.Config.NamespaceSelector)
+	if err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("namespaceonboarding").
+		For(&corev1.Namespace{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return selector.Matches(labels.Set(obj.GetLabels()))
+		}))).
+		Complete(r)
+}
+
+func (r *NamespaceOnboardingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx).WithName("NamespaceOnboarding")
+	ctx, log = withCorrelationID(ctx, log)
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	namespace := &corev1.Namespace{}
+	if err := r.Client.Get(ctx, req.NamespacedName, namespace); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		log.Error(err, "failed to get namespace", l.Action, l.ActionView)
+		return ctrl.Result{}, err
+	}
+	if !namespace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	status := &NamespaceOnboardingStatus{}
+	if statusAnnotation, exists := annotations.OnboardingStatus.Get(namespace); exists {
+		if err := json.Unmarshal([]byte(statusAnnotation), status); err != nil {
+			// The annotation was edited manually, start over
+			status = &NamespaceOnboardingStatus{}
+		}
+	}
+	if status.State == NamespaceOnboardingStateReady {
+		// The bootstrap is done once, removing the status annotation runs it again
+		return ctrl.Result{}, nil
+	}
+	originalStatus := *status
+	originalStatus.ImageRepositories = slices.Clone(status.ImageRepositories)
+
+	result, onboardingErr := r.onboardNamespace(ctx, namespace, status)
+	if onboardingErr != nil || status.Message != "" {
+		status.State = NamespaceOnboardingStateFailed
+	} else {
+		status.State = NamespaceOnboardingStateReady
+	}
+	if status.State != originalStatus.State || status.Team != originalStatus.Team || status.Message != originalStatus.Message ||
+		!slices.Equal(status.ImageRepositories, originalStatus.ImageRepositories) {
+		if err := r.saveOnboardingStatus(ctx, namespace, status); err != nil {
+			return ctrl.Result{}, err
+		}
+		if status.State == NamespaceOnboardingStateReady {
+			log.Info("Namespace onboarded", "Team", status.Team, "ImageRepositories", status.ImageRepositories, l.Audit, "true")
+		}
+	}
+	return result, onboardingErr
+}
+
+// onboardNamespace does the bootstrap steps and records their result in the status.
+// Failures that need a user action are reported in the status message, other failures are returned.
+func (r *NamespaceOnboardingReconciler) onboardNamespace(ctx context.Context, namespace *corev1.Namespace, status *NamespaceOnboardingStatus) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+	status.Message = ""
+
+	quayClient := r.BuildQuayClient(log)
+	quayClient.SetCorrelationID(getCorrelationID(ctx))
+	quayClient.SetRequestObserver(metrics.QuayRequestObserver("namespaceonboarding", namespace.Name))
+
+	teamName := getNamespaceTeamName(namespace.Name)
+	if status.Team != teamName {
+		// Creation of existing team updates it, so a team not created for the namespace must not be reused,
+		// otherwise the additional users would get access to image repositories of other tenant
+		existingTeam, err := quayClient.GetTeam(r.QuayOrganization, teamName)
+		if err != nil {
+			log.Error(err, "failed to get namespace team", "TeamName", teamName, l.Action, l.ActionView)
+			status.Message = fmt.Sprintf("failed to get Quay team %s: %s", teamName, err.Error())
+			return ctrl.Result{}, err
+		}
+		if existingTeam != nil && existingTeam.Description != getNamespaceTeamDescription(namespace.Name) {
+			log.Info("Quay team of the namespace already exists and is not owned by the namespace", "TeamName", teamName, "Description", existingTeam.Description, l.Audit, "true")
+			status.Message = fmt.Sprintf("Quay team %s already exists and doesn't belong to %s namespace", teamName, namespace.Name)
+			return ctrl.Result{RequeueAfter: namespaceOnboardingRetryInterval}, nil
+		}
+		if err := quayClient.CreateTeam(r.QuayOrganization, teamName, getNamespaceTeamDescription(namespace.Name)); err != nil {
+			log.Error(err, "failed to create namespace team", "TeamName", teamName, l.Action, l.ActionAdd)
+			status.Message = fmt.Sprintf("failed to create Quay team %s: %s", teamName, err.Error())
+			return ctrl.Result{}, err
+		}
+		log.Info("Created namespace team", "TeamName", teamName, l.Action, l.ActionAdd, l.Audit, "true")
+		status.Team = teamName
+	}
+
+	users, invalidMessage, err := r.getAdditionalUsers(ctx, namespace.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if invalidMessage != "" {
+		log.Info("invalid additional users ConfigMap", "Reason", invalidMessage)
+		status.Message = invalidMessage
+		return ctrl.Result{RequeueAfter: namespaceOnboardingRetryInterval}, nil
+	}
+	for _, user := range users {
+		if err := quayClient.AddTeamMember(r.QuayOrganization, teamName, user); err != nil {
+			log.Error(err, "failed to add user to namespace team", "TeamName", teamName, "UserName", user, l.Action, l.ActionUpdate)
+			status.Message = fmt.Sprintf("failed to add user %s to Quay team %s: %s", user, teamName, err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
+	for _, template := range r.Config.ImageRepositories {
+		if slices.Contains(status.ImageRepositories, template.Name) {
+			continue
+		}
+		if err := r.createTemplateImageRepository(ctx, namespace.Name, template); err != nil {
+			status.Message = fmt.Sprintf("failed to create ImageRepository %s: %s", template.Name, err.Error())
+			return ctrl.Result{}, err
+		}
+		status.ImageRepositories = append(status.ImageRepositories, template.Name)
+	}
+	return ctrl.Result{}, nil
+}
+
+// getAdditionalUsers reads Quay user names from the additional users ConfigMap of the namespace.
+// Returns message describing why the ConfigMap is invalid, if it is.
+func (r *NamespaceOnboardingReconciler) getAdditionalUsers(ctx context.Context, namespace string) ([]string, string, error) {
+	log := ctrllog.FromContext(ctx)
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: AdditionalUsersConfigMapName}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, "", nil
+		}
+		log.Error(err, "failed to get additional users ConfigMap", l.Action, l.ActionView)
+		return nil, "", err
+	}
+	return validateAdditionalUsers(configMap)
+}
+
+// validateAdditionalUsers checks the additional users ConfigMap schema and returns the listed Quay user names.
+func validateAdditionalUsers(configMap *corev1.ConfigMap) ([]string, string, error) {
+	if len(configMap.BinaryData) > 0 {
+		return nil, fmt.Sprintf("ConfigMap %s must not have binary data", configMap.Name), nil
+	}
+	for key := range configMap.Data {
+		if key != AdditionalUsersConfigMapKey {
+			return nil, fmt.Sprintf("ConfigMap %s has unknown key %s, only %s key is allowed", configMap.Name, key, AdditionalUsersConfigMapKey), nil
+		}
+	}
+	users := strings.Fields(configMap.Data[AdditionalUsersConfigMapKey])
+	for _, user := range users {
+		if len(user) > 255 || !quayUserNameRegexp.MatchString(user) {
+			return nil, fmt.Sprintf("ConfigMap %s has invalid Quay user name %q", configMap.Name, user), nil
+		}
+	}
+	return users, "", nil
+}
+
+// createTemplateImageRepository creates the ImageRepository of the template, an existing ImageRepository is kept untouched.
+func (r *NamespaceOnboardingReconciler) createTemplateImageRepository(ctx context.Context, namespace string, template ImageRepositoryTemplate) error {
+	log := ctrllog.FromContext(ctx)
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      template.Name,
+			Namespace: namespace,
+		},
+		Spec: imagerepositoryv1alpha1.ImageRepositorySpec{
+			Image: imagerepositoryv1alpha1.ImageParameters{
+				Name:       template.ImageName,
+				Visibility: template.Visibility,
+			},
+		},
+	}
+	if err := r.Client.Create(ctx, imageRepository); err != nil {
+		if errors.IsAlreadyExists(err) {
+			log.Info("ImageRepository already exists, keeping it", "ImageRepositoryName", template.Name)
+			return nil
+		}
+		log.Error(err, "failed to create ImageRepository from template", "ImageRepositoryName", template.Name, l.Action, l.ActionAdd)
+		return err
+	}
+	log.Info("Created ImageRepository from template", "ImageRepositoryName", template.Name, l.Action, l.ActionAdd, l.Audit, "true")
+	return nil
+}
+
+func (r *NamespaceOnboardingReconciler) saveOnboardingStatus(ctx context.Context, namespace *corev1.Namespace, status *NamespaceOnboardingStatus) error {
+	log := ctrllog.FromContext(ctx)
+
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		log.Error(err, "failed to marshal onboarding status")
+		return err
+	}
+	patch := client.MergeFrom(namespace.DeepCopy())
+	annotations.OnboardingStatus.Set(namespace, string(statusBytes))
+	if err := r.Client.Patch(ctx, namespace, patch); err != nil {
+		log.Error(err, "failed to update namespace onboarding status", l.Action, l.ActionUpdate)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/annotations"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// teamsQuayClient records created teams and their members.
+type teamsQuayClient struct {
+	quay.TestQuayClient
+	existingTeams map[string]*quay.Team
+	teams         []string
+	members       []string
+}
+
+func (c *teamsQuayClient) GetTeam(organization, teamName string) (*quay.Team, error) {
+	return c.existingTeams[teamName], nil
+}
+
+func (c *teamsQuayClient) CreateTeam(organization, teamName, description string) error {
+	c.teams = append(c.teams, teamName)
+	return nil
+}
+
+func (c *teamsQuayClient) AddTeamMember(organization, teamName, memberName string) error {
+	c.members = append(c.members, teamName+"/"+memberName)
+	return nil
+}
+
+func TestLoadNamespaceOnboardingConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expectedErr string
+	}{
+		{
+			name:    "should load selector and templates",
+			content: "namespaceSelector:\n  matchLabels:\n    konflux-ci.dev/type: tenant\nimageRepositories:\n- name: default\n  visibility: private\n",
+		},
+		{
+			name:        "should reject empty selector",
+			content:     "imageRepositories:\n- name: default\n",
+			expectedErr: "namespace selector must not be empty",
+		},
+		{
+			name:        "should reject invalid template name",
+			content:     "namespaceSelector:\n  matchLabels:\n    tenant: \"true\"\nimageRepositories:\n- name: Default\n",
+			expectedErr: "invalid name",
+		},
+		{
+			name:        "should reject duplicated template name",
+			content:     "namespaceSelector:\n  matchLabels:\n    tenant: \"true\"\nimageRepositories:\n- name: default\n- name: default\n",
+			expectedErr: "duplicated name",
+		},
+		{
+			name:        "should reject invalid visibility",
+			content:     "namespaceSelector:\n  matchLabels:\n    tenant: \"true\"\nimageRepositories:\n- name: default\n  visibility: internal\n",
+			expectedErr: "invalid visibility",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "onboarding.yaml")
+			if err := os.WriteFile(configPath, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadNamespaceOnboardingConfig(configPath)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(config.ImageRepositories) != 1 || config.ImageRepositories[0].Visibility != imagerepositoryv1alpha1.ImageVisibilityPrivate {
+				t.Errorf("unexpected config %v", config)
+			}
+		})
+	}
+}
+
+func TestNamespaceOnboardingReconcile(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-ns", Labels: map[string]string{"tenant": "true"}}}
+	usersConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: AdditionalUsersConfigMapName, Namespace: "tenant-ns"},
+		Data:       map[string]string{AdditionalUsersConfigMapKey: "user1 User2"},
+	}
+	existingImageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "tenant-ns"},
+		Spec:       imagerepositoryv1alpha1.ImageRepositorySpec{Image: imagerepositoryv1alpha1.ImageParameters{Name: "custom"}},
+	}
+	fakeClient := newTestClientBuilder(scheme).WithObjects(namespace, usersConfigMap, existingImageRepository).Build()
+
+	quayClient := &teamsQuayClient{}
+	r := &NamespaceOnboardingReconciler{
+		Client:           fakeClient,
+		BuildQuayClient:  func(logr.Logger) quay.QuayService { return quayClient },
+		QuayOrganization: quay.TestQuayOrg,
+		Config: &NamespaceOnboardingConfig{
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}},
+			ImageRepositories: []ImageRepositoryTemplate{
+				{Name: "default", Visibility: imagerepositoryv1alpha1.ImageVisibilityPrivate},
+				{Name: "existing", ImageName: "default-name"},
+			},
+		},
+	}
+	ctx := context.TODO()
+	namespaceKey := types.NamespacedName{Name: "tenant-ns"}
+	getStatus := func() *NamespaceOnboardingStatus {
+		if err := fakeClient.Get(ctx, namespaceKey, namespace); err != nil {
+			t.Fatal(err)
+		}
+		statusAnnotation, _ := annotations.OnboardingStatus.Get(namespace)
+		status := &NamespaceOnboardingStatus{}
+		if err := json.Unmarshal([]byte(statusAnnotation), status); err != nil {
+			t.Fatalf("invalid onboarding status annotation %q: %v", statusAnnotation, err)
+		}
+		return status
+	}
+
+	// Invalid additional users are reported and checked again later
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: namespaceKey})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != namespaceOnboardingRetryInterval {
+		t.Errorf("expected recheck of invalid ConfigMap, got %v", result)
+	}
+	status := getStatus()
+	if status.State != NamespaceOnboardingStateFailed || status.Team != "tenantnsteam2f99ea56" || !strings.Contains(status.Message, `"User2"`) {
+		t.Errorf("expected failed onboarding with created team, got %v", status)
+	}
+	if !slices.Equal(quayClient.teams, []string{"tenantnsteam2f99ea56"}) || len(quayClient.members) != 0 {
+		t.Errorf("expected team without members, got %v and %v", quayClient.teams, quayClient.members)
+	}
+
+	usersConfigMap.Data[AdditionalUsersConfigMapKey] = "user1\nuser2"
+	if err := fakeClient.Update(ctx, usersConfigMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: namespaceKey}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status = getStatus()
+	if status.State != NamespaceOnboardingStateReady || status.Message != "" || !slices.Equal(status.ImageRepositories, []string{"default", "existing"}) {
+		t.Errorf("expected ready onboarding, got %v", status)
+	}
+	if len(quayClient.teams) != 1 {
+		t.Errorf("expected team to be created once, got %v", quayClient.teams)
+	}
+	if !slices.Equal(quayClient.members, []string{"tenantnsteam2f99ea56/user1", "tenantnsteam2f99ea56/user2"}) {
+		t.Errorf("expected users added to team, got %v", quayClient.members)
+	}
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-ns", Name: "default"}, imageRepository); err != nil {
+		t.Fatalf("expected image repository from template, got %v", err)
+	}
+	if imageRepository.Spec.Image.Visibility != imagerepositoryv1alpha1.ImageVisibilityPrivate {
+		t.Errorf("expected template visibility, got %s", imageRepository.Spec.Image.Visibility)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-ns", Name: "existing"}, imageRepository); err != nil || imageRepository.Spec.Image.Name != "custom" {
+		t.Errorf("expected existing image repository to be kept, got %v, %v", imageRepository.Spec.Image, err)
+	}
+
+	// Onboarded namespace is not bootstrapped again
+	if err := fakeClient.Delete(ctx, &imagerepositoryv1alpha1.ImageRepository{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "tenant-ns"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: namespaceKey}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quayClient.members) != 2 {
+		t.Errorf("expected no more Quay calls, got %v", quayClient.members)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "tenant-ns", Name: "default"}, imageRepository); err == nil {
+		t.Errorf("expected deleted image repository not to be recreated")
+	}
+}
+
+func TestGetNamespaceTeamName(t *testing.T) {
+	if teamName := getNamespaceTeamName("my-tenant"); teamName != "mytenantteamacf5cd32" {
+		t.Errorf("unexpected team name %s", teamName)
+	}
+	if getNamespaceTeamName("a-b") == getNamespaceTeamName("ab") {
+		t.Errorf("expected different namespaces to have different teams")
+	}
+}
+
+func TestNamespaceOnboardingRejectsTeamOfOtherNamespace(t *testing.T) {
+	scheme := newTestScheme(t)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-ns", Labels: map[string]string{"tenant": "true"}}}
+	usersConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: AdditionalUsersConfigMapName, Namespace: "tenant-ns"},
+		Data:       map[string]string{AdditionalUsersConfigMapKey: "user1"},
+	}
+	fakeClient := newTestClientBuilder(scheme).WithObjects(namespace, usersConfigMap).Build()
+
+	teamName := getNamespaceTeamName("tenant-ns")
+	quayClient := &teamsQuayClient{existingTeams: map[string]*quay.Team{teamName: {Name: teamName, Description: "Team of other-ns namespace"}}}
+	r := &NamespaceOnboardingReconciler{
+		Client:           fakeClient,
+		BuildQuayClient:  func(logr.Logger) quay.QuayService { return quayClient },
+		QuayOrganization: quay.TestQuayOrg,
+		Config:           &NamespaceOnboardingConfig{NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "true"}}},
+	}
+	ctx := context.TODO()
+	namespaceKey := types.NamespacedName{Name: "tenant-ns"}
+
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: namespaceKey})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != namespaceOnboardingRetryInterval {
+		t.Errorf("expected conflict to be checked again, got %v", result)
+	}
+	if len(quayClient.teams) != 0 || len(quayClient.members) != 0 {
+		t.Errorf("expected team of other namespace not to be reused, got %v and %v", quayClient.teams, quayClient.members)
+	}
+	if err := fakeClient.Get(ctx, namespaceKey, namespace); err != nil {
+		t.Fatal(err)
+	}
+	statusAnnotation, _ := annotations.OnboardingStatus.Get(namespace)
+	status := &NamespaceOnboardingStatus{}
+	if err := json.Unmarshal([]byte(statusAnnotation), status); err != nil {
+		t.Fatal(err)
+	}
+	if status.State != NamespaceOnboardingStateFailed || status.Team != "" || !strings.Contains(status.Message, "doesn't belong to tenant-ns namespace") {
+		t.Errorf("expected conflict to be reported, got %v", status)
+	}
+
+	// Team created for the namespace before is reused
+	quayClient.existingTeams[teamName].Description = getNamespaceTeamDescription("tenant-ns")
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: namespaceKey}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(quayClient.teams, []string{teamName}) || !slices.Equal(quayClient.members, []string{teamName + "/user1"}) {
+		t.Errorf("expected team of the namespace to be reused, got %v and %v", quayClient.teams, quayClient.members)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const (
+	prototypeDelegateKindUser = "user"
+	prototypeDelegateKindTeam = "team"
+
+	permissionPrototypesRetryInterval = time.Minute
+)
+
+// PermissionPrototypesConfig is the content of the file set by --permission-prototypes-config flag.
+type PermissionPrototypesConfig struct {
+	Prototypes []PermissionPrototypeConfig `json:"prototypes"`
+}
+
+// PermissionPrototypeConfig is a default permission the Quay organization should grant on new repositories.
+// Exactly one of Robot and Team must be set.
+type PermissionPrototypeConfig struct {
+	// Robot is the short name of the organization robot account, e.g. ci for robot account org+ci.
+	Robot string `json:"robot,omitempty"`
+	// Team is the name of the organization team.
+	Team string `json:"team,omitempty"`
+	// Role is read, write or admin.
+	Role string `json:"role"`
+}
+
+// LoadPermissionPrototypesConfig reads and validates permission prototypes config file.
+func LoadPermissionPrototypesConfig(configPath string) ([]PermissionPrototypeConfig, error) {
+	/* #nosec the path is set by the controller administrator */
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read permission prototypes config: %w", err)
+	}
+
+	config := &PermissionPrototypesConfig{}
+	if err := yaml.UnmarshalStrict(configContent, config); err != nil {
+		return nil, fmt.Errorf("failed to parse permission prototypes config: %w", err)
+	}
+
+	for i, prototype := range config.Prototypes {
+		if (prototype.Robot == "") == (prototype.Team == "") {
+			return nil, fmt.Errorf("permission prototype %d must have exactly one of robot and team", i)
+		}
+		switch prototype.Role {
+		case "read", "write", "admin":
+		default:
+			return nil, fmt.Errorf("permission prototype %d has invalid role %q, must be read, write or admin", i, prototype.Role)
+		}
+	}
+	return config.Prototypes, nil
+}
+
+// delegate returns the Quay delegate the prototype grants the permission to.
+func (p PermissionPrototypeConfig) delegate(quayOrganization string) quay.PrototypeDelegate {
+	if p.Robot != "" {
+		return quay.PrototypeDelegate{Name: quayOrganization + "+" + p.Robot, Kind: prototypeDelegateKindUser}
+	}
+	return quay.PrototypeDelegate{Name: p.Team, Kind: prototypeDelegateKindTeam}
+}
+
+// PermissionPrototypesEnsurer makes sure the Quay organization has the configured permission prototypes,
+// so common grants, e.g. read access of a CI robot account, are applied by Quay to each new repository
+// instead of being added by the controller repository by repository.
+// Prototypes not present in the config are never modified.
+type PermissionPrototypesEnsurer struct {
+	BuildQuayClient  func(logr.Logger) quay.QuayService
+	QuayOrganization string
+	Prototypes       []PermissionPrototypeConfig
+}
+
+// Start implements manager.Runnable, it retries the sync until it succeeds or the manager stops.
+func (e *PermissionPrototypesEnsurer) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("PermissionPrototypesEnsurer")
+	ctx = ctrllog.IntoContext(ctx, log)
+
+	for {
+		if err := e.Ensure(ctx); err == nil {
+			log.Info("Permission prototypes are in sync", "Prototypes", len(e.Prototypes))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(permissionPrototypesRetryInterval):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so only one instance modifies the organization.
+func (e *PermissionPrototypesEnsurer) NeedLeaderElection() bool {
+	return true
+}
+
+// Ensure creates configured permission prototypes missing in the Quay organization.
+// A prototype of the same delegate with a different role is replaced.
+func (e *PermissionPrototypesEnsurer) Ensure(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx)
+	quayClient := e.BuildQuayClient(log)
+
+	existingPrototypes, err := quayClient.ListPermissionPrototypes(e.QuayOrganization)
+	if err != nil {
+		log.Error(err, "failed to list permission prototypes", l.Action, l.ActionView)
+		return err
+	}
+
+	for _, prototype := range e.Prototypes {
+		delegate := prototype.delegate(e.QuayOrganization)
+		prototypeLog := log.WithValues("Delegate", delegate.Name, "Kind", delegate.Kind, "Role", prototype.Role)
+
+		var existing *quay.PermissionPrototype
+		for i := range existingPrototypes {
+			p := &existingPrototypes[i]
+			if p.ActivatingUser == nil && p.Delegate.Kind == delegate.Kind && p.Delegate.Name == delegate.Name {
+				existing = p
+				break
+			}
+		}
+		if existing != nil && existing.Role == prototype.Role {
+			continue
+		}
+		if existing != nil {
+			if _, err := quayClient.DeletePermissionPrototype(e.QuayOrganization, existing.ID); err != nil {
+				prototypeLog.Error(err, "failed to delete permission prototype", "PrototypeID", existing.ID, l.Action, l.ActionDelete)
+				return err
+			}
+			prototypeLog.Info("Deleted permission prototype with outdated role", "PrototypeID", existing.ID, "PreviousRole", existing.Role, l.Action, l.ActionDelete, l.Audit, "true")
+		}
+
+		if _, err := quayClient.CreatePermissionPrototype(e.QuayOrganization, quay.PermissionPrototype{Role: prototype.Role, Delegate: delegate}); err != nil {
+			prototypeLog.Error(err, "failed to create permission prototype", l.Action, l.ActionAdd)
+			return err
+		}
+		prototypeLog.Info("Created permission prototype", l.Action, l.ActionAdd, l.Audit, "true")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// prototypesQuayClient keeps permission prototypes of the organization in memory.
+type prototypesQuayClient struct {
+	quay.TestQuayClient
+	prototypes []quay.PermissionPrototype
+	deleted    []string
+}
+
+func (c *prototypesQuayClient) ListPermissionPrototypes(organization string) ([]quay.PermissionPrototype, error) {
+	return c.prototypes, nil
+}
+
+func (c *prototypesQuayClient) CreatePermissionPrototype(organization string, prototype quay.PermissionPrototype) (*quay.PermissionPrototype, error) {
+	c.prototypes = append(c.prototypes, prototype)
+	return &prototype, nil
+}
+
+func (c *prototypesQuayClient) DeletePermissionPrototype(organization, prototypeID string) (bool, error) {
+	c.deleted = append(c.deleted, prototypeID)
+	return true, nil
+}
+
+func TestPermissionPrototypesEnsurerEnsure(t *testing.T) {
+	quayClient := &prototypesQuayClient{
+		prototypes: []quay.PermissionPrototype{
+			{ID: "in-sync", Role: "read", Delegate: quay.PrototypeDelegate{Name: quay.TestQuayOrg + "+ci", Kind: "user", IsRobot: true}},
+			{ID: "outdated", Role: "read", Delegate: quay.PrototypeDelegate{Name: "deployers", Kind: "team"}},
+			{ID: "unmanaged", Role: "admin", Delegate: quay.PrototypeDelegate{Name: "owners", Kind: "team"}},
+		},
+	}
+	e := &PermissionPrototypesEnsurer{
+		BuildQuayClient:  func(logr.Logger) quay.QuayService { return quayClient },
+		QuayOrganization: quay.TestQuayOrg,
+		Prototypes: []PermissionPrototypeConfig{
+			{Robot: "ci", Role: "read"},
+			{Team: "deployers", Role: "write"},
+			{Robot: "scanner", Role: "read"},
+		},
+	}
+
+	if err := e.Ensure(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(quayClient.deleted, []string{"outdated"}) {
+		t.Errorf("expected only outdated prototype to be deleted, got %v", quayClient.deleted)
+	}
+	created := quayClient.prototypes[3:]
+	expectedCreated := []quay.PermissionPrototype{
+		{Role: "write", Delegate: quay.PrototypeDelegate{Name: "deployers", Kind: "team"}},
+		{Role: "read", Delegate: quay.PrototypeDelegate{Name: quay.TestQuayOrg + "+scanner", Kind: "user"}},
+	}
+	if !reflect.DeepEqual(created, expectedCreated) {
+		t.Errorf("expected created prototypes %v, got %v", expectedCreated, created)
+	}
+}
+
+func TestLoadPermissionPrototypesConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    []PermissionPrototypeConfig
+		expectedErr bool
+	}{
+		{
+			name:     "should load robot and team prototypes",
+			content:  "prototypes:\n- robot: ci\n  role: read\n- team: deployers\n  role: write\n",
+			expected: []PermissionPrototypeConfig{{Robot: "ci", Role: "read"}, {Team: "deployers", Role: "write"}},
+		},
+		{
+			name:        "should reject prototype with both robot and team",
+			content:     "prototypes:\n- robot: ci\n  team: deployers\n  role: read\n",
+			expectedErr: true,
+		},
+		{
+			name:        "should reject invalid role",
+			content:     "prototypes:\n- robot: ci\n  role: owner\n",
+			expectedErr: true,
+		},
+		{
+			name:        "should reject unknown field",
+			content:     "prototypes:\n- user: someone\n  role: read\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "prototypes.yaml")
+			if err := os.WriteFile(configPath, []byte(tc.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			prototypes, err := LoadPermissionPrototypesConfig(configPath)
+			if tc.expectedErr {
+				if err == nil {
+					t.Errorf("expected error, got prototypes %v", prototypes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(prototypes, tc.expected) {
+				t.Errorf("expected prototypes %v, got %v", tc.expected, prototypes)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRegistryHost is the registry hostname used in generated secrets and image URLs if not configured.
+const DefaultRegistryHost = "quay.io"
+
+// ValidateRegistryHost checks the registry hostname, optionally with port, e.g. quay.internal.example.com:8443 or [fd00::1]:5000.
+// Docker config matches registries by host, so scheme and path are not allowed.
+func ValidateRegistryHost(registryHost string) error {
+	if registryHost == "" || strings.Contains(registryHost, "/") || strings.ContainsAny(registryHost, " \t") {
+		return fmt.Errorf("invalid registry host %q, hostname with optional port expected", registryHost)
+	}
+	return nil
+}
+
+// getImageURL returns URL of the image repository as referenced in generated secrets and status.
+func getImageURL(registryHost, organization, imageRepositoryName string) string {
+	if registryHost == "" {
+		registryHost = DefaultRegistryHost
+	}
+	return fmt.Sprintf("%s/%s/%s", registryHost, organization, imageRepositoryName)
+}
+
+// getImageRepositoryNameFromURL returns name of the image repository of the organization referenced by the image URL,
+// false if the image belongs to other registry or organization.
+// URLs with the default registry host are accepted too, so image repositories provisioned before the host was configured are recognized.
+func getImageRepositoryNameFromURL(imageURL, registryHost, organization string) (string, bool) {
+	for _, host := range []string{registryHost, DefaultRegistryHost} {
+		if host == "" {
+			continue
+		}
+		if imageRepositoryName, found := strings.CutPrefix(imageURL, host+"/"+organization+"/"); found {
+			return imageRepositoryName, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	repositoryDescriptionNamespacePlaceholder   = "{namespace}"
+	repositoryDescriptionNamePlaceholder        = "{name}"
+	repositoryDescriptionComponentPlaceholder   = "{component}"
+	repositoryDescriptionApplicationPlaceholder = "{application}"
+	repositoryDescriptionClusterPlaceholder     = "{cluster}"
+)
+
+// RepositoryDescriptionTemplate defines the description of image repositories that don't request one,
+// so Quay admins could find owners of an image repository directly from its description.
+// Empty template means the static imageRepositoryDescription.
+type RepositoryDescriptionTemplate string
+
+// NewRepositoryDescriptionTemplate returns validated description template.
+func NewRepositoryDescriptionTemplate(template string) (RepositoryDescriptionTemplate, error) {
+	for _, placeholder := range repositoryPathPlaceholderRegexp.FindAllString(template, -1) {
+		switch placeholder {
+		case repositoryDescriptionNamespacePlaceholder, repositoryDescriptionNamePlaceholder, repositoryDescriptionComponentPlaceholder,
+			repositoryDescriptionApplicationPlaceholder, repositoryDescriptionClusterPlaceholder:
+		default:
+			return "", fmt.Errorf("unknown placeholder %s in image repository description template %q", placeholder, template)
+		}
+	}
+	return RepositoryDescriptionTemplate(strings.TrimSpace(template)), nil
+}
+
+// Render returns image repository description for the given object.
+// Placeholders of missing values, e.g. component of ImageRepository not linked to a Component, are replaced by empty string.
+func (t RepositoryDescriptionTemplate) Render(namespace, name, component, application, cluster string) string {
+	if t == "" {
+		return imageRepositoryDescription
+	}
+	return strings.NewReplacer(
+		repositoryDescriptionNamespacePlaceholder, namespace,
+		repositoryDescriptionNamePlaceholder, name,
+		repositoryDescriptionComponentPlaceholder, component,
+		repositoryDescriptionApplicationPlaceholder, application,
+		repositoryDescriptionClusterPlaceholder, cluster,
+	).Replace(string(t))
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// RepositoryPathStrategyNamespace generates <namespace>/<name> image repository names.
+	RepositoryPathStrategyNamespace = "namespace"
+	// RepositoryPathStrategyNamespaceApplication generates <namespace>/<application>/<name> image repository names,
+	// the application segment is omitted if the image repository doesn't belong to an application.
+	RepositoryPathStrategyNamespaceApplication = "namespace-application"
+	// RepositoryPathStrategyCustom generates image repository names from a custom template.
+	RepositoryPathStrategyCustom = "custom"
+
+	repositoryPathNamespacePlaceholder   = "{namespace}"
+	repositoryPathApplicationPlaceholder = "{application}"
+	repositoryPathNamePlaceholder        = "{name}"
+
+	defaultRepositoryPathTemplate RepositoryPathTemplate = repositoryPathNamespacePlaceholder + "/" + repositoryPathApplicationPlaceholder + "/" + repositoryPathNamePlaceholder
+)
+
+var repositoryPathPlaceholderRegexp = regexp.MustCompile(`\{[^}]*\}`)
+
+// RepositoryPathTemplate defines layout of image repository names generated for objects that don't request a name.
+// The template always starts with the namespace, so image repositories of different namespaces cannot collide.
+// Already provisioned image repositories keep their names, the template is used only for new ones.
+type RepositoryPathTemplate string
+
+// NewRepositoryPathTemplate returns template for the given strategy.
+// The custom template is used only with the custom strategy.
+func NewRepositoryPathTemplate(strategy, customTemplate string) (RepositoryPathTemplate, error) {
+	switch strategy {
+	case RepositoryPathStrategyNamespace:
+		return repositoryPathNamespacePlaceholder + "/" + repositoryPathNamePlaceholder, nil
+	case RepositoryPathStrategyNamespaceApplication:
+		return defaultRepositoryPathTemplate, nil
+	case RepositoryPathStrategyCustom:
+		template := RepositoryPathTemplate(customTemplate)
+		if err := template.validate(); err != nil {
+			return "", err
+		}
+		return template, nil
+	}
+	return "", fmt.Errorf("unknown image repository path strategy %q, allowed values are %s, %s and %s",
+		strategy, RepositoryPathStrategyNamespace, RepositoryPathStrategyNamespaceApplication, RepositoryPathStrategyCustom)
+}
+
+func (t RepositoryPathTemplate) validate() error {
+	template := string(t)
+	if !strings.HasPrefix(template, repositoryPathNamespacePlaceholder+"/") {
+		return fmt.Errorf("image repository path template %q must start with %s/", template, repositoryPathNamespacePlaceholder)
+	}
+	if !strings.Contains(template, repositoryPathNamePlaceholder) {
+		return fmt.Errorf("image repository path template %q must contain %s", template, repositoryPathNamePlaceholder)
+	}
+	for _, placeholder := range repositoryPathPlaceholderRegexp.FindAllString(template, -1) {
+		if placeholder != repositoryPathNamespacePlaceholder && placeholder != repositoryPathApplicationPlaceholder && placeholder != repositoryPathNamePlaceholder {
+			return fmt.Errorf("unknown placeholder %s in image repository path template %q", placeholder, template)
+		}
+	}
+	if sample := t.Render("namespace", "application", "name"); !imageRepositoryNameRegexp.MatchString(sample) {
+		return fmt.Errorf("image repository path template %q generates invalid name %s", template, sample)
+	}
+	return nil
+}
+
+// Render returns image repository name for the given object.
+// Empty path segments, e.g. when the object doesn't belong to an application, are dropped.
+func (t RepositoryPathTemplate) Render(namespace, application, name string) string {
+	if t == "" {
+		t = defaultRepositoryPathTemplate
+	}
+	path := strings.NewReplacer(
+		repositoryPathNamespacePlaceholder, namespace,
+		repositoryPathApplicationPlaceholder, application,
+		repositoryPathNamePlaceholder, name,
+	).Replace(string(t))
+
+	segments := []string{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReservedRepositoryNames lists names that cannot be claimed by tenants, e.g. "openshift" or "redhat".
+// Entries are regular expressions, e.g. "internal-.*", matched against whole segments of the image repository name.
+// The leading namespace segment is not checked, as the namespace is already owned by the tenant.
+type ReservedRepositoryNames struct {
+	entries []string
+	regexps []*regexp.Regexp
+}
+
+// NewReservedRepositoryNames parses the deny-list entries.
+func NewReservedRepositoryNames(entries []string) (*ReservedRepositoryNames, error) {
+	reservedNames := &ReservedRepositoryNames{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		entryRegexp, err := regexp.Compile("^(?:" + entry + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid reserved image repository name %s: %w", entry, err)
+		}
+		reservedNames.entries = append(reservedNames.entries, entry)
+		reservedNames.regexps = append(reservedNames.regexps, entryRegexp)
+	}
+	return reservedNames, nil
+}
+
+// Check returns error if any segment of the image repository name, except the namespace, is reserved.
+func (n *ReservedRepositoryNames) Check(namespace, imageRepositoryName string) error {
+	if n == nil {
+		return nil
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(imageRepositoryName, namespace+"/"), "/") {
+		for i, entryRegexp := range n.regexps {
+			if entryRegexp.MatchString(segment) {
+				return fmt.Errorf("image repository name %s is reserved, %s matches reserved name %s", imageRepositoryName, segment, n.entries[i])
+			}
+		}
+	}
+	return nil
+}
@@ -58,6 +58,7 @@ type imageRepositoryConfig struct {
 	Labels        map[string]string
 	Annotations   map[string]string
 	Notifications []imagerepositoryv1alpha1.Notifications
+	Credentials   *imagerepositoryv1alpha1.ImageCredentials
 }
 
 func getImageRepositoryConfig(config imageRepositoryConfig) *imagerepositoryv1alpha1.ImageRepository {
@@ -91,6 +92,7 @@ func getImageRepositoryConfig(config imageRepositoryConfig) *imagerepositoryv1al
 				Visibility: imagerepositoryv1alpha1.ImageVisibility(visibility),
 			},
 			Notifications: config.Notifications,
+			Credentials:   config.Credentials,
 		},
 	}
 }
@@ -297,13 +299,18 @@ func waitImageRepositoryFinalizerOnImageRepository(imageRepositoryKey types.Name
 }
 
 func createNamespace(name string) {
+	createNamespaceWithAnnotations(name, nil)
+}
+
+func createNamespaceWithAnnotations(name string, annotations map[string]string) {
 	namespace := corev1.Namespace{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Namespace",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: name,
+			Name:        name,
+			Annotations: annotations,
 		},
 	}
 
@@ -321,6 +328,12 @@ func waitSecretExist(secretKey types.NamespacedName) *corev1.Secret {
 	return secret
 }
 
+func waitSecretGone(secretKey types.NamespacedName) {
+	Eventually(func() bool {
+		return k8sErrors.IsNotFound(k8sClient.Get(ctx, secretKey, &corev1.Secret{}))
+	}, timeout, interval).Should(BeTrue())
+}
+
 func deleteSecret(resourceKey types.NamespacedName) {
 	secret := &corev1.Secret{}
 	if err := k8sClient.Get(ctx, resourceKey, secret); err != nil {
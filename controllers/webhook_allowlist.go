@@ -0,0 +1,139 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"syscall"
+)
+
+// WebhookAllowlist restricts targets of webhook notifications, so image events cannot be sent to arbitrary hosts.
+// Entries are domains, e.g. "hooks.example.com", domains with subdomains, e.g. "*.example.com",
+// or CIDRs, e.g. "10.0.0.0/8". CIDRs are matched only against URLs with IP address host, hostnames are not resolved.
+type WebhookAllowlist struct {
+	domains         []string
+	wildcardDomains []string
+	networks        []*net.IPNet
+}
+
+// NewWebhookAllowlist parses the allowlist entries.
+func NewWebhookAllowlist(entries []string) (*WebhookAllowlist, error) {
+	allowlist := &WebhookAllowlist{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %s in webhook allowlist: %w", entry, err)
+			}
+			allowlist.networks = append(allowlist.networks, network)
+		case strings.HasPrefix(entry, "*."):
+			allowlist.wildcardDomains = append(allowlist.wildcardDomains, strings.TrimPrefix(entry, "*"))
+		case strings.Contains(entry, "*"):
+			return nil, fmt.Errorf("invalid domain %s in webhook allowlist, only leading wildcard is supported", entry)
+		default:
+			allowlist.domains = append(allowlist.domains, strings.TrimSuffix(entry, "."))
+		}
+	}
+	return allowlist, nil
+}
+
+// Check returns error if the webhook URL is malformed or its host is not allowed.
+func (a *WebhookAllowlist) Check(rawURL string) error {
+	host, err := getWebhookHost(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range a.networks {
+			if network.Contains(ip) {
+				return nil
+			}
+		}
+		return fmt.Errorf("webhook target %s is not in the allowlist", host)
+	}
+	for _, domain := range a.domains {
+		if host == domain {
+			return nil
+		}
+	}
+	for _, wildcardDomain := range a.wildcardDomains {
+		if strings.HasSuffix(host, wildcardDomain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook target %s is not in the allowlist", host)
+}
+
+// getWebhookHost returns normalized host of the webhook URL, i.e. lowercased and without trailing dot.
+// Only absolute http(s) URLs without user information are accepted.
+func getWebhookHost(rawURL string) (string, error) {
+	url, err := neturl.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook url: %w", err)
+	}
+	scheme := strings.ToLower(url.Scheme)
+	if scheme != "https" && scheme != "http" {
+		return "", fmt.Errorf("invalid webhook url, only http and https schemes are allowed")
+	}
+	if url.User != nil {
+		return "", fmt.Errorf("invalid webhook url, user information is not allowed")
+	}
+	host := strings.TrimSuffix(strings.ToLower(url.Hostname()), ".")
+	if host == "" {
+		return "", fmt.Errorf("invalid webhook url, host is missing")
+	}
+	return host, nil
+}
+
+// checkWebhookValidationAddress rejects addresses the webhook targets validation must not connect to:
+// loopback, link-local, e.g. cloud metadata endpoints, unspecified and multicast ones,
+// and also private networks, unless allowPrivateNetworks is set.
+func checkWebhookValidationAddress(ip net.IP, allowPrivateNetworks bool) error {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook target address %s is not allowed", ip)
+	}
+	if ip.IsPrivate() && !allowPrivateNetworks {
+		return fmt.Errorf("webhook target address %s is in a private network", ip)
+	}
+	return nil
+}
+
+// WebhookValidationDialControl returns net.Dialer Control function for the webhook targets validation client.
+// The address is checked right before connecting, after the host is resolved, so that a hostname
+// resolving to a cluster internal address cannot be used to probe the cluster network.
+func WebhookValidationDialControl(allowPrivateNetworks bool) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return fmt.Errorf("webhook target address %s is not an IP address", host)
+		}
+		return checkWebhookValidationAddress(ip, allowPrivateNetworks)
+	}
+}
@@ -17,11 +17,15 @@ limitations under the License.
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -47,6 +51,8 @@ import (
 
 	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
 	"github.com/konflux-ci/image-controller/controllers"
+	"github.com/konflux-ci/image-controller/pkg/admin"
+	"github.com/konflux-ci/image-controller/pkg/features"
 	"github.com/konflux-ci/image-controller/pkg/quay"
 	//+kubebuilder:scaffold:imports
 )
@@ -55,6 +61,9 @@ const (
 	/* #nosec it's the path to the token, not the token itself */
 	quayTokenPath string = "/workspace/quaytoken"
 	quayOrgPath   string = "/workspace/organization"
+
+	// Shortened image repository names must keep the namespace prefix, which is up to 63 characters long
+	minImageRepositoryNameLength = 128
 )
 
 var (
@@ -73,11 +82,207 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var dryRunGlobal bool
+	var pauseRepositoryDeletion bool
+	var strictSecretUnlink bool
+	var pullSecretExportLabels string
+	var pullSecretExportAnnotations string
+	var adminEndpointTokenPath string
+	var pushNotificationsTokenPath string
+	var repositoryTokensNamespace string
+	var maxImageRepositoryNameLength int
+	var shortenLongImageRepositoryNames bool
+	var imageRepositoryPathStrategy string
+	var imageRepositoryPathTemplate string
+	var imageRepositoryDescriptionTemplate string
+	var clusterID string
+	var availabilityProbesConfigPath string
+	var availabilityProbeInterval time.Duration
+	var quayProbeURL string
+	var quayProbeMode string
+	var quayProbeRobotAccount string
+	var quayProbeTokenPath string
+	var maxProvisionAttempts int
+	var provisionTimeout time.Duration
+	var quayRequestTimeout time.Duration
+	var shutdownDrainTimeout time.Duration
+	var credentialsRotationWarningAge time.Duration
+	var credentialsMaxAge time.Duration
+	var defaultVisibility string
+	var waitForPrivateRepositoriesQuota bool
+	var pushSecretLinking string
+	var pullSecretLinking string
+	var validateWebhookNotifications bool
+	var webhookValidationCAPath string
+	var webhookValidationInsecureSkipVerify bool
+	var webhookValidationAllowPrivateNetworks bool
+	var webhookNotificationsAllowlist string
+	var reservedRepositoryNamesList string
+	var provisionFairness bool
+	var provisionFairnessWeights string
+	var maxNotifications int
+	var adminNamespacesList string
+	var visibilityDriftPolicy string
+	var discoverNudgeTargets bool
+	var archiveOrganization string
+	var archiveRobotAccountName string
+	var archiveOnDeletion bool
+	var featureGatesList string
+	var permissionPrototypesConfigPath string
+	var namespaceOnboardingConfigPath string
+	var metricsExemplars bool
+	var quayRequestMetrics bool
+	var quayAPIURL string
+	var registryHost string
+	var pprofBindAddress string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flag.BoolVar(&metricsExemplars, "metrics-exemplars", false,
+		"Attach reconcile ID and object name exemplars to reconcile duration metric. "+
+			"Exemplars are exposed only in OpenMetrics format at "+metrics.OpenMetricsEndpointPath+" endpoint.")
+	flag.BoolVar(&quayRequestMetrics, "quay-request-metrics", false,
+		"Count Quay API requests of reconciles per controller, namespace and operation, e.g. to find objects that exhaust the Quay API budget.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&dryRunGlobal, "dry-run-global", false,
+		"Run the controller in read-only mode. "+
+			"All changes to Quay and to the cluster are only logged and counted, but not applied.")
+	flag.BoolVar(&strictSecretUnlink, "strict-secret-unlink", false,
+		"Before an ImageRepository finalizer is removed, verify bypassing the cache that no service account in the namespace "+
+			"references its push or pull secrets, so pods aren't left with dangling image pull secrets.")
+	flag.BoolVar(&pauseRepositoryDeletion, "pause-repository-deletion", false,
+		"Keep image repositories in Quay when ImageRepository objects or Components are deleted, "+
+			"e.g. during Quay organization migration. Robot accounts and secrets are still deleted.")
+	flag.StringVar(&pullSecretExportLabels, "pull-secret-export-labels", "",
+		"Comma separated list of key=value labels to add to generated pull secrets, "+
+			"e.g. to export the secrets into remote clusters.")
+	flag.StringVar(&pullSecretExportAnnotations, "pull-secret-export-annotations", "",
+		"Comma separated list of key=value annotations to add to generated pull secrets, "+
+			"e.g. to export the secrets into remote clusters.")
+	flag.StringVar(&adminEndpointTokenPath, "admin-endpoint-token-path", "",
+		"Path to a file with bearer token for the admin endpoint that lists all managed image repositories. "+
+			"The endpoint is served alongside metrics at "+admin.ImageRepositoriesEndpointPath+" only if the token is set.")
+	flag.StringVar(&pushNotificationsTokenPath, "push-notifications-token-path", "",
+		"Path to a file with token for the endpoint receiving repo_push notifications of Quay, passed in token query parameter. "+
+			"The endpoint is served alongside metrics at "+controllers.PushNotificationsEndpointPath+" only if the token is set, "+
+			"then the latest tag is looked up on push instead of periodically.")
+	flag.StringVar(&pprofBindAddress, "pprof-bind-address", "",
+		"The loopback address the runtime profiles endpoint binds to, e.g. 127.0.0.1:8082. "+
+			"The profiles are served at "+admin.PprofEndpointPath+" without authentication, so only loopback addresses are allowed. Disabled if not set.")
+	flag.StringVar(&repositoryTokensNamespace, "repository-tokens-namespace", "",
+		"Namespace with repository scoped Quay tokens. If set, the tokens are used for day-2 operations on the repositories, "+
+			"while the organization token is used only for creation and deletion.")
+	flag.IntVar(&maxImageRepositoryNameLength, "max-image-repository-name-length", controllers.QuayRepositoryNameMaxLength,
+		"Maximum length of image repository names.")
+	flag.BoolVar(&shortenLongImageRepositoryNames, "shorten-long-image-repository-names", true,
+		"Shorten too long image repository names by replacing their end with a hash. "+
+			"If disabled, provision of image repositories with too long names fails.")
+	flag.StringVar(&imageRepositoryPathStrategy, "image-repository-path-strategy", controllers.RepositoryPathStrategyNamespaceApplication,
+		"Layout of generated image repository names: namespace, namespace-application or custom. "+
+			"Already provisioned image repositories keep their names.")
+	flag.StringVar(&imageRepositoryPathTemplate, "image-repository-path-template", "",
+		"Template of generated image repository names for the custom path strategy, "+
+			"e.g. {namespace}/{application}/{name}. It must start with {namespace}/ and contain {name}.")
+	flag.StringVar(&imageRepositoryDescriptionTemplate, "image-repository-description-template", "",
+		"Template of the description of image repositories that don't request one, "+
+			"e.g. 'Component {component} of application {application}, contact owners of namespace {namespace} in cluster {cluster}'. "+
+			"Allowed placeholders are {namespace}, {name}, {component}, {application} and {cluster}.")
+	flag.StringVar(&clusterID, "cluster-id", "",
+		"Identifier of the cluster recorded in created image repositories, "+
+			"so the repositories could be traced back if several clusters share one Quay organization.")
+	flag.StringVar(&availabilityProbesConfigPath, "availability-probes-config", "",
+		"Path to a YAML file with additional availability probes, e.g. for other registries.")
+	flag.DurationVar(&availabilityProbeInterval, "availability-probe-interval", metrics.DefaultProbeInterval,
+		"How often the availability probes are checked.")
+	flag.StringVar(&quayProbeMode, "quay-probe-mode", metrics.QuayProbeModeOrganization,
+		"How the Quay availability probe authenticates: "+metrics.QuayProbeModeOrganization+" (organization token), "+
+			metrics.QuayProbeModeAnonymous+" (no credentials), "+metrics.QuayProbeModeRobot+" (read-only robot account token) or "+
+			metrics.QuayProbeModeFederatedRobot+" (OIDC token exchanged through Quay robot federation).")
+	flag.StringVar(&quayProbeRobotAccount, "quay-probe-robot-account", "",
+		"Full name of the robot account used by the Quay availability probe in robot modes, e.g. my-org+probe.")
+	flag.StringVar(&quayProbeTokenPath, "quay-probe-token-path", "",
+		"Path to the robot account token, or to the OIDC token to exchange, used by the Quay availability probe in robot modes.")
+	flag.StringVar(&quayProbeURL, "quay-probe-url", "",
+		"Endpoint checked by the Quay availability probe, e.g. of a Quay instance behind a proxy. In "+metrics.QuayProbeModeAnonymous+
+			" mode it replaces the Quay API discovery endpoint, in robot modes it replaces the registry URL and in "+
+			metrics.QuayProbeModeOrganization+" mode it replaces the Quay API URL.")
+	flag.IntVar(&maxProvisionAttempts, "max-provision-attempts", 10,
+		"Number of failed image repository provision attempts after which the image repository becomes failed.")
+	flag.DurationVar(&provisionTimeout, "provision-timeout", 0,
+		"Maximum duration of an image repository provision attempt. Each retry after a failed attempt starts a new attempt. Robot accounts and secrets "+
+			"of a timed out attempt are deleted and the provision is retried from scratch. If not set, there is no timeout.")
+	flag.DurationVar(&quayRequestTimeout, "quay-request-timeout", time.Minute,
+		"Maximum duration of a Quay API request, so a hanging request doesn't block the reconcile.")
+	flag.DurationVar(&shutdownDrainTimeout, "shutdown-drain-timeout", 0,
+		"Time given to in-flight reconciles to finish their Quay mutations on controller shutdown. New reconciles are not started meanwhile "+
+			"and interrupted provisions save their progress, so they are resumed after restart. If not set, in-flight reconciles are canceled immediately.")
+	flag.DurationVar(&credentialsRotationWarningAge, "credentials-rotation-warning-age", 0,
+		"Age of image repository credentials after which they are listed in the "+controllers.CredentialsAgeReportConfigMapName+
+			" ConfigMap of the namespace as nearing rotation. If not set, credentials age is not reported.")
+	flag.DurationVar(&credentialsMaxAge, "credentials-max-age", 0,
+		"Age of image repository credentials after which they are reported as expired. Used only with --credentials-rotation-warning-age.")
+	flag.StringVar(&defaultVisibility, "default-visibility", string(imagerepositoryv1alpha1.ImageVisibilityPublic),
+		"Visibility of image repositories that don't request it, public or private.")
+	flag.StringVar(&pushSecretLinking, "push-secret-linking", string(imagerepositoryv1alpha1.PushSecretLinkingAll),
+		"Lists of the build pipeline service account the push secret is linked to: all, secrets or none. Can be overridden per ImageRepository.")
+	flag.StringVar(&pullSecretLinking, "pull-secret-linking", string(imagerepositoryv1alpha1.PullSecretLinkingApplication),
+		"Service accounts the pull secret is linked to: application, all (Application and Component service accounts) or none. Can be overridden per ImageRepository.")
+	flag.BoolVar(&waitForPrivateRepositoriesQuota, "wait-for-private-repositories-quota", false,
+		"Queue private image repositories when Quay organization plan limit is reached, instead of failing the provision.")
+	flag.BoolVar(&validateWebhookNotifications, "validate-webhook-notifications", false,
+		"Check that webhook notification targets are reachable before the notifications are created in Quay.")
+	flag.StringVar(&webhookValidationCAPath, "webhook-validation-ca-path", "",
+		"Path to a PEM file with additional CA certificates trusted when webhook notification targets are validated.")
+	flag.BoolVar(&webhookValidationInsecureSkipVerify, "webhook-validation-insecure-skip-verify", false,
+		"Skip TLS certificate verification when webhook notification targets are validated.")
+	flag.BoolVar(&webhookValidationAllowPrivateNetworks, "webhook-validation-allow-private-networks", false,
+		"Allow webhook notification targets in private networks to be validated, e.g. internal endpoints. "+
+			"Loopback and link-local targets are never validated.")
+	flag.StringVar(&webhookNotificationsAllowlist, "webhook-notifications-allowlist", "",
+		"Comma separated list of domains, e.g. hooks.example.com or *.example.com, and CIDRs allowed as webhook notification targets. "+
+			"If not set, any target is allowed.")
+	flag.BoolVar(&provisionFairness, "provision-fairness", false,
+		"Share image repository provisions among namespaces in round-robin fashion, "+
+			"so a namespace creating many ImageRepository objects doesn't delay provisions of other namespaces.")
+	flag.StringVar(&provisionFairnessWeights, "provision-fairness-weights", "",
+		"Comma separated list of namespace=weight pairs, e.g. bulk-import=5. "+
+			"A namespace gets as many provisions in each round as its weight, 1 by default.")
+	flag.StringVar(&reservedRepositoryNamesList, "reserved-repository-names", "",
+		"Comma separated list of image repository names, e.g. openshift or internal-.*, that cannot be claimed by tenants. "+
+			"Entries are regular expressions matched against whole segments of the name after the namespace.")
+	flag.IntVar(&maxNotifications, "max-notifications", 0,
+		"Maximum number of notifications created in Quay for one image repository. If not set, there is no limit.")
+	flag.StringVar(&adminNamespacesList, "admin-namespaces", "",
+		"Comma separated list of namespaces allowed to manage image repositories of other namespaces. "+
+			"Objects in other namespaces manage only image repositories prefixed with their namespace.")
+	flag.StringVar(&visibilityDriftPolicy, "visibility-drift-policy", "",
+		"How image repository visibility changed directly in Quay is handled: Observe updates the ImageRepository status to match Quay, "+
+			"Enforce reverts the change in Quay. If not set, visibility drift is not detected.")
+	flag.BoolVar(&discoverNudgeTargets, "discover-nudge-targets", false,
+		"Request rebuild also of Components referenced by build-nudges-ref of the Component linked to the image repository, "+
+			"when the image repository url or credentials change.")
+	flag.StringVar(&archiveOrganization, "archive-organization", "",
+		"Quay organization where image repositories are archived on ImageRepository deletion instead of being deleted. "+
+			"Image repositories are archived only if --archive-on-deletion is set or the ImageRepository has archive-on-deletion annotation.")
+	flag.StringVar(&archiveRobotAccountName, "archive-robot-account", "",
+		"Robot account of the archive organization, e.g. archive+mirror, that Quay uses to push tags of archived image repositories.")
+	flag.BoolVar(&archiveOnDeletion, "archive-on-deletion", false,
+		"Archive all image repositories on ImageRepository deletion, unless the ImageRepository has archive-on-deletion annotation set to false.")
+	flag.StringVar(&featureGatesList, "feature-gates", "",
+		"Comma separated list of Feature=true|false pairs that switch features being rolled out, e.g. ManifestLabels=true. "+
+			"Features not in the list keep their default state.")
+	flag.StringVar(&permissionPrototypesConfigPath, "permission-prototypes-config", "",
+		"Path to a YAML file with default permissions, e.g. read access of a CI robot account, "+
+			"that the Quay organization grants on each new repository. Missing ones are created on start.")
+	flag.StringVar(&namespaceOnboardingConfigPath, "namespace-onboarding-config", "",
+		"Path to a YAML file with selector of tenant namespaces and ImageRepository templates. "+
+			"If set, Quay team and default ImageRepositories are created for each selected namespace.")
+	flag.StringVar(&quayAPIURL, "quay-api-url", "https://quay.io/api/v1",
+		"URL of the Quay API, e.g. of a fake Quay started by 'make run-fake-quay' for local development.")
+	flag.StringVar(&registryHost, "registry-host", controllers.DefaultRegistryHost,
+		"Registry hostname, optionally with port, referenced by generated secrets and image URLs in status, "+
+			"e.g. the internal hostname of Quay in air-gapped deployments, where it differs from the Quay API host.")
 
 	zapOpts := zap.Options{
 		TimeEncoder: uberzapcore.ISO8601TimeEncoder,
@@ -91,6 +296,128 @@ func main() {
 	setupLog := ctrl.Log.WithName("setup")
 	klog.SetLogger(setupLog)
 
+	if maxImageRepositoryNameLength < minImageRepositoryNameLength || maxImageRepositoryNameLength > controllers.QuayRepositoryNameMaxLength {
+		setupLog.Error(nil, "invalid max-image-repository-name-length flag",
+			"min", minImageRepositoryNameLength, "max", controllers.QuayRepositoryNameMaxLength)
+		os.Exit(1)
+	}
+
+	if credentialsMaxAge > 0 && credentialsMaxAge <= credentialsRotationWarningAge {
+		setupLog.Error(nil, "invalid credentials-max-age flag, it must be greater than credentials-rotation-warning-age",
+			"credentialsMaxAge", credentialsMaxAge, "credentialsRotationWarningAge", credentialsRotationWarningAge)
+		os.Exit(1)
+	}
+
+	featureGates, err := features.NewFeatureGates(featureGatesList)
+	if err != nil {
+		setupLog.Error(err, "invalid feature-gates flag")
+		os.Exit(1)
+	}
+
+	repositoryPathTemplate, err := controllers.NewRepositoryPathTemplate(imageRepositoryPathStrategy, imageRepositoryPathTemplate)
+	if err != nil {
+		setupLog.Error(err, "invalid image-repository-path-strategy or image-repository-path-template flag")
+		os.Exit(1)
+	}
+
+	repositoryDescriptionTemplate, err := controllers.NewRepositoryDescriptionTemplate(imageRepositoryDescriptionTemplate)
+	if err != nil {
+		setupLog.Error(err, "invalid image-repository-description-template flag")
+		os.Exit(1)
+	}
+
+	if defaultVisibility != string(imagerepositoryv1alpha1.ImageVisibilityPublic) && defaultVisibility != string(imagerepositoryv1alpha1.ImageVisibilityPrivate) {
+		setupLog.Error(nil, "invalid default-visibility flag, allowed values are public and private", "value", defaultVisibility)
+		os.Exit(1)
+	}
+	defaultSecretLinking := imagerepositoryv1alpha1.SecretLinking{
+		PushSecret: imagerepositoryv1alpha1.PushSecretLinking(pushSecretLinking),
+		PullSecret: imagerepositoryv1alpha1.PullSecretLinking(pullSecretLinking),
+	}
+	if err := controllers.ValidateSecretLinking(defaultSecretLinking); err != nil {
+		setupLog.Error(err, "invalid push-secret-linking or pull-secret-linking flag")
+		os.Exit(1)
+	}
+
+	pullSecretLabels, err := parseKeyValueList(pullSecretExportLabels)
+	if err != nil {
+		setupLog.Error(err, "invalid pull-secret-export-labels flag")
+		os.Exit(1)
+	}
+	pullSecretAnnotations, err := parseKeyValueList(pullSecretExportAnnotations)
+	if err != nil {
+		setupLog.Error(err, "invalid pull-secret-export-annotations flag")
+		os.Exit(1)
+	}
+
+	var webhookValidationClient *http.Client
+	if validateWebhookNotifications {
+		webhookValidationClient, err = newWebhookValidationClient(webhookValidationCAPath, webhookValidationInsecureSkipVerify, webhookValidationAllowPrivateNetworks)
+		if err != nil {
+			setupLog.Error(err, "unable to set up webhook notifications validation")
+			os.Exit(1)
+		}
+	}
+
+	var webhookAllowlist *controllers.WebhookAllowlist
+	if webhookNotificationsAllowlist != "" {
+		webhookAllowlist, err = controllers.NewWebhookAllowlist(strings.Split(webhookNotificationsAllowlist, ","))
+		if err != nil {
+			setupLog.Error(err, "invalid webhook-notifications-allowlist flag")
+			os.Exit(1)
+		}
+	}
+
+	var shutdownDrain *controllers.ShutdownDrain
+	var gracefulShutdownTimeout *time.Duration
+	if shutdownDrainTimeout > 0 {
+		shutdownDrain = &controllers.ShutdownDrain{Timeout: shutdownDrainTimeout}
+		// Leave time to save progress of the interrupted reconciles after the drain
+		managerShutdownTimeout := shutdownDrainTimeout + 10*time.Second
+		gracefulShutdownTimeout = &managerShutdownTimeout
+	}
+
+	var imageRepositoryProvisionFairness *controllers.ProvisionFairness
+	if provisionFairness {
+		weights, err := parseKeyValueList(provisionFairnessWeights)
+		if err != nil {
+			setupLog.Error(err, "invalid provision-fairness-weights flag")
+			os.Exit(1)
+		}
+		if imageRepositoryProvisionFairness, err = controllers.NewProvisionFairness(weights); err != nil {
+			setupLog.Error(err, "invalid provision-fairness-weights flag")
+			os.Exit(1)
+		}
+	}
+
+	if err := controllers.ValidateRegistryHost(registryHost); err != nil {
+		setupLog.Error(err, "invalid registry-host flag")
+		os.Exit(1)
+	}
+
+	reservedRepositoryNames, err := controllers.NewReservedRepositoryNames(strings.Split(reservedRepositoryNamesList, ","))
+	if err != nil {
+		setupLog.Error(err, "invalid reserved-repository-names flag")
+		os.Exit(1)
+	}
+
+	if visibilityDriftPolicy != "" && visibilityDriftPolicy != controllers.VisibilityDriftPolicyObserve && visibilityDriftPolicy != controllers.VisibilityDriftPolicyEnforce {
+		setupLog.Error(nil, "invalid visibility-drift-policy flag, allowed values are Observe and Enforce", "value", visibilityDriftPolicy)
+		os.Exit(1)
+	}
+
+	if archiveOrganization != "" && archiveRobotAccountName == "" {
+		setupLog.Error(nil, "archive-robot-account flag is required when archive-organization flag is set")
+		os.Exit(1)
+	}
+
+	adminNamespaces := []string{}
+	for _, namespace := range strings.Split(adminNamespacesList, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			adminNamespaces = append(adminNamespaces, namespace)
+		}
+	}
+
 	clientOpts := client.Options{
 		Cache: &client.CacheOptions{
 			DisableFor: getCacheExcludedObjectsTypes(),
@@ -100,13 +427,121 @@ func main() {
 		BindAddress: metricsAddr,
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Client:                 clientOpts,
-		Scheme:                 scheme,
-		Metrics:                metricsOpts,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "ed4c18c3.appstudio.redhat.com",
+	readConfig := func(l logr.Logger, path string) string {
+		/* #nosec we are sure the input path is clean */
+		tokenContent, err := os.ReadFile(path)
+		if err != nil {
+			l.Error(err, fmt.Sprintf("unable to read %s", path))
+		}
+		return strings.TrimSpace(string(tokenContent))
+	}
+	quayOrganization := readConfig(setupLog, quayOrgPath)
+
+	// Shared by all clients, so the budget survives clients rebuilt on each reconcile
+	quayRateLimitBudget := quay.NewRateLimitBudget()
+	// Clients are built per reconcile, because they carry the reconcile correlation ID, but share connections and the token
+	quayHTTPClient := &http.Client{Transport: &http.Transport{}, Timeout: quayRequestTimeout}
+	quayTokenProvider := quay.NewFileTokenProvider(quayTokenPath, ctrl.Log.WithName("quay-token"))
+	wrapQuayClient := func(l logr.Logger, quayClient *quay.QuayClient) quay.QuayService {
+		quayClient.OnPageFetched = func(operation string) {
+			metrics.QuayFetchedPagesMetric.WithLabelValues(operation).Inc()
+		}
+		quayClient.OnRateLimit = func(rateLimit quay.RateLimit) {
+			quayRateLimitBudget.Observe(rateLimit)
+			metrics.QuayRateLimitRemainingMetric.Set(float64(rateLimit.Remaining))
+			metrics.QuayRateLimitLimitMetric.Set(float64(rateLimit.Limit))
+		}
+		quayClient.OnRequestWithoutRateLimit = quayRateLimitBudget.Spend
+		var quayService quay.QuayService = quayClient
+		if pauseRepositoryDeletion {
+			quayService = quay.NewRetainRepositoriesQuayClient(quayService, l, func() {
+				metrics.RetainedRepositoriesMetric.Inc()
+			})
+		}
+		if dryRunGlobal {
+			return quay.NewDryRunQuayClient(quayService, l, func(operation string) {
+				metrics.QuayDryRunInterceptedCallsMetric.WithLabelValues(operation).Inc()
+			})
+		}
+		return quayService
+	}
+	buildQuayClientWithTokenFunc := func(l logr.Logger, token string) quay.QuayService {
+		return wrapQuayClient(l, quay.NewQuayClient(quayHTTPClient, token, quayAPIURL))
+	}
+	buildQuayClientFunc := func(l logr.Logger) quay.QuayService {
+		return wrapQuayClient(l, quay.NewQuayClientWithTokenProvider(quayHTTPClient, quayTokenProvider, quayAPIURL))
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+
+	var syncStates *admin.SyncStateTracker
+	var adminEndpointToken string
+	if adminEndpointTokenPath != "" {
+		adminEndpointToken = readConfig(setupLog, adminEndpointTokenPath)
+		if adminEndpointToken == "" {
+			setupLog.Error(nil, "admin endpoint token is empty", "path", adminEndpointTokenPath)
+			os.Exit(1)
+		}
+		adminClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create admin endpoint client")
+			os.Exit(1)
+		}
+		syncStates = admin.NewSyncStateTracker()
+		metricsOpts.ExtraHandlers = map[string]http.Handler{
+			admin.ImageRepositoriesEndpointPath: admin.NewImageRepositoriesHandler(adminClient, syncStates, quayOrganization, adminEndpointToken, ctrl.Log),
+		}
+		setupLog.Info("Admin endpoint is enabled", "path", admin.ImageRepositoriesEndpointPath)
+		// Without cluster ID, image repositories created by other clusters cannot be told apart
+		if clusterID != "" {
+			metricsOpts.ExtraHandlers[admin.OrphanedRepositoriesEndpointPath] = admin.NewOrphanedRepositoriesHandler(
+				adminClient, buildQuayClientFunc, quayOrganization, clusterID, adminEndpointToken, ctrl.Log)
+			setupLog.Info("Admin endpoint is enabled", "path", admin.OrphanedRepositoriesEndpointPath)
+		}
+	}
+
+	var pushNotifications *controllers.PushNotifications
+	if pushNotificationsTokenPath != "" {
+		pushNotificationsToken := readConfig(setupLog, pushNotificationsTokenPath)
+		if pushNotificationsToken == "" {
+			setupLog.Error(nil, "push notifications token is empty", "path", pushNotificationsTokenPath)
+			os.Exit(1)
+		}
+		pushNotifications = controllers.NewPushNotifications(pushNotificationsToken, ctrl.Log)
+		if metricsOpts.ExtraHandlers == nil {
+			metricsOpts.ExtraHandlers = map[string]http.Handler{}
+		}
+		metricsOpts.ExtraHandlers[controllers.PushNotificationsEndpointPath] = pushNotifications
+		setupLog.Info("Push notifications endpoint is enabled", "path", controllers.PushNotificationsEndpointPath)
+	}
+
+	metrics.QuayRequestsMetricEnabled = quayRequestMetrics
+
+	if metricsExemplars {
+		metrics.ReconcileExemplarsEnabled = true
+		if metricsOpts.ExtraHandlers == nil {
+			metricsOpts.ExtraHandlers = map[string]http.Handler{}
+		}
+		metricsOpts.ExtraHandlers[metrics.OpenMetricsEndpointPath] = metrics.NewOpenMetricsHandler()
+		setupLog.Info("Reconcile duration exemplars are enabled", "path", metrics.OpenMetricsEndpointPath)
+	}
+
+	if pprofBindAddress != "" {
+		if err := admin.ValidatePprofBindAddress(pprofBindAddress); err != nil {
+			setupLog.Error(err, "unable to set up pprof endpoint")
+			os.Exit(1)
+		}
+	}
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
+		Client:                  clientOpts,
+		Scheme:                  scheme,
+		Metrics:                 metricsOpts,
+		HealthProbeBindAddress:  probeAddr,
+		PprofBindAddress:        pprofBindAddress,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "ed4c18c3.appstudio.redhat.com",
+		GracefulShutdownTimeout: gracefulShutdownTimeout,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -124,42 +559,202 @@ func main() {
 		os.Exit(1)
 	}
 
-	readConfig := func(l logr.Logger, path string) string {
-		/* #nosec we are sure the input path is clean */
-		tokenContent, err := os.ReadFile(path)
-		if err != nil {
-			l.Error(err, fmt.Sprintf("unable to read %s", path))
+	if err := mgr.Add(quayTokenProvider); err != nil {
+		setupLog.Error(err, "unable to set up Quay token watcher")
+		os.Exit(1)
+	}
+
+	if pprofBindAddress != "" {
+		setupLog.Info("Pprof endpoint is enabled", "address", pprofBindAddress, "path", admin.PprofEndpointPath)
+	}
+
+	for _, feature := range features.Known() {
+		enabled := featureGates.Enabled(feature)
+		value := 0.0
+		if enabled {
+			value = 1
 		}
-		return strings.TrimSpace(string(tokenContent))
+		metrics.FeatureGateEnabledMetric.WithLabelValues(string(feature)).Set(value)
+		setupLog.Info("Feature gate", "feature", feature, "enabled", enabled)
 	}
-	quayOrganization := readConfig(setupLog, quayOrgPath)
-	buildQuayClientFunc := func(l logr.Logger) quay.QuayService {
-		token := readConfig(l, quayTokenPath)
-		quayClient := quay.NewQuayClient(&http.Client{Transport: &http.Transport{}}, token, "https://quay.io/api/v1")
-		return quayClient
+
+	if dryRunGlobal {
+		setupLog.Info("Global dry-run mode is enabled, no changes will be applied")
+	}
+	if pauseRepositoryDeletion {
+		setupLog.Info("WARNING: image repository deletion is paused, image repositories of deleted objects are kept in Quay")
+		metrics.RepositoryDeletionPausedMetric.Set(1)
+	}
+
+	// The reconcilers share the client with watch handlers, so it's wrapped once instead of on each reconcile
+	reconcilerClient := mgr.GetClient()
+	if dryRunGlobal {
+		reconcilerClient = client.NewDryRunClient(reconcilerClient)
 	}
 
 	if err = (&controllers.ComponentReconciler{
-		Client:           mgr.GetClient(),
+		Client:           reconcilerClient,
 		Scheme:           mgr.GetScheme(),
 		BuildQuayClient:  buildQuayClientFunc,
 		QuayOrganization: quayOrganization,
+		RegistryHost:     registryHost,
+		ClusterID:        clusterID,
+		DryRun:           dryRunGlobal,
+
+		RepositoryPathTemplate:  repositoryPathTemplate,
+		AdminNamespaces:         adminNamespaces,
+		ReservedRepositoryNames: reservedRepositoryNames,
+
+		PullSecretExportLabels:      pullSecretLabels,
+		PullSecretExportAnnotations: pullSecretAnnotations,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Controller")
 		os.Exit(1)
 	}
 
+	// Maintenance requests are granted in a ConfigMap of the controller namespace, so they are rejected without it
+	var maintenanceGrants *controllers.MaintenanceGrants
+	if controllerNamespace := os.Getenv("POD_NAMESPACE"); controllerNamespace != "" {
+		maintenanceGrants = &controllers.MaintenanceGrants{Client: reconcilerClient, Namespace: controllerNamespace}
+	} else {
+		setupLog.Info("POD_NAMESPACE is not set, maintenance requests are rejected")
+	}
+
 	if err = (&controllers.ImageRepositoryReconciler{
-		Client:           mgr.GetClient(),
+		Client:           reconcilerClient,
 		Scheme:           mgr.GetScheme(),
 		BuildQuayClient:  buildQuayClientFunc,
 		QuayOrganization: quayOrganization,
+		RegistryHost:     registryHost,
+		ClusterID:        clusterID,
+		DryRun:           dryRunGlobal,
+		PersistingClient: mgr.GetClient(),
+		SyncStates:       syncStates,
+
+		RepositoryTokensNamespace: repositoryTokensNamespace,
+		BuildRepositoryQuayClient: buildQuayClientWithTokenFunc,
+
+		MaxImageRepositoryNameLength:    maxImageRepositoryNameLength,
+		ShortenLongImageRepositoryNames: shortenLongImageRepositoryNames,
+		RepositoryPathTemplate:          repositoryPathTemplate,
+		DescriptionTemplate:             repositoryDescriptionTemplate,
+		MaxProvisionAttempts:            maxProvisionAttempts,
+		ProvisionTimeout:                provisionTimeout,
+		DefaultVisibility:               imagerepositoryv1alpha1.ImageVisibility(defaultVisibility),
+		DefaultSecretLinking:            defaultSecretLinking,
+		WaitForPrivateRepositoriesQuota: waitForPrivateRepositoriesQuota,
+		StrictSecretUnlink:              strictSecretUnlink,
+		APIReader:                       mgr.GetAPIReader(),
+		WebhookValidationClient:         webhookValidationClient,
+		WebhookAllowlist:                webhookAllowlist,
+		ReservedRepositoryNames:         reservedRepositoryNames,
+		ProvisionFairness:               imageRepositoryProvisionFairness,
+		ShutdownDrain:                   shutdownDrain,
+		MaxNotifications:                maxNotifications,
+		AdminNamespaces:                 adminNamespaces,
+		MaintenanceGrants:               maintenanceGrants,
+		VisibilityDriftPolicy:           visibilityDriftPolicy,
+		DiscoverNudgeTargets:            discoverNudgeTargets,
+		ArchiveOrganization:             archiveOrganization,
+		ArchiveRobotAccountName:         archiveRobotAccountName,
+		ArchiveOnDeletion:               archiveOnDeletion,
+		FeatureGates:                    featureGates,
+		EventRecorder:                   mgr.GetEventRecorderFor("imagerepository-controller"),
+		PushNotifications:               pushNotifications,
+
+		PullSecretExportLabels:      pullSecretLabels,
+		PullSecretExportAnnotations: pullSecretAnnotations,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ImageRepository")
 		os.Exit(1)
 	}
+
+	if namespaceOnboardingConfigPath != "" {
+		onboardingConfig, err := controllers.LoadNamespaceOnboardingConfig(namespaceOnboardingConfigPath)
+		if err != nil {
+			setupLog.Error(err, "unable to load namespace onboarding config", "path", namespaceOnboardingConfigPath)
+			os.Exit(1)
+		}
+		onboardingClient := mgr.GetClient()
+		if dryRunGlobal {
+			onboardingClient = client.NewDryRunClient(onboardingClient)
+		}
+		if err = (&controllers.NamespaceOnboardingReconciler{
+			Client:           onboardingClient,
+			BuildQuayClient:  buildQuayClientFunc,
+			QuayOrganization: quayOrganization,
+			Config:           onboardingConfig,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NamespaceOnboarding")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if err := mgr.Add(&controllers.NamespaceIsolationAuditor{
+		Client:           mgr.GetClient(),
+		QuayOrganization: quayOrganization,
+		RegistryHost:     registryHost,
+		AdminNamespaces:  adminNamespaces,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up namespace isolation auditor")
+		os.Exit(1)
+	}
+
+	if credentialsRotationWarningAge > 0 {
+		reportClient := mgr.GetClient()
+		if dryRunGlobal {
+			reportClient = client.NewDryRunClient(reportClient)
+		}
+		if err := mgr.Add(&controllers.CredentialsAgeReporter{
+			Client:             reportClient,
+			RotationWarningAge: credentialsRotationWarningAge,
+			MaxAge:             credentialsMaxAge,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up credentials age reporter")
+			os.Exit(1)
+		}
+	}
+
+	if permissionPrototypesConfigPath != "" {
+		prototypes, err := controllers.LoadPermissionPrototypesConfig(permissionPrototypesConfigPath)
+		if err != nil {
+			setupLog.Error(err, "unable to load permission prototypes config", "path", permissionPrototypesConfigPath)
+			os.Exit(1)
+		}
+		if err := mgr.Add(&controllers.PermissionPrototypesEnsurer{
+			BuildQuayClient:  buildQuayClientFunc,
+			QuayOrganization: quayOrganization,
+			Prototypes:       prototypes,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up permission prototypes ensurer")
+			os.Exit(1)
+		}
+	}
+
+	if controllerNamespace := os.Getenv("POD_NAMESPACE"); controllerNamespace != "" {
+		configClient := mgr.GetClient()
+		if dryRunGlobal {
+			configClient = client.NewDryRunClient(configClient)
+		}
+		if err := mgr.Add(&controllers.ControllerConfigPublisher{
+			Client:    configClient,
+			Namespace: controllerNamespace,
+			Config: controllers.ControllerConfig{
+				QuayOrganization:             quayOrganization,
+				RegistryHost:                 registryHost,
+				DefaultVisibility:            imagerepositoryv1alpha1.ImageVisibility(defaultVisibility),
+				MaxImageRepositoryNameLength: maxImageRepositoryNameLength,
+				DryRun:                       dryRunGlobal,
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to set up controller config publisher")
+			os.Exit(1)
+		}
+	} else {
+		setupLog.Info("POD_NAMESPACE is not set, controller configuration is not published")
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -170,16 +765,43 @@ func main() {
 	}
 
 	ctx := ctrl.SetupSignalHandler()
-	quayProbe, err := metrics.NewQuayAvailabilityProbe(ctx, buildQuayClientFunc, quayOrganization)
+	var quayProbe metrics.AvailabilityProbe
+	if quayProbeMode == metrics.QuayProbeModeOrganization {
+		probeQuayClientFunc := buildQuayClientFunc
+		probeAPIURL := quayAPIURL
+		if quayProbeURL != "" {
+			probeAPIURL = quayProbeURL
+			probeQuayClientFunc = func(l logr.Logger) quay.QuayService {
+				return wrapQuayClient(l, quay.NewQuayClientWithTokenProvider(quayHTTPClient, quayTokenProvider, probeAPIURL))
+			}
+		}
+		quayProbe, err = metrics.NewQuayAvailabilityProbe(ctx, probeQuayClientFunc, quayOrganization, probeAPIURL)
+	} else {
+		quayProbe, err = metrics.NewLowPrivilegeQuayAvailabilityProbe(quayProbeMode, quayAPIURL, quayProbeURL, quayProbeRobotAccount, quayProbeTokenPath, &http.Client{Transport: &http.Transport{}})
+	}
 	if err != nil {
 		setupLog.Error(err, "unable to register quay availability probe")
 		os.Exit(1)
 	}
 	imageControllerMetrics := metrics.NewImageControllerMetrics([]metrics.AvailabilityProbe{quayProbe})
+	imageControllerMetrics.ProbeInterval = availabilityProbeInterval
 	if err := imageControllerMetrics.InitMetrics(cmetrics.Registry); err != nil {
 		setupLog.Error(err, "unable to initialize metrics")
 		os.Exit(1)
 	}
+	if availabilityProbesConfigPath != "" {
+		probes, err := metrics.NewProbesFromConfig(availabilityProbesConfigPath, &http.Client{Transport: &http.Transport{}})
+		if err != nil {
+			setupLog.Error(err, "unable to load availability probes config", "path", availabilityProbesConfigPath)
+			os.Exit(1)
+		}
+		for _, probe := range probes {
+			if err := imageControllerMetrics.RegisterProbe(probe); err != nil {
+				setupLog.Error(err, "unable to register availability probe")
+				os.Exit(1)
+			}
+		}
+	}
 	imageControllerMetrics.StartMetrics(ctx)
 
 	setupLog.Info("starting manager")
@@ -189,6 +811,57 @@ func main() {
 	}
 }
 
+// newWebhookValidationClient returns HTTP client for webhook notification targets validation.
+// Certificates from the given CA file are trusted in addition to the system ones.
+// The client doesn't use proxies nor follow redirects, so only the checked address is ever connected to.
+func newWebhookValidationClient(caPath string, insecureSkipVerify, allowPrivateNetworks bool) (*http.Client, error) {
+	/* #nosec skipping verification is explicitly requested by the administrator */
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caPath != "" {
+		caPool, err := x509.SystemCertPool()
+		if err != nil {
+			caPool = x509.NewCertPool()
+		}
+		/* #nosec we are sure the input path is clean */
+		caData, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, err
+		}
+		if !caPool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in %s", caPath)
+		}
+		tlsConfig.RootCAs = caPool
+	}
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: controllers.WebhookValidationDialControl(allowPrivateNetworks),
+	}
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: 10 * time.Second,
+	}, nil
+}
+
+// parseKeyValueList parses "key1=value1,key2=value2" string into a map.
+func parseKeyValueList(list string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid key=value pair: %s", pair)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result, nil
+}
+
 func getCacheExcludedObjectsTypes() []client.Object {
 	return []client.Object{
 		&imagerepositoryv1alpha1.ImageRepository{},
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+const ImageRepositoriesEndpointPath = "/debug/imagerepositories"
+
+// ImageRepositoryInfo is the controller's view of an ImageRepository.
+type ImageRepositoryInfo struct {
+	Namespace         string     `json:"namespace"`
+	Name              string     `json:"name"`
+	State             string     `json:"state,omitempty"`
+	Message           string     `json:"message,omitempty"`
+	QuayOrganization  string     `json:"quayOrganization"`
+	QuayRepository    string     `json:"quayRepository,omitempty"`
+	URL               string     `json:"url,omitempty"`
+	LastReconcileTime *time.Time `json:"lastReconcileTime,omitempty"`
+	LastError         string     `json:"lastError,omitempty"`
+}
+
+// ImageRepositoriesHandler serves all ImageRepositories in the cluster together with their sync state as JSON.
+// Requests must be authenticated with the configured bearer token.
+type ImageRepositoriesHandler struct {
+	client           client.Reader
+	syncStates       *SyncStateTracker
+	quayOrganization string
+	token            string
+	log              logr.Logger
+}
+
+func NewImageRepositoriesHandler(client client.Reader, syncStates *SyncStateTracker, quayOrganization, token string, log logr.Logger) *ImageRepositoriesHandler {
+	return &ImageRepositoriesHandler{
+		client:           client,
+		syncStates:       syncStates,
+		quayOrganization: quayOrganization,
+		token:            token,
+		log:              log.WithName("AdminEndpoint"),
+	}
+}
+
+func (h *ImageRepositoriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAuthorized(r, h.token) {
+		h.log.Info("unauthorized request to admin endpoint", "RemoteAddr", r.RemoteAddr, l.Audit, "true")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := h.client.List(r.Context(), imageRepositoryList); err != nil {
+		h.log.Error(err, "failed to list image repositories", l.Action, l.ActionView)
+		http.Error(w, "failed to list image repositories", http.StatusInternalServerError)
+		return
+	}
+
+	infos := make([]ImageRepositoryInfo, 0, len(imageRepositoryList.Items))
+	for _, imageRepository := range imageRepositoryList.Items {
+		info := ImageRepositoryInfo{
+			Namespace:        imageRepository.Namespace,
+			Name:             imageRepository.Name,
+			State:            string(imageRepository.Status.State),
+			Message:          imageRepository.Status.Message,
+			QuayOrganization: h.quayOrganization,
+			QuayRepository:   imageRepository.Spec.Image.Name,
+			URL:              imageRepository.Status.Image.URL,
+		}
+		if h.syncStates != nil {
+			if state, found := h.syncStates.Get(types.NamespacedName{Namespace: imageRepository.Namespace, Name: imageRepository.Name}); found {
+				lastReconcileTime := state.LastReconcileTime
+				info.LastReconcileTime = &lastReconcileTime
+				info.LastError = state.LastError
+			}
+		}
+		infos = append(infos, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(infos); err != nil {
+		h.log.Error(err, "failed to write admin endpoint response")
+	}
+}
+
+// isAuthorized checks that the request has the given bearer token. Empty token disables access.
+func isAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	expected := "Bearer " + token
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+func TestImageRepositoriesHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, imagerepositoryv1alpha1.AddToScheme(scheme))
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-image", Namespace: "test-ns"},
+		Spec: imagerepositoryv1alpha1.ImageRepositorySpec{
+			Image: imagerepositoryv1alpha1.ImageParameters{Name: "test-ns/my-image"},
+		},
+		Status: imagerepositoryv1alpha1.ImageRepositoryStatus{
+			State: imagerepositoryv1alpha1.ImageRepositoryStateReady,
+			Image: imagerepositoryv1alpha1.ImageStatus{URL: "quay.io/test-org/test-ns/my-image"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(imageRepository).Build()
+
+	syncStates := NewSyncStateTracker()
+	syncStates.Record(types.NamespacedName{Namespace: "test-ns", Name: "my-image"}, errors.New("quay is unavailable"))
+
+	handler := NewImageRepositoriesHandler(fakeClient, syncStates, "test-org", "secret-token", logr.Discard())
+
+	testCases := []struct {
+		name               string
+		method             string
+		authorization      string
+		expectedStatusCode int
+	}{
+		{
+			name:               "should list image repositories",
+			method:             http.MethodGet,
+			authorization:      "Bearer secret-token",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should reject request without token",
+			method:             http.MethodGet,
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should reject request with wrong token",
+			method:             http.MethodGet,
+			authorization:      "Bearer wrong-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should reject not GET requests",
+			method:             http.MethodPost,
+			authorization:      "Bearer secret-token",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, ImageRepositoriesEndpointPath, nil)
+			if tc.authorization != "" {
+				request.Header.Set("Authorization", tc.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, recorder.Code, tc.expectedStatusCode)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+
+			infos := []ImageRepositoryInfo{}
+			assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), &infos))
+			assert.Equal(t, len(infos), 1)
+			assert.Equal(t, infos[0].Namespace, "test-ns")
+			assert.Equal(t, infos[0].Name, "my-image")
+			assert.Equal(t, infos[0].State, "ready")
+			assert.Equal(t, infos[0].QuayOrganization, "test-org")
+			assert.Equal(t, infos[0].QuayRepository, "test-ns/my-image")
+			assert.Equal(t, infos[0].URL, "quay.io/test-org/test-ns/my-image")
+			assert.Equal(t, infos[0].LastError, "quay is unavailable")
+			assert.Assert(t, infos[0].LastReconcileTime != nil)
+		})
+	}
+}
+
+func TestImageRepositoriesHandler_DisabledWithoutToken(t *testing.T) {
+	handler := NewImageRepositoriesHandler(nil, nil, "test-org", "", logr.Discard())
+
+	request := httptest.NewRequest(http.MethodGet, ImageRepositoriesEndpointPath, nil)
+	request.Header.Set("Authorization", "Bearer ")
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const OrphanedRepositoriesEndpointPath = "/debug/orphanedrepositories"
+
+// OrphanedRepositoryInfo describes Quay image repository created by this cluster for an object that doesn't exist anymore.
+type OrphanedRepositoryInfo struct {
+	QuayOrganization string                   `json:"quayOrganization"`
+	QuayRepository   string                   `json:"quayRepository"`
+	Ownership        quay.RepositoryOwnership `json:"ownership"`
+}
+
+// OrphanedRepositoriesHandler cross-references image repositories in the Quay organization with the cluster objects.
+// Only image repositories created by this cluster are checked, so the result is safe to garbage collect
+// even if several clusters share the Quay organization.
+// Requests must be authenticated with the configured bearer token.
+type OrphanedRepositoriesHandler struct {
+	client           client.Reader
+	buildQuayClient  func(logr.Logger) quay.QuayService
+	quayOrganization string
+	clusterID        string
+	token            string
+	log              logr.Logger
+}
+
+func NewOrphanedRepositoriesHandler(client client.Reader, buildQuayClient func(logr.Logger) quay.QuayService, quayOrganization, clusterID, token string, log logr.Logger) *OrphanedRepositoriesHandler {
+	return &OrphanedRepositoriesHandler{
+		client:           client,
+		buildQuayClient:  buildQuayClient,
+		quayOrganization: quayOrganization,
+		clusterID:        clusterID,
+		token:            token,
+		log:              log.WithName("AdminEndpoint"),
+	}
+}
+
+func (h *OrphanedRepositoriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAuthorized(r, h.token) {
+		h.log.Info("unauthorized request to admin endpoint", "RemoteAddr", r.RemoteAddr, l.Audit, "true")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// List Quay repositories first, so repositories created meanwhile are not reported.
+	// The listing is stopped if the request is cancelled.
+	var repositories []quay.Repository
+	err := h.buildQuayClient(h.log).ListRepositories(r.Context(), h.quayOrganization, quay.ListRepositoriesOptions{}, func(page []quay.Repository) bool {
+		repositories = append(repositories, page...)
+		return true
+	})
+	if err != nil {
+		h.log.Error(err, "failed to list Quay repositories", l.Action, l.ActionView)
+		http.Error(w, "failed to list Quay repositories", http.StatusInternalServerError)
+		return
+	}
+
+	ownerUIDs, err := h.getOwnerUIDs(r)
+	if err != nil {
+		h.log.Error(err, "failed to list image repository owners", l.Action, l.ActionView)
+		http.Error(w, "failed to list image repository owners", http.StatusInternalServerError)
+		return
+	}
+
+	infos := []OrphanedRepositoryInfo{}
+	for _, repository := range repositories {
+		ownership, found := quay.ParseRepositoryOwnership(repository.Description)
+		if !found || ownership.ClusterID != h.clusterID {
+			// Unknown owner or the repository belongs to other cluster
+			continue
+		}
+		if _, exists := ownerUIDs[types.UID(ownership.UID)]; exists {
+			continue
+		}
+		infos = append(infos, OrphanedRepositoryInfo{
+			QuayOrganization: h.quayOrganization,
+			QuayRepository:   repository.Name,
+			Ownership:        ownership,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(infos); err != nil {
+		h.log.Error(err, "failed to write admin endpoint response")
+	}
+}
+
+// getOwnerUIDs returns UIDs of all objects image repositories could be created for.
+func (h *OrphanedRepositoriesHandler) getOwnerUIDs(r *http.Request) (map[types.UID]struct{}, error) {
+	ownerUIDs := map[types.UID]struct{}{}
+
+	imageRepositoryList := &imagerepositoryv1alpha1.ImageRepositoryList{}
+	if err := h.client.List(r.Context(), imageRepositoryList); err != nil {
+		return nil, err
+	}
+	for _, imageRepository := range imageRepositoryList.Items {
+		ownerUIDs[imageRepository.UID] = struct{}{}
+	}
+
+	componentList := &appstudioredhatcomv1alpha1.ComponentList{}
+	if err := h.client.List(r.Context(), componentList); err != nil {
+		return nil, err
+	}
+	for _, component := range componentList.Items {
+		ownerUIDs[component.UID] = struct{}{}
+	}
+
+	return ownerUIDs, nil
+}
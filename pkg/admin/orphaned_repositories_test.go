@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+	appstudioredhatcomv1alpha1 "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+func TestOrphanedRepositoriesHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, imagerepositoryv1alpha1.AddToScheme(scheme))
+	assert.NilError(t, appstudioredhatcomv1alpha1.AddToScheme(scheme))
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-image", Namespace: "test-ns", UID: "image-repository-uid"},
+	}
+	component := &appstudioredhatcomv1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-component", Namespace: "test-ns", UID: "component-uid"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(imageRepository, component).Build()
+
+	ownedBy := func(clusterID, uid string) string {
+		ownership := quay.RepositoryOwnership{ManagedBy: quay.OwnershipManagedBy, ClusterID: clusterID, Namespace: "test-ns", UID: uid}
+		return ownership.Description("AppStudio repository for the user")
+	}
+	quay.ResetTestQuayClient()
+	defer quay.ResetTestQuayClient()
+	quay.ListRepositoriesFunc = func(ctx context.Context, organization string, opts quay.ListRepositoriesOptions, visit quay.RepositoriesPageVisitor) error {
+		assert.Equal(t, organization, "test-org")
+		visit([]quay.Repository{
+			{Name: "test-ns/my-image", Description: ownedBy("cluster-1", "image-repository-uid")},
+			{Name: "test-ns/my-app/my-component", Description: ownedBy("cluster-1", "component-uid")},
+			{Name: "test-ns/deleted-image", Description: ownedBy("cluster-1", "deleted-uid")},
+			{Name: "test-ns/other-cluster-image", Description: ownedBy("cluster-2", "other-cluster-uid")},
+			{Name: "test-ns/legacy-image", Description: "AppStudio repository for the user"},
+		})
+		return nil
+	}
+	buildQuayClient := func(logr.Logger) quay.QuayService { return quay.TestQuayClient{} }
+
+	handler := NewOrphanedRepositoriesHandler(fakeClient, buildQuayClient, "test-org", "cluster-1", "secret-token", logr.Discard())
+
+	request := httptest.NewRequest(http.MethodGet, OrphanedRepositoriesEndpointPath, nil)
+	request.Header.Set("Authorization", "Bearer secret-token")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	infos := []OrphanedRepositoryInfo{}
+	assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), &infos))
+	assert.Equal(t, len(infos), 1)
+	assert.Equal(t, infos[0].QuayOrganization, "test-org")
+	assert.Equal(t, infos[0].QuayRepository, "test-ns/deleted-image")
+	assert.Equal(t, infos[0].Ownership.UID, "deleted-uid")
+	assert.Equal(t, infos[0].Ownership.ClusterID, "cluster-1")
+
+	unauthorizedRequest := httptest.NewRequest(http.MethodGet, OrphanedRepositoriesEndpointPath, nil)
+	unauthorizedRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(unauthorizedRecorder, unauthorizedRequest)
+	assert.Equal(t, unauthorizedRecorder.Code, http.StatusUnauthorized)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"fmt"
+	"net"
+)
+
+const PprofEndpointPath = "/debug/pprof/"
+
+// ValidatePprofBindAddress checks that the runtime profiles endpoint is bound to the loopback interface only.
+// The profiles are served by controller-runtime manager without authentication,
+// so they must be reachable only from within the pod, e.g. with kubectl port-forward.
+func ValidatePprofBindAddress(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid pprof bind address %s: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("pprof bind address %s must be a loopback address, e.g. 127.0.0.1:8082", addr)
+}
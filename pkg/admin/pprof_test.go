@@ -0,0 +1,47 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"testing"
+)
+
+func TestValidatePprofBindAddress(t *testing.T) {
+	testCases := []struct {
+		addr    string
+		isValid bool
+	}{
+		{addr: "127.0.0.1:8082", isValid: true},
+		{addr: "localhost:8082", isValid: true},
+		{addr: "[::1]:8082", isValid: true},
+		{addr: ":8082", isValid: false},
+		{addr: "0.0.0.0:8082", isValid: false},
+		{addr: "10.0.0.1:8082", isValid: false},
+		{addr: "127.0.0.1", isValid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.addr, func(t *testing.T) {
+			err := ValidatePprofBindAddress(tc.addr)
+			if tc.isValid && err != nil {
+				t.Errorf("expected %s to be valid, got: %v", tc.addr, err)
+			}
+			if !tc.isValid && err == nil {
+				t.Errorf("expected %s to be rejected", tc.addr)
+			}
+		})
+	}
+}
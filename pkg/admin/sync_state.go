@@ -0,0 +1,70 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admin
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SyncState holds the result of the last reconcile of an object.
+type SyncState struct {
+	LastReconcileTime time.Time
+	LastError         string
+}
+
+// SyncStateTracker keeps in memory the last reconcile results of the processed objects.
+// It's safe for concurrent use.
+type SyncStateTracker struct {
+	lock   sync.RWMutex
+	states map[types.NamespacedName]SyncState
+}
+
+func NewSyncStateTracker() *SyncStateTracker {
+	return &SyncStateTracker{
+		states: make(map[types.NamespacedName]SyncState),
+	}
+}
+
+// Record saves result of a reconcile of the given object.
+func (t *SyncStateTracker) Record(key types.NamespacedName, reconcileErr error) {
+	state := SyncState{LastReconcileTime: time.Now()}
+	if reconcileErr != nil {
+		state.LastError = reconcileErr.Error()
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.states[key] = state
+}
+
+// Forget removes the record about the given object, e.g. after its deletion.
+func (t *SyncStateTracker) Forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.states, key)
+}
+
+// Get returns the last recorded reconcile result of the given object.
+func (t *SyncStateTracker) Get(key types.NamespacedName) (SyncState, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	state, found := t.states[key]
+	return state, found
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package annotations defines annotations that image-controller reads from or writes to objects
+// it shares with other components. Integrators should use the helpers instead of raw annotation names,
+// so renamed annotations keep working during their deprecation window.
+package annotations
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Key is the name of an annotation recognized by image-controller.
+type Key string
+
+const (
+	// Image holds JSON with the provisioned image repository information of a legacy Component.
+	Image Key = "image.redhat.com/image"
+	// GenerateImage requests image repository provision for a legacy Component.
+	// The value is JSON with the image repository options or "true".
+	GenerateImage Key = "image.redhat.com/generate"
+	// ImageName overrides name of the image repository generated for a legacy Component.
+	ImageName Key = "image.redhat.com/image-name"
+	// ImageVisibility overrides visibility of the image repository generated for a legacy Component.
+	ImageVisibility Key = "image.redhat.com/visibility"
+	// SkipProvision set to "true" skips image repository provision for a legacy Component.
+	SkipProvision Key = "image.redhat.com/skip-provision"
+
+	// UpdateComponentImage set to "true" on an ImageRepository updates the Component container image after provision.
+	UpdateComponentImage Key = "image-controller.appstudio.redhat.com/update-component-image"
+	// RetryProvision set to "true" on an ImageRepository resets failed provision attempts.
+	RetryProvision Key = "image-controller.appstudio.redhat.com/retry-provision"
+	// AllowedConsumers holds comma separated list of namespaces, ImageRepositories of which may create secrets
+	// in the annotated Namespace.
+	AllowedConsumers Key = "image-controller.appstudio.redhat.com/allowed-consumers"
+	// AdoptLegacyComponent holds name of the legacy Component, image repository of which is taken over by the ImageRepository.
+	AdoptLegacyComponent Key = "image-controller.appstudio.redhat.com/adopt-legacy-component"
+	// ImageRepositories holds JSON list of image repositories created for the Component through ImageRepository objects.
+	ImageRepositories Key = "image-controller.appstudio.redhat.com/image-repositories"
+	// Applications holds comma separated list of additional Applications the Component linked ImageRepository belongs to,
+	// when the Component is shared between Applications.
+	Applications Key = "image-controller.appstudio.redhat.com/applications"
+	// KeepOnComponentDeletion set to "true" on a Component or on its ImageRepository keeps the ImageRepository
+	// when the Component is deleted, the ImageRepository becomes free-standing instead of being garbage collected.
+	KeepOnComponentDeletion Key = "image-controller.appstudio.redhat.com/keep-on-component-deletion"
+	// OptOut set to "true" on a Component disables image repository provision for it, e.g. when it's built into an external registry.
+	// Image repositories already provisioned for the Component are deleted.
+	OptOut Key = "image-controller.appstudio.redhat.com/opt-out"
+	// ArchiveOnDeletion set to "true" or "false" on an ImageRepository overrides whether the image repository
+	// is moved into the archive organization instead of being deleted.
+	ArchiveOnDeletion Key = "image-controller.appstudio.redhat.com/archive-on-deletion"
+	// ManifestLabels holds JSON object with labels, e.g. build provenance, attached to the latest manifest
+	// of an ImageRepository tracking the latest tag, whenever a new push is detected.
+	ManifestLabels Key = "image-controller.appstudio.redhat.com/manifest-labels"
+	// AdoptRobotAccounts holds JSON with existing robot accounts, see RobotAccountsAdoption, that a new ImageRepository
+	// takes over instead of creating new ones, e.g. when a pre-existing image repository is brought under the controller.
+	AdoptRobotAccounts Key = "image-controller.appstudio.redhat.com/adopt-robot-accounts"
+	// TemporaryPullCredentials requests short-lived pull credentials of an ImageRepository.
+	// The value is the lifetime of the credentials as a duration, e.g. "2h", or "true" for the default lifetime.
+	// The annotation is removed once the credentials are issued.
+	TemporaryPullCredentials Key = "image-controller.appstudio.redhat.com/temporary-pull-credentials"
+	// DeleteManifest holds digest of a manifest, e.g. sha256:..., to be deleted from the image repository of an ImageRepository.
+	// The annotation is removed once the request is handled.
+	DeleteManifest Key = "image-controller.appstudio.redhat.com/delete-manifest"
+	// ReownRobotAccounts set to "true" on an ImageRepository, if granted by maintenance grants, recreates its robot accounts named
+	// by a former naming scheme and takes over orphaned robot accounts with access to the image repository.
+	// Replaced and orphaned robot accounts are deleted after a delay. The annotation is removed once the request is handled.
+	ReownRobotAccounts Key = "image-controller.appstudio.redhat.com/reown-robot-accounts"
+
+	// OnboardingStatus holds JSON with the result of the namespace onboarding bootstrap on a tenant Namespace.
+	OnboardingStatus Key = "image-controller.appstudio.redhat.com/onboarding-status"
+
+	// BuildRequest is set on a Component to request an action from the build-service, e.g. a new build.
+	BuildRequest Key = "build.appstudio.openshift.io/request"
+)
+
+// BuildRequestTriggerPaCBuild is the BuildRequest value that makes the build-service trigger a new build of the Component.
+const BuildRequestTriggerPaCBuild = "trigger-pac-build"
+
+// deprecatedKeys maps annotations to their former names, that are still read until the deprecation window ends.
+// New values are always written under the current name.
+var deprecatedKeys = map[Key][]Key{}
+
+// validators check annotation values, annotations without a validator accept any value.
+var validators = map[Key]func(value string) bool{
+	Image:                    isJSON,
+	GenerateImage:            isGenerateImageOptions,
+	ImageVisibility:          isVisibility,
+	SkipProvision:            isBool,
+	UpdateComponentImage:     isBool,
+	RetryProvision:           isBool,
+	ImageRepositories:        isJSON,
+	KeepOnComponentDeletion:  isBool,
+	OptOut:                   isBool,
+	ArchiveOnDeletion:        isBool,
+	ManifestLabels:           isStringMap,
+	AdoptRobotAccounts:       isRobotAccountsAdoption,
+	TemporaryPullCredentials: isDurationOrTrue,
+	DeleteManifest:           isManifestDigest,
+	ReownRobotAccounts:       isBool,
+	OnboardingStatus:         isJSON,
+}
+
+// RobotAccountsAdoption is the value of AdoptRobotAccounts annotation.
+// Robot accounts are given by their short names, e.g. ci_push for my-org+ci_push robot account.
+type RobotAccountsAdoption struct {
+	// Push is the robot account granted write access to the image repository.
+	Push string `json:"push"`
+	// Pull is the robot account granted read access, used only by ImageRepositories linked to a Component.
+	Pull string `json:"pull,omitempty"`
+	// RegenerateTokens rotates tokens of the robot accounts instead of reading the current ones.
+	RegenerateTokens bool `json:"regenerateTokens,omitempty"`
+}
+
+// ParseRobotAccountsAdoption decodes value of AdoptRobotAccounts annotation, the push robot account is required.
+func ParseRobotAccountsAdoption(value string) (*RobotAccountsAdoption, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	adoption := &RobotAccountsAdoption{}
+	if err := decoder.Decode(adoption); err != nil {
+		return nil, fmt.Errorf("failed to parse %s annotation: %w", AdoptRobotAccounts, err)
+	}
+	if adoption.Push == "" {
+		return nil, fmt.Errorf("push robot account is not set in %s annotation", AdoptRobotAccounts)
+	}
+	return adoption, nil
+}
+
+// DeprecatedKeys returns former names of the annotation that are still recognized.
+func (k Key) DeprecatedKeys() []Key {
+	return deprecatedKeys[k]
+}
+
+// Get returns value of the annotation on the object.
+// If the annotation is not set under its current name, the deprecated names are checked.
+func (k Key) Get(obj metav1.Object) (string, bool) {
+	objAnnotations := obj.GetAnnotations()
+	if value, exists := objAnnotations[string(k)]; exists {
+		return value, true
+	}
+	for _, deprecatedKey := range deprecatedKeys[k] {
+		if value, exists := objAnnotations[string(deprecatedKey)]; exists {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// IsTrue checks whether the annotation is set to "true".
+func (k Key) IsTrue(obj metav1.Object) bool {
+	value, _ := k.Get(obj)
+	return value == "true"
+}
+
+// Set sets the annotation under its current name and drops the deprecated names.
+func (k Key) Set(obj metav1.Object, value string) {
+	objAnnotations := obj.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = make(map[string]string)
+	}
+	for _, deprecatedKey := range deprecatedKeys[k] {
+		delete(objAnnotations, string(deprecatedKey))
+	}
+	objAnnotations[string(k)] = value
+	obj.SetAnnotations(objAnnotations)
+}
+
+// Remove deletes the annotation under its current and deprecated names.
+func (k Key) Remove(obj metav1.Object) {
+	objAnnotations := obj.GetAnnotations()
+	delete(objAnnotations, string(k))
+	for _, deprecatedKey := range deprecatedKeys[k] {
+		delete(objAnnotations, string(deprecatedKey))
+	}
+	obj.SetAnnotations(objAnnotations)
+}
+
+// Validate checks the annotation value on the object, if it's set.
+func (k Key) Validate(obj metav1.Object) error {
+	value, exists := k.Get(obj)
+	if !exists {
+		return nil
+	}
+	if isValid, hasValidator := validators[k]; hasValidator && !isValid(value) {
+		return fmt.Errorf("invalid value: %s in %s annotation", value, k)
+	}
+	return nil
+}
+
+func isJSON(value string) bool {
+	return json.Valid([]byte(value))
+}
+
+func isStringMap(value string) bool {
+	var stringMap map[string]string
+	return json.Unmarshal([]byte(value), &stringMap) == nil
+}
+
+func isRobotAccountsAdoption(value string) bool {
+	_, err := ParseRobotAccountsAdoption(value)
+	return err == nil
+}
+
+func isGenerateImageOptions(value string) bool {
+	// "true" is accepted for backward compatibility
+	return value == "true" || isJSON(value)
+}
+
+func isVisibility(value string) bool {
+	return value == "public" || value == "private"
+}
+
+func isBool(value string) bool {
+	return value == "true" || value == "false"
+}
+
+var manifestDigestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+func isManifestDigest(value string) bool {
+	return manifestDigestRegexp.MatchString(value)
+}
+
+func isDurationOrTrue(value string) bool {
+	if value == "true" {
+		return true
+	}
+	duration, err := time.ParseDuration(value)
+	return err == nil && duration > 0
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeprecatedKeys(t *testing.T) {
+	const current Key = "image-controller.appstudio.redhat.com/new-name"
+	const deprecated Key = "image-controller.appstudio.redhat.com/old-name"
+	deprecatedKeys[current] = []Key{deprecated}
+	defer delete(deprecatedKeys, current)
+
+	obj := &metav1.ObjectMeta{}
+	if _, exists := current.Get(obj); exists {
+		t.Fatalf("expected annotation not to be set on object without annotations")
+	}
+
+	obj.Annotations = map[string]string{string(deprecated): "old"}
+	if value, exists := current.Get(obj); !exists || value != "old" {
+		t.Errorf("expected value from deprecated name, got %q, %v", value, exists)
+	}
+
+	obj.Annotations[string(current)] = "new"
+	if value, _ := current.Get(obj); value != "new" {
+		t.Errorf("expected current name to take precedence, got %q", value)
+	}
+
+	current.Set(obj, "updated")
+	if _, exists := obj.Annotations[string(deprecated)]; exists {
+		t.Errorf("expected deprecated name to be dropped on set")
+	}
+	if obj.Annotations[string(current)] != "updated" {
+		t.Errorf("expected value to be set under current name, got %q", obj.Annotations[string(current)])
+	}
+
+	obj.Annotations[string(deprecated)] = "old"
+	current.Remove(obj)
+	if len(obj.Annotations) != 0 {
+		t.Errorf("expected all names to be removed, got %v", obj.Annotations)
+	}
+}
+
+func TestSetOnObjectWithoutAnnotations(t *testing.T) {
+	obj := &metav1.ObjectMeta{}
+	RetryProvision.Set(obj, "true")
+	if !RetryProvision.IsTrue(obj) {
+		t.Errorf("expected annotation to be set, got %v", obj.Annotations)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		key     Key
+		value   *string
+		wantErr string
+	}{
+		{name: "not set annotation is valid", key: ImageVisibility},
+		{name: "valid visibility", key: ImageVisibility, value: ptr("private")},
+		{name: "invalid visibility", key: ImageVisibility, value: ptr("internal"), wantErr: "invalid value: internal in image.redhat.com/visibility annotation"},
+		{name: "generate image accepts true", key: GenerateImage, value: ptr("true")},
+		{name: "generate image accepts JSON", key: GenerateImage, value: ptr(`{"visibility":"public"}`)},
+		{name: "generate image rejects invalid JSON", key: GenerateImage, value: ptr(`{"visibility"`), wantErr: "invalid value"},
+		{name: "invalid boolean", key: SkipProvision, value: ptr("yes"), wantErr: "invalid value: yes in image.redhat.com/skip-provision annotation"},
+		{name: "manifest labels accept string map", key: ManifestLabels, value: ptr(`{"vcs-ref":"abc123"}`)},
+		{name: "manifest labels reject non-string values", key: ManifestLabels, value: ptr(`{"build":1}`), wantErr: "invalid value"},
+		{name: "robot accounts adoption accepts push and pull", key: AdoptRobotAccounts, value: ptr(`{"push":"ci_push","pull":"ci_pull","regenerateTokens":true}`)},
+		{name: "robot accounts adoption requires push", key: AdoptRobotAccounts, value: ptr(`{"pull":"ci_pull"}`), wantErr: "invalid value"},
+		{name: "robot accounts adoption rejects unknown fields", key: AdoptRobotAccounts, value: ptr(`{"push":"ci_push","token":"secret"}`), wantErr: "invalid value"},
+		{name: "temporary pull credentials accept true", key: TemporaryPullCredentials, value: ptr("true")},
+		{name: "temporary pull credentials accept duration", key: TemporaryPullCredentials, value: ptr("90m")},
+		{name: "temporary pull credentials reject negative duration", key: TemporaryPullCredentials, value: ptr("-1h"), wantErr: "invalid value"},
+		{name: "delete manifest accepts digest", key: DeleteManifest, value: ptr("sha256:" + strings.Repeat("a", 64))},
+		{name: "delete manifest rejects tag", key: DeleteManifest, value: ptr("latest"), wantErr: "invalid value"},
+		{name: "annotation without validator accepts any value", key: AllowedConsumers, value: ptr("*")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{}
+			if tc.value != nil {
+				tc.key.Set(obj, *tc.value)
+			}
+			err := tc.key.Validate(obj)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func ptr(value string) *string {
+	return &value
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Feature is the name of a capability that could be switched on or off by --feature-gates manager flag,
+// so new capabilities could be rolled out per environment.
+type Feature string
+
+const (
+	// ManifestLabels attaches labels from the manifest-labels annotation to newly pushed manifests.
+	ManifestLabels Feature = "ManifestLabels"
+	// PushBlockedDetection checks the image repository state in Quay and reports image repositories rejecting pushes.
+	PushBlockedDetection Feature = "PushBlockedDetection"
+)
+
+// defaultGates holds all known features with their default state.
+// New features should start disabled and be enabled by default once proven.
+var defaultGates = map[Feature]bool{
+	ManifestLabels:       false,
+	PushBlockedDetection: false,
+}
+
+// FeatureGates is the state of all known features. The zero value and nil use the defaults.
+type FeatureGates struct {
+	enabled map[Feature]bool
+}
+
+// NewFeatureGates parses comma separated list of Feature=true|false pairs, e.g. "Foo=true,Bar=false".
+// Features not in the list keep their default state, unknown features are rejected.
+func NewFeatureGates(spec string) (*FeatureGates, error) {
+	enabled := make(map[Feature]bool, len(defaultGates))
+	for feature, value := range defaultGates {
+		enabled[feature] = value
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid feature gate %q, expected Feature=true|false", pair)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		if _, known := defaultGates[feature]; !known {
+			return nil, fmt.Errorf("unknown feature gate %q", feature)
+		}
+		isEnabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of feature gate %q", value, feature)
+		}
+		enabled[feature] = isEnabled
+	}
+	return &FeatureGates{enabled: enabled}, nil
+}
+
+// Enabled checks whether the feature is switched on.
+func (g *FeatureGates) Enabled(feature Feature) bool {
+	if g != nil {
+		if isEnabled, found := g.enabled[feature]; found {
+			return isEnabled
+		}
+	}
+	return defaultGates[feature]
+}
+
+// Known returns all known features sorted by name.
+func Known() []Feature {
+	known := make([]Feature, 0, len(defaultGates))
+	for feature := range defaultGates {
+		known = append(known, feature)
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i] < known[j] })
+	return known
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestNewFeatureGates(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     string
+		expected bool
+		wantErr  string
+	}{
+		{name: "empty spec keeps defaults", spec: "", expected: defaultGates[ManifestLabels]},
+		{name: "feature could be disabled", spec: "ManifestLabels=false", expected: false},
+		{name: "feature could be enabled", spec: " ManifestLabels = true ,", expected: true},
+		{name: "unknown feature is rejected", spec: "Unknown=true", wantErr: `unknown feature gate "Unknown"`},
+		{name: "missing value is rejected", spec: "ManifestLabels", wantErr: "expected Feature=true|false"},
+		{name: "invalid value is rejected", spec: "ManifestLabels=maybe", wantErr: `invalid value "maybe"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			gates, err := NewFeatureGates(tc.spec)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, gates.Enabled(ManifestLabels), tc.expected)
+		})
+	}
+}
+
+func TestNilFeatureGatesUseDefaults(t *testing.T) {
+	var gates *FeatureGates
+	for _, feature := range Known() {
+		assert.Equal(t, gates.Enabled(feature), defaultGates[feature])
+	}
+	assert.Equal(t, gates.Enabled(Feature("Unknown")), false)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package imagerepository helps other services to create ImageRepository objects and to wait for their provision,
+// so integrators don't have to build the objects by hand. The helpers work with any controller-runtime client.
+package imagerepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+const (
+	// ApplicationLabelName and ComponentLabelName link an ImageRepository to a Component,
+	// the pull secret is then created and linked to the Application service account.
+	ApplicationLabelName = "appstudio.redhat.com/application"
+	ComponentLabelName   = "appstudio.redhat.com/component"
+
+	// DefaultPollInterval is the interval of status checks in WaitForReady, if not given.
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Option customizes ImageRepository created by New.
+type Option func(imageRepository *imagerepositoryv1alpha1.ImageRepository)
+
+// WithImageName sets the name of the image within the Quay organization, namespace/name is used if not set.
+func WithImageName(imageName string) Option {
+	return func(imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+		imageRepository.Spec.Image.Name = imageName
+	}
+}
+
+// WithVisibility sets visibility of the image repository, the controller default is used if not set.
+func WithVisibility(visibility imagerepositoryv1alpha1.ImageVisibility) Option {
+	return func(imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+		imageRepository.Spec.Image.Visibility = visibility
+	}
+}
+
+// ForComponent links the ImageRepository to the Component of the Application.
+func ForComponent(applicationName, componentName string) Option {
+	return func(imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+		if imageRepository.Labels == nil {
+			imageRepository.Labels = map[string]string{}
+		}
+		imageRepository.Labels[ApplicationLabelName] = applicationName
+		imageRepository.Labels[ComponentLabelName] = componentName
+	}
+}
+
+// WithNotifications adds Quay notifications of the image repository.
+func WithNotifications(notifications ...imagerepositoryv1alpha1.Notifications) Option {
+	return func(imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+		imageRepository.Spec.Notifications = append(imageRepository.Spec.Notifications, notifications...)
+	}
+}
+
+// New returns ImageRepository object to be created in the namespace.
+// Fields not set by the options are left empty, so the controller defaults apply.
+func New(namespace, name string, options ...Option) *imagerepositoryv1alpha1.ImageRepository {
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: imagerepositoryv1alpha1.GroupVersion.String(),
+			Kind:       "ImageRepository",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	for _, option := range options {
+		option(imageRepository)
+	}
+	return imageRepository
+}
+
+// IsReady checks that the image repository is provisioned and could be used.
+func IsReady(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateReady
+}
+
+// IsFailed checks that the provision failed permanently, the reason is in the status message.
+func IsFailed(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return imageRepository.Status.State == imagerepositoryv1alpha1.ImageRepositoryStateFailed
+}
+
+// IsPendingQuota checks that the private image repository waits for Quay organization quota.
+func IsPendingQuota(imageRepository *imagerepositoryv1alpha1.ImageRepository) bool {
+	return meta.IsStatusConditionTrue(imageRepository.Status.Conditions, imagerepositoryv1alpha1.ConditionTypePendingQuota)
+}
+
+// GetCondition returns the status condition of the given type, e.g. imagerepositoryv1alpha1.ConditionTypePushBlocked,
+// nil if it's not set.
+func GetCondition(imageRepository *imagerepositoryv1alpha1.ImageRepository, conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(imageRepository.Status.Conditions, conditionType)
+}
+
+// FailedError is returned by WaitForReady if the provision failed.
+type FailedError struct {
+	// Message is the status message of the failed ImageRepository.
+	Message string
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("image repository provision failed: %s", e.Message)
+}
+
+// WaitForReady polls the ImageRepository until it's ready and returns it.
+// Returns FailedError if the provision fails, or the context error if the context is done first.
+// DefaultPollInterval is used if pollInterval is not positive.
+func WaitForReady(ctx context.Context, c client.Client, key types.NamespacedName, pollInterval time.Duration) (*imagerepositoryv1alpha1.ImageRepository, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	err := wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		if err := c.Get(ctx, key, imageRepository); err != nil {
+			// The object might not be in the cache yet right after creation
+			return false, client.IgnoreNotFound(err)
+		}
+		if IsFailed(imageRepository) {
+			return false, &FailedError{Message: imageRepository.Status.Message}
+		}
+		return IsReady(imageRepository), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return imageRepository, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imagerepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+func TestNew(t *testing.T) {
+	imageRepository := New("test-ns", "my-image",
+		WithImageName("test-ns/custom"),
+		WithVisibility(imagerepositoryv1alpha1.ImageVisibilityPrivate),
+		ForComponent("my-app", "my-component"))
+
+	if imageRepository.Namespace != "test-ns" || imageRepository.Name != "my-image" || imageRepository.Kind != "ImageRepository" {
+		t.Errorf("unexpected object: %v", imageRepository.ObjectMeta)
+	}
+	if imageRepository.Spec.Image.Name != "test-ns/custom" || imageRepository.Spec.Image.Visibility != imagerepositoryv1alpha1.ImageVisibilityPrivate {
+		t.Errorf("unexpected image parameters: %v", imageRepository.Spec.Image)
+	}
+	if imageRepository.Labels[ApplicationLabelName] != "my-app" || imageRepository.Labels[ComponentLabelName] != "my-component" {
+		t.Errorf("expected Component labels, got %v", imageRepository.Labels)
+	}
+
+	defaultImageRepository := New("test-ns", "my-image")
+	if defaultImageRepository.Spec.Image.Name != "" || defaultImageRepository.Spec.Image.Visibility != "" || defaultImageRepository.Labels != nil {
+		t.Errorf("expected controller defaults to apply, got %v", defaultImageRepository.Spec)
+	}
+}
+
+func TestStatusAccessors(t *testing.T) {
+	imageRepository := New("test-ns", "my-image")
+	meta.SetStatusCondition(&imageRepository.Status.Conditions, metav1.Condition{
+		Type:   imagerepositoryv1alpha1.ConditionTypePendingQuota,
+		Status: metav1.ConditionTrue,
+		Reason: "QuotaExceeded",
+	})
+	if IsReady(imageRepository) || IsFailed(imageRepository) || !IsPendingQuota(imageRepository) {
+		t.Errorf("expected pending image repository")
+	}
+	if condition := GetCondition(imageRepository, imagerepositoryv1alpha1.ConditionTypePendingQuota); condition == nil || condition.Reason != "QuotaExceeded" {
+		t.Errorf("unexpected condition: %v", condition)
+	}
+	if GetCondition(imageRepository, imagerepositoryv1alpha1.ConditionTypePushBlocked) != nil {
+		t.Errorf("expected missing condition to be nil")
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := imagerepositoryv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	readyImageRepository := New("test-ns", "ready")
+	readyImageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateReady
+	failedImageRepository := New("test-ns", "failed")
+	failedImageRepository.Status.State = imagerepositoryv1alpha1.ImageRepositoryStateFailed
+	failedImageRepository.Status.Message = "invalid image name"
+	pendingImageRepository := New("test-ns", "pending")
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(readyImageRepository, failedImageRepository, pendingImageRepository).Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	imageRepository, err := WaitForReady(ctx, fakeClient, types.NamespacedName{Namespace: "test-ns", Name: "ready"}, time.Millisecond)
+	if err != nil || imageRepository.Name != "ready" {
+		t.Errorf("expected ready image repository, got %v, %v", imageRepository, err)
+	}
+
+	_, err = WaitForReady(ctx, fakeClient, types.NamespacedName{Namespace: "test-ns", Name: "failed"}, time.Millisecond)
+	failedErr := &FailedError{}
+	if !errors.As(err, &failedErr) || failedErr.Message != "invalid image name" {
+		t.Errorf("expected provision failure, got %v", err)
+	}
+
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shortCancel()
+	if _, err := WaitForReady(shortCtx, fakeClient, types.NamespacedName{Namespace: "test-ns", Name: "pending"}, time.Millisecond); err == nil {
+		t.Errorf("expected wait for pending image repository to time out")
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	resultLabel = "result"
+
+	ReconcileResultSuccess = "success"
+	ReconcileResultFailure = "failure"
+
+	// errorBudgetBucketSize is the granularity of the rolling error rate window.
+	errorBudgetBucketSize = time.Minute
+)
+
+var (
+	ReconcileResultsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "reconcile_results_total",
+		Help:      "The number of successful and failed reconciles per controller.",
+	}, []string{controllerLabel, resultLabel})
+
+	ReconcileErrorRateMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "reconcile_error_rate",
+		Help:      "The ratio of failed reconciles per controller within the rolling error budget window.",
+	}, []string{controllerLabel})
+
+	// ErrorBudgetWindow is the length of the rolling window of ReconcileErrorRateMetric.
+	ErrorBudgetWindow = 10 * time.Minute
+
+	reconcileErrorBudget = newErrorBudgetTracker(time.Now)
+)
+
+// ObserveReconcileResult counts the reconcile result of the controller and updates its rolling error rate.
+func ObserveReconcileResult(controller string, reconcileErr error) {
+	result := ReconcileResultSuccess
+	if reconcileErr != nil {
+		result = ReconcileResultFailure
+	}
+	ReconcileResultsMetric.WithLabelValues(controller, result).Inc()
+	ReconcileErrorRateMetric.WithLabelValues(controller).Set(reconcileErrorBudget.observe(controller, reconcileErr != nil, ErrorBudgetWindow))
+}
+
+type errorBudgetBucket struct {
+	start  time.Time
+	total  int
+	failed int
+}
+
+// errorBudgetTracker keeps reconcile results per controller in minute buckets, so the memory doesn't grow with reconciles.
+type errorBudgetTracker struct {
+	lock    sync.Mutex
+	buckets map[string][]errorBudgetBucket
+	now     func() time.Time
+}
+
+func newErrorBudgetTracker(now func() time.Time) *errorBudgetTracker {
+	return &errorBudgetTracker{buckets: map[string][]errorBudgetBucket{}, now: now}
+}
+
+// observe records the result and returns the ratio of failures within the window.
+func (t *errorBudgetTracker) observe(controller string, failed bool, window time.Duration) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	bucketStart := now.Truncate(errorBudgetBucketSize)
+	buckets := t.buckets[controller]
+	if len(buckets) == 0 || !buckets[len(buckets)-1].start.Equal(bucketStart) {
+		buckets = append(buckets, errorBudgetBucket{start: bucketStart})
+	}
+	buckets[len(buckets)-1].total++
+	if failed {
+		buckets[len(buckets)-1].failed++
+	}
+
+	windowStart := now.Add(-window)
+	for len(buckets) > 1 && !buckets[0].start.After(windowStart) {
+		buckets = buckets[1:]
+	}
+	t.buckets[controller] = buckets
+
+	total, failures := 0, 0
+	for _, bucket := range buckets {
+		total += bucket.total
+		failures += bucket.failed
+	}
+	return float64(failures) / float64(total)
+}
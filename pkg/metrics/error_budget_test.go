@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveReconcileResult(t *testing.T) {
+	defer ReconcileResultsMetric.Reset()
+	defer ReconcileErrorRateMetric.Reset()
+	defer func() { reconcileErrorBudget = newErrorBudgetTracker(time.Now) }()
+	reconcileErrorBudget = newErrorBudgetTracker(time.Now)
+
+	ObserveReconcileResult("imagerepository", nil)
+	ObserveReconcileResult("imagerepository", nil)
+	ObserveReconcileResult("imagerepository", nil)
+	ObserveReconcileResult("imagerepository", errors.New("quay is down"))
+	ObserveReconcileResult("component", nil)
+
+	if count := testutil.ToFloat64(ReconcileResultsMetric.WithLabelValues("imagerepository", ReconcileResultSuccess)); count != 3 {
+		t.Errorf("expected 3 successful reconciles, got %v", count)
+	}
+	if count := testutil.ToFloat64(ReconcileResultsMetric.WithLabelValues("imagerepository", ReconcileResultFailure)); count != 1 {
+		t.Errorf("expected 1 failed reconcile, got %v", count)
+	}
+	if rate := testutil.ToFloat64(ReconcileErrorRateMetric.WithLabelValues("imagerepository")); rate != 0.25 {
+		t.Errorf("expected error rate 0.25, got %v", rate)
+	}
+	if rate := testutil.ToFloat64(ReconcileErrorRateMetric.WithLabelValues("component")); rate != 0 {
+		t.Errorf("expected error rate per controller, got %v", rate)
+	}
+}
+
+func TestErrorBudgetTracker(t *testing.T) {
+	now := time.Date(2023, 8, 23, 14, 0, 0, 0, time.UTC)
+	tracker := newErrorBudgetTracker(func() time.Time { return now })
+	window := 10 * time.Minute
+
+	tracker.observe("imagerepository", true, window)
+	tracker.observe("imagerepository", true, window)
+	now = now.Add(5 * time.Minute)
+	if rate := tracker.observe("imagerepository", false, window); rate != 2.0/3 {
+		t.Errorf("expected failures within the window to count, got %v", rate)
+	}
+
+	now = now.Add(6 * time.Minute)
+	if rate := tracker.observe("imagerepository", false, window); rate != 0 {
+		t.Errorf("expected failures out of the window to be dropped, got %v", rate)
+	}
+	if buckets := len(tracker.buckets["imagerepository"]); buckets != 2 {
+		t.Errorf("expected old buckets to be pruned, got %d buckets", buckets)
+	}
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sync"
 	"time"
 )
 
 const (
 	MetricsNamespace = "redhat_appstudio"
 	MetricsSubsystem = "imagecontroller"
+
+	DefaultProbeInterval = time.Minute
 )
 
 var (
@@ -31,33 +34,161 @@ var (
 		Help:      "The time in seconds spent from the moment of Image repository provision request to Image repository failure.",
 	})
 
+	QuayDryRunInterceptedCallsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_dry_run_intercepted_calls_total",
+		Help:      "The number of Quay API calls skipped because the controller runs in global dry-run mode.",
+	}, []string{"operation"})
+
+	QuayFetchedPagesMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_fetched_pages_total",
+		Help:      "The number of fetched pages of paginated Quay API results.",
+	}, []string{"operation"})
+
+	QuayPostCreateRetriesMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_post_create_retries_total",
+		Help:      "The number of Quay API calls retried because a just created image repository was not found yet.",
+	}, []string{"operation"})
+
+	QuayRateLimitRemainingMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_rate_limit_remaining",
+		Help:      "The number of Quay API requests left in the current rate limit window, as last reported by Quay.",
+	})
+
+	QuayRateLimitLimitMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_rate_limit_limit",
+		Help:      "The number of Quay API requests allowed in the rate limit window, as last reported by Quay.",
+	})
+
+	OrphanedSecretsDeletedMetric = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "orphaned_secrets_deleted_total",
+		Help:      "The number of deleted generated secrets left behind by already deleted image repositories.",
+	})
+
+	RepositoryDeletionPausedMetric = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "repository_deletion_paused",
+		Help:      "Set to 1 while image repository deletion is paused, e.g. during Quay organization migration.",
+	})
+
+	ImageRepositoryPushBlockedMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "image_repository_push_blocked",
+		Help:      "Set to 1 for each image repository that rejects pushes because of its state in Quay, the reason label tells the state.",
+	}, []string{"namespace", "name", "reason"})
+
+	FeatureGateEnabledMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "feature_enabled",
+		Help:      "Set to 1 if the feature gate given by the name label is enabled, 0 otherwise.",
+	}, []string{"name"})
+
+	RetainedRepositoriesMetric = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "retained_repositories_total",
+		Help:      "The number of image repository deletions skipped because image repository deletion is paused.",
+	})
+
+	SpecRevertsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "spec_reverts_total",
+		Help:      "The number of image repository spec changes reverted by the controller, by the reverted field and reason.",
+	}, []string{"namespace", "field", "reason"})
+
+	ReservedRepositoryNameRejectionsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "reserved_repository_name_rejections_total",
+		Help:      "The number of image repository provisions rejected because the requested name is reserved.",
+	}, []string{"namespace"})
+
+	AvailabilityProbeDurationMetric = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		Name:      "availability_probe_duration_seconds",
+		Help:      "The time in seconds spent by availability probe checks, including the failed ones.",
+	}, []string{probeLabel, registryLabel})
+
+	AvailabilityProbeErrorsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "availability_probe_errors_total",
+		Help:      "The number of failed availability probe checks, the class label tells the cause, e.g. dns, tls, timeout, auth or 5xx.",
+	}, []string{probeLabel, registryLabel, "class"})
+
 	RepositoryTimesForMetrics = map[string]time.Time{}
 )
 
 func (m *ImageControllerMetrics) InitMetrics(registerer prometheus.Registerer) error {
 	// controller metrics
-	registerer.MustRegister(ImageRepositoryProvisionTimeMetric, ImageRepositoryProvisionFailureTimeMetric)
+	registerer.MustRegister(ImageRepositoryProvisionTimeMetric, ImageRepositoryProvisionFailureTimeMetric, QuayDryRunInterceptedCallsMetric, QuayFetchedPagesMetric, QuayPostCreateRetriesMetric, OrphanedSecretsDeletedMetric,
+		RepositoryDeletionPausedMetric, RetainedRepositoriesMetric, QuayRateLimitRemainingMetric, QuayRateLimitLimitMetric, FeatureGateEnabledMetric,
+		ImageRepositoryPushBlockedMetric, ReconcileDurationMetric, QuayRequestsMetric, ReconcileResultsMetric, ReconcileErrorRateMetric, SpecRevertsMetric,
+		AvailabilityProbeDurationMetric, AvailabilityProbeErrorsMetric, ReservedRepositoryNameRejectionsMetric)
 	// availability metrics
+	m.probesLock.Lock()
+	defer m.probesLock.Unlock()
 	for _, probe := range m.probes {
 		if err := registerer.Register(probe.AvailabilityGauge()); err != nil {
 			return fmt.Errorf("failed to register the availability metric: %w", err)
 		}
 	}
+	m.registerer = registerer
 	return nil
 }
 
 // ImageControllerMetrics represents a collection of metrics to be registered on a
 // Prometheus metrics registry for a image controller service.
 type ImageControllerMetrics struct {
-	probes []AvailabilityProbe
+	// ProbeInterval is the period of availability probe checks, DefaultProbeInterval if not set.
+	ProbeInterval time.Duration
+
+	probes     []AvailabilityProbe
+	probesLock sync.Mutex
+	registerer prometheus.Registerer
 }
 
 func NewImageControllerMetrics(probes []AvailabilityProbe) *ImageControllerMetrics {
 	return &ImageControllerMetrics{probes: probes}
 }
 
+// RegisterProbe adds the probe to the checked ones.
+// Could be called at any time, the probe availability metric is registered if metrics are already initialized.
+func (m *ImageControllerMetrics) RegisterProbe(probe AvailabilityProbe) error {
+	m.probesLock.Lock()
+	defer m.probesLock.Unlock()
+	if m.registerer != nil {
+		if err := m.registerer.Register(probe.AvailabilityGauge()); err != nil {
+			return fmt.Errorf("failed to register the availability metric: %w", err)
+		}
+	}
+	m.probes = append(m.probes, probe)
+	return nil
+}
+
 func (m *ImageControllerMetrics) StartMetrics(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
+	probeInterval := m.ProbeInterval
+	if probeInterval <= 0 {
+		probeInterval = DefaultProbeInterval
+	}
+	ticker := time.NewTicker(probeInterval)
 	log := ctrllog.FromContext(ctx)
 	log.Info("Starting image controller metrics")
 	go func() {
@@ -75,11 +206,20 @@ func (m *ImageControllerMetrics) StartMetrics(ctx context.Context) {
 }
 
 func (m *ImageControllerMetrics) checkProbes(ctx context.Context) {
-	for _, probe := range m.probes {
+	m.probesLock.Lock()
+	probes := append([]AvailabilityProbe{}, m.probes...)
+	m.probesLock.Unlock()
+
+	for _, probe := range probes {
+		labels := probe.ProbeLabels()
+		startTime := time.Now()
 		pingErr := probe.CheckAvailability(ctx)
+		AvailabilityProbeDurationMetric.With(labels).Observe(time.Since(startTime).Seconds())
 		if pingErr != nil {
+			errorClass := ClassifyProbeError(pingErr)
 			log := ctrllog.FromContext(ctx)
-			log.Error(pingErr, "Error checking availability probe", "probe", probe)
+			log.Error(pingErr, "Error checking availability probe", "probe", probe, "errorClass", errorClass)
+			AvailabilityProbeErrorsMetric.With(prometheus.Labels{probeLabel: labels[probeLabel], registryLabel: labels[registryLabel], "class": errorClass}).Inc()
 			probe.AvailabilityGauge().Set(0)
 		} else {
 			probe.AvailabilityGauge().Set(1)
@@ -91,4 +231,6 @@ func (m *ImageControllerMetrics) checkProbes(ctx context.Context) {
 type AvailabilityProbe interface {
 	CheckAvailability(ctx context.Context) error
 	AvailabilityGauge() prometheus.Gauge
+	// ProbeLabels returns the probe and registry labels of the probe, used by the probe duration and errors metrics.
+	ProbeLabels() prometheus.Labels
 }
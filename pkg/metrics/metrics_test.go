@@ -12,10 +12,13 @@ import (
 
 func TestRegisterMetrics(t *testing.T) {
 	t.Run("Should register and record availability metric", func(t *testing.T) {
-		probe, err := NewQuayAvailabilityProbe(context.Background(), getTestClient, quay.TestQuayOrg)
+		probe, err := NewQuayAvailabilityProbe(context.Background(), getTestClient, quay.TestQuayOrg, "https://quay.io/api/v1")
 		if err != nil {
 			t.Errorf("Fail to register probe: %v", err)
 		}
+		if registry := probe.ProbeLabels()[registryLabel]; registry != "quay.io" {
+			t.Errorf("Expected registry label of the Quay API URL, got: %v", registry)
+		}
 		buildMetrics := NewImageControllerMetrics([]AvailabilityProbe{probe})
 		registry := prometheus.NewPedanticRegistry()
 		err = buildMetrics.InitMetrics(registry)
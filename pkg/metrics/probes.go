@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	probeLabel    = "probe"
+	registryLabel = "registry"
+
+	HTTPProbeType          = "http"
+	TokenValidityProbeType = "token"
+
+	probeTimeout = 10 * time.Second
+
+	// Error classes of failed probe checks, shown in the class label of the probe errors metric.
+	ProbeErrorClassDNS        = "dns"
+	ProbeErrorClassTLS        = "tls"
+	ProbeErrorClassTimeout    = "timeout"
+	ProbeErrorClassAuth       = "auth"
+	ProbeErrorClassServer     = "5xx"
+	ProbeErrorClassStatusCode = "status"
+	ProbeErrorClassOther      = "other"
+)
+
+// probeStatusError is returned by checks of endpoints that respond with unexpected status code.
+type probeStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *probeStatusError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden {
+		return fmt.Sprintf("token is rejected by %s, status code: %d", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("unexpected response from %s, status code: %d", e.URL, e.StatusCode)
+}
+
+// ClassifyProbeError returns class of the failed probe check, so outages of DNS, TLS, or the registry itself could be told apart.
+func ClassifyProbeError(err error) string {
+	var dnsErr *net.DNSError
+	var statusErr *probeStatusError
+	var certVerificationErr *tls.CertificateVerificationError
+	var recordHeaderErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return ProbeErrorClassDNS
+	case errors.As(err, &certVerificationErr), errors.As(err, &recordHeaderErr), errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &certInvalidErr):
+		return ProbeErrorClassTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ProbeErrorClassTimeout
+	case errors.As(err, &statusErr):
+		return classifyStatusCode(statusErr.StatusCode)
+	}
+	// Quay client used by the organization mode of the Quay probe
+	if statusCode, isStatusErr := quay.GetStatusCode(err); isStatusErr {
+		return classifyStatusCode(statusCode)
+	}
+	return ProbeErrorClassOther
+}
+
+func classifyStatusCode(statusCode int) string {
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return ProbeErrorClassAuth
+	}
+	if statusCode >= 500 {
+		return ProbeErrorClassServer
+	}
+	return ProbeErrorClassStatusCode
+}
+
+// newAvailabilityGauge creates availability metric of the probe.
+// All probes share the metric name and are distinguished by the probe and registry labels.
+func newAvailabilityGauge(probeName, registry string) prometheus.Gauge {
+	return prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: MetricsNamespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "registry_available",
+			Help:      "The availability of the registry checked by the probe",
+			ConstLabels: prometheus.Labels{
+				probeLabel:    probeName,
+				registryLabel: registry,
+			},
+		})
+}
+
+// HTTPAvailabilityProbe checks that the endpoint responds with success status code.
+type HTTPAvailabilityProbe struct {
+	Name       string
+	URL        string
+	httpClient *http.Client
+	gauge      prometheus.Gauge
+}
+
+func NewHTTPAvailabilityProbe(name, url string, httpClient *http.Client) (*HTTPAvailabilityProbe, error) {
+	registry, err := getRegistryHost(url)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPAvailabilityProbe{
+		Name:       name,
+		URL:        url,
+		httpClient: httpClient,
+		gauge:      newAvailabilityGauge(name, registry),
+	}, nil
+}
+
+func (p *HTTPAvailabilityProbe) CheckAvailability(ctx context.Context) error {
+	return checkEndpoint(ctx, p.httpClient, p.URL, "")
+}
+
+func (p *HTTPAvailabilityProbe) AvailabilityGauge() prometheus.Gauge {
+	return p.gauge
+}
+
+func (p *HTTPAvailabilityProbe) ProbeLabels() prometheus.Labels {
+	registry, _ := getRegistryHost(p.URL)
+	return prometheus.Labels{probeLabel: p.Name, registryLabel: registry}
+}
+
+// TokenValidityProbe checks that the registry accepts the token.
+// The token is read on each check, so it could be rotated without restart.
+type TokenValidityProbe struct {
+	Name       string
+	URL        string
+	TokenPath  string
+	httpClient *http.Client
+	gauge      prometheus.Gauge
+}
+
+func NewTokenValidityProbe(name, url, tokenPath string, httpClient *http.Client) (*TokenValidityProbe, error) {
+	registry, err := getRegistryHost(url)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenValidityProbe{
+		Name:       name,
+		URL:        url,
+		TokenPath:  tokenPath,
+		httpClient: httpClient,
+		gauge:      newAvailabilityGauge(name, registry),
+	}, nil
+}
+
+func (p *TokenValidityProbe) CheckAvailability(ctx context.Context) error {
+	/* #nosec the path is set by the controller administrator */
+	tokenContent, err := os.ReadFile(p.TokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(tokenContent))
+	if token == "" {
+		return fmt.Errorf("token in %s is empty", p.TokenPath)
+	}
+	return checkEndpoint(ctx, p.httpClient, p.URL, token)
+}
+
+func (p *TokenValidityProbe) AvailabilityGauge() prometheus.Gauge {
+	return p.gauge
+}
+
+func (p *TokenValidityProbe) ProbeLabels() prometheus.Labels {
+	registry, _ := getRegistryHost(p.URL)
+	return prometheus.Labels{probeLabel: p.Name, registryLabel: registry}
+}
+
+func checkEndpoint(ctx context.Context, httpClient *http.Client, url, token string) error {
+	return doCheck(ctx, httpClient, url, func(req *http.Request) {
+		if token != "" {
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		}
+	})
+}
+
+func checkEndpointWithBasicAuth(ctx context.Context, httpClient *http.Client, url, username, password string) error {
+	return doCheck(ctx, httpClient, url, func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	})
+}
+
+func doCheck(ctx context.Context, httpClient *http.Client, url string, authorize func(req *http.Request)) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	authorize(req)
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &probeStatusError{URL: url, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+func getRegistryHost(url string) (string, error) {
+	parsedUrl, err := neturl.Parse(url)
+	if err != nil {
+		return "", fmt.Errorf("invalid probe url %s: %w", url, err)
+	}
+	if parsedUrl.Host == "" {
+		return "", fmt.Errorf("invalid probe url %s: host is missing", url)
+	}
+	return parsedUrl.Host, nil
+}
+
+// ProbesConfig defines additional availability probes, e.g. for other registries.
+type ProbesConfig struct {
+	Probes []ProbeConfig `json:"probes"`
+}
+
+type ProbeConfig struct {
+	// Name is unique name of the probe, shown in the probe label of the availability metric.
+	Name string `json:"name"`
+	// Type is one of: http, token.
+	Type string `json:"type"`
+	// URL is the checked endpoint.
+	URL string `json:"url"`
+	// TokenPath is path to the file with bearer token, required for token probes.
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
+// NewProbesFromConfig creates availability probes defined in the given YAML or JSON config file.
+func NewProbesFromConfig(configPath string, httpClient *http.Client) ([]AvailabilityProbe, error) {
+	/* #nosec the path is set by the controller administrator */
+	configContent, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probes config: %w", err)
+	}
+	config := &ProbesConfig{}
+	if err := yaml.UnmarshalStrict(configContent, config); err != nil {
+		return nil, fmt.Errorf("failed to parse probes config: %w", err)
+	}
+
+	probes := []AvailabilityProbe{}
+	probeNames := map[string]bool{}
+	for _, probeConfig := range config.Probes {
+		if probeConfig.Name == "" {
+			return nil, fmt.Errorf("probe name is required")
+		}
+		if probeNames[probeConfig.Name] {
+			return nil, fmt.Errorf("duplicate probe name: %s", probeConfig.Name)
+		}
+		probeNames[probeConfig.Name] = true
+
+		var probe AvailabilityProbe
+		switch probeConfig.Type {
+		case HTTPProbeType:
+			probe, err = NewHTTPAvailabilityProbe(probeConfig.Name, probeConfig.URL, httpClient)
+		case TokenValidityProbeType:
+			if probeConfig.TokenPath == "" {
+				return nil, fmt.Errorf("tokenPath is required for %s probe", probeConfig.Name)
+			}
+			probe, err = NewTokenValidityProbe(probeConfig.Name, probeConfig.URL, probeConfig.TokenPath, httpClient)
+		default:
+			return nil, fmt.Errorf("unknown type %q of %s probe", probeConfig.Type, probeConfig.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, probe)
+	}
+	return probes, nil
+}
@@ -0,0 +1,343 @@
+package metrics
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHTTPAvailabilityProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	probe, err := NewHTTPAvailabilityProbe("mirror", server.URL+"/health", server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err != nil {
+		t.Errorf("expected endpoint to be available, got: %v", err)
+	}
+
+	probe, err = NewHTTPAvailabilityProbe("mirror", server.URL+"/unavailable", server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err == nil {
+		t.Error("expected endpoint to be unavailable")
+	}
+
+	if _, err := NewHTTPAvailabilityProbe("mirror", "not-a-url", server.Client()); err == nil {
+		t.Error("expected error for url without host")
+	}
+}
+
+func TestTokenValidityProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer valid-token" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	probe, err := NewTokenValidityProbe("quay-token", server.URL, tokenPath, server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+
+	if err := probe.CheckAvailability(context.Background()); err == nil {
+		t.Error("expected error if token file is missing")
+	}
+
+	if err := os.WriteFile(tokenPath, []byte("valid-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err != nil {
+		t.Errorf("expected token to be valid, got: %v", err)
+	}
+
+	if err := os.WriteFile(tokenPath, []byte("expired-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err == nil {
+		t.Error("expected rotated invalid token to be rejected")
+	}
+}
+
+func TestNewProbesFromConfig(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         string
+		expectedProbes int
+		expectError    bool
+	}{
+		{
+			name: "should create configured probes",
+			config: `
+probes:
+- name: mirror
+  type: http
+  url: https://mirror.example.com/health
+- name: quay-token
+  type: token
+  url: https://quay.io/api/v1/user/
+  tokenPath: /workspace/quaytoken
+`,
+			expectedProbes: 2,
+		},
+		{
+			name:           "should allow empty config",
+			config:         `probes: []`,
+			expectedProbes: 0,
+		},
+		{
+			name: "should fail on unknown probe type",
+			config: `
+probes:
+- name: mirror
+  type: ping
+  url: https://mirror.example.com
+`,
+			expectError: true,
+		},
+		{
+			name: "should fail on duplicate probe names",
+			config: `
+probes:
+- name: mirror
+  type: http
+  url: https://mirror.example.com
+- name: mirror
+  type: http
+  url: https://other-mirror.example.com
+`,
+			expectError: true,
+		},
+		{
+			name: "should fail on token probe without token",
+			config: `
+probes:
+- name: quay-token
+  type: token
+  url: https://quay.io/api/v1/user/
+`,
+			expectError: true,
+		},
+		{
+			name: "should fail on unknown fields",
+			config: `
+probes:
+- name: mirror
+  type: http
+  endpoint: https://mirror.example.com
+`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "probes.yaml")
+			if err := os.WriteFile(configPath, []byte(tc.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			probes, err := NewProbesFromConfig(configPath, http.DefaultClient)
+			if tc.expectError {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(probes) != tc.expectedProbes {
+				t.Errorf("expected %d probes, got %d", tc.expectedProbes, len(probes))
+			}
+		})
+	}
+}
+
+func TestRegisterProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	buildMetrics := NewImageControllerMetrics(nil)
+	registry := prometheus.NewPedanticRegistry()
+	if err := buildMetrics.InitMetrics(registry); err != nil {
+		t.Fatalf("Fail to register metrics: %v", err)
+	}
+
+	for _, name := range []string{"mirror", "backup-mirror"} {
+		probe, err := NewHTTPAvailabilityProbe(name, server.URL, server.Client())
+		if err != nil {
+			t.Fatalf("failed to create probe: %v", err)
+		}
+		if err := buildMetrics.RegisterProbe(probe); err != nil {
+			t.Fatalf("failed to register probe: %v", err)
+		}
+	}
+
+	buildMetrics.checkProbes(context.Background())
+
+	count, err := testutil.GatherAndCount(registry, "redhat_appstudio_imagecontroller_registry_available")
+	if err != nil {
+		t.Errorf("Fail to gather metrics: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected metric per probe. Expected 2 got : %v", count)
+	}
+}
+
+func TestLowPrivilegeQuayAvailabilityProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" && r.URL.Path == "/api/v1/discovery" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		username, password, hasBasicAuth := r.BasicAuth()
+		switch {
+		case r.URL.Path == "/api/v1/discovery":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v2/auth" && r.URL.Query().Get("account") == "my-org+probe" && hasBasicAuth && username == "my-org+probe" && password == "robot-token":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/oauth2/federation/robot/token" && hasBasicAuth && username == "my-org+probe" && password == "oidc-token":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	apiURL := server.URL + "/api/v1"
+
+	probe, err := NewLowPrivilegeQuayAvailabilityProbe(QuayProbeModeAnonymous, apiURL, "", "", "", server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err != nil {
+		t.Errorf("expected anonymous check to pass, got: %v", err)
+	}
+	probe, err = NewLowPrivilegeQuayAvailabilityProbe(QuayProbeModeAnonymous, apiURL, server.URL+"/health", "", "", server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err == nil {
+		t.Error("expected configured probe url to be checked")
+	}
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("robot-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	probe, err = NewLowPrivilegeQuayAvailabilityProbe(QuayProbeModeRobot, apiURL, "", "my-org+probe", tokenPath, server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err != nil {
+		t.Errorf("expected robot account login to pass, got: %v", err)
+	}
+	probe.Mode = QuayProbeModeFederatedRobot
+	if err := probe.CheckAvailability(context.Background()); err == nil {
+		t.Error("expected robot token to be rejected by token exchange")
+	}
+
+	if err := os.WriteFile(tokenPath, []byte("oidc-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := probe.CheckAvailability(context.Background()); err != nil {
+		t.Errorf("expected token exchange to pass, got: %v", err)
+	}
+
+	if _, err := NewLowPrivilegeQuayAvailabilityProbe(QuayProbeModeRobot, apiURL, "", "my-org+probe", "", server.Client()); err == nil {
+		t.Error("expected error if token path is missing")
+	}
+	if _, err := NewLowPrivilegeQuayAvailabilityProbe("admin", apiURL, "", "", "", server.Client()); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if registry := probe.ProbeLabels()[registryLabel]; registry != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("expected registry label of the api url, got %s", registry)
+	}
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer proxy.Close()
+	probe, err = NewLowPrivilegeQuayAvailabilityProbe(QuayProbeModeRobot, apiURL, proxy.URL, "my-org+probe", tokenPath, server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	if err := probe.CheckAvailability(context.Background()); ClassifyProbeError(err) != ProbeErrorClassServer {
+		t.Errorf("expected configured probe url to be checked in robot mode, got: %v", err)
+	}
+	if registry := probe.ProbeLabels()[registryLabel]; registry != strings.TrimPrefix(proxy.URL, "http://") {
+		t.Errorf("expected registry label of the probe url, got %s", registry)
+	}
+}
+
+func TestClassifyProbeError(t *testing.T) {
+	testCases := []struct {
+		err           error
+		expectedClass string
+	}{
+		{err: fmt.Errorf("failed to Do request: %w", &net.DNSError{Err: "no such host", Name: "quay.io"}), expectedClass: ProbeErrorClassDNS},
+		{err: fmt.Errorf("failed to Do request: %w", x509.UnknownAuthorityError{}), expectedClass: ProbeErrorClassTLS},
+		{err: fmt.Errorf("failed to Do request: %w", context.DeadlineExceeded), expectedClass: ProbeErrorClassTimeout},
+		{err: &probeStatusError{URL: "https://quay.io", StatusCode: http.StatusForbidden}, expectedClass: ProbeErrorClassAuth},
+		{err: &probeStatusError{URL: "https://quay.io", StatusCode: http.StatusBadGateway}, expectedClass: ProbeErrorClassServer},
+		{err: &probeStatusError{URL: "https://quay.io", StatusCode: http.StatusNotFound}, expectedClass: ProbeErrorClassStatusCode},
+		{err: &quay.StatusError{StatusCode: http.StatusUnauthorized, Message: "Unauthorized"}, expectedClass: ProbeErrorClassAuth},
+		{err: fmt.Errorf("failed to get robot account: %w", &quay.StatusError{StatusCode: http.StatusServiceUnavailable, Message: "503 Service Unavailable"}), expectedClass: ProbeErrorClassServer},
+		{err: &quay.StatusError{StatusCode: http.StatusBadRequest, Message: "Could not find robot with specified username"}, expectedClass: ProbeErrorClassStatusCode},
+		{err: errors.New("robot account not found"), expectedClass: ProbeErrorClassOther},
+	}
+	for _, tc := range testCases {
+		if class := ClassifyProbeError(tc.err); class != tc.expectedClass {
+			t.Errorf("expected class %s of %v, got %s", tc.expectedClass, tc.err, class)
+		}
+	}
+}
+
+func TestCheckProbesRecordsDurationAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	probe, err := NewHTTPAvailabilityProbe("failing-mirror", server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("failed to create probe: %v", err)
+	}
+	buildMetrics := NewImageControllerMetrics([]AvailabilityProbe{probe})
+	buildMetrics.checkProbes(context.Background())
+
+	labels := probe.ProbeLabels()
+	if value := testutil.ToFloat64(AvailabilityProbeErrorsMetric.WithLabelValues(labels[probeLabel], labels[registryLabel], ProbeErrorClassServer)); value != 1 {
+		t.Errorf("expected one 5xx error, got %v", value)
+	}
+	if count := testutil.CollectAndCount(AvailabilityProbeDurationMetric, "redhat_appstudio_imagecontroller_availability_probe_duration_seconds"); count == 0 {
+		t.Error("expected probe duration to be recorded")
+	}
+}
@@ -3,6 +3,10 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/konflux-ci/image-controller/pkg/quay"
@@ -13,30 +17,61 @@ import (
 type QuayAvailabilityProbe struct {
 	BuildQuayClient  func(logr.Logger) quay.QuayService
 	QuayOrganization string
-	gauge            prometheus.Gauge
+	// Registry is the host of the Quay API URL used by the client, shown in the registry label.
+	Registry string
+	gauge    prometheus.Gauge
 }
 
 const testRobotAccountName = "robot_konflux_api_healthcheck"
 
-func NewQuayAvailabilityProbe(ctx context.Context, clientBuilder func(logr.Logger) quay.QuayService, quayOrganization string) (*QuayAvailabilityProbe, error) {
-	client := clientBuilder(ctrllog.FromContext(ctx))
-	_, err := client.CreateRobotAccount(quayOrganization, testRobotAccountName)
+const (
+	// QuayProbeModeOrganization reads a test robot account with the organization token.
+	QuayProbeModeOrganization = "organization"
+	// QuayProbeModeAnonymous calls the Quay API discovery endpoint without credentials.
+	QuayProbeModeAnonymous = "anonymous"
+	// QuayProbeModeRobot logs a dedicated read-only robot account into the registry with its token.
+	QuayProbeModeRobot = "robot"
+	// QuayProbeModeFederatedRobot exchanges an OIDC token, e.g. projected service account token,
+	// for a token of a robot account that trusts the issuer through Quay robot federation.
+	QuayProbeModeFederatedRobot = "federated-robot"
+)
+
+// NewQuayAvailabilityProbe creates the probe, apiURL is the Quay API URL used by clients of clientBuilder, e.g. https://quay.io/api/v1
+func NewQuayAvailabilityProbe(ctx context.Context, clientBuilder func(logr.Logger) quay.QuayService, quayOrganization, apiURL string) (*QuayAvailabilityProbe, error) {
+	registry, err := getRegistryHost(apiURL)
 	if err != nil {
+		return nil, err
+	}
+	client := clientBuilder(ctrllog.FromContext(ctx))
+	if _, err := client.CreateRobotAccount(quayOrganization, testRobotAccountName); err != nil {
 		return nil, fmt.Errorf("could not create test robot account: %w", err)
 	}
 	return &QuayAvailabilityProbe{
 		BuildQuayClient:  clientBuilder,
 		QuayOrganization: quayOrganization,
-		gauge: prometheus.NewGauge(
-			prometheus.GaugeOpts{
-				Namespace: MetricsNamespace,
-				Subsystem: MetricsSubsystem,
-				Name:      "global_quay_app_available",
-				Help:      "The availability of the Quay App",
-			}),
+		Registry:         registry,
+		gauge:            newQuayAvailabilityGauge(registry),
 	}, nil
 }
 
+func newQuayAvailabilityGauge(registry string) prometheus.Gauge {
+	return prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   MetricsNamespace,
+			Subsystem:   MetricsSubsystem,
+			Name:        "global_quay_app_available",
+			Help:        "The availability of the Quay App",
+			ConstLabels: quayProbeLabels(registry),
+		})
+}
+
+func quayProbeLabels(registry string) prometheus.Labels {
+	return prometheus.Labels{
+		probeLabel:    "quay",
+		registryLabel: registry,
+	}
+}
+
 func (q *QuayAvailabilityProbe) CheckAvailability(ctx context.Context) error {
 	client := q.BuildQuayClient(ctrllog.FromContext(ctx))
 	_, err := client.GetRobotAccount(q.QuayOrganization, testRobotAccountName)
@@ -46,3 +81,100 @@ func (q *QuayAvailabilityProbe) CheckAvailability(ctx context.Context) error {
 func (q *QuayAvailabilityProbe) AvailabilityGauge() prometheus.Gauge {
 	return q.gauge
 }
+
+func (q *QuayAvailabilityProbe) ProbeLabels() prometheus.Labels {
+	return quayProbeLabels(q.Registry)
+}
+
+// LowPrivilegeQuayAvailabilityProbe checks Quay availability without the organization token,
+// so the privileged credentials are not used every minute just to see that Quay responds.
+// It reports into the same metric as QuayAvailabilityProbe.
+type LowPrivilegeQuayAvailabilityProbe struct {
+	Mode string
+	// APIURL is the Quay API URL, e.g. https://quay.io/api/v1
+	APIURL string
+	// URL, if set, is the endpoint checked by anonymous mode instead of the API discovery endpoint,
+	// e.g. a health endpoint of a Quay instance behind a proxy. In robot modes, scheme and host of the URL
+	// replace those of the API URL, so the token is sent to the registry behind the proxy.
+	URL string
+	// RobotAccountName is the full name of the robot account, e.g. my-org+probe, required by robot modes.
+	RobotAccountName string
+	// TokenPath is path to the robot account token or to the OIDC token to exchange, required by robot modes.
+	// The token is read on each check, so it could be rotated without restart.
+	TokenPath string
+
+	registry   string
+	httpClient *http.Client
+	gauge      prometheus.Gauge
+}
+
+func NewLowPrivilegeQuayAvailabilityProbe(mode, apiURL, probeURL, robotAccountName, tokenPath string, httpClient *http.Client) (*LowPrivilegeQuayAvailabilityProbe, error) {
+	switch mode {
+	case QuayProbeModeAnonymous:
+	case QuayProbeModeRobot, QuayProbeModeFederatedRobot:
+		if robotAccountName == "" || tokenPath == "" {
+			return nil, fmt.Errorf("robot account name and token path are required by %s probe mode", mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown quay probe mode %q", mode)
+	}
+	registry, err := getRegistryHost(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	if probeURL != "" {
+		// The registry label tells which endpoint is actually checked
+		if registry, err = getRegistryHost(probeURL); err != nil {
+			return nil, err
+		}
+	}
+	return &LowPrivilegeQuayAvailabilityProbe{
+		Mode:             mode,
+		APIURL:           strings.TrimSuffix(apiURL, "/"),
+		URL:              probeURL,
+		RobotAccountName: robotAccountName,
+		TokenPath:        tokenPath,
+		registry:         registry,
+		httpClient:       httpClient,
+		gauge:            newQuayAvailabilityGauge(registry),
+	}, nil
+}
+
+func (p *LowPrivilegeQuayAvailabilityProbe) CheckAvailability(ctx context.Context) error {
+	if p.Mode == QuayProbeModeAnonymous {
+		url := p.URL
+		if url == "" {
+			url = p.APIURL + "/discovery"
+		}
+		return checkEndpoint(ctx, p.httpClient, url, "")
+	}
+
+	/* #nosec the path is set by the controller administrator */
+	tokenContent, err := os.ReadFile(p.TokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+	token := strings.TrimSpace(string(tokenContent))
+	if token == "" {
+		return fmt.Errorf("token in %s is empty", p.TokenPath)
+	}
+
+	// The URLs are validated in the constructor
+	registryURL, _ := neturl.Parse(p.APIURL)
+	if p.URL != "" {
+		registryURL, _ = neturl.Parse(p.URL)
+	}
+	url := fmt.Sprintf("%s://%s/oauth2/federation/robot/token", registryURL.Scheme, registryURL.Host)
+	if p.Mode == QuayProbeModeRobot {
+		url = fmt.Sprintf("%s://%s/v2/auth?service=%s&account=%s", registryURL.Scheme, registryURL.Host, registryURL.Host, neturl.QueryEscape(p.RobotAccountName))
+	}
+	return checkEndpointWithBasicAuth(ctx, p.httpClient, url, p.RobotAccountName, token)
+}
+
+func (p *LowPrivilegeQuayAvailabilityProbe) AvailabilityGauge() prometheus.Gauge {
+	return p.gauge
+}
+
+func (p *LowPrivilegeQuayAvailabilityProbe) ProbeLabels() prometheus.Labels {
+	return quayProbeLabels(p.registry)
+}
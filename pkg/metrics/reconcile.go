@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	cmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// OpenMetricsEndpointPath serves the same metrics as /metrics in OpenMetrics format, the only format with exemplars.
+	OpenMetricsEndpointPath = "/metrics/openmetrics"
+
+	controllerLabel  = "controller"
+	namespaceLabel   = "namespace"
+	reconcileIDLabel = "reconcile_id"
+	nameLabel        = "name"
+	operationLabel   = "operation"
+)
+
+var (
+	ReconcileDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+	ReconcileDurationMetric  = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Buckets:   ReconcileDurationBuckets,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Duration of reconciles per controller and namespace of the reconciled object.",
+	}, []string{controllerLabel, namespaceLabel})
+
+	// ReconcileExemplarsEnabled attaches reconcile ID and object name to observed reconcile durations,
+	// so a slow bucket could be traced to the reconcile logs and Quay requests with the same correlation ID.
+	ReconcileExemplarsEnabled = false
+
+	QuayRequestsMetric = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: MetricsSubsystem,
+		Name:      "quay_requests_total",
+		Help:      "The number of Quay API requests sent by reconciles per controller, namespace of the reconciled object and operation.",
+	}, []string{controllerLabel, namespaceLabel, operationLabel})
+
+	// QuayRequestsMetricEnabled enables QuayRequestsMetric. It's off by default, as its cardinality grows with namespaces.
+	QuayRequestsMetricEnabled = false
+)
+
+// QuayRequestObserver returns function counting Quay requests of reconciles of objects in the namespace,
+// nil if QuayRequestsMetric is not enabled.
+func QuayRequestObserver(controller, namespace string) func(operation string) {
+	if !QuayRequestsMetricEnabled {
+		return nil
+	}
+	return func(operation string) {
+		QuayRequestsMetric.WithLabelValues(controller, namespace, operation).Inc()
+	}
+}
+
+// ObserveReconcileDuration records duration of the reconcile of the namespace/name object.
+func ObserveReconcileDuration(controller, namespace, name, reconcileID string, duration time.Duration) {
+	observer := ReconcileDurationMetric.WithLabelValues(controller, namespace)
+	if !ReconcileExemplarsEnabled || reconcileID == "" {
+		observer.Observe(duration.Seconds())
+		return
+	}
+
+	exemplar := prometheus.Labels{reconcileIDLabel: reconcileID, nameLabel: name}
+	if exemplarRunes(exemplar) > prometheus.ExemplarMaxRunes {
+		// Exemplars over the limit are rejected with panic, the reconcile ID alone links to the logs with the name
+		delete(exemplar, nameLabel)
+	}
+	if exemplarRunes(exemplar) > prometheus.ExemplarMaxRunes {
+		observer.Observe(duration.Seconds())
+		return
+	}
+	observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration.Seconds(), exemplar)
+}
+
+func exemplarRunes(exemplar prometheus.Labels) int {
+	runes := 0
+	for name, value := range exemplar {
+		runes += utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+	}
+	return runes
+}
+
+// NewOpenMetricsHandler returns handler of the controller-runtime metrics registry that negotiates OpenMetrics format,
+// which controller-runtime doesn't offer on /metrics.
+func NewOpenMetricsHandler() http.Handler {
+	return promhttp.HandlerFor(cmetrics.Registry, promhttp.HandlerOpts{
+		ErrorHandling:     promhttp.HTTPErrorOnError,
+		EnableOpenMetrics: true,
+	})
+}
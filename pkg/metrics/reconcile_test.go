@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveReconcileDuration(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(ReconcileDurationMetric)
+	defer ReconcileDurationMetric.Reset()
+	defer func() { ReconcileExemplarsEnabled = false }()
+
+	ObserveReconcileDuration("imagerepository", "test-ns", "my-image", "reconcile-1", 2*time.Second)
+	ReconcileExemplarsEnabled = true
+	ObserveReconcileDuration("imagerepository", "test-ns", "my-image", "reconcile-2", 3*time.Second)
+	ObserveReconcileDuration("imagerepository", "test-ns", strings.Repeat("long-name-", 20), "reconcile-3", 20*time.Second)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	if len(families) != 1 || len(families[0].GetMetric()) != 1 {
+		t.Fatalf("expected one reconcile duration series, got %v", families)
+	}
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 3 {
+		t.Errorf("expected 3 observations, got %d", histogram.GetSampleCount())
+	}
+
+	exemplars := map[string]map[string]string{}
+	for _, bucket := range histogram.GetBucket() {
+		if bucket.GetExemplar() == nil {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range bucket.GetExemplar().GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		exemplars[labels[reconcileIDLabel]] = labels
+	}
+	if _, exists := exemplars["reconcile-1"]; exists {
+		t.Errorf("expected no exemplar if exemplars are disabled")
+	}
+	if exemplars["reconcile-2"][nameLabel] != "my-image" {
+		t.Errorf("expected exemplar with object name, got %v", exemplars["reconcile-2"])
+	}
+	if labels, exists := exemplars["reconcile-3"]; !exists || labels[nameLabel] != "" {
+		t.Errorf("expected exemplar without too long object name, got %v", labels)
+	}
+}
+
+func TestQuayRequestObserver(t *testing.T) {
+	defer QuayRequestsMetric.Reset()
+	defer func() { QuayRequestsMetricEnabled = false }()
+
+	if observer := QuayRequestObserver("imagerepository", "test-ns"); observer != nil {
+		t.Errorf("expected no observer if the metric is disabled")
+	}
+	QuayRequestsMetricEnabled = true
+	observer := QuayRequestObserver("imagerepository", "test-ns")
+	observer("CreateNotification")
+	observer("CreateNotification")
+	observer("GetNotifications")
+
+	if count := testutil.ToFloat64(QuayRequestsMetric.WithLabelValues("imagerepository", "test-ns", "CreateNotification")); count != 2 {
+		t.Errorf("expected 2 counted requests, got %v", count)
+	}
+	if series := testutil.CollectAndCount(QuayRequestsMetric); series != 2 {
+		t.Errorf("expected series per operation, got %d", series)
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// ImageRepositoriesPathPrefix is the prefix of the provisioning API paths:
+// /api/v1/namespaces/<namespace>/imagerepositories[/<name>]
+const ImageRepositoriesPathPrefix = "/api/v1/namespaces/"
+
+const imageRepositoriesPathSegment = "imagerepositories"
+
+// maxRequestBodySize limits the request body, which is read before the caller is authenticated.
+const maxRequestBodySize = 64 * 1024
+
+// CreateRequest is the body of the request creating an ImageRepository.
+type CreateRequest struct {
+	// Name of the ImageRepository object.
+	Name string `json:"name"`
+	// ImageName is the name of the image within the Quay organization, defaults to namespace/name.
+	ImageName string `json:"imageName,omitempty"`
+	// Visibility is public or private, defaults to the visibility configured in the controller.
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// ImageRepositoryStatus is the provisioning API view of an ImageRepository.
+type ImageRepositoryStatus struct {
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`
+	State          string `json:"state,omitempty"`
+	Message        string `json:"message,omitempty"`
+	URL            string `json:"url,omitempty"`
+	Visibility     string `json:"visibility,omitempty"`
+	PushSecretName string `json:"pushSecret,omitempty"`
+	PullSecretName string `json:"pullSecret,omitempty"`
+}
+
+// ImageRepositoriesHandler translates provisioning API requests of non-Kubernetes clients into ImageRepository objects.
+// Each request must carry a bearer token of the caller, which is verified with TokenReview, and the caller must be
+// allowed to do the same operation on ImageRepositories in the namespace with the Kubernetes API.
+type ImageRepositoriesHandler struct {
+	client   client.Client
+	reviewer Reviewer
+	log      logr.Logger
+}
+
+func NewImageRepositoriesHandler(client client.Client, reviewer Reviewer, log logr.Logger) *ImageRepositoriesHandler {
+	return &ImageRepositoriesHandler{
+		client:   client,
+		reviewer: reviewer,
+		log:      log.WithName("ProvisioningAPI"),
+	}
+}
+
+func (h *ImageRepositoriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace, name, ok := parsePath(r.URL.Path)
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	var verb string
+	switch {
+	case r.Method == http.MethodPost && name == "":
+		verb = "create"
+	case r.Method == http.MethodGet && name != "":
+		verb = "get"
+	case r.Method == http.MethodDelete && name != "":
+		verb = "delete"
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var createRequest CreateRequest
+	if verb == "create" {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+		if err := json.NewDecoder(r.Body).Decode(&createRequest); err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if goerrors.As(err, &maxBytesErr) {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if createRequest.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		visibility := imagerepositoryv1alpha1.ImageVisibility(createRequest.Visibility)
+		if visibility != "" && visibility != imagerepositoryv1alpha1.ImageVisibilityPublic && visibility != imagerepositoryv1alpha1.ImageVisibilityPrivate {
+			http.Error(w, "visibility must be public or private", http.StatusBadRequest)
+			return
+		}
+		name = createRequest.Name
+	}
+
+	log := h.log.WithValues("Namespace", namespace, "Name", name, "Verb", verb)
+	user, ok := h.authorize(w, r, log, verb, namespace, name)
+	if !ok {
+		return
+	}
+	log = log.WithValues("User", user.Username)
+
+	switch verb {
+	case "create":
+		h.create(w, r, log, namespace, createRequest)
+	case "get":
+		h.get(w, r, log, namespace, name)
+	case "delete":
+		h.delete(w, r, log, namespace, name)
+	}
+}
+
+// authorize authenticates the caller and checks its access, the error response is written if the request is denied.
+func (h *ImageRepositoriesHandler) authorize(w http.ResponseWriter, r *http.Request, log logr.Logger, verb, namespace, name string) (authenticationv1.UserInfo, bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		log.Info("unauthenticated request to provisioning API", "RemoteAddr", r.RemoteAddr, l.Audit, "true")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return authenticationv1.UserInfo{}, false
+	}
+	user, authenticated, err := h.reviewer.Authenticate(r.Context(), token)
+	if err != nil {
+		log.Error(err, "failed to review token")
+		http.Error(w, "failed to authenticate request", http.StatusInternalServerError)
+		return authenticationv1.UserInfo{}, false
+	}
+	if !authenticated {
+		log.Info("unauthenticated request to provisioning API", "RemoteAddr", r.RemoteAddr, l.Audit, "true")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return authenticationv1.UserInfo{}, false
+	}
+
+	allowed, err := h.reviewer.Authorize(r.Context(), user, verb, namespace, name)
+	if err != nil {
+		log.Error(err, "failed to review access", "User", user.Username)
+		http.Error(w, "failed to authorize request", http.StatusInternalServerError)
+		return authenticationv1.UserInfo{}, false
+	}
+	if !allowed {
+		log.Info("forbidden request to provisioning API", "User", user.Username, "RemoteAddr", r.RemoteAddr, l.Audit, "true")
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return authenticationv1.UserInfo{}, false
+	}
+	return user, true
+}
+
+func (h *ImageRepositoriesHandler) create(w http.ResponseWriter, r *http.Request, log logr.Logger, namespace string, createRequest CreateRequest) {
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      createRequest.Name,
+			Namespace: namespace,
+		},
+		Spec: imagerepositoryv1alpha1.ImageRepositorySpec{
+			Image: imagerepositoryv1alpha1.ImageParameters{
+				Name:       createRequest.ImageName,
+				Visibility: imagerepositoryv1alpha1.ImageVisibility(createRequest.Visibility),
+			},
+		},
+	}
+	if err := h.client.Create(r.Context(), imageRepository); err != nil {
+		log.Error(err, "failed to create image repository", l.Action, l.ActionAdd)
+		writeAPIError(w, err)
+		return
+	}
+	log.Info("Created image repository via provisioning API", l.Action, l.ActionAdd, l.Audit, "true")
+	writeStatus(w, log, http.StatusCreated, imageRepository)
+}
+
+func (h *ImageRepositoriesHandler) get(w http.ResponseWriter, r *http.Request, log logr.Logger, namespace, name string) {
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{}
+	if err := h.client.Get(r.Context(), client.ObjectKey{Namespace: namespace, Name: name}, imageRepository); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to get image repository", l.Action, l.ActionView)
+		}
+		writeAPIError(w, err)
+		return
+	}
+	writeStatus(w, log, http.StatusOK, imageRepository)
+}
+
+func (h *ImageRepositoriesHandler) delete(w http.ResponseWriter, r *http.Request, log logr.Logger, namespace, name string) {
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	if err := h.client.Delete(r.Context(), imageRepository); err != nil {
+		if !errors.IsNotFound(err) {
+			log.Error(err, "failed to delete image repository", l.Action, l.ActionDelete)
+		}
+		writeAPIError(w, err)
+		return
+	}
+	log.Info("Deleted image repository via provisioning API", l.Action, l.ActionDelete, l.Audit, "true")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parsePath extracts namespace and optional name from /api/v1/namespaces/<namespace>/imagerepositories[/<name>].
+func parsePath(path string) (string, string, bool) {
+	rest, found := strings.CutPrefix(path, ImageRepositoriesPathPrefix)
+	if !found {
+		return "", "", false
+	}
+	segments := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(segments) < 2 || len(segments) > 3 || segments[0] == "" || segments[1] != imageRepositoriesPathSegment {
+		return "", "", false
+	}
+	if len(segments) == 3 {
+		if segments[2] == "" {
+			return "", "", false
+		}
+		return segments[0], segments[2], true
+	}
+	return segments[0], "", true
+}
+
+// writeAPIError translates error of the Kubernetes API into the response status.
+func writeAPIError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.IsNotFound(err):
+		http.Error(w, "image repository not found", http.StatusNotFound)
+	case errors.IsAlreadyExists(err):
+		http.Error(w, "image repository already exists", http.StatusConflict)
+	case errors.IsInvalid(err), errors.IsBadRequest(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func writeStatus(w http.ResponseWriter, log logr.Logger, statusCode int, imageRepository *imagerepositoryv1alpha1.ImageRepository) {
+	status := ImageRepositoryStatus{
+		Namespace:      imageRepository.Namespace,
+		Name:           imageRepository.Name,
+		State:          string(imageRepository.Status.State),
+		Message:        imageRepository.Status.Message,
+		URL:            imageRepository.Status.Image.URL,
+		Visibility:     string(imageRepository.Status.Image.Visibility),
+		PushSecretName: imageRepository.Status.Credentials.PushSecretName,
+		PullSecretName: imageRepository.Status.Credentials.PullSecretName,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Error(err, "failed to write provisioning API response")
+	}
+}
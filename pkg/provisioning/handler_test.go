@@ -0,0 +1,210 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"gotest.tools/v3/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+// testReviewer accepts tokens of the known users and allows them the listed verbs in the listed namespaces.
+type testReviewer struct {
+	users   map[string]string
+	allowed map[string][]string
+}
+
+func (r *testReviewer) Authenticate(_ context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	username, found := r.users[token]
+	return authenticationv1.UserInfo{Username: username}, found, nil
+}
+
+func (r *testReviewer) Authorize(_ context.Context, user authenticationv1.UserInfo, verb, namespace, _ string) (bool, error) {
+	for _, allowed := range r.allowed[user.Username] {
+		if allowed == verb+":"+namespace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestImageRepositoriesHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NilError(t, imagerepositoryv1alpha1.AddToScheme(scheme))
+
+	imageRepository := &imagerepositoryv1alpha1.ImageRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-image", Namespace: "test-ns"},
+		Status: imagerepositoryv1alpha1.ImageRepositoryStatus{
+			State: imagerepositoryv1alpha1.ImageRepositoryStateReady,
+			Image: imagerepositoryv1alpha1.ImageStatus{URL: "quay.io/test-org/test-ns/my-image"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(imageRepository).Build()
+
+	reviewer := &testReviewer{
+		users: map[string]string{"user-token": "user", "viewer-token": "viewer"},
+		allowed: map[string][]string{
+			"user":   {"create:test-ns", "get:test-ns", "delete:test-ns"},
+			"viewer": {"get:test-ns"},
+		},
+	}
+	handler := NewImageRepositoriesHandler(fakeClient, reviewer, logr.Discard())
+
+	testCases := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		token              string
+		expectedStatusCode int
+	}{
+		{
+			name:               "should return image repository status",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/my-image",
+			token:              "viewer-token",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "should reject request without token",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/my-image",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should reject request with invalid token",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/my-image",
+			token:              "wrong-token",
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "should reject request the user is not allowed to do",
+			method:             http.MethodDelete,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/my-image",
+			token:              "viewer-token",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "should reject request in other namespace",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/other-ns/imagerepositories/my-image",
+			token:              "user-token",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "should return not found for missing image repository",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/missing",
+			token:              "user-token",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should return not found for unknown path",
+			method:             http.MethodGet,
+			path:               "/api/v1/namespaces/test-ns/secrets/my-secret",
+			token:              "user-token",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "should reject unsupported method",
+			method:             http.MethodPut,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories/my-image",
+			token:              "user-token",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:               "should reject too large request body",
+			method:             http.MethodPost,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories",
+			body:               `{"name": "new-image", "imageName": "` + strings.Repeat("a", maxRequestBodySize) + `"}`,
+			token:              "user-token",
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "should reject invalid visibility",
+			method:             http.MethodPost,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories",
+			body:               `{"name": "new-image", "visibility": "internal"}`,
+			token:              "user-token",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should reject creation of existing image repository",
+			method:             http.MethodPost,
+			path:               "/api/v1/namespaces/test-ns/imagerepositories",
+			body:               `{"name": "my-image"}`,
+			token:              "user-token",
+			expectedStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				request.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, recorder.Code, tc.expectedStatusCode)
+			if tc.expectedStatusCode == http.StatusOK {
+				status := ImageRepositoryStatus{}
+				assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+				assert.Equal(t, status.State, string(imagerepositoryv1alpha1.ImageRepositoryStateReady))
+				assert.Equal(t, status.URL, "quay.io/test-org/test-ns/my-image")
+			}
+		})
+	}
+
+	t.Run("should create and delete image repository", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/namespaces/test-ns/imagerepositories",
+			strings.NewReader(`{"name": "new-image", "visibility": "private"}`))
+		request.Header.Set("Authorization", "Bearer user-token")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, recorder.Code, http.StatusCreated)
+
+		created := &imagerepositoryv1alpha1.ImageRepository{}
+		assert.NilError(t, fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: "test-ns", Name: "new-image"}, created))
+		assert.Equal(t, created.Spec.Image.Visibility, imagerepositoryv1alpha1.ImageVisibilityPrivate)
+
+		request = httptest.NewRequest(http.MethodDelete, "/api/v1/namespaces/test-ns/imagerepositories/new-image", nil)
+		request.Header.Set("Authorization", "Bearer user-token")
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, recorder.Code, http.StatusAccepted)
+
+		err := fakeClient.Get(context.TODO(), client.ObjectKey{Namespace: "test-ns", Name: "new-image"}, created)
+		assert.Assert(t, err != nil)
+	})
+}
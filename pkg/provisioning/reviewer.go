@@ -0,0 +1,85 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	imagerepositoryv1alpha1 "github.com/konflux-ci/image-controller/api/v1alpha1"
+)
+
+// imageRepositoriesResource is the resource checked in SubjectAccessReview of the requests.
+const imageRepositoriesResource = "imagerepositories"
+
+// Reviewer authenticates callers of the provisioning API and authorizes their requests.
+type Reviewer interface {
+	// Authenticate returns the user owning the bearer token, false if the token is not valid.
+	Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error)
+	// Authorize checks whether the user is allowed to do the verb on the ImageRepository in the namespace.
+	Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, namespace, name string) (bool, error)
+}
+
+// KubernetesReviewer delegates authentication to TokenReview and authorization to SubjectAccessReview,
+// so the callers have exactly the same access to ImageRepositories as they have in the cluster.
+type KubernetesReviewer struct {
+	client client.Client
+}
+
+func NewKubernetesReviewer(client client.Client) *KubernetesReviewer {
+	return &KubernetesReviewer{client: client}
+}
+
+func (r *KubernetesReviewer) Authenticate(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	tokenReview := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	if err := r.client.Create(ctx, tokenReview); err != nil {
+		return authenticationv1.UserInfo{}, false, err
+	}
+	return tokenReview.Status.User, tokenReview.Status.Authenticated, nil
+}
+
+func (r *KubernetesReviewer) Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, namespace, name string) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	subjectAccessReview := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     imagerepositoryv1alpha1.GroupVersion.Group,
+				Version:   imagerepositoryv1alpha1.GroupVersion.Version,
+				Resource:  imageRepositoriesResource,
+				Name:      name,
+			},
+		},
+	}
+	if err := r.client.Create(ctx, subjectAccessReview); err != nil {
+		return false, err
+	}
+	return subjectAccessReview.Status.Allowed, nil
+}
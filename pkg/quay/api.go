@@ -7,6 +7,7 @@ type Tag struct {
 	ManifestDigest string `json:"manifest_digest,omitempty"`
 	Size           int    `json:"int"`
 	StartTS        int64  `json:"start_ts"`
+	EndTS          int64  `json:"end_ts,omitempty"`
 }
 
 type Repository struct {
@@ -18,30 +19,68 @@ type Repository struct {
 	IsStarred      bool           `json:"is_starred"`
 	IsPublic       bool           `json:"is_public"`
 	LastModified   int            `json:"last_modified"`
+	Popularity     float64        `json:"popularity"`
 	Name           string         `json:"name"`
 	Namespace      string         `json:"namespace"`
 	Image          string         `json:"image"`
+	Kind           string         `json:"kind"`
 	TagExpirationS int            `json:"tag_expiration_s"`
 	Tags           map[string]Tag `json:"tags"`
 	StatusToken    string         `json:"status_token"`
+	State          string         `json:"state,omitempty"`
 	ErrorMessage   string         `json:"error_message"`
 }
 
+// RepositoryMetadata is a summary of an image repository used by reporting, see QuayClient.GetRepositoriesMetadata.
+type RepositoryMetadata struct {
+	Name       string
+	Visibility string
+	IsPublic   bool
+	IsStarred  bool
+	// LastModified is unix timestamp of the last image push, zero if nothing was pushed.
+	LastModified int
+	// Popularity is the Quay action count based popularity score.
+	Popularity float64
+}
+
 type RepositoryRequest struct {
 	Namespace   string `json:"namespace"`
 	Visibility  string `json:"visibility"`
 	Repository  string `json:"repository"`
 	Description string `json:"description"`
-	//Kind        string `json:"repo_kind"`
+	// Kind is image or application, Quay creates image repository if omitted.
+	Kind string `json:"repo_kind,omitempty"`
 }
-type RobotAccount struct {
+
+type RepositoryUpdateRequest struct {
 	Description string `json:"description"`
-	Created     string `json:"created"`
-	// UnstructuredData []byte  `json:"unstructured_metadata"`
-	LastAccessed string `json:"last_accessed"`
-	Token        string `json:"token"`
-	Name         string `json:"name"`
-	Message      string `json:"message"`
+}
+type RobotAccount struct {
+	Description          string                 `json:"description"`
+	Created              string                 `json:"created"`
+	UnstructuredMetadata map[string]interface{} `json:"unstructured_metadata,omitempty"`
+	LastAccessed         string                 `json:"last_accessed"`
+	Token                string                 `json:"token"`
+	Name                 string                 `json:"name"`
+	Message              string                 `json:"message"`
+}
+
+// RobotAccountRequest holds information recorded in a robot account, see also QuayClient.UpdateRobotAccountDescription.
+type RobotAccountRequest struct {
+	Description          string            `json:"description"`
+	UnstructuredMetadata map[string]string `json:"unstructured_metadata,omitempty"`
+}
+
+// RobotAccountPermission is a role of a robot account in a repository, see QuayClient.GetRobotAccountPermissions.
+type RobotAccountPermission struct {
+	Repository RobotAccountPermissionRepository `json:"repository"`
+	// Role is read, write or admin
+	Role string `json:"role"`
+}
+
+type RobotAccountPermissionRepository struct {
+	Name     string `json:"name"`
+	IsPublic bool   `json:"is_public"`
 }
 
 // Quay API can sometimes return {"error": "..."} and sometimes {"error_message": "..."} without the field error
@@ -67,3 +106,102 @@ type NotificationConfig struct {
 
 type NotificationEventConfig struct {
 }
+
+type OrganizationMember struct {
+	Name         string       `json:"name"`
+	Kind         string       `json:"kind"`
+	Teams        []MemberTeam `json:"teams"`
+	Repositories []string     `json:"repositories"`
+}
+
+type MemberTeam struct {
+	Name string `json:"name"`
+}
+
+type Team struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Role        string `json:"role"`
+}
+
+type Collaborator struct {
+	Name         string   `json:"name"`
+	Kind         string   `json:"kind"`
+	Repositories []string `json:"repositories"`
+}
+
+// OrganizationQuota is the private repositories usage of the organization.
+type OrganizationQuota struct {
+	PrivateAllowed bool `json:"privateAllowed"`
+	// PrivateCount is the number of private repositories in the organization.
+	PrivateCount int `json:"privateCount"`
+	// ReposAllowed is the private repositories limit of the organization plan.
+	ReposAllowed int `json:"reposAllowed"`
+}
+
+// OrganizationPlan is the billing plan the organization is subscribed to.
+type OrganizationPlan struct {
+	HasSubscription  bool   `json:"hasSubscription"`
+	Plan             string `json:"plan"`
+	UsedPrivateRepos int    `json:"usedPrivateRepos"`
+}
+
+// PermissionPrototype is a default permission of the organization, which Quay grants on each newly created repository.
+type PermissionPrototype struct {
+	ID       string            `json:"id,omitempty"`
+	Role     string            `json:"role"`
+	Delegate PrototypeDelegate `json:"delegate"`
+	// ActivatingUser limits the prototype to repositories created by the user, nil applies it to all new repositories.
+	ActivatingUser *PrototypeDelegate `json:"activating_user,omitempty"`
+}
+
+// PrototypeDelegate is the user, robot account or team the prototype grants the permission to.
+type PrototypeDelegate struct {
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	IsRobot bool   `json:"is_robot,omitempty"`
+}
+
+// RepositoryMirror is configuration of a mirrored repository, that pulls tags from an external repository.
+type RepositoryMirror struct {
+	IsEnabled                bool                      `json:"is_enabled"`
+	ExternalReference        string                    `json:"external_reference"`
+	ExternalRegistryUsername string                    `json:"external_registry_username,omitempty"`
+	ExternalRegistryPassword string                    `json:"external_registry_password,omitempty"`
+	SyncInterval             int                       `json:"sync_interval"`
+	SyncStartDate            string                    `json:"sync_start_date"`
+	RobotUsername            string                    `json:"robot_username"`
+	RootRule                 RepositoryMirrorRule      `json:"root_rule"`
+	ExternalRegistryConfig   map[string]interface{}    `json:"external_registry_config"`
+	SyncStatus               RepositoryMirrorSyncState `json:"sync_status,omitempty"`
+}
+
+type RepositoryMirrorRule struct {
+	RuleKind  string   `json:"rule_kind"`
+	RuleValue []string `json:"rule_value"`
+}
+
+// RepositoryMirrorSyncState is the state of the last mirror synchronization.
+type RepositoryMirrorSyncState string
+
+const (
+	RepositoryMirrorSyncSuccess RepositoryMirrorSyncState = "SYNC_SUCCESS"
+	RepositoryMirrorSyncFailed  RepositoryMirrorSyncState = "SYNC_FAILED"
+)
+
+// Repository states, only repositories in mirror state accept mirror configuration.
+// Pushes are accepted only in normal state.
+const (
+	RepositoryStateNormal   = "NORMAL"
+	RepositoryStateMirror   = "MIRROR"
+	RepositoryStateReadOnly = "READ_ONLY"
+)
+
+// ManifestLabel is a key/value label attached to a manifest in Quay.
+type ManifestLabel struct {
+	ID         string `json:"id,omitempty"`
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	SourceType string `json:"source_type,omitempty"`
+	MediaType  string `json:"media_type,omitempty"`
+}
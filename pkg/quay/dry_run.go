@@ -0,0 +1,189 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"github.com/go-logr/logr"
+)
+
+const DryRunRobotAccountToken = "dry-run"
+
+// DryRunQuayClient wraps a QuayService and intercepts all calls that would change state in Quay.
+// Intercepted calls are logged and reported via OnIntercept, but not executed.
+// Read only calls are passed to the wrapped client.
+type DryRunQuayClient struct {
+	QuayService
+
+	log logr.Logger
+	// OnIntercept is invoked with the name of the intercepted operation, e.g. for metrics.
+	OnIntercept func(operation string)
+}
+
+var _ QuayService = (*DryRunQuayClient)(nil)
+
+func NewDryRunQuayClient(quayClient QuayService, log logr.Logger, onIntercept func(operation string)) *DryRunQuayClient {
+	return &DryRunQuayClient{
+		QuayService: quayClient,
+		log:         log.WithName("QuayDryRun"),
+		OnIntercept: onIntercept,
+	}
+}
+
+func (c *DryRunQuayClient) intercept(operation string, keysAndValues ...interface{}) {
+	c.log.Info("dry-run: skipped Quay call", append([]interface{}{"operation", operation}, keysAndValues...)...)
+	if c.OnIntercept != nil {
+		c.OnIntercept(operation)
+	}
+}
+
+func (c *DryRunQuayClient) CreateRepository(repositoryRequest RepositoryRequest) (*Repository, error) {
+	c.intercept("CreateRepository", "Organization", repositoryRequest.Namespace, "Repository", repositoryRequest.Repository, "Visibility", repositoryRequest.Visibility)
+	return &Repository{
+		Name:        repositoryRequest.Repository,
+		Namespace:   repositoryRequest.Namespace,
+		Description: repositoryRequest.Description,
+		IsPublic:    repositoryRequest.Visibility == "public",
+		Kind:        repositoryRequest.Kind,
+	}, nil
+}
+
+func (c *DryRunQuayClient) DeleteRepository(organization, imageRepository string) (bool, error) {
+	c.intercept("DeleteRepository", "Organization", organization, "Repository", imageRepository)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) ChangeRepositoryVisibility(organization, imageRepository, visibility string) error {
+	c.intercept("ChangeRepositoryVisibility", "Organization", organization, "Repository", imageRepository, "Visibility", visibility)
+	return nil
+}
+
+func (c *DryRunQuayClient) UpdateRepositoryDescription(organization, imageRepository, description string) error {
+	c.intercept("UpdateRepositoryDescription", "Organization", organization, "Repository", imageRepository)
+	return nil
+}
+
+func (c *DryRunQuayClient) ChangeRepositoryState(organization, imageRepository, state string) error {
+	c.intercept("ChangeRepositoryState", "Organization", organization, "Repository", imageRepository, "State", state)
+	return nil
+}
+
+func (c *DryRunQuayClient) CreateRepositoryMirror(organization, imageRepository string, mirror RepositoryMirror) error {
+	c.intercept("CreateRepositoryMirror", "Organization", organization, "Repository", imageRepository, "ExternalReference", mirror.ExternalReference)
+	return nil
+}
+
+func (c *DryRunQuayClient) SyncRepositoryMirror(organization, imageRepository string) error {
+	c.intercept("SyncRepositoryMirror", "Organization", organization, "Repository", imageRepository)
+	return nil
+}
+
+func (c *DryRunQuayClient) AddManifestLabel(organization, imageRepository, manifestDigest, key, value string) error {
+	c.intercept("AddManifestLabel", "Organization", organization, "Repository", imageRepository, "Digest", manifestDigest, "Key", key)
+	return nil
+}
+
+func (c *DryRunQuayClient) CreateRobotAccount(organization string, robotName string) (*RobotAccount, error) {
+	c.intercept("CreateRobotAccount", "Organization", organization, "RobotAccountName", robotName)
+	robotName, err := handleRobotName(robotName)
+	if err != nil {
+		return nil, err
+	}
+	return &RobotAccount{Name: organization + "+" + robotName, Token: DryRunRobotAccountToken}, nil
+}
+
+func (c *DryRunQuayClient) UpdateRobotAccountDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) error {
+	c.intercept("UpdateRobotAccountDescription", "Organization", organization, "RobotAccountName", robotName, "Description", robotAccountRequest.Description)
+	return nil
+}
+
+func (c *DryRunQuayClient) CreateRobotAccountWithDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error) {
+	c.intercept("CreateRobotAccount", "Organization", organization, "RobotAccountName", robotName, "Description", robotAccountRequest.Description)
+	robotName, err := handleRobotName(robotName)
+	if err != nil {
+		return nil, err
+	}
+	return &RobotAccount{Name: organization + "+" + robotName, Token: DryRunRobotAccountToken, Description: robotAccountRequest.Description}, nil
+}
+
+func (c *DryRunQuayClient) DeleteRobotAccount(organization string, robotName string) (bool, error) {
+	c.intercept("DeleteRobotAccount", "Organization", organization, "RobotAccountName", robotName)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) AddPermissionsForRepositoryToRobotAccount(organization, imageRepository, robotAccountName string, isWrite bool) error {
+	c.intercept("AddPermissionsForRepositoryToRobotAccount", "Organization", organization, "Repository", imageRepository, "RobotAccountName", robotAccountName, "IsWrite", isWrite)
+	return nil
+}
+
+func (c *DryRunQuayClient) SetRepositoryUserPermission(organization, imageRepository, userName, role string) error {
+	c.intercept("SetRepositoryUserPermission", "Organization", organization, "Repository", imageRepository, "UserName", userName, "Role", role)
+	return nil
+}
+
+func (c *DryRunQuayClient) DeleteRepositoryUserPermission(organization, imageRepository, userName string) (bool, error) {
+	c.intercept("DeleteRepositoryUserPermission", "Organization", organization, "Repository", imageRepository, "UserName", userName)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) RegenerateRobotAccountToken(organization string, robotName string) (*RobotAccount, error) {
+	c.intercept("RegenerateRobotAccountToken", "Organization", organization, "RobotAccountName", robotName)
+	return &RobotAccount{Name: robotName, Token: DryRunRobotAccountToken}, nil
+}
+
+func (c *DryRunQuayClient) DeleteTag(organization, repository, tag string) (bool, error) {
+	c.intercept("DeleteTag", "Organization", organization, "Repository", repository, "Tag", tag)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) DeleteManifestByDigest(organization, repository, manifestDigest string) ([]string, error) {
+	c.intercept("DeleteManifestByDigest", "Organization", organization, "Repository", repository, "ManifestDigest", manifestDigest)
+	return nil, nil
+}
+
+func (c *DryRunQuayClient) CreateNotification(organization, repository string, notification Notification) (*Notification, error) {
+	c.intercept("CreateNotification", "Organization", organization, "Repository", repository, "Title", notification.Title)
+	return &notification, nil
+}
+
+func (c *DryRunQuayClient) DeleteNotification(organization, repository, notificationUUID string) (bool, error) {
+	c.intercept("DeleteNotification", "Organization", organization, "Repository", repository, "UUID", notificationUUID)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) RemoveOrganizationMember(organization, memberName string) (bool, error) {
+	c.intercept("RemoveOrganizationMember", "Organization", organization, "MemberName", memberName)
+	return true, nil
+}
+
+func (c *DryRunQuayClient) CreateTeam(organization, teamName, description string) error {
+	c.intercept("CreateTeam", "Organization", organization, "TeamName", teamName)
+	return nil
+}
+
+func (c *DryRunQuayClient) AddTeamMember(organization, teamName, memberName string) error {
+	c.intercept("AddTeamMember", "Organization", organization, "TeamName", teamName, "MemberName", memberName)
+	return nil
+}
+
+func (c *DryRunQuayClient) CreatePermissionPrototype(organization string, prototype PermissionPrototype) (*PermissionPrototype, error) {
+	c.intercept("CreatePermissionPrototype", "Organization", organization, "Delegate", prototype.Delegate.Name, "Role", prototype.Role)
+	return &prototype, nil
+}
+
+func (c *DryRunQuayClient) DeletePermissionPrototype(organization, prototypeID string) (bool, error) {
+	c.intercept("DeletePermissionPrototype", "Organization", organization, "PrototypeID", prototypeID)
+	return true, nil
+}
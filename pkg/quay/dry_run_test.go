@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"gotest.tools/v3/assert"
+)
+
+func TestDryRunQuayClient_InterceptsMutations(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	// No mocks registered: any request reaching the server would fail.
+	intercepted := []string{}
+	quayClient := NewDryRunQuayClient(NewQuayClient(client, "authtoken", testQuayApiUrl), logr.Discard(), func(operation string) {
+		intercepted = append(intercepted, operation)
+	})
+
+	repository, err := quayClient.CreateRepository(RepositoryRequest{Namespace: org, Repository: repo, Visibility: "public"})
+	assert.NilError(t, err)
+	assert.Equal(t, repository.Name, repo)
+	assert.Equal(t, repository.IsPublic, true)
+
+	robotAccount, err := quayClient.CreateRobotAccount(org, robotName)
+	assert.NilError(t, err)
+	assert.Equal(t, robotAccount.Name, org+"+"+robotName)
+	assert.Equal(t, robotAccount.Token, DryRunRobotAccountToken)
+
+	_, err = quayClient.CreateRobotAccount(org, "invalid robot name")
+	assert.ErrorContains(t, err, "robot name is invalid")
+
+	assert.NilError(t, quayClient.AddPermissionsForRepositoryToRobotAccount(org, repo, robotName, true))
+	assert.NilError(t, quayClient.SetRepositoryUserPermission(org, repo, "user", "read"))
+	isDeleted, err := quayClient.DeleteRepositoryUserPermission(org, repo, "user")
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	assert.NilError(t, quayClient.ChangeRepositoryVisibility(org, repo, "private"))
+
+	robotAccount, err = quayClient.RegenerateRobotAccountToken(org, robotName)
+	assert.NilError(t, err)
+	assert.Equal(t, robotAccount.Token, DryRunRobotAccountToken)
+
+	notification, err := quayClient.CreateNotification(org, repo, Notification{Title: "title"})
+	assert.NilError(t, err)
+	assert.Equal(t, notification.Title, "title")
+
+	isDeleted, err = quayClient.DeleteNotification(org, repo, "uuid")
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	isDeleted, err = quayClient.DeleteTag(org, repo, "tag")
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	_, err = quayClient.DeleteManifestByDigest(org, repo, "sha256:digest")
+	assert.NilError(t, err)
+	isDeleted, err = quayClient.DeleteRobotAccount(org, robotName)
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	isDeleted, err = quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	isRemoved, err := quayClient.RemoveOrganizationMember(org, "user")
+	assert.NilError(t, err)
+	assert.Assert(t, isRemoved)
+	err = quayClient.CreateTeam(org, "team", "description")
+	assert.NilError(t, err)
+	err = quayClient.AddTeamMember(org, "team", "user")
+	assert.NilError(t, err)
+	_, err = quayClient.CreatePermissionPrototype(org, PermissionPrototype{Role: "read", Delegate: PrototypeDelegate{Name: org + "+ci", Kind: "user"}})
+	assert.NilError(t, err)
+	isDeleted, err = quayClient.DeletePermissionPrototype(org, "id")
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+
+	assert.DeepEqual(t, intercepted, []string{
+		"CreateRepository",
+		"CreateRobotAccount",
+		"CreateRobotAccount",
+		"AddPermissionsForRepositoryToRobotAccount",
+		"SetRepositoryUserPermission",
+		"DeleteRepositoryUserPermission",
+		"ChangeRepositoryVisibility",
+		"RegenerateRobotAccountToken",
+		"CreateNotification",
+		"DeleteNotification",
+		"DeleteTag",
+		"DeleteManifestByDigest",
+		"DeleteRobotAccount",
+		"DeleteRepository",
+		"RemoveOrganizationMember",
+		"CreateTeam",
+		"AddTeamMember",
+		"CreatePermissionPrototype",
+		"DeletePermissionPrototype",
+	})
+}
+
+func TestDryRunQuayClient_PassesReads(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		Reply(200).
+		JSON(map[string]string{"name": org + "+" + robotName, "token": "token"})
+
+	quayClient := NewDryRunQuayClient(NewQuayClient(client, "authtoken", testQuayApiUrl), logr.Discard(), func(operation string) {
+		t.Errorf("read only operation should not be intercepted, got: %s", operation)
+	})
+
+	robotAccount, err := quayClient.GetRobotAccount(org, robotName)
+	assert.NilError(t, err)
+	assert.Equal(t, robotAccount.Token, "token")
+	assert.Assert(t, gock.IsDone())
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quay
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// OwnershipManagedBy marks image repositories created by the controller
+	OwnershipManagedBy = "image-controller"
+
+	ownershipManagedByKey = "managed-by"
+	ownershipClusterIDKey = "cluster-id"
+	ownershipNamespaceKey = "namespace"
+	ownershipUIDKey       = "cr-uid"
+)
+
+// RepositoryOwnership identifies the cluster object for which an image repository was created.
+// Quay doesn't support labels on repositories, so the ownership is stored in the repository description.
+type RepositoryOwnership struct {
+	ManagedBy string `json:"managedBy"`
+	ClusterID string `json:"clusterId"`
+	Namespace string `json:"namespace"`
+	UID       string `json:"uid"`
+}
+
+// Description returns repository description that consists of the given summary followed by the ownership lines.
+func (o RepositoryOwnership) Description(summary string) string {
+	return fmt.Sprintf("%s\n\n%s: %s\n%s: %s\n%s: %s\n%s: %s\n", summary,
+		ownershipManagedByKey, o.ManagedBy,
+		ownershipClusterIDKey, o.ClusterID,
+		ownershipNamespaceKey, o.Namespace,
+		ownershipUIDKey, o.UID)
+}
+
+// Metadata returns the ownership as key value pairs, e.g. for robot account metadata.
+func (o RepositoryOwnership) Metadata() map[string]string {
+	return map[string]string{
+		ownershipManagedByKey: o.ManagedBy,
+		ownershipClusterIDKey: o.ClusterID,
+		ownershipNamespaceKey: o.Namespace,
+		ownershipUIDKey:       o.UID,
+	}
+}
+
+// ParseRepositoryOwnership extracts the ownership from the repository description.
+// Returns false if the repository wasn't created by the controller or was created before the ownership was recorded.
+func ParseRepositoryOwnership(description string) (RepositoryOwnership, bool) {
+	ownership := RepositoryOwnership{}
+	for _, line := range strings.Split(description, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case ownershipManagedByKey:
+			ownership.ManagedBy = value
+		case ownershipClusterIDKey:
+			ownership.ClusterID = value
+		case ownershipNamespaceKey:
+			ownership.Namespace = value
+		case ownershipUIDKey:
+			ownership.UID = value
+		}
+	}
+	if ownership.ManagedBy != OwnershipManagedBy || ownership.UID == "" {
+		return RepositoryOwnership{}, false
+	}
+	return ownership, true
+}
+
+// IsCreatedByController checks whether the robot account was created by the controller, see RepositoryOwnership.Metadata.
+func (robotAccount *RobotAccount) IsCreatedByController() bool {
+	managedBy, _ := robotAccount.UnstructuredMetadata[ownershipManagedByKey].(string)
+	return managedBy == OwnershipManagedBy
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quay
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRepositoryOwnership(t *testing.T) {
+	ownership := RepositoryOwnership{
+		ManagedBy: OwnershipManagedBy,
+		ClusterID: "member-cluster-1",
+		Namespace: "test-ns",
+		UID:       "0e0f30b6-d77e-406f-bfdf-5802db1447a4",
+	}
+
+	testCases := []struct {
+		name              string
+		description       string
+		expectedOwnership RepositoryOwnership
+		expectedFound     bool
+	}{
+		{
+			name:              "should parse generated description",
+			description:       ownership.Description("AppStudio repository for the user"),
+			expectedOwnership: ownership,
+			expectedFound:     true,
+		},
+		{
+			name:          "should not find ownership in legacy description",
+			description:   "AppStudio repository for the user",
+			expectedFound: false,
+		},
+		{
+			name:          "should not find ownership of repository managed by other tool",
+			description:   "Some repository\n\nmanaged-by: other-tool\ncr-uid: 1234\n",
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsedOwnership, found := ParseRepositoryOwnership(tc.description)
+			assert.Equal(t, found, tc.expectedFound)
+			assert.DeepEqual(t, parsedOwnership, tc.expectedOwnership)
+		})
+	}
+}
+
+func TestRobotAccountIsCreatedByController(t *testing.T) {
+	metadata := map[string]interface{}{}
+	for key, value := range (RepositoryOwnership{ManagedBy: OwnershipManagedBy, UID: "1234"}).Metadata() {
+		metadata[key] = value
+	}
+	assert.Assert(t, (&RobotAccount{UnstructuredMetadata: metadata}).IsCreatedByController())
+	assert.Assert(t, !(&RobotAccount{UnstructuredMetadata: map[string]interface{}{"managed-by": "other-tool"}}).IsCreatedByController())
+	assert.Assert(t, !(&RobotAccount{}).IsCreatedByController())
+}
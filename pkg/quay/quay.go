@@ -17,6 +17,7 @@ package quay
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,23 +26,56 @@ import (
 	neturl "net/url"
 	"regexp"
 	"strings"
+	"time"
 )
 
 type QuayService interface {
 	CreateRepository(repositoryRequest RepositoryRequest) (*Repository, error)
 	DeleteRepository(organization, imageRepository string) (bool, error)
+	IsRepositoryPublic(organization, imageRepository string) (bool, error)
+	GetRepository(organization, imageRepository string) (*Repository, error)
 	ChangeRepositoryVisibility(organization, imageRepository, visibility string) error
+	UpdateRepositoryDescription(organization, imageRepository, description string) error
+	ChangeRepositoryState(organization, imageRepository, state string) error
+	GetRepositoryMirror(organization, imageRepository string) (*RepositoryMirror, error)
+	CreateRepositoryMirror(organization, imageRepository string, mirror RepositoryMirror) error
+	SyncRepositoryMirror(organization, imageRepository string) error
+	ListManifestLabels(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error)
+	AddManifestLabel(organization, imageRepository, manifestDigest, key, value string) error
 	GetRobotAccount(organization string, robotName string) (*RobotAccount, error)
 	CreateRobotAccount(organization string, robotName string) (*RobotAccount, error)
+	CreateRobotAccountWithDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error)
+	UpdateRobotAccountDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) error
 	DeleteRobotAccount(organization string, robotName string) (bool, error)
 	AddPermissionsForRepositoryToRobotAccount(organization, imageRepository, robotAccountName string, isWrite bool) error
+	SetRepositoryUserPermission(organization, imageRepository, userName, role string) error
+	GetRepositoryUserPermission(organization, imageRepository, userName string) (string, error)
+	DeleteRepositoryUserPermission(organization, imageRepository, userName string) (bool, error)
 	RegenerateRobotAccountToken(organization string, robotName string) (*RobotAccount, error)
 	GetAllRepositories(organization string) ([]Repository, error)
+	ListRepositories(ctx context.Context, organization string, opts ListRepositoriesOptions, visit RepositoriesPageVisitor) error
+	GetRepositoriesMetadata(ctx context.Context, organization string) ([]RepositoryMetadata, error)
 	GetAllRobotAccounts(organization string) ([]RobotAccount, error)
+	GetRobotAccountPermissions(organization, robotName string) ([]RobotAccountPermission, error)
 	GetTagsFromPage(organization, repository string, page int) ([]Tag, bool, error)
 	DeleteTag(organization, repository, tag string) (bool, error)
+	DeleteManifestByDigest(organization, repository, manifestDigest string) ([]string, error)
 	GetNotifications(organization, repository string) ([]Notification, error)
 	CreateNotification(organization, repository string, notification Notification) (*Notification, error)
+	DeleteNotification(organization, repository, notificationUUID string) (bool, error)
+	ListOrganizationMembers(organization string) ([]OrganizationMember, error)
+	RemoveOrganizationMember(organization, memberName string) (bool, error)
+	ListCollaborators(organization string) ([]Collaborator, error)
+	GetTeam(organization, teamName string) (*Team, error)
+	CreateTeam(organization, teamName, description string) error
+	AddTeamMember(organization, teamName, memberName string) error
+	GetOrganizationQuota(organization string) (*OrganizationQuota, error)
+	GetOrganizationPlan(organization string) (*OrganizationPlan, error)
+	ListPermissionPrototypes(organization string) ([]PermissionPrototype, error)
+	CreatePermissionPrototype(organization string, prototype PermissionPrototype) (*PermissionPrototype, error)
+	DeletePermissionPrototype(organization, prototypeID string) (bool, error)
+	SetCorrelationID(correlationID string)
+	SetRequestObserver(observer func(operation string))
 }
 
 var _ QuayService = (*QuayClient)(nil)
@@ -50,8 +84,45 @@ type QuayClient struct {
 	url        string
 	httpClient *http.Client
 	AuthToken  string
+	// TokenProvider, if set, supplies the token of each request instead of AuthToken, e.g. to pick up rotated tokens.
+	TokenProvider TokenProvider
+
+	// OnPageFetched is invoked with the name of the operation for each fetched page of paginated results, e.g. for metrics.
+	OnPageFetched func(operation string)
+	// CorrelationID is sent in CorrelationIDHeader with each request, so Quay logs could be matched with the caller logs.
+	CorrelationID string
+	// OnRateLimit is invoked for each response with rate limit headers, e.g. to feed RateLimitBudget and metrics.
+	// OnRequestWithoutRateLimit is invoked for each response without them.
+	OnRateLimit               func(rateLimit RateLimit)
+	OnRequestWithoutRateLimit func()
+	// OnRequest is invoked with the name of the operation before each request, e.g. to count requests per reconciled object.
+	OnRequest func(operation string)
 }
 
+const defaultRobotAccountDescription = "Robot account for AppStudio Component"
+
+// CorrelationIDHeader is the request header that carries the caller correlation ID.
+const CorrelationIDHeader = "X-Request-Id"
+
+// DefaultMaxRepositoriesPages limits number of pages fetched by ListRepositories,
+// so listing of a huge organization doesn't block the caller forever.
+const DefaultMaxRepositoriesPages = 1000
+
+// ErrPageLimitReached is returned if listing is stopped by the page limit before all results are fetched.
+var ErrPageLimitReached = errors.New("page limit reached")
+
+// ListRepositoriesOptions configures ListRepositories.
+type ListRepositoriesOptions struct {
+	// MaxPages limits number of fetched pages, DefaultMaxRepositoriesPages is used if not set.
+	MaxPages int
+	// Popularity requests popularity score of the repositories, that costs Quay some more work.
+	Popularity bool
+}
+
+// RepositoriesPageVisitor is invoked with each fetched page of repositories.
+// Returning false stops the listing, e.g. when the sought repository is found.
+type RepositoriesPageVisitor func(repositories []Repository) bool
+
 func NewQuayClient(c *http.Client, authToken, url string) *QuayClient {
 	return &QuayClient{
 		httpClient: c,
@@ -60,6 +131,16 @@ func NewQuayClient(c *http.Client, authToken, url string) *QuayClient {
 	}
 }
 
+// NewQuayClientWithTokenProvider creates client that takes token of each request from the provider.
+// Clients built per reconcile should share the http.Client, so connections are reused.
+func NewQuayClientWithTokenProvider(c *http.Client, tokenProvider TokenProvider, url string) *QuayClient {
+	return &QuayClient{
+		httpClient:    c,
+		TokenProvider: tokenProvider,
+		url:           url,
+	}
+}
+
 // QuayResponse wraps http.Response in order to provide custom methods, e.g. GetJson
 type QuayResponse struct {
 	response *http.Response
@@ -81,25 +162,83 @@ func (r *QuayResponse) GetStatusCode() int {
 	return r.response.StatusCode
 }
 
+// getRateLimitedError returns RateLimitedError if Quay rejected the request by rate limiting, nil otherwise.
+// The body of the rejected response is discarded.
+func (r *QuayResponse) getRateLimitedError(operation string) error {
+	if r.response.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	r.response.Body.Close()
+	return &RateLimitedError{Operation: operation, RetryAfter: ParseRetryAfter(r.response.Header, time.Now())}
+}
+
+// StatusError is returned when Quay rejects a request with an error status code,
+// so callers could tell what went wrong without parsing the message.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// GetStatusCode returns the status code of the Quay response, if the error is caused by Quay rejecting the request.
+func GetStatusCode(err error) (int, bool) {
+	statusErr := &StatusError{}
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	return statusErr.StatusCode, true
+}
+
 func (c *QuayClient) makeRequest(url, method string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.AuthToken))
+	authToken := c.AuthToken
+	if c.TokenProvider != nil {
+		authToken = c.TokenProvider.Token()
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", authToken))
 	req.Header.Add("Content-Type", "application/json")
+	if c.CorrelationID != "" {
+		req.Header.Add(CorrelationIDHeader, c.CorrelationID)
+	}
 	return req, nil
 }
 
-func (c *QuayClient) doRequest(url, method string, body io.Reader) (*QuayResponse, error) {
+// SetCorrelationID sets ID sent with all following requests of the client.
+func (c *QuayClient) SetCorrelationID(correlationID string) {
+	c.CorrelationID = correlationID
+}
+
+// SetRequestObserver sets OnRequest for all following requests of the client.
+func (c *QuayClient) SetRequestObserver(observer func(operation string)) {
+	c.OnRequest = observer
+}
+
+// doRequest sends the request of the named operation.
+func (c *QuayClient) doRequest(operation, url, method string, body io.Reader) (*QuayResponse, error) {
 	req, err := c.makeRequest(url, method, body)
 	if err != nil {
 		return nil, err
 	}
+	if c.OnRequest != nil {
+		c.OnRequest(operation)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to Do request: %w", err)
 	}
+	if rateLimit, ok := ParseRateLimit(resp.Header); ok {
+		if c.OnRateLimit != nil {
+			c.OnRateLimit(rateLimit)
+		}
+	} else if c.OnRequestWithoutRateLimit != nil {
+		c.OnRequestWithoutRateLimit()
+	}
 	return &QuayResponse{response: resp}, nil
 }
 
@@ -112,26 +251,31 @@ func (c *QuayClient) CreateRepository(repositoryRequest RepositoryRequest) (*Rep
 		return nil, fmt.Errorf("failed to marshal repository request data: %w", err)
 	}
 
-	resp, err := c.doRequest(url, http.MethodPost, bytes.NewReader(b))
+	resp, err := c.doRequest("CreateRepository", url, http.MethodPost, bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
 
 	statusCode := resp.GetStatusCode()
+	if statusCode == 402 {
+		// Current plan doesn't allow private image repositories
+		resp.response.Body.Close()
+		return nil, &StatusError{StatusCode: statusCode, Message: "payment required"}
+	}
 
 	data := &Repository{}
 	if err := resp.GetJson(data); err != nil {
+		if statusCode != 200 {
+			return nil, &StatusError{StatusCode: statusCode, Message: fmt.Sprintf("failed to create repository. Status code: %d", statusCode)}
+		}
 		return nil, fmt.Errorf("failed to unmarshal response, got response code %d with error: %w", statusCode, err)
 	}
 
 	if statusCode != 200 {
-		if statusCode == 402 {
-			// Current plan doesn't allow private image repositories
-			return nil, errors.New("payment required")
-		} else if statusCode == 400 && data.ErrorMessage == "Repository already exists" {
+		if statusCode == 400 && data.ErrorMessage == "Repository already exists" {
 			data.Name = repositoryRequest.Repository
 		} else if data.ErrorMessage != "" {
-			return data, errors.New(data.ErrorMessage)
+			return data, &StatusError{StatusCode: statusCode, Message: data.ErrorMessage}
 		}
 	}
 
@@ -142,7 +286,7 @@ func (c *QuayClient) CreateRepository(repositoryRequest RepositoryRequest) (*Rep
 func (c *QuayClient) DoesRepositoryExist(organization, imageRepository string) (bool, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s", c.url, organization, imageRepository)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("DoesRepositoryExist", url, http.MethodGet, nil)
 	if err != nil {
 		return false, err
 	}
@@ -167,7 +311,7 @@ func (c *QuayClient) DoesRepositoryExist(organization, imageRepository string) (
 func (c *QuayClient) IsRepositoryPublic(organization, imageRepository string) (bool, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s", c.url, organization, imageRepository)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("IsRepositoryPublic", url, http.MethodGet, nil)
 	if err != nil {
 		return false, err
 	}
@@ -198,11 +342,42 @@ func (c *QuayClient) IsRepositoryPublic(organization, imageRepository string) (b
 	return false, errors.New(data.ErrorMessage)
 }
 
+// GetRepository returns details of the image repository, including its state.
+func (c *QuayClient) GetRepository(organization, imageRepository string) (*Repository, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s", c.url, organization, imageRepository)
+
+	resp, err := c.doRequest("GetRepository", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() == 404 {
+		return nil, fmt.Errorf("repository %s does not exist in %s organization", imageRepository, organization)
+	}
+
+	if resp.GetStatusCode() == 200 {
+		repo := &Repository{}
+		if err := resp.GetJson(repo); err != nil {
+			return nil, err
+		}
+		return repo, nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return nil, err
+	}
+	if data.Error != "" {
+		return nil, errors.New(data.Error)
+	}
+	return nil, errors.New(data.ErrorMessage)
+}
+
 // DeleteRepository deletes specified image repository.
 func (c *QuayClient) DeleteRepository(organization, imageRepository string) (bool, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s", c.url, organization, imageRepository)
 
-	resp, err := c.doRequest(url, http.MethodDelete, nil)
+	resp, err := c.doRequest("DeleteRepository", url, http.MethodDelete, nil)
 	if err != nil {
 		return false, err
 	}
@@ -236,7 +411,7 @@ func (c *QuayClient) ChangeRepositoryVisibility(organization, imageRepositoryNam
 	url := fmt.Sprintf("%s/repository/%s/%s/changevisibility", c.url, organization, imageRepositoryName)
 	requestData := strings.NewReader(fmt.Sprintf(`{"visibility": "%s"}`, visibility))
 
-	resp, err := c.doRequest(url, http.MethodPost, requestData)
+	resp, err := c.doRequest("ChangeRepositoryVisibility", url, http.MethodPost, requestData)
 	if err != nil {
 		return err
 	}
@@ -249,7 +424,62 @@ func (c *QuayClient) ChangeRepositoryVisibility(organization, imageRepositoryNam
 
 	if statusCode == 402 {
 		// Current plan doesn't allow private image repositories
-		return errors.New("payment required")
+		resp.response.Body.Close()
+		return &StatusError{StatusCode: statusCode, Message: "payment required"}
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return err
+	}
+	if data.ErrorMessage != "" {
+		return &StatusError{StatusCode: statusCode, Message: data.ErrorMessage}
+	}
+	return &StatusError{StatusCode: statusCode, Message: resp.response.Status}
+}
+
+// UpdateRepositoryDescription replaces description of existing repository.
+// Quay renders the description as markdown on the repository page.
+func (c *QuayClient) UpdateRepositoryDescription(organization, imageRepositoryName, description string) error {
+	// https://quay.io/api/v1/repository/user-org/repo-name
+	url := fmt.Sprintf("%s/repository/%s/%s", c.url, organization, imageRepositoryName)
+	b, err := json.Marshal(RepositoryUpdateRequest{Description: description})
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository update request data: %w", err)
+	}
+
+	resp, err := c.doRequest("UpdateRepositoryDescription", url, http.MethodPut, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() == 200 {
+		return nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return err
+	}
+	if data.ErrorMessage != "" {
+		return errors.New(data.ErrorMessage)
+	}
+	return errors.New(resp.response.Status)
+}
+
+// ChangeRepositoryState switches existing repository between normal and mirror state.
+func (c *QuayClient) ChangeRepositoryState(organization, imageRepository, state string) error {
+	// https://quay.io/api/v1/repository/user-org/repo-name/changestate
+	url := fmt.Sprintf("%s/repository/%s/%s/changestate", c.url, organization, imageRepository)
+	requestData := strings.NewReader(fmt.Sprintf(`{"state": "%s"}`, state))
+
+	resp, err := c.doRequest("ChangeRepositoryState", url, http.MethodPut, requestData)
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() == 200 {
+		return nil
 	}
 
 	data := &QuayError{}
@@ -262,21 +492,140 @@ func (c *QuayClient) ChangeRepositoryVisibility(organization, imageRepositoryNam
 	return errors.New(resp.response.Status)
 }
 
+// GetRepositoryMirror returns mirror configuration of the repository, nil if the repository is not mirrored.
+func (c *QuayClient) GetRepositoryMirror(organization, imageRepository string) (*RepositoryMirror, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s/mirror", c.url, organization, imageRepository)
+
+	resp, err := c.doRequest("GetRepositoryMirror", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() == 404 {
+		return nil, nil
+	}
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get repository mirror. Status code: %d", resp.GetStatusCode())
+	}
+
+	mirror := &RepositoryMirror{}
+	if err := resp.GetJson(mirror); err != nil {
+		return nil, err
+	}
+	return mirror, nil
+}
+
+// CreateRepositoryMirror configures the repository, that is in mirror state, to mirror tags of an external repository.
+func (c *QuayClient) CreateRepositoryMirror(organization, imageRepository string, mirror RepositoryMirror) error {
+	url := fmt.Sprintf("%s/repository/%s/%s/mirror", c.url, organization, imageRepository)
+
+	b, err := json.Marshal(mirror)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repository mirror data: %w", err)
+	}
+
+	resp, err := c.doRequest("CreateRepositoryMirror", url, http.MethodPost, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() == 201 {
+		return nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return err
+	}
+	return fmt.Errorf("failed to create repository mirror. Status code: %d, message: %s", resp.GetStatusCode(), data.ErrorMessage)
+}
+
+// SyncRepositoryMirror requests immediate synchronization of the mirrored repository.
+func (c *QuayClient) SyncRepositoryMirror(organization, imageRepository string) error {
+	url := fmt.Sprintf("%s/repository/%s/%s/mirror/sync-now", c.url, organization, imageRepository)
+
+	resp, err := c.doRequest("SyncRepositoryMirror", url, http.MethodPost, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() == 204 {
+		return nil
+	}
+	return fmt.Errorf("failed to request repository mirror sync. Status code: %d", resp.GetStatusCode())
+}
+
+// ListManifestLabels returns all labels of the manifest, including those defined in the image config.
+func (c *QuayClient) ListManifestLabels(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s/manifest/%s/labels", c.url, organization, imageRepository, manifestDigest)
+
+	resp, err := c.doRequest("ListManifestLabels", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to list manifest labels. Status code: %d, message: %s", resp.GetStatusCode(), data.ErrorMessage)
+	}
+
+	var response struct {
+		Labels []ManifestLabel `json:"labels"`
+	}
+	if err := resp.GetJson(&response); err != nil {
+		return nil, err
+	}
+	return response.Labels, nil
+}
+
+// AddManifestLabel attaches a label to the manifest.
+// Quay allows several labels with the same key, so an existing label is not replaced.
+func (c *QuayClient) AddManifestLabel(organization, imageRepository, manifestDigest, key, value string) error {
+	url := fmt.Sprintf("%s/repository/%s/%s/manifest/%s/labels", c.url, organization, imageRepository, manifestDigest)
+
+	b, err := json.Marshal(ManifestLabel{Key: key, Value: value, MediaType: "text/plain"})
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest label data: %w", err)
+	}
+
+	resp, err := c.doRequest("AddManifestLabel", url, http.MethodPost, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() == 201 {
+		return nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return err
+	}
+	return fmt.Errorf("failed to add manifest label. Status code: %d, message: %s", resp.GetStatusCode(), data.ErrorMessage)
+}
+
 func (c *QuayClient) GetRobotAccount(organization string, robotName string) (*RobotAccount, error) {
 	url := fmt.Sprintf("%s/%s/%s/%s/%s", c.url, "organization", organization, "robots", robotName)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("GetRobotAccount", url, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	data := &RobotAccount{}
-	if err := resp.GetJson(data); err != nil {
-		return nil, err
+	if statusCode := resp.GetStatusCode(); statusCode != http.StatusOK {
+		// The body of rejected requests, e.g. by a proxy in front of Quay, is not always JSON
+		if err := resp.GetJson(data); err != nil || data.Message == "" {
+			return nil, &StatusError{StatusCode: statusCode, Message: resp.response.Status}
+		}
+		return nil, &StatusError{StatusCode: statusCode, Message: data.Message}
 	}
 
-	if resp.GetStatusCode() != http.StatusOK {
-		return nil, errors.New(data.Message)
+	if err := resp.GetJson(data); err != nil {
+		return nil, err
 	}
 
 	return data, nil
@@ -284,14 +633,23 @@ func (c *QuayClient) GetRobotAccount(organization string, robotName string) (*Ro
 
 // CreateRobotAccount creates a new Quay.io robot account in the organization.
 func (c *QuayClient) CreateRobotAccount(organization string, robotName string) (*RobotAccount, error) {
+	return c.CreateRobotAccountWithDescription(organization, robotName, RobotAccountRequest{Description: defaultRobotAccountDescription})
+}
+
+// CreateRobotAccountWithDescription creates robot account with the given description and metadata,
+// e.g. to record where the robot account came from. Existing robot account is returned as is.
+func (c *QuayClient) CreateRobotAccountWithDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error) {
 	robotName, err := handleRobotName(robotName)
 	if err != nil {
 		return nil, err
 	}
 
 	url := fmt.Sprintf("%s/%s/%s/%s/%s", c.url, "organization", organization, "robots", robotName)
-	payload := strings.NewReader(`{"description": "Robot account for AppStudio Component"}`)
-	resp, err := c.doRequest(url, http.MethodPut, payload)
+	b, err := json.Marshal(robotAccountRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal robot account request data: %w", err)
+	}
+	resp, err := c.doRequest("CreateRobotAccountWithDescription", url, http.MethodPut, bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -324,7 +682,44 @@ func (c *QuayClient) CreateRobotAccount(organization string, robotName string) (
 		return c.GetRobotAccount(organization, robotName)
 	}
 
-	return nil, fmt.Errorf("failed to create robot account. Status code: %d, message: %s", statusCode, message)
+	return nil, &StatusError{StatusCode: statusCode, Message: fmt.Sprintf("failed to create robot account. Status code: %d, message: %s", statusCode, message)}
+}
+
+// UpdateRobotAccountDescription replaces description and metadata of existing robot account, the token is kept.
+// Quay versions that don't allow to change existing robot accounts reject the request, the rejection is returned as StatusError.
+func (c *QuayClient) UpdateRobotAccountDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) error {
+	robotName, err := handleRobotName(robotName)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s/%s/%s/%s", c.url, "organization", organization, "robots", robotName)
+	b, err := json.Marshal(robotAccountRequest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal robot account request data: %w", err)
+	}
+	resp, err := c.doRequest("UpdateRobotAccountDescription", url, http.MethodPost, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	statusCode := resp.GetStatusCode()
+	if statusCode >= 200 && statusCode <= 204 {
+		return nil
+	}
+
+	data := &QuayError{}
+	message := resp.response.Status
+	if err := resp.GetJson(data); err == nil {
+		if data.Message != "" {
+			message = data.Message
+		} else if data.ErrorMessage != "" {
+			message = data.ErrorMessage
+		} else if data.Error != "" {
+			message = data.Error
+		}
+	}
+	return &StatusError{StatusCode: statusCode, Message: fmt.Sprintf("failed to update robot account. Status code: %d, message: %s", statusCode, message)}
 }
 
 // DeleteRobotAccount deletes given Quay.io robot account in the organization.
@@ -335,7 +730,7 @@ func (c *QuayClient) DeleteRobotAccount(organization string, robotName string) (
 	}
 	url := fmt.Sprintf("%s/organization/%s/robots/%s", c.url, organization, robotName)
 
-	resp, err := c.doRequest(url, http.MethodDelete, nil)
+	resp, err := c.doRequest("DeleteRobotAccount", url, http.MethodDelete, nil)
 	if err != nil {
 		return false, err
 	}
@@ -375,7 +770,7 @@ func (c *QuayClient) AddPermissionsForRepositoryToRobotAccount(organization, ima
 		role = "write"
 	}
 	body := strings.NewReader(fmt.Sprintf(`{"role": "%s"}`, role))
-	resp, err := c.doRequest(url, http.MethodPut, body)
+	resp, err := c.doRequest("AddPermissionsForRepositoryToRobotAccount", url, http.MethodPut, body)
 	if err != nil {
 		return err
 	}
@@ -390,83 +785,242 @@ func (c *QuayClient) AddPermissionsForRepositoryToRobotAccount(organization, ima
 				message = data.Error
 			}
 		}
-		return fmt.Errorf("failed to add permissions to the robot account. Status code: %d, message: %s", resp.GetStatusCode(), message)
+		return &StatusError{StatusCode: resp.GetStatusCode(), Message: fmt.Sprintf("failed to add permissions to the robot account. Status code: %d, message: %s", resp.GetStatusCode(), message)}
 	}
 	return nil
 }
 
-func (c *QuayClient) RegenerateRobotAccountToken(organization string, robotName string) (*RobotAccount, error) {
-	url := fmt.Sprintf("%s/organization/%s/robots/%s/regenerate", c.url, organization, robotName)
+// SetRepositoryUserPermission grants the user, or the robot account given by its full name, the role in the repository.
+// An already granted role is replaced.
+func (c *QuayClient) SetRepositoryUserPermission(organization, imageRepository, userName, role string) error {
+	url := fmt.Sprintf("%s/repository/%s/%s/permissions/user/%s", c.url, organization, imageRepository, userName)
 
-	resp, err := c.doRequest(url, http.MethodPost, nil)
+	b, err := json.Marshal(map[string]string{"role": role})
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	data := &RobotAccount{}
-	if err := resp.GetJson(data); err != nil {
-		return nil, err
+	resp, err := c.doRequest("SetRepositoryUserPermission", url, http.MethodPut, bytes.NewReader(b))
+	if err != nil {
+		return err
 	}
 
-	if resp.GetStatusCode() != http.StatusOK {
-		return nil, errors.New(data.Message)
+	if resp.GetStatusCode() != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return err
+		}
+		if data.Error != "" {
+			return errors.New(data.Error)
+		}
+		return errors.New(data.ErrorMessage)
 	}
-
-	return data, nil
+	return nil
 }
 
-// GetAllRepositories returns all repositories of the DEFAULT_QUAY_ORG organization (used in e2e-tests)
-// Returns all repositories of the DEFAULT_QUAY_ORG organization (used in e2e-tests)
-func (c *QuayClient) GetAllRepositories(organization string) ([]Repository, error) {
-	url, _ := neturl.Parse(fmt.Sprintf("%s/repository", c.url))
-	values := neturl.Values{}
-	values.Add("last_modified", "true")
-	values.Add("namespace", organization)
-	url.RawQuery = values.Encode()
+// GetRepositoryUserPermission returns role of the user, or the robot account given by its full name, in the repository.
+// Returns empty string if the user doesn't have any permission in the repository.
+func (c *QuayClient) GetRepositoryUserPermission(organization, imageRepository, userName string) (string, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s/permissions/user/%s", c.url, organization, imageRepository, userName)
 
-	req, err := c.makeRequest(url.String(), http.MethodGet, nil)
+	resp, err := c.doRequest("GetRepositoryUserPermission", url, http.MethodGet, nil)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	type Response struct {
-		Repositories []Repository `json:"repositories"`
-		NextPage     string       `json:"next_page"`
+	statusCode := resp.GetStatusCode()
+	if statusCode == 404 {
+		return "", nil
 	}
-	var response Response
-	var repositories []Repository
-
-	for {
-		res, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to Do request, error: %s", err)
+	if statusCode != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return "", err
 		}
-		if res.StatusCode != 200 {
-			return nil, fmt.Errorf("error getting repositories, got status code %d", res.StatusCode)
+		if data.Error != "" {
+			return "", errors.New(data.Error)
 		}
+		return "", errors.New(data.ErrorMessage)
+	}
 
-		resp := QuayResponse{response: res}
-		if err := resp.GetJson(&response); err != nil {
-			return nil, err
+	permission := &struct {
+		Role string `json:"role"`
+	}{}
+	if err := resp.GetJson(permission); err != nil {
+		return "", err
+	}
+	return permission.Role, nil
+}
+
+// DeleteRepositoryUserPermission revokes access of the user, or the robot account given by its full name, to the repository.
+// Returns false if the user doesn't have any permission in the repository.
+func (c *QuayClient) DeleteRepositoryUserPermission(organization, imageRepository, userName string) (bool, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s/permissions/user/%s", c.url, organization, imageRepository, userName)
+
+	resp, err := c.doRequest("DeleteRepositoryUserPermission", url, http.MethodDelete, nil)
+	if err != nil {
+		return false, err
+	}
+
+	statusCode := resp.GetStatusCode()
+
+	if statusCode == 204 {
+		return true, nil
+	}
+	if statusCode == 404 {
+		return false, nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return false, err
+	}
+	if data.Error != "" {
+		return false, errors.New(data.Error)
+	}
+	return false, errors.New(data.ErrorMessage)
+}
+
+func (c *QuayClient) RegenerateRobotAccountToken(organization string, robotName string) (*RobotAccount, error) {
+	url := fmt.Sprintf("%s/organization/%s/robots/%s/regenerate", c.url, organization, robotName)
+
+	resp, err := c.doRequest("RegenerateRobotAccountToken", url, http.MethodPost, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.getRateLimitedError("RegenerateRobotAccountToken"); err != nil {
+		return nil, err
+	}
+
+	data := &RobotAccount{}
+	if statusCode := resp.GetStatusCode(); statusCode != http.StatusOK {
+		// The body of rejected requests, e.g. by a proxy in front of Quay, is not always JSON
+		if err := resp.GetJson(data); err != nil || data.Message == "" {
+			return nil, &StatusError{StatusCode: statusCode, Message: resp.response.Status}
+		}
+		return nil, &StatusError{StatusCode: statusCode, Message: data.Message}
+	}
+
+	if err := resp.GetJson(data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetAllRepositories returns all repositories of the DEFAULT_QUAY_ORG organization (used in e2e-tests)
+// Returns ErrPageLimitReached if the organization has more than DefaultMaxRepositoriesPages pages of repositories.
+func (c *QuayClient) GetAllRepositories(organization string) ([]Repository, error) {
+	var repositories []Repository
+	err := c.ListRepositories(context.Background(), organization, ListRepositoriesOptions{}, func(page []Repository) bool {
+		repositories = append(repositories, page...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repositories, nil
+}
+
+// ListRepositories fetches repositories of the organization page by page and passes each page to the visitor.
+// Stops when all pages are fetched, the visitor returns false, the context is cancelled or the page limit is reached.
+func (c *QuayClient) ListRepositories(ctx context.Context, organization string, opts ListRepositoriesOptions, visit RepositoriesPageVisitor) error {
+	maxPages := opts.MaxPages
+	if maxPages <= 0 {
+		maxPages = DefaultMaxRepositoriesPages
+	}
+
+	url, _ := neturl.Parse(fmt.Sprintf("%s/repository", c.url))
+	values := neturl.Values{}
+	values.Add("last_modified", "true")
+	values.Add("namespace", organization)
+	if opts.Popularity {
+		values.Add("popularity", "true")
+	}
+	url.RawQuery = values.Encode()
+
+	req, err := c.makeRequest(url.String(), http.MethodGet, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	type Response struct {
+		Repositories []Repository `json:"repositories"`
+		NextPage     string       `json:"next_page"`
+	}
+
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if c.OnRequest != nil {
+			c.OnRequest("ListRepositories")
+		}
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to Do request, error: %s", err)
+		}
+		if res.StatusCode != 200 {
+			res.Body.Close()
+			return fmt.Errorf("error getting repositories, got status code %d", res.StatusCode)
+		}
+
+		var response Response
+		resp := QuayResponse{response: res}
+		if err := resp.GetJson(&response); err != nil {
+			return err
+		}
+		if c.OnPageFetched != nil {
+			c.OnPageFetched("ListRepositories")
 		}
 
-		repositories = append(repositories, response.Repositories...)
+		if !visit(response.Repositories) {
+			return nil
+		}
 
 		if response.NextPage == "" || values.Get("next_page") == response.NextPage {
-			break
+			return nil
+		}
+		if page >= maxPages {
+			return fmt.Errorf("%w: fetched %d pages of repositories", ErrPageLimitReached, page)
 		}
 
 		values.Set("next_page", response.NextPage)
 		req.URL.RawQuery = values.Encode()
 	}
-	return repositories, nil
+}
+
+// GetRepositoriesMetadata returns metadata of all image repositories of the organization.
+// All repositories are fetched in one paginated sweep, instead of a request per repository.
+// If the page limit is reached, metadata of the already fetched repositories is returned along with the error.
+func (c *QuayClient) GetRepositoriesMetadata(ctx context.Context, organization string) ([]RepositoryMetadata, error) {
+	metadata := []RepositoryMetadata{}
+	err := c.ListRepositories(ctx, organization, ListRepositoriesOptions{Popularity: true}, func(repositories []Repository) bool {
+		for _, repository := range repositories {
+			visibility := "private"
+			if repository.IsPublic {
+				visibility = "public"
+			}
+			metadata = append(metadata, RepositoryMetadata{
+				Name:         repository.Name,
+				Visibility:   visibility,
+				IsPublic:     repository.IsPublic,
+				IsStarred:    repository.IsStarred,
+				LastModified: repository.LastModified,
+				Popularity:   repository.Popularity,
+			})
+		}
+		return true
+	})
+	return metadata, err
 }
 
 // GetAllRobotAccounts returns all robot accounts of the DEFAULT_QUAY_ORG organization (used in e2e-tests)
 func (c *QuayClient) GetAllRobotAccounts(organization string) ([]RobotAccount, error) {
 	url := fmt.Sprintf("%s/organization/%s/robots", c.url, organization)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("GetAllRobotAccounts", url, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -485,6 +1039,53 @@ func (c *QuayClient) GetAllRobotAccounts(organization string) ([]RobotAccount, e
 	return response.Robots, nil
 }
 
+// GetRobotAccountPermissions returns repositories of the organization the robot account has access to, together with its roles.
+// Returns ErrPageLimitReached if the permissions span more than DefaultMaxRepositoriesPages pages.
+func (c *QuayClient) GetRobotAccountPermissions(organization, robotName string) ([]RobotAccountPermission, error) {
+	robotName, err := handleRobotName(robotName)
+	if err != nil {
+		return nil, err
+	}
+	url, _ := neturl.Parse(fmt.Sprintf("%s/organization/%s/robots/%s/permissions", c.url, organization, robotName))
+	values := neturl.Values{}
+
+	type Response struct {
+		Permissions []RobotAccountPermission `json:"permissions"`
+		NextPage    string                   `json:"next_page"`
+	}
+
+	permissions := []RobotAccountPermission{}
+	for page := 1; ; page++ {
+		url.RawQuery = values.Encode()
+		resp, err := c.doRequest("GetRobotAccountPermissions", url.String(), http.MethodGet, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.GetStatusCode() != http.StatusOK {
+			data := &QuayError{}
+			_ = resp.GetJson(data)
+			return nil, fmt.Errorf("failed to get robot account permissions. Status code: %d, message: %s", resp.GetStatusCode(), data.Message)
+		}
+
+		var response Response
+		if err := resp.GetJson(&response); err != nil {
+			return nil, err
+		}
+		if c.OnPageFetched != nil {
+			c.OnPageFetched("GetRobotAccountPermissions")
+		}
+		permissions = append(permissions, response.Permissions...)
+
+		if response.NextPage == "" || values.Get("next_page") == response.NextPage {
+			return permissions, nil
+		}
+		if page >= DefaultMaxRepositoriesPages {
+			return permissions, fmt.Errorf("%w: fetched %d pages of robot account permissions", ErrPageLimitReached, page)
+		}
+		values.Set("next_page", response.NextPage)
+	}
+}
+
 // If robotName is in longform, return shortname
 // e.g. `org+robot` will be changed to `robot`, `robot` will stay `robot`
 func handleRobotName(robotName string) (string, error) {
@@ -503,7 +1104,7 @@ func handleRobotName(robotName string) (string, error) {
 func (c *QuayClient) GetTagsFromPage(organization, repository string, page int) ([]Tag, bool, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s/tag/?page=%d", c.url, organization, repository, page)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("GetTagsFromPage", url, http.MethodGet, nil)
 	if err != nil {
 		return nil, false, err
 	}
@@ -528,7 +1129,7 @@ func (c *QuayClient) GetTagsFromPage(organization, repository string, page int)
 func (c *QuayClient) DeleteTag(organization, repository, tag string) (bool, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s/tag/%s", c.url, organization, repository, tag)
 
-	resp, err := c.doRequest(url, http.MethodDelete, nil)
+	resp, err := c.doRequest("DeleteTag", url, http.MethodDelete, nil)
 	if err != nil {
 		return false, err
 	}
@@ -550,10 +1151,51 @@ func (c *QuayClient) DeleteTag(organization, repository, tag string) (bool, erro
 	return false, errors.New(data.ErrorMessage)
 }
 
+// maxTagPages limits number of tag pages fetched when looking for tags of a manifest.
+const maxTagPages = 1000
+
+// DeleteManifestByDigest deletes all active tags pointing to the manifest and returns their names.
+// Quay has no API to delete a manifest, the untagged manifest is garbage collected
+// once the time machine expiration of the organization passes.
+func (c *QuayClient) DeleteManifestByDigest(organization, repository, manifestDigest string) ([]string, error) {
+	// Collect the tags first, deleting them while paging would shift the pages
+	tagNames := []string{}
+	now := time.Now().Unix()
+	for page := 1; ; page++ {
+		if page > maxTagPages {
+			return nil, fmt.Errorf("failed to find tags of manifest %s: %w", manifestDigest, ErrPageLimitReached)
+		}
+		tags, hasAdditional, err := c.GetTagsFromPage(organization, repository, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if tag.ManifestDigest == manifestDigest && (tag.EndTS == 0 || tag.EndTS > now) {
+				tagNames = append(tagNames, tag.Name)
+			}
+		}
+		if !hasAdditional {
+			break
+		}
+	}
+
+	deletedTagNames := []string{}
+	for _, tagName := range tagNames {
+		isDeleted, err := c.DeleteTag(organization, repository, tagName)
+		if err != nil {
+			return deletedTagNames, fmt.Errorf("failed to delete tag %s of manifest %s: %w", tagName, manifestDigest, err)
+		}
+		if isDeleted {
+			deletedTagNames = append(deletedTagNames, tagName)
+		}
+	}
+	return deletedTagNames, nil
+}
+
 func (c *QuayClient) GetNotifications(organization, repository string) ([]Notification, error) {
 	url := fmt.Sprintf("%s/repository/%s/%s/notification/", c.url, organization, repository)
 
-	resp, err := c.doRequest(url, http.MethodGet, nil)
+	resp, err := c.doRequest("GetNotifications", url, http.MethodGet, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -591,7 +1233,7 @@ func (c *QuayClient) CreateNotification(organization, repository string, notific
 		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
 	}
 
-	resp, err := c.doRequest(url, http.MethodPost, bytes.NewReader(b))
+	resp, err := c.doRequest("CreateNotification", url, http.MethodPost, bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
@@ -609,3 +1251,330 @@ func (c *QuayClient) CreateNotification(organization, repository string, notific
 	}
 	return &notificationResponse, nil
 }
+
+// DeleteNotification deletes repository notification with the given UUID.
+// Returns false if the notification doesn't exist.
+func (c *QuayClient) DeleteNotification(organization, repository, notificationUUID string) (bool, error) {
+	url := fmt.Sprintf("%s/repository/%s/%s/notification/%s", c.url, organization, repository, notificationUUID)
+
+	resp, err := c.doRequest("DeleteNotification", url, http.MethodDelete, nil)
+	if err != nil {
+		return false, err
+	}
+
+	statusCode := resp.GetStatusCode()
+
+	if statusCode == 204 {
+		return true, nil
+	}
+	if statusCode == 404 {
+		return false, nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return false, err
+	}
+	if data.Error != "" {
+		return false, errors.New(data.Error)
+	}
+	return false, errors.New(data.ErrorMessage)
+}
+
+// ListOrganizationMembers returns all direct members of the organization together with their teams.
+func (c *QuayClient) ListOrganizationMembers(organization string) ([]OrganizationMember, error) {
+	url := fmt.Sprintf("%s/organization/%s/members", c.url, organization)
+
+	resp, err := c.doRequest("ListOrganizationMembers", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get organization members. Status code: %d", resp.GetStatusCode())
+	}
+
+	var response struct {
+		Members []OrganizationMember `json:"members"`
+	}
+	if err := resp.GetJson(&response); err != nil {
+		return nil, err
+	}
+	return response.Members, nil
+}
+
+// RemoveOrganizationMember removes the user from the organization, including all its teams and repository permissions.
+// Returns false if the user is not a member of the organization.
+func (c *QuayClient) RemoveOrganizationMember(organization, memberName string) (bool, error) {
+	url := fmt.Sprintf("%s/organization/%s/members/%s", c.url, organization, memberName)
+
+	resp, err := c.doRequest("RemoveOrganizationMember", url, http.MethodDelete, nil)
+	if err != nil {
+		return false, err
+	}
+
+	statusCode := resp.GetStatusCode()
+
+	if statusCode == 204 {
+		return true, nil
+	}
+	if statusCode == 404 {
+		return false, nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return false, err
+	}
+	if data.Error != "" {
+		return false, errors.New(data.Error)
+	}
+	return false, errors.New(data.ErrorMessage)
+}
+
+// GetTeam returns the organization team, nil if it doesn't exist.
+// Quay has no endpoint for a single team, so the team is looked up in teams of the organization.
+func (c *QuayClient) GetTeam(organization, teamName string) (*Team, error) {
+	url := fmt.Sprintf("%s/organization/%s", c.url, organization)
+
+	resp, err := c.doRequest("GetTeam", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode := resp.GetStatusCode(); statusCode != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil || data.ErrorMessage == "" {
+			return nil, &StatusError{StatusCode: statusCode, Message: resp.response.Status}
+		}
+		return nil, &StatusError{StatusCode: statusCode, Message: data.ErrorMessage}
+	}
+
+	data := &struct {
+		Teams map[string]Team `json:"teams"`
+	}{}
+	if err := resp.GetJson(data); err != nil {
+		return nil, err
+	}
+	team, exists := data.Teams[teamName]
+	if !exists {
+		return nil, nil
+	}
+	return &team, nil
+}
+
+// CreateTeam creates the organization team with member role, or updates description of the existing one.
+func (c *QuayClient) CreateTeam(organization, teamName, description string) error {
+	url := fmt.Sprintf("%s/organization/%s/team/%s", c.url, organization, teamName)
+
+	b, err := json.Marshal(map[string]string{"role": "member", "description": description})
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRequest("CreateTeam", url, http.MethodPut, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return err
+		}
+		if data.Error != "" {
+			return errors.New(data.Error)
+		}
+		return errors.New(data.ErrorMessage)
+	}
+	return nil
+}
+
+// AddTeamMember adds the user to the organization team, adding an existing member is a no-op.
+func (c *QuayClient) AddTeamMember(organization, teamName, memberName string) error {
+	url := fmt.Sprintf("%s/organization/%s/team/%s/members/%s", c.url, organization, teamName, memberName)
+
+	resp, err := c.doRequest("AddTeamMember", url, http.MethodPut, nil)
+	if err != nil {
+		return err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return err
+		}
+		if data.Error != "" {
+			return errors.New(data.Error)
+		}
+		return errors.New(data.ErrorMessage)
+	}
+	return nil
+}
+
+// GetOrganizationQuota returns the number of private repositories the organization has and is allowed to have.
+// The counts are returned only to organization admins.
+func (c *QuayClient) GetOrganizationQuota(organization string) (*OrganizationQuota, error) {
+	url := fmt.Sprintf("%s/organization/%s/private", c.url, organization)
+
+	resp, err := c.doRequest("GetOrganizationQuota", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get organization quota. Status code: %d", resp.GetStatusCode())
+	}
+
+	quota := &OrganizationQuota{}
+	if err := resp.GetJson(quota); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// GetOrganizationPlan returns the billing plan of the organization.
+// Organizations without subscription have the free plan.
+func (c *QuayClient) GetOrganizationPlan(organization string) (*OrganizationPlan, error) {
+	url := fmt.Sprintf("%s/organization/%s/plan", c.url, organization)
+
+	resp, err := c.doRequest("GetOrganizationPlan", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get organization plan. Status code: %d", resp.GetStatusCode())
+	}
+
+	plan := &OrganizationPlan{}
+	if err := resp.GetJson(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ListCollaborators returns users that have direct permissions to the organization repositories,
+// but are not members of the organization.
+func (c *QuayClient) ListCollaborators(organization string) ([]Collaborator, error) {
+	url := fmt.Sprintf("%s/organization/%s/collaborators", c.url, organization)
+
+	resp, err := c.doRequest("ListCollaborators", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get organization collaborators. Status code: %d", resp.GetStatusCode())
+	}
+
+	var response struct {
+		Collaborators []Collaborator `json:"collaborators"`
+	}
+	if err := resp.GetJson(&response); err != nil {
+		return nil, err
+	}
+	return response.Collaborators, nil
+}
+
+// ListPermissionPrototypes returns default permissions the organization grants on newly created repositories.
+func (c *QuayClient) ListPermissionPrototypes(organization string) ([]PermissionPrototype, error) {
+	url := fmt.Sprintf("%s/organization/%s/prototypes", c.url, organization)
+
+	resp, err := c.doRequest("ListPermissionPrototypes", url, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		return nil, fmt.Errorf("failed to get organization permission prototypes. Status code: %d", resp.GetStatusCode())
+	}
+
+	var response struct {
+		Prototypes []PermissionPrototype `json:"prototypes"`
+	}
+	if err := resp.GetJson(&response); err != nil {
+		return nil, err
+	}
+	return response.Prototypes, nil
+}
+
+// CreatePermissionPrototype adds default permission of the organization granted on each newly created repository.
+// Repositories that already exist are not affected.
+func (c *QuayClient) CreatePermissionPrototype(organization string, prototype PermissionPrototype) (*PermissionPrototype, error) {
+	url := fmt.Sprintf("%s/organization/%s/prototypes", c.url, organization)
+
+	type delegate struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+	}
+	body := struct {
+		Role           string    `json:"role"`
+		Delegate       delegate  `json:"delegate"`
+		ActivatingUser *delegate `json:"activating_user,omitempty"`
+	}{
+		Role:     prototype.Role,
+		Delegate: delegate{Name: prototype.Delegate.Name, Kind: prototype.Delegate.Kind},
+	}
+	if prototype.ActivatingUser != nil {
+		body.ActivatingUser = &delegate{Name: prototype.ActivatingUser.Name, Kind: prototype.ActivatingUser.Kind}
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal permission prototype request data: %w", err)
+	}
+
+	resp, err := c.doRequest("CreatePermissionPrototype", url, http.MethodPost, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.GetStatusCode() != 200 {
+		data := &QuayError{}
+		if err := resp.GetJson(data); err != nil {
+			return nil, err
+		}
+		if data.Error != "" {
+			return nil, errors.New(data.Error)
+		}
+		if data.ErrorMessage != "" {
+			return nil, errors.New(data.ErrorMessage)
+		}
+		return nil, fmt.Errorf("failed to create organization permission prototype. Status code: %d", resp.GetStatusCode())
+	}
+
+	created := &PermissionPrototype{}
+	if err := resp.GetJson(created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// DeletePermissionPrototype removes default permission of the organization.
+// Returns false if the prototype does not exist.
+func (c *QuayClient) DeletePermissionPrototype(organization, prototypeID string) (bool, error) {
+	url := fmt.Sprintf("%s/organization/%s/prototypes/%s", c.url, organization, prototypeID)
+
+	resp, err := c.doRequest("DeletePermissionPrototype", url, http.MethodDelete, nil)
+	if err != nil {
+		return false, err
+	}
+
+	statusCode := resp.GetStatusCode()
+
+	if statusCode == 204 {
+		return true, nil
+	}
+	if statusCode == 404 {
+		return false, nil
+	}
+
+	data := &QuayError{}
+	if err := resp.GetJson(data); err != nil {
+		return false, err
+	}
+	if data.Error != "" {
+		return false, errors.New(data.Error)
+	}
+	return false, errors.New(data.ErrorMessage)
+}
@@ -16,6 +16,7 @@ limitations under the License.
 package quay
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -59,6 +60,7 @@ func TestQuayClient_CreateRepository(t *testing.T) {
 		responseData       interface{}
 		expectedRepository *Repository
 		expectedErr        string // Empty string means that no error is expected
+		expectedStatusCode int    // Zero means that the error is not a Quay rejection
 	}{
 		{
 			name:               "successful repository creation",
@@ -86,6 +88,7 @@ func TestQuayClient_CreateRepository(t *testing.T) {
 			responseData:       map[string]string{"name": repo},
 			expectedRepository: nil,
 			expectedErr:        "payment required",
+			expectedStatusCode: 402,
 		},
 		{
 			name:       "not handled status with error message",
@@ -98,7 +101,8 @@ func TestQuayClient_CreateRepository(t *testing.T) {
 				Name:         repo,
 				ErrorMessage: "something is wrong in the server",
 			},
-			expectedErr: "something is wrong in the server",
+			expectedErr:        "something is wrong in the server",
+			expectedStatusCode: 500,
 		},
 		{
 			name:               "response data can't be encoded to a JSON data",
@@ -139,6 +143,8 @@ func TestQuayClient_CreateRepository(t *testing.T) {
 				assert.NilError(t, err, fmt.Sprintf("expected error to be nil, got '%v'", err))
 			} else {
 				assert.ErrorContains(t, err, tc.expectedErr)
+				statusCode, _ := GetStatusCode(err)
+				assert.Equal(t, tc.expectedStatusCode, statusCode)
 			}
 
 			if tc.expectedRepository == nil {
@@ -151,6 +157,98 @@ func TestQuayClient_CreateRepository(t *testing.T) {
 	}
 }
 
+func TestQuayClient_CreateApplicationRepository(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Content-type", "application/json").
+		Post("/repository").
+		JSON(map[string]string{
+			"namespace":   testRepoNamespace,
+			"repository":  repo,
+			"visibility":  "private",
+			"description": testRepoDescription,
+			"repo_kind":   "application",
+		}).
+		Reply(200).JSON(map[string]string{"name": repo, "kind": "application"})
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	repoInfo, err := quayClient.CreateRepository(RepositoryRequest{
+		Namespace:   testRepoNamespace,
+		Description: testRepoDescription,
+		Visibility:  "private",
+		Repository:  repo,
+		Kind:        "application",
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, repoInfo.Kind, "application")
+	assert.Assert(t, gock.IsDone(), "expected repo_kind to be sent in the request")
+}
+
+func TestQuayClient_CreateRobotAccountWithDescription(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Content-type", "application/json").
+		Put(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		JSON(map[string]interface{}{
+			"description":           "Push robot account of ImageRepository test-ns/my-image",
+			"unstructured_metadata": map[string]string{"namespace": "test-ns", "name": "my-image"},
+		}).
+		Reply(201).JSON(map[string]interface{}{
+		"name":                  org + "+" + robotName,
+		"token":                 "robotaccountoken",
+		"description":           "Push robot account of ImageRepository test-ns/my-image",
+		"unstructured_metadata": map[string]string{"namespace": "test-ns", "name": "my-image"},
+	})
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	robotAccount, err := quayClient.CreateRobotAccountWithDescription(org, robotName, RobotAccountRequest{
+		Description:          "Push robot account of ImageRepository test-ns/my-image",
+		UnstructuredMetadata: map[string]string{"namespace": "test-ns", "name": "my-image"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, robotAccount.Description, "Push robot account of ImageRepository test-ns/my-image")
+	assert.Equal(t, robotAccount.UnstructuredMetadata["namespace"], "test-ns")
+	assert.Assert(t, gock.IsDone(), "expected description and metadata to be sent in the request")
+}
+
+func TestQuayClient_UpdateRobotAccountDescription(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	robotAccountRequest := RobotAccountRequest{
+		Description:          "Push robot account of ImageRepository test-ns/my-image",
+		UnstructuredMetadata: map[string]string{"namespace": "test-ns", "name": "my-image"},
+	}
+	gock.New(testQuayApiUrl).
+		MatchHeader("Content-type", "application/json").
+		Post(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		JSON(map[string]interface{}{
+			"description":           "Push robot account of ImageRepository test-ns/my-image",
+			"unstructured_metadata": map[string]string{"namespace": "test-ns", "name": "my-image"},
+		}).
+		Reply(200).JSON(map[string]string{"name": org + "+" + robotName})
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	assert.NilError(t, quayClient.UpdateRobotAccountDescription(org, robotName, robotAccountRequest))
+	assert.Assert(t, gock.IsDone(), "expected description and metadata to be sent in the request")
+
+	gock.New(testQuayApiUrl).
+		Post(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		Reply(405).JSON(map[string]string{"error_message": "Method not allowed"})
+	err := quayClient.UpdateRobotAccountDescription(org, robotName, robotAccountRequest)
+	assert.ErrorContains(t, err, "Method not allowed")
+	statusCode, isStatusError := GetStatusCode(err)
+	assert.Assert(t, isStatusError)
+	assert.Equal(t, statusCode, 405)
+}
+
 func TestQuayClient_CreateRobotAccount(t *testing.T) {
 	defer gock.Off()
 
@@ -465,6 +563,157 @@ func TestQuayClient_GetAllRepositories(t *testing.T) {
 	}
 }
 
+func TestQuayClient_ListRepositories(t *testing.T) {
+	type Response struct {
+		Repositories []Repository `json:"repositories"`
+		NextPage     string       `json:"next_page"`
+	}
+	mockPages := func(pagesCount int) {
+		for i := 1; i <= pagesCount; i++ {
+			request := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get("/repository")
+			if i > 1 {
+				request.MatchParam("next_page", fmt.Sprintf("page%d", i))
+			}
+			request.Reply(200).JSON(Response{Repositories: []Repository{{Name: fmt.Sprintf("repo%d", i)}}, NextPage: fmt.Sprintf("page%d", i+1)})
+		}
+	}
+	newClient := func() *QuayClient {
+		client := &http.Client{Transport: &http.Transport{}}
+		gock.InterceptClient(client)
+		return NewQuayClient(client, "authtoken", testQuayApiUrl)
+	}
+
+	t.Run("stop when the visitor returns false", func(t *testing.T) {
+		defer gock.Off()
+		mockPages(3)
+
+		quayClient := newClient()
+		fetchedPages := 0
+		quayClient.OnPageFetched = func(operation string) {
+			assert.Equal(t, operation, "ListRepositories")
+			fetchedPages++
+		}
+		visited := []string{}
+		err := quayClient.ListRepositories(context.Background(), org, ListRepositoriesOptions{}, func(repositories []Repository) bool {
+			for _, repository := range repositories {
+				visited = append(visited, repository.Name)
+			}
+			return repositories[0].Name != "repo2"
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, visited, []string{"repo1", "repo2"})
+		assert.Equal(t, fetchedPages, 2)
+	})
+
+	t.Run("stop when the page limit is reached", func(t *testing.T) {
+		defer gock.Off()
+		mockPages(3)
+
+		visitedPages := 0
+		err := newClient().ListRepositories(context.Background(), org, ListRepositoriesOptions{MaxPages: 2}, func(repositories []Repository) bool {
+			visitedPages++
+			return true
+		})
+		assert.Assert(t, errors.Is(err, ErrPageLimitReached))
+		assert.Equal(t, visitedPages, 2)
+	})
+
+	t.Run("stop when the context is cancelled", func(t *testing.T) {
+		defer gock.Off()
+		mockPages(3)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		visitedPages := 0
+		err := newClient().ListRepositories(ctx, org, ListRepositoriesOptions{}, func(repositories []Repository) bool {
+			visitedPages++
+			cancel()
+			return true
+		})
+		assert.Assert(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, visitedPages, 1)
+	})
+}
+
+func TestQuayClient_GetRepositoriesMetadata(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	type Response struct {
+		Repositories []Repository `json:"repositories"`
+		NextPage     string       `json:"next_page"`
+	}
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchParam("namespace", org).
+		MatchParam("popularity", "true").
+		MatchParam("last_modified", "true").
+		Get("/repository").
+		Reply(200).JSON(Response{
+		Repositories: []Repository{{Name: "repo1", IsPublic: true, IsStarred: true, LastModified: 1700000000, Popularity: 12.5}},
+		NextPage:     "page2",
+	})
+	gock.New(testQuayApiUrl).
+		MatchParam("next_page", "page2").
+		MatchParam("popularity", "true").
+		Get("/repository").
+		Reply(200).JSON(Response{Repositories: []Repository{{Name: "repo2"}}})
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	metadata, err := quayClient.GetRepositoriesMetadata(context.Background(), org)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, metadata, []RepositoryMetadata{
+		{Name: "repo1", Visibility: "public", IsPublic: true, IsStarred: true, LastModified: 1700000000, Popularity: 12.5},
+		{Name: "repo2", Visibility: "private"},
+	})
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestQuayClient_GetRobotAccountPermissions(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	type Response struct {
+		Permissions []RobotAccountPermission `json:"permissions"`
+		NextPage    string                   `json:"next_page,omitempty"`
+	}
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get("/organization/" + org + "/robots/robot1/permissions").
+		Reply(200).JSON(Response{
+		Permissions: []RobotAccountPermission{{Repository: RobotAccountPermissionRepository{Name: "repo1"}, Role: "write"}},
+		NextPage:    "page2",
+	})
+	gock.New(testQuayApiUrl).
+		MatchParam("next_page", "page2").
+		Get("/organization/" + org + "/robots/robot1/permissions").
+		Reply(200).JSON(Response{
+		Permissions: []RobotAccountPermission{{Repository: RobotAccountPermissionRepository{Name: "repo2", IsPublic: true}, Role: "admin"}},
+	})
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	// Long robot account name is accepted too
+	permissions, err := quayClient.GetRobotAccountPermissions(org, org+"+robot1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, permissions, []RobotAccountPermission{
+		{Repository: RobotAccountPermissionRepository{Name: "repo1"}, Role: "write"},
+		{Repository: RobotAccountPermissionRepository{Name: "repo2", IsPublic: true}, Role: "admin"},
+	})
+	assert.Assert(t, gock.IsDone())
+
+	gock.New(testQuayApiUrl).
+		Get("/organization/" + org + "/robots/missing/permissions").
+		Reply(404).JSON(map[string]string{"message": "Could not find robot with specified username"})
+	_, err = quayClient.GetRobotAccountPermissions(org, "missing")
+	assert.ErrorContains(t, err, "Status code: 404, message: Could not find robot")
+
+	_, err = quayClient.GetRobotAccountPermissions(org, "Invalid Robot")
+	assert.ErrorContains(t, err, "robot name is invalid")
+}
+
 func TestQuayClient_GetAllRobotAccounts(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -804,6 +1053,49 @@ func TestQuayClient_DeleteTag(t *testing.T) {
 	}
 }
 
+func TestQuayClient_DeleteManifestByDigest(t *testing.T) {
+	defer gock.Off()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+
+	manifestDigest := "sha256:leaked"
+	gock.New(testQuayApiUrl).
+		Get(fmt.Sprintf("repository/%s/%s/tag/", org, repo)).
+		MatchParam("page", "1").
+		Reply(200).
+		JSON(map[string]interface{}{
+			"tags": []Tag{
+				{Name: "v1", ManifestDigest: manifestDigest},
+				{Name: "latest", ManifestDigest: "sha256:other"},
+			},
+			"has_additional": true,
+		})
+	gock.New(testQuayApiUrl).
+		Get(fmt.Sprintf("repository/%s/%s/tag/", org, repo)).
+		MatchParam("page", "2").
+		Reply(200).
+		JSON(map[string]interface{}{
+			"tags": []Tag{
+				{Name: "v1-expired", ManifestDigest: manifestDigest, EndTS: 1},
+				{Name: "v1-build", ManifestDigest: manifestDigest},
+			},
+			"has_additional": false,
+		})
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("repository/%s/%s/tag/v1", org, repo)).
+		Reply(204)
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("repository/%s/%s/tag/v1-build", org, repo)).
+		Reply(204)
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	deletedTagNames, err := quayClient.DeleteManifestByDigest(org, repo, manifestDigest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, deletedTagNames, []string{"v1", "v1-build"})
+	assert.Assert(t, gock.IsDone())
+}
+
 func TestQuayClient_DoesRepositoryExist(t *testing.T) {
 	client := &http.Client{Transport: &http.Transport{}}
 	gock.InterceptClient(client)
@@ -1151,17 +1443,163 @@ func TestQuayClient_ChangeRepositoryVisibility(t *testing.T) {
 	}
 }
 
+func TestQuayClient_UpdateRepositoryDescription(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+
+	description := "# My image\n\nBuilt from \"main\" branch"
+
+	testCases := []struct {
+		name       string
+		err        string
+		statusCode int
+		response   interface{}
+	}{
+		{
+			name:       "Update description",
+			statusCode: 200,
+			response:   map[string]bool{"success": true},
+		},
+		{
+			name:       "Unauthorized access",
+			err:        "Unauthorized",
+			statusCode: 403,
+			response:   responseUnauthorized,
+		},
+		{
+			name:       "return res.Status as error",
+			err:        "404 ",
+			statusCode: 404,
+			response:   map[string]string{"detail": "Not Found"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Content-Type", "application/json").
+				MatchHeader("Authorization", "Bearer authtoken").
+				BodyString(`{"description":"# My image\\n\\nBuilt from \\"main\\" branch"}`).
+				Put(fmt.Sprintf("repository/%s/%s", org, repo)).
+				Reply(tc.statusCode).JSON(tc.response)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			err := quayClient.UpdateRepositoryDescription(org, repo, description)
+			if tc.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestQuayClient_RepositoryMirror(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s/mirror", org, repo)).
+		Reply(404).JSON(map[string]string{"detail": "Not Found"})
+	mirror, err := quayClient.GetRepositoryMirror(org, repo)
+	assert.NilError(t, err)
+	assert.Assert(t, mirror == nil)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		BodyString(`{"state": "MIRROR"}`).
+		Put(fmt.Sprintf("repository/%s/%s/changestate", org, repo)).
+		Reply(200).JSON(map[string]string{"state": "MIRROR"})
+	assert.NilError(t, quayClient.ChangeRepositoryState(org, repo, RepositoryStateMirror))
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"is_enabled":                 true,
+			"external_reference":         "quay.io/source-org/source-repo",
+			"external_registry_username": "source-org+robot",
+			"external_registry_password": "token",
+			"sync_interval":              86400,
+			"sync_start_date":            "2024-01-01T00:00:00Z",
+			"robot_username":             org + "+mirror",
+			"root_rule":                  map[string]interface{}{"rule_kind": "tag_glob_csv", "rule_value": []string{"*"}},
+			"external_registry_config":   map[string]interface{}{},
+		}).
+		Post(fmt.Sprintf("repository/%s/%s/mirror", org, repo)).
+		Reply(201)
+	assert.NilError(t, quayClient.CreateRepositoryMirror(org, repo, RepositoryMirror{
+		IsEnabled:                true,
+		ExternalReference:        "quay.io/source-org/source-repo",
+		ExternalRegistryUsername: "source-org+robot",
+		ExternalRegistryPassword: "token",
+		SyncInterval:             86400,
+		SyncStartDate:            "2024-01-01T00:00:00Z",
+		RobotUsername:            org + "+mirror",
+		RootRule:                 RepositoryMirrorRule{RuleKind: "tag_glob_csv", RuleValue: []string{"*"}},
+		ExternalRegistryConfig:   map[string]interface{}{},
+	}))
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Post(fmt.Sprintf("repository/%s/%s/mirror/sync-now", org, repo)).
+		Reply(204)
+	assert.NilError(t, quayClient.SyncRepositoryMirror(org, repo))
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s/mirror", org, repo)).
+		Reply(200).JSON(map[string]interface{}{"is_enabled": true, "sync_status": "SYNC_SUCCESS"})
+	mirror, err = quayClient.GetRepositoryMirror(org, repo)
+	assert.NilError(t, err)
+	assert.Equal(t, mirror.SyncStatus, RepositoryMirrorSyncSuccess)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Post(fmt.Sprintf("repository/%s/%s/mirror", org, repo)).
+		Reply(400).JSON(map[string]string{"error_message": "Repository is not in mirror state"})
+	err = quayClient.CreateRepositoryMirror(org, repo, RepositoryMirror{})
+	assert.ErrorContains(t, err, "Status code: 400, message: Repository is not in mirror state")
+
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestQuayClient_CorrelationID(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchHeader(CorrelationIDHeader, "6f1c2a4e-reconcile").
+		Delete(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(204)
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	quayClient.SetCorrelationID("6f1c2a4e-reconcile")
+	_, err := quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	assert.Assert(t, gock.IsDone())
+}
+
 func TestQuayClient_GetRobotAccount(t *testing.T) {
 	client := &http.Client{Transport: &http.Transport{}}
 	gock.InterceptClient(client)
 
 	sampleRobot := &RobotAccount{
-		Description:  "",
-		Created:      "Wed, 12 Jul 2023 10:25:41 -0000",
-		LastAccessed: "",
-		Token:        "abc123",
-		Name:         fmt.Sprintf("%s+%s", org, robotName),
-		Message:      "",
+		Description:          "",
+		Created:              "Wed, 12 Jul 2023 10:25:41 -0000",
+		UnstructuredMetadata: map[string]interface{}{},
+		LastAccessed:         "",
+		Token:                "abc123",
+		Name:                 fmt.Sprintf("%s+%s", org, robotName),
+		Message:              "",
 	}
 
 	testCases := []struct {
@@ -1189,9 +1627,16 @@ func TestQuayClient_GetRobotAccount(t *testing.T) {
 			name:        "server responds an invalid JSON string",
 			robot:       nil,
 			expectedErr: "failed to unmarshal response body",
-			statusCode:  400, // this field can be ignored for this case
+			statusCode:  200,
 			response:    "{\"error\": \"something is wrong}",
 		},
+		{
+			name:        "return status error when server responds non-200 without JSON body",
+			robot:       nil,
+			expectedErr: "502 Bad Gateway",
+			statusCode:  502,
+			response:    "<html>Bad Gateway</html>",
+		},
 		{
 			name:        "stop if http request fails",
 			expectedErr: "failed to Do request:",
@@ -1222,6 +1667,11 @@ func TestQuayClient_GetRobotAccount(t *testing.T) {
 			} else {
 				assert.ErrorContains(t, err, tc.expectedErr)
 			}
+			if tc.statusCode != 0 && tc.statusCode != http.StatusOK {
+				statusCode, isStatusErr := GetStatusCode(err)
+				assert.Assert(t, isStatusErr)
+				assert.Equal(t, statusCode, tc.statusCode)
+			}
 		})
 	}
 }
@@ -1322,12 +1772,13 @@ func TestQuayClient_RegenerateRobotAccountToken(t *testing.T) {
 	gock.InterceptClient(client)
 
 	sampleRobot := &RobotAccount{
-		Description:  "",
-		Created:      "Wed, 12 Jul 2023 10:25:41 -0000",
-		LastAccessed: "",
-		Token:        "abc123",
-		Name:         fmt.Sprintf("%s+%s", org, robotName),
-		Message:      "",
+		Description:          "",
+		Created:              "Wed, 12 Jul 2023 10:25:41 -0000",
+		UnstructuredMetadata: map[string]interface{}{},
+		LastAccessed:         "",
+		Token:                "abc123",
+		Name:                 fmt.Sprintf("%s+%s", org, robotName),
+		Message:              "",
 	}
 
 	testCases := []struct {
@@ -1470,6 +1921,63 @@ func TestQuayClient_CreateNotification(t *testing.T) {
 	}
 }
 
+func TestQuayClient_DeleteNotification(t *testing.T) {
+	const notificationUUID = "1234"
+
+	testCases := []struct {
+		name          string
+		statusCode    int
+		responseData  interface{}
+		expectDeleted bool
+		expectedErr   string
+	}{
+		{
+			name:          "Notification is deleted",
+			statusCode:    204,
+			expectDeleted: true,
+		},
+		{
+			name:          "Notification does not exist",
+			statusCode:    404,
+			expectDeleted: false,
+		},
+		{
+			name:         "Server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Delete(fmt.Sprintf("repository/%s/%s/notification/%s", org, repo, notificationUUID)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			isDeleted, err := quayClient.DeleteNotification(org, repo, notificationUUID)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectDeleted, isDeleted)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
 func TestMakeRequest(t *testing.T) {
 	client := &http.Client{Transport: &http.Transport{}}
 	gock.InterceptClient(client)
@@ -1555,7 +2063,7 @@ func TestDoRequest(t *testing.T) {
 			}
 
 			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
-			resp, err := quayClient.doRequest(testQuayApiUrl, tc.httpMethod, nil)
+			resp, err := quayClient.doRequest("Test", testQuayApiUrl, tc.httpMethod, nil)
 			if tc.expectErr == "" {
 				assert.NilError(t, err)
 				assert.Assert(t, resp != nil)
@@ -1568,3 +2076,856 @@ func TestDoRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestQuayClient_ListOrganizationMembers(t *testing.T) {
+	testCases := []struct {
+		name            string
+		statusCode      int
+		responseData    interface{}
+		expectedMembers []OrganizationMember
+		expectedErr     string
+	}{
+		{
+			name:         "get organization members normally",
+			statusCode:   200,
+			responseData: `{"members": [{"name": "user1", "kind": "user", "teams": [{"name": "owners"}], "repositories": ["repo1"]}]}`,
+			expectedMembers: []OrganizationMember{
+				{Name: "user1", Kind: "user", Teams: []MemberTeam{{Name: "owners"}}, Repositories: []string{"repo1"}},
+			},
+		},
+		{
+			name:        "server does not respond 200",
+			statusCode:  403,
+			expectedErr: "failed to get organization members. Status code: 403",
+		},
+		{
+			name:         "server responds invalid JSON",
+			statusCode:   200,
+			responseData: `{"members": [{"name": "user1}]}`,
+			expectedErr:  "failed to unmarshal response body",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s/members", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			members, err := quayClient.ListOrganizationMembers(org)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.DeepEqual(t, tc.expectedMembers, members)
+		})
+	}
+}
+
+func TestQuayClient_RemoveOrganizationMember(t *testing.T) {
+	const memberName = "user1"
+
+	testCases := []struct {
+		name          string
+		statusCode    int
+		responseData  interface{}
+		expectRemoved bool
+		expectedErr   string
+	}{
+		{
+			name:          "remove organization member",
+			statusCode:    204,
+			expectRemoved: true,
+		},
+		{
+			name:          "member does not exist",
+			statusCode:    404,
+			expectRemoved: false,
+		},
+		{
+			name:         "server responds error",
+			statusCode:   400,
+			responseData: map[string]string{"error_message": "cannot remove the last admin"},
+			expectedErr:  "cannot remove the last admin",
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Delete(fmt.Sprintf("/organization/%s/members/%s", org, memberName)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			isRemoved, err := quayClient.RemoveOrganizationMember(org, memberName)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectRemoved, isRemoved)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_CreateTeam(t *testing.T) {
+	const teamName = "tenantnsteam2f99ea56"
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedErr  string
+	}{
+		{
+			name:       "create team",
+			statusCode: 200,
+		},
+		{
+			name:         "server responds error",
+			statusCode:   400,
+			responseData: map[string]string{"error_message": "Invalid team name"},
+			expectedErr:  "Invalid team name",
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Put(fmt.Sprintf("/organization/%s/team/%s", org, teamName)).
+				JSON(map[string]string{"role": "member", "description": "Team of tenant-ns namespace"}).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			err := quayClient.CreateTeam(org, teamName, "Team of tenant-ns namespace")
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_GetTeam(t *testing.T) {
+	testCases := []struct {
+		name         string
+		teamName     string
+		statusCode   int
+		responseData interface{}
+		expectedTeam *Team
+		expectedErr  string
+	}{
+		{
+			name:         "get existing team",
+			teamName:     "owners",
+			statusCode:   200,
+			responseData: map[string]interface{}{"name": org, "teams": map[string]interface{}{"owners": map[string]string{"name": "owners", "description": "Admins", "role": "admin"}}},
+			expectedTeam: &Team{Name: "owners", Description: "Admins", Role: "admin"},
+		},
+		{
+			name:         "missing team",
+			teamName:     "tenantnsteam",
+			statusCode:   200,
+			responseData: map[string]interface{}{"name": org, "teams": map[string]interface{}{"owners": map[string]string{"name": "owners"}}},
+		},
+		{
+			name:         "server responds unauthorized",
+			teamName:     "owners",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			team, err := quayClient.GetTeam(org, tc.teamName)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				statusCode, _ := GetStatusCode(err)
+				assert.Equal(t, statusCode, tc.statusCode)
+			}
+			assert.DeepEqual(t, team, tc.expectedTeam)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_AddTeamMember(t *testing.T) {
+	const teamName = "tenantnsteam2f99ea56"
+	const memberName = "user1"
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedErr  string
+	}{
+		{
+			name:       "add team member",
+			statusCode: 200,
+		},
+		{
+			name:         "user does not exist",
+			statusCode:   400,
+			responseData: map[string]string{"error_message": "Unknown user"},
+			expectedErr:  "Unknown user",
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Put(fmt.Sprintf("/organization/%s/team/%s/members/%s", org, teamName, memberName)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			err := quayClient.AddTeamMember(org, teamName, memberName)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_ListPermissionPrototypes(t *testing.T) {
+	testCases := []struct {
+		name               string
+		statusCode         int
+		responseData       interface{}
+		expectedPrototypes []PermissionPrototype
+		expectedErr        string
+	}{
+		{
+			name:         "get permission prototypes normally",
+			statusCode:   200,
+			responseData: `{"prototypes": [{"id": "uuid1", "role": "read", "delegate": {"name": "org+ci", "kind": "user", "is_robot": true}}]}`,
+			expectedPrototypes: []PermissionPrototype{
+				{ID: "uuid1", Role: "read", Delegate: PrototypeDelegate{Name: "org+ci", Kind: "user", IsRobot: true}},
+			},
+		},
+		{
+			name:        "server does not respond 200",
+			statusCode:  403,
+			expectedErr: "failed to get organization permission prototypes. Status code: 403",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s/prototypes", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			prototypes, err := quayClient.ListPermissionPrototypes(org)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.DeepEqual(t, tc.expectedPrototypes, prototypes)
+		})
+	}
+}
+
+func TestQuayClient_CreatePermissionPrototype(t *testing.T) {
+	prototype := PermissionPrototype{Role: "read", Delegate: PrototypeDelegate{Name: "org+ci", Kind: "user"}}
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedID   string
+		expectedErr  string
+	}{
+		{
+			name:         "create permission prototype",
+			statusCode:   200,
+			responseData: map[string]interface{}{"id": "uuid1", "role": "read", "delegate": map[string]interface{}{"name": "org+ci", "kind": "user", "is_robot": true}},
+			expectedID:   "uuid1",
+		},
+		{
+			name:         "server responds error",
+			statusCode:   400,
+			responseData: map[string]string{"error_message": "Unknown user or robot"},
+			expectedErr:  "Unknown user or robot",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Post(fmt.Sprintf("/organization/%s/prototypes", org)).
+				JSON(map[string]interface{}{
+					"role":     "read",
+					"delegate": map[string]string{"name": "org+ci", "kind": "user"},
+				}).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			created, err := quayClient.CreatePermissionPrototype(org, prototype)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+				assert.Equal(t, tc.expectedID, created.ID)
+				assert.Assert(t, created.Delegate.IsRobot)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_DeletePermissionPrototype(t *testing.T) {
+	const prototypeID = "uuid1"
+
+	testCases := []struct {
+		name          string
+		statusCode    int
+		responseData  interface{}
+		expectDeleted bool
+		expectedErr   string
+	}{
+		{
+			name:          "delete permission prototype",
+			statusCode:    204,
+			expectDeleted: true,
+		},
+		{
+			name:          "prototype does not exist",
+			statusCode:    404,
+			expectDeleted: false,
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Delete(fmt.Sprintf("/organization/%s/prototypes/%s", org, prototypeID)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			isDeleted, err := quayClient.DeletePermissionPrototype(org, prototypeID)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectDeleted, isDeleted)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_SetRepositoryUserPermission(t *testing.T) {
+	const userName = "external-user"
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedErr  string
+	}{
+		{
+			name:         "grant permission",
+			statusCode:   200,
+			responseData: map[string]string{"role": "write", "name": userName},
+		},
+		{
+			name:         "user does not exist",
+			statusCode:   400,
+			responseData: map[string]string{"error_message": "Invalid username: " + userName},
+			expectedErr:  "Invalid username",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Put(fmt.Sprintf("/repository/%s/%s/permissions/user/%s", org, repo, userName)).
+				JSON(map[string]string{"role": "write"}).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			err := quayClient.SetRepositoryUserPermission(org, repo, userName, "write")
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_GetRepositoryUserPermission(t *testing.T) {
+	const userName = "sbom-uploader"
+
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedRole string
+		expectedErr  string
+	}{
+		{
+			name:         "get permission",
+			statusCode:   200,
+			responseData: map[string]string{"role": "write"},
+			expectedRole: "write",
+		},
+		{
+			name:       "permission does not exist",
+			statusCode: 404,
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/repository/%s/%s/permissions/user/%s", org, repo, userName)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			role, err := quayClient.GetRepositoryUserPermission(org, repo, userName)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectedRole, role)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_DeleteRepositoryUserPermission(t *testing.T) {
+	const userName = "external-user"
+
+	testCases := []struct {
+		name          string
+		statusCode    int
+		responseData  interface{}
+		expectDeleted bool
+		expectedErr   string
+	}{
+		{
+			name:          "revoke permission",
+			statusCode:    204,
+			expectDeleted: true,
+		},
+		{
+			name:          "permission does not exist",
+			statusCode:    404,
+			expectDeleted: false,
+		},
+		{
+			name:         "server responds unauthorized",
+			statusCode:   403,
+			responseData: responseUnauthorized,
+			expectedErr:  "Unauthorized",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			req := gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Delete(fmt.Sprintf("/repository/%s/%s/permissions/user/%s", org, repo, userName)).
+				Reply(tc.statusCode)
+			if tc.responseData != nil {
+				req.JSON(tc.responseData)
+			}
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			isDeleted, err := quayClient.DeleteRepositoryUserPermission(org, repo, userName)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.Equal(t, tc.expectDeleted, isDeleted)
+			assert.Assert(t, gock.IsDone())
+		})
+	}
+}
+
+func TestQuayClient_ListCollaborators(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		statusCode            int
+		responseData          interface{}
+		expectedCollaborators []Collaborator
+		expectedErr           string
+	}{
+		{
+			name:         "get collaborators normally",
+			statusCode:   200,
+			responseData: `{"collaborators": [{"name": "outside_user", "kind": "user", "repositories": ["repo1", "repo2"]}]}`,
+			expectedCollaborators: []Collaborator{
+				{Name: "outside_user", Kind: "user", Repositories: []string{"repo1", "repo2"}},
+			},
+		},
+		{
+			name:        "server does not respond 200",
+			statusCode:  403,
+			expectedErr: "failed to get organization collaborators. Status code: 403",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s/collaborators", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			collaborators, err := quayClient.ListCollaborators(org)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.DeepEqual(t, tc.expectedCollaborators, collaborators)
+		})
+	}
+}
+
+func TestQuayClient_GetOrganizationQuota(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statusCode    int
+		responseData  interface{}
+		expectedQuota *OrganizationQuota
+		expectedErr   string
+	}{
+		{
+			name:          "get quota normally",
+			statusCode:    200,
+			responseData:  `{"privateAllowed": true, "privateCount": 12, "reposAllowed": 20}`,
+			expectedQuota: &OrganizationQuota{PrivateAllowed: true, PrivateCount: 12, ReposAllowed: 20},
+		},
+		{
+			name:        "server does not respond 200",
+			statusCode:  403,
+			expectedErr: "failed to get organization quota. Status code: 403",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s/private", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			quota, err := quayClient.GetOrganizationQuota(org)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.DeepEqual(t, tc.expectedQuota, quota)
+		})
+	}
+}
+
+func TestQuayClient_GetOrganizationPlan(t *testing.T) {
+	testCases := []struct {
+		name         string
+		statusCode   int
+		responseData interface{}
+		expectedPlan *OrganizationPlan
+		expectedErr  string
+	}{
+		{
+			name:         "get subscribed plan",
+			statusCode:   200,
+			responseData: `{"hasSubscription": true, "isExistingCustomer": true, "plan": "bus-medium-2018", "usedPrivateRepos": 42}`,
+			expectedPlan: &OrganizationPlan{HasSubscription: true, Plan: "bus-medium-2018", UsedPrivateRepos: 42},
+		},
+		{
+			name:         "get free plan",
+			statusCode:   200,
+			responseData: `{"hasSubscription": false, "isExistingCustomer": false, "plan": "free", "usedPrivateRepos": 0}`,
+			expectedPlan: &OrganizationPlan{Plan: "free"},
+		},
+		{
+			name:        "server does not respond 200",
+			statusCode:  404,
+			expectedErr: "failed to get organization plan. Status code: 404",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer gock.Off()
+
+			gock.New(testQuayApiUrl).
+				MatchHeader("Authorization", "Bearer authtoken").
+				Get(fmt.Sprintf("/organization/%s/plan", org)).
+				Reply(tc.statusCode).
+				JSON(tc.responseData)
+
+			client := &http.Client{Transport: &http.Transport{}}
+			gock.InterceptClient(client)
+
+			quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+			plan, err := quayClient.GetOrganizationPlan(org)
+
+			if tc.expectedErr == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+			assert.DeepEqual(t, tc.expectedPlan, plan)
+		})
+	}
+}
+
+func TestQuayClient_ManifestLabels(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	digest := "sha256:4c1b"
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s/manifest/%s/labels", org, repo, digest)).
+		Reply(200).JSON(map[string]interface{}{
+		"labels": []map[string]string{
+			{"id": "1", "key": "vcs-ref", "value": "abc123", "source_type": "api", "media_type": "text/plain"},
+		},
+	})
+	labels, err := quayClient.ListManifestLabels(org, repo, digest)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, labels, []ManifestLabel{{ID: "1", Key: "vcs-ref", Value: "abc123", SourceType: "api", MediaType: "text/plain"}})
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchType("json").
+		JSON(map[string]string{"key": "pipeline-run", "value": "build-1", "media_type": "text/plain"}).
+		Post(fmt.Sprintf("repository/%s/%s/manifest/%s/labels", org, repo, digest)).
+		Reply(201).JSON(map[string]interface{}{"label": map[string]string{"key": "pipeline-run"}})
+	assert.NilError(t, quayClient.AddManifestLabel(org, repo, digest, "pipeline-run", "build-1"))
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s/manifest/%s/labels", org, repo, digest)).
+		Reply(404).JSON(map[string]string{"error_message": "Not Found"})
+	_, err = quayClient.ListManifestLabels(org, repo, digest)
+	assert.ErrorContains(t, err, "Status code: 404")
+
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestQuayClient_GetRepository(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(200).JSON(map[string]interface{}{"namespace": org, "name": repo, "is_public": false, "state": "READ_ONLY"})
+	repository, err := quayClient.GetRepository(org, repo)
+	assert.NilError(t, err)
+	assert.Equal(t, repository.Name, repo)
+	assert.Equal(t, repository.State, RepositoryStateReadOnly)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(404).JSON(map[string]string{"error_message": "Not Found"})
+	_, err = quayClient.GetRepository(org, repo)
+	assert.ErrorContains(t, err, "does not exist")
+
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestQuayClient_RequestObserver(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(204)
+	gock.New(testQuayApiUrl).
+		Get(fmt.Sprintf("repository/%s/%s/notification/", org, repo)).
+		Reply(200).JSON(map[string][]Notification{"notifications": {}})
+
+	operations := []string{}
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	quayClient.SetRequestObserver(func(operation string) {
+		operations = append(operations, operation)
+	})
+
+	_, err := quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	_, err = quayClient.GetNotifications(org, repo)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, operations, []string{"DeleteRepository", "GetNotifications"})
+}
@@ -0,0 +1,583 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quaytest provides a stateful fake of the Quay API for tests and local development.
+// Unlike quay.TestQuayClient, the real quay.QuayClient talks to it over HTTP,
+// so pagination, error codes and rate limiting are exercised the same way as with Quay.
+package quaytest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+// APIPath is the prefix of all API endpoints, the same as in Quay.
+const APIPath = "/api/v1"
+
+// DefaultPageSize is the number of items in one page of paginated results.
+const DefaultPageSize = 100
+
+type fakeRepository struct {
+	quay.Repository
+	permissions   map[string]string
+	tags          map[string]quay.Tag
+	notifications []quay.Notification
+}
+
+type fault struct {
+	method     string
+	pathPrefix string
+	statusCode int
+	remaining  int
+}
+
+// FakeQuay keeps Quay organizations, repositories and robot accounts in memory and serves them over the Quay API.
+// Only the endpoints used by the image controller are implemented.
+// FakeQuay is safe for concurrent use.
+type FakeQuay struct {
+	lock sync.Mutex
+
+	// Token, if set, is the only bearer token accepted, other requests are rejected with 401.
+	Token string
+	// PageSize is the number of items in one page of paginated results, DefaultPageSize if not set.
+	PageSize int
+
+	repositories map[string]*fakeRepository
+	robots       map[string]*quay.RobotAccount
+	faults       []*fault
+
+	rateLimit       int
+	rateLimitWindow time.Duration
+	windowStart     time.Time
+	windowRequests  int
+
+	requestCount int
+}
+
+func NewFakeQuay() *FakeQuay {
+	return &FakeQuay{
+		repositories: map[string]*fakeRepository{},
+		robots:       map[string]*quay.RobotAccount{},
+	}
+}
+
+// Server is FakeQuay served by a local test HTTP server.
+type Server struct {
+	*FakeQuay
+	httpServer *httptest.Server
+}
+
+// NewServer starts FakeQuay on a local port. The caller must Close it.
+func NewServer() *Server {
+	fakeQuay := NewFakeQuay()
+	return &Server{FakeQuay: fakeQuay, httpServer: httptest.NewServer(fakeQuay)}
+}
+
+// URL returns the API URL to be passed to quay.NewQuayClient.
+func (s *Server) URL() string {
+	return s.httpServer.URL + APIPath
+}
+
+// Client returns HTTP client for the server.
+func (s *Server) Client() *http.Client {
+	return s.httpServer.Client()
+}
+
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// FailRequests makes the next count requests with the method and path prefix fail with the status code.
+// The path is relative to the API URL, e.g. /organization/my-org/robots. Empty method matches all methods.
+func (f *FakeQuay) FailRequests(method, pathPrefix string, statusCode, count int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.faults = append(f.faults, &fault{method: method, pathPrefix: pathPrefix, statusCode: statusCode, remaining: count})
+}
+
+// SetRateLimit limits number of requests in each window, requests over the limit are rejected with 429.
+// Rate limit headers are sent with every response, zero limit disables rate limiting.
+func (f *FakeQuay) SetRateLimit(limit int, window time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.rateLimit = limit
+	f.rateLimitWindow = window
+	f.windowStart = time.Now()
+	f.windowRequests = 0
+}
+
+// RequestCount returns number of served requests, including rejected ones.
+func (f *FakeQuay) RequestCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requestCount
+}
+
+// AddRepository creates repository directly, e.g. to prepare state for a test.
+func (f *FakeQuay) AddRepository(namespace, name string, isPublic bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.addRepository(namespace, name, isPublic, "", "")
+}
+
+// AddTag adds tag to existing repository.
+func (f *FakeQuay) AddTag(namespace, name string, tag quay.Tag) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	repository, exists := f.repositories[namespace+"/"+name]
+	if exists {
+		repository.tags[tag.Name] = tag
+	}
+	return exists
+}
+
+// GetRepository returns copy of the repository state.
+func (f *FakeQuay) GetRepository(namespace, name string) (quay.Repository, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	repository, exists := f.repositories[namespace+"/"+name]
+	if !exists {
+		return quay.Repository{}, false
+	}
+	return repository.Repository, true
+}
+
+// GetRepositoryPermissions returns roles of users and robot accounts in the repository.
+func (f *FakeQuay) GetRepositoryPermissions(namespace, name string) map[string]string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	permissions := map[string]string{}
+	if repository, exists := f.repositories[namespace+"/"+name]; exists {
+		for userName, role := range repository.permissions {
+			permissions[userName] = role
+		}
+	}
+	return permissions
+}
+
+// GetRobotAccount returns copy of the robot account given by its full name, e.g. my-org+robot.
+func (f *FakeQuay) GetRobotAccount(fullName string) (quay.RobotAccount, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	robot, exists := f.robots[fullName]
+	if !exists {
+		return quay.RobotAccount{}, false
+	}
+	return *robot, true
+}
+
+func (f *FakeQuay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requestCount++
+
+	if f.Token != "" && r.Header.Get("Authorization") != "Bearer "+f.Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if !f.allowRequest(w) {
+		writeError(w, http.StatusTooManyRequests, "Too Many Requests")
+		return
+	}
+
+	path, found := strings.CutPrefix(r.URL.Path, APIPath)
+	if !found {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if statusCode := f.injectedFault(r.Method, path); statusCode != 0 {
+		writeError(w, statusCode, http.StatusText(statusCode))
+		return
+	}
+
+	switch {
+	case path == "/repository":
+		f.serveRepositories(w, r)
+	case strings.HasPrefix(path, "/repository/"):
+		f.serveRepository(w, r, strings.TrimPrefix(path, "/repository/"))
+	case strings.HasPrefix(path, "/organization/"):
+		f.serveOrganization(w, r, strings.Split(strings.TrimPrefix(path, "/organization/"), "/"))
+	default:
+		writeError(w, http.StatusNotImplemented, "endpoint is not implemented by fake Quay")
+	}
+}
+
+// allowRequest accounts the request in the rate limit window and sets the rate limit headers.
+func (f *FakeQuay) allowRequest(w http.ResponseWriter) bool {
+	if f.rateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	if now.Sub(f.windowStart) >= f.rateLimitWindow {
+		f.windowStart = now
+		f.windowRequests = 0
+	}
+	isAllowed := f.windowRequests < f.rateLimit
+	if isAllowed {
+		f.windowRequests++
+	}
+	w.Header().Set(quay.RateLimitLimitHeader, strconv.Itoa(f.rateLimit))
+	w.Header().Set(quay.RateLimitRemainingHeader, strconv.Itoa(f.rateLimit-f.windowRequests))
+	w.Header().Set(quay.RateLimitResetHeader, strconv.FormatInt(f.windowStart.Add(f.rateLimitWindow).Unix(), 10))
+	return isAllowed
+}
+
+func (f *FakeQuay) injectedFault(method, path string) int {
+	for i, fault := range f.faults {
+		if (fault.method == "" || fault.method == method) && strings.HasPrefix(path, fault.pathPrefix) {
+			fault.remaining--
+			if fault.remaining <= 0 {
+				f.faults = append(f.faults[:i], f.faults[i+1:]...)
+			}
+			return fault.statusCode
+		}
+	}
+	return 0
+}
+
+func (f *FakeQuay) pageSize() int {
+	if f.PageSize > 0 {
+		return f.PageSize
+	}
+	return DefaultPageSize
+}
+
+func (f *FakeQuay) addRepository(namespace, name string, isPublic bool, description, kind string) *fakeRepository {
+	if kind == "" {
+		kind = "image"
+	}
+	repository := &fakeRepository{
+		Repository: quay.Repository{
+			Namespace:   namespace,
+			Name:        name,
+			IsPublic:    isPublic,
+			Description: description,
+			Kind:        kind,
+			State:       "NORMAL",
+		},
+		permissions: map[string]string{},
+		tags:        map[string]quay.Tag{},
+	}
+	f.repositories[namespace+"/"+name] = repository
+	return repository
+}
+
+// serveRepositories creates repositories and lists them page by page.
+func (f *FakeQuay) serveRepositories(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		request := quay.RepositoryRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Namespace == "" || request.Repository == "" {
+			writeError(w, http.StatusBadRequest, "Invalid repository request")
+			return
+		}
+		if _, exists := f.repositories[request.Namespace+"/"+request.Repository]; exists {
+			writeError(w, http.StatusBadRequest, "Repository already exists")
+			return
+		}
+		repository := f.addRepository(request.Namespace, request.Repository, request.Visibility == "public", request.Description, request.Kind)
+		writeJSON(w, http.StatusCreated, map[string]string{"namespace": repository.Namespace, "name": repository.Name, "kind": repository.Kind})
+	case http.MethodGet:
+		namespace := r.URL.Query().Get("namespace")
+		names := []string{}
+		for key, repository := range f.repositories {
+			if repository.Namespace == namespace {
+				names = append(names, key)
+			}
+		}
+		sort.Strings(names)
+		start, end, nextPage := paginate(len(names), r.URL.Query().Get("next_page"), f.pageSize())
+		repositories := []quay.Repository{}
+		for _, key := range names[start:end] {
+			repositories = append(repositories, f.repositories[key].Repository)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"repositories": repositories, "next_page": nextPage})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// serveRepository serves endpoints of single repository.
+// Repository name may contain slashes, so the known sub-resources are matched from the end of the path.
+func (f *FakeQuay) serveRepository(w http.ResponseWriter, r *http.Request, path string) {
+	repositoryKey, subresource := path, ""
+	for _, marker := range []string{"/changevisibility", "/changestate", "/permissions/user/", "/notification/", "/tag/"} {
+		if index := strings.LastIndex(path, marker); index > 0 {
+			repositoryKey, subresource = path[:index], path[index:]
+			break
+		}
+	}
+	repository, exists := f.repositories[repositoryKey]
+	if !exists {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch {
+	case subresource == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, repository.Repository)
+	case subresource == "" && r.Method == http.MethodPut:
+		request := quay.RepositoryUpdateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid repository update request")
+			return
+		}
+		repository.Description = request.Description
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	case subresource == "" && r.Method == http.MethodDelete:
+		delete(f.repositories, repositoryKey)
+		w.WriteHeader(http.StatusNoContent)
+	case subresource == "/changevisibility" && r.Method == http.MethodPost:
+		var request struct {
+			Visibility string `json:"visibility"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || (request.Visibility != "public" && request.Visibility != "private") {
+			writeError(w, http.StatusBadRequest, "Invalid visibility")
+			return
+		}
+		repository.IsPublic = request.Visibility == "public"
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	case subresource == "/changestate" && r.Method == http.MethodPut:
+		var request struct {
+			State string `json:"state"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.State == "" {
+			writeError(w, http.StatusBadRequest, "Invalid state")
+			return
+		}
+		repository.State = request.State
+		writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+	case strings.HasPrefix(subresource, "/permissions/user/"):
+		f.serveRepositoryPermission(w, r, repository, strings.TrimPrefix(subresource, "/permissions/user/"))
+	case strings.HasPrefix(subresource, "/notification/"):
+		serveRepositoryNotifications(w, r, repository, strings.TrimPrefix(subresource, "/notification/"))
+	case strings.HasPrefix(subresource, "/tag/"):
+		f.serveRepositoryTags(w, r, repository, strings.TrimPrefix(subresource, "/tag/"))
+	default:
+		writeError(w, http.StatusNotImplemented, "endpoint is not implemented by fake Quay")
+	}
+}
+
+func (f *FakeQuay) serveRepositoryPermission(w http.ResponseWriter, r *http.Request, repository *fakeRepository, userName string) {
+	switch r.Method {
+	case http.MethodPut:
+		var request struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || (request.Role != "read" && request.Role != "write" && request.Role != "admin") {
+			writeError(w, http.StatusBadRequest, "Invalid role")
+			return
+		}
+		if _, exists := f.robots[userName]; strings.Contains(userName, "+") && !exists {
+			writeError(w, http.StatusBadRequest, "Invalid username: "+userName)
+			return
+		}
+		repository.permissions[userName] = request.Role
+		writeJSON(w, http.StatusOK, map[string]string{"role": request.Role, "name": userName})
+	case http.MethodDelete:
+		if _, exists := repository.permissions[userName]; !exists {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		delete(repository.permissions, userName)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func serveRepositoryNotifications(w http.ResponseWriter, r *http.Request, repository *fakeRepository, notificationUUID string) {
+	switch {
+	case notificationUUID == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"notifications": repository.notifications, "page": 1, "has_additional": false})
+	case notificationUUID == "" && r.Method == http.MethodPost:
+		notification := quay.Notification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid notification")
+			return
+		}
+		notification.UUID = randomHex(16)
+		repository.notifications = append(repository.notifications, notification)
+		writeJSON(w, http.StatusCreated, notification)
+	case notificationUUID != "" && r.Method == http.MethodDelete:
+		for i, notification := range repository.notifications {
+			if notification.UUID == notificationUUID {
+				repository.notifications = append(repository.notifications[:i], repository.notifications[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Not Found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (f *FakeQuay) serveRepositoryTags(w http.ResponseWriter, r *http.Request, repository *fakeRepository, tagName string) {
+	switch {
+	case tagName == "" && r.Method == http.MethodGet:
+		names := []string{}
+		for name := range repository.tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+		start := min((page-1)*f.pageSize(), len(names))
+		end := min(start+f.pageSize(), len(names))
+		tags := []quay.Tag{}
+		for _, name := range names[start:end] {
+			tags = append(tags, repository.tags[name])
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tags, "page": page, "has_additional": end < len(names)})
+	case tagName != "" && r.Method == http.MethodDelete:
+		if _, exists := repository.tags[tagName]; !exists {
+			writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		delete(repository.tags, tagName)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// serveOrganization serves robot accounts endpoints of the organization.
+func (f *FakeQuay) serveOrganization(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) < 2 || segments[1] != "robots" {
+		writeError(w, http.StatusNotImplemented, "endpoint is not implemented by fake Quay")
+		return
+	}
+	organization := segments[0]
+
+	if len(segments) == 2 && r.Method == http.MethodGet {
+		robots := []quay.RobotAccount{}
+		for fullName, robot := range f.robots {
+			if strings.HasPrefix(fullName, organization+"+") {
+				robots = append(robots, *robot)
+			}
+		}
+		sort.Slice(robots, func(i, j int) bool { return robots[i].Name < robots[j].Name })
+		writeJSON(w, http.StatusOK, map[string]interface{}{"robots": robots})
+		return
+	}
+	if len(segments) < 3 || segments[2] == "" {
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	fullName := organization + "+" + segments[2]
+	robot, exists := f.robots[fullName]
+	switch {
+	case len(segments) == 3 && r.Method == http.MethodPut:
+		if exists {
+			writeJSON(w, http.StatusBadRequest, quay.QuayError{Message: "Existing robot with name: " + fullName})
+			return
+		}
+		request := quay.RobotAccountRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		metadata := map[string]interface{}{}
+		for key, value := range request.UnstructuredMetadata {
+			metadata[key] = value
+		}
+		robot = &quay.RobotAccount{
+			Name:                 fullName,
+			Description:          request.Description,
+			UnstructuredMetadata: metadata,
+			Created:              time.Now().UTC().Format(time.RFC1123Z),
+			Token:                newRobotToken(),
+		}
+		f.robots[fullName] = robot
+		writeJSON(w, http.StatusCreated, robot)
+	case !exists:
+		writeJSON(w, http.StatusNotFound, quay.QuayError{Message: "Could not find robot with specified username"})
+	case len(segments) == 3 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, robot)
+	case len(segments) == 3 && r.Method == http.MethodDelete:
+		delete(f.robots, fullName)
+		for _, repository := range f.repositories {
+			delete(repository.permissions, fullName)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(segments) == 4 && segments[3] == "regenerate" && r.Method == http.MethodPost:
+		robot.Token = newRobotToken()
+		writeJSON(w, http.StatusOK, robot)
+	case len(segments) == 4 && segments[3] == "permissions" && r.Method == http.MethodGet:
+		permissions := []quay.RobotAccountPermission{}
+		for _, repository := range f.repositories {
+			if role, exists := repository.permissions[fullName]; exists && repository.Namespace == organization {
+				permissions = append(permissions, quay.RobotAccountPermission{
+					Repository: quay.RobotAccountPermissionRepository{Name: repository.Name, IsPublic: repository.IsPublic},
+					Role:       role,
+				})
+			}
+		}
+		sort.Slice(permissions, func(i, j int) bool { return permissions[i].Repository.Name < permissions[j].Repository.Name })
+		writeJSON(w, http.StatusOK, map[string]interface{}{"permissions": permissions})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// paginate returns bounds of the page given by the opaque next page token and the token of the following page.
+func paginate(total int, nextPage string, pageSize int) (int, int, string) {
+	start := 0
+	if nextPage != "" {
+		if offset, err := strconv.Atoi(nextPage); err == nil && offset > 0 {
+			start = min(offset, total)
+		}
+	}
+	end := min(start+pageSize, total)
+	if end < total {
+		return start, end, strconv.Itoa(end)
+	}
+	return start, end, ""
+}
+
+func newRobotToken() string {
+	return strings.ToUpper(randomHex(32))
+}
+
+func randomHex(length int) string {
+	b := make([]byte, length/2)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate random data: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, quay.QuayError{ErrorMessage: message})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quaytest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/konflux-ci/image-controller/pkg/quay"
+)
+
+const org = "test-org"
+
+func newTestClient(t *testing.T) (*Server, *quay.QuayClient) {
+	server := NewServer()
+	t.Cleanup(server.Close)
+	server.Token = "authtoken"
+	return server, quay.NewQuayClient(server.Client(), "authtoken", server.URL())
+}
+
+func TestFakeQuayRepositoryLifecycle(t *testing.T) {
+	server, quayClient := newTestClient(t)
+
+	repository, err := quayClient.CreateRepository(quay.RepositoryRequest{Namespace: org, Repository: "test-ns/my-image", Visibility: "public"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repository.Name != "test-ns/my-image" {
+		t.Errorf("unexpected created repository: %v", repository)
+	}
+	// Quay reports existing repository as an error, that the client turns into success
+	if _, err := quayClient.CreateRepository(quay.RepositoryRequest{Namespace: org, Repository: "test-ns/my-image", Visibility: "public"}); err != nil {
+		t.Errorf("unexpected error on existing repository: %v", err)
+	}
+
+	robotAccount, err := quayClient.CreateRobotAccount(org, "my_robot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if robotAccount.Name != org+"+my_robot" || robotAccount.Token == "" {
+		t.Errorf("unexpected robot account: %v", robotAccount)
+	}
+	existingRobotAccount, err := quayClient.CreateRobotAccount(org, "my_robot")
+	if err != nil || existingRobotAccount.Token != robotAccount.Token {
+		t.Errorf("expected existing robot account to be returned, got %v, %v", existingRobotAccount, err)
+	}
+	if err := quayClient.AddPermissionsForRepositoryToRobotAccount(org, "test-ns/my-image", "my_robot", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if role := server.GetRepositoryPermissions(org, "test-ns/my-image")[org+"+my_robot"]; role != "write" {
+		t.Errorf("expected write permission of the robot account, got %q", role)
+	}
+
+	if err := quayClient.ChangeRepositoryVisibility(org, "test-ns/my-image", "private"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	isPublic, err := quayClient.IsRepositoryPublic(org, "test-ns/my-image")
+	if err != nil || isPublic {
+		t.Errorf("expected private repository, got %v, %v", isPublic, err)
+	}
+
+	isDeleted, err := quayClient.DeleteRepository(org, "test-ns/my-image")
+	if err != nil || !isDeleted {
+		t.Errorf("expected repository to be deleted, got %v, %v", isDeleted, err)
+	}
+	isDeleted, err = quayClient.DeleteRepository(org, "test-ns/my-image")
+	if err != nil || isDeleted {
+		t.Errorf("expected missing repository not to be deleted, got %v, %v", isDeleted, err)
+	}
+	if _, exists := server.GetRepository(org, "test-ns/my-image"); exists {
+		t.Errorf("expected repository to be removed from the fake")
+	}
+}
+
+func TestFakeQuayPagination(t *testing.T) {
+	server, quayClient := newTestClient(t)
+	server.PageSize = 2
+	for i := 0; i < 5; i++ {
+		server.AddRepository(org, fmt.Sprintf("repo-%d", i), true)
+	}
+	server.AddRepository("other-org", "repo", true)
+
+	fetchedPages := 0
+	quayClient.OnPageFetched = func(operation string) {
+		fetchedPages++
+	}
+	repositories, err := quayClient.GetAllRepositories(org)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repositories) != 5 || fetchedPages != 3 {
+		t.Errorf("expected 5 repositories in 3 pages, got %d repositories in %d pages", len(repositories), fetchedPages)
+	}
+
+	err = quayClient.ListRepositories(context.Background(), org, quay.ListRepositoriesOptions{MaxPages: 2}, func([]quay.Repository) bool { return true })
+	if err == nil {
+		t.Errorf("expected page limit to be reached")
+	}
+}
+
+func TestFakeQuayFailRequests(t *testing.T) {
+	server, quayClient := newTestClient(t)
+	server.FailRequests(http.MethodPut, "/organization/"+org+"/robots/", http.StatusInternalServerError, 1)
+
+	if _, err := quayClient.CreateRobotAccount(org, "my_robot"); err == nil {
+		t.Errorf("expected injected error")
+	}
+	if _, err := quayClient.CreateRobotAccount(org, "my_robot"); err != nil {
+		t.Errorf("expected only the first request to fail, got: %v", err)
+	}
+
+	unauthorizedClient := quay.NewQuayClient(server.Client(), "wrong", server.URL())
+	if _, err := unauthorizedClient.GetRobotAccount(org, "my_robot"); err == nil {
+		t.Errorf("expected request with wrong token to be rejected")
+	}
+}
+
+func TestFakeQuayRateLimit(t *testing.T) {
+	server, quayClient := newTestClient(t)
+	server.SetRateLimit(2, time.Hour)
+
+	rateLimits := []quay.RateLimit{}
+	quayClient.OnRateLimit = func(rateLimit quay.RateLimit) {
+		rateLimits = append(rateLimits, rateLimit)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := quayClient.CreateRobotAccount(org, fmt.Sprintf("robot_%d", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := quayClient.CreateRobotAccount(org, "robot_2"); err == nil {
+		t.Errorf("expected request over the limit to be rejected")
+	}
+
+	if len(rateLimits) != 3 || rateLimits[0].Limit != 2 || rateLimits[0].Remaining != 1 || rateLimits[2].Remaining != 0 {
+		t.Errorf("unexpected reported rate limits: %v", rateLimits)
+	}
+	if _, exists := server.GetRobotAccount(org + "+robot_2"); exists {
+		t.Errorf("expected rejected request not to change state")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit headers sent by Quay, if rate limiting is enabled on the Quay instance.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+
+	// RetryAfterHeader is sent along with 429 Too Many Requests response.
+	RetryAfterHeader = "Retry-After"
+)
+
+// RateLimit is the request budget reported by Quay in an API response.
+type RateLimit struct {
+	// Limit is the number of requests allowed in the current window.
+	Limit int
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// Reset is when the current window ends and the budget is renewed, zero if not reported.
+	Reset time.Time
+}
+
+// ParseRateLimit reads the rate limit headers of a Quay response.
+// Returns false if the response doesn't carry valid rate limit information.
+func ParseRateLimit(header http.Header) (RateLimit, bool) {
+	limit, err := strconv.Atoi(header.Get(RateLimitLimitHeader))
+	if err != nil || limit < 0 {
+		return RateLimit{}, false
+	}
+	remaining, err := strconv.Atoi(header.Get(RateLimitRemainingHeader))
+	if err != nil || remaining < 0 {
+		return RateLimit{}, false
+	}
+	rateLimit := RateLimit{Limit: limit, Remaining: remaining}
+	if reset, err := strconv.ParseInt(header.Get(RateLimitResetHeader), 10, 64); err == nil && reset > 0 {
+		rateLimit.Reset = time.Unix(reset, 0)
+	}
+	return rateLimit, true
+}
+
+// RateLimitedError is returned when Quay rejects a request with 429 Too Many Requests.
+type RateLimitedError struct {
+	Operation string
+	// RetryAfter is the delay requested by Quay before the request is retried, zero if not reported.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s request was rate limited by Quay, retry after %s", e.Operation, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s request was rate limited by Quay", e.Operation)
+}
+
+// GetRetryAfter returns the delay requested by Quay, if the error is caused by rate limiting.
+func GetRetryAfter(err error) (time.Duration, bool) {
+	rateLimitedErr := &RateLimitedError{}
+	if !errors.As(err, &rateLimitedErr) {
+		return 0, false
+	}
+	return rateLimitedErr.RetryAfter, true
+}
+
+// ParseRetryAfter reads the Retry-After header, given either in seconds or as HTTP date.
+// Returns zero if the header is missing, invalid or in the past.
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get(RetryAfterHeader)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if retryTime, err := http.ParseTime(value); err == nil && retryTime.After(now) {
+		return retryTime.Sub(now)
+	}
+	return 0
+}
+
+// RateLimitBudget accounts the Quay request budget across all clients sharing the same token, so a rate limiter
+// could adapt to the actual limits instead of using static ones.
+// Quay clients are created per reconcile, so the budget lives outside of them and is fed by QuayClient.OnRateLimit.
+type RateLimitBudget struct {
+	lock      sync.Mutex
+	rateLimit RateLimit
+	observed  bool
+	spent     int
+}
+
+func NewRateLimitBudget() *RateLimitBudget {
+	return &RateLimitBudget{}
+}
+
+// Observe records rate limit reported by Quay, it replaces the estimate made from spent requests.
+func (b *RateLimitBudget) Observe(rateLimit RateLimit) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.rateLimit = rateLimit
+	b.observed = true
+	b.spent = 0
+}
+
+// Spend accounts a request sent to Quay, whose response didn't report the rate limit.
+func (b *RateLimitBudget) Spend() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.spent++
+}
+
+// Remaining returns estimated number of requests left in the current window and the window limit.
+// Returns false if Quay hasn't reported any rate limit yet.
+func (b *RateLimitBudget) Remaining(now time.Time) (int, int, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if !b.observed {
+		return 0, 0, false
+	}
+	if !b.rateLimit.Reset.IsZero() && now.After(b.rateLimit.Reset) {
+		// The window has ended since the last report
+		return b.rateLimit.Limit, b.rateLimit.Limit, true
+	}
+	remaining := b.rateLimit.Remaining - b.spent
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, b.rateLimit.Limit, true
+}
+
+// Reset returns when the current window ends, zero if not known.
+func (b *RateLimitBudget) Reset() time.Time {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.rateLimit.Reset
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/h2non/gock"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	testCases := []struct {
+		name      string
+		header    map[string]string
+		rateLimit RateLimit
+		ok        bool
+	}{
+		{
+			name:      "all headers",
+			header:    map[string]string{RateLimitLimitHeader: "100", RateLimitRemainingHeader: "42", RateLimitResetHeader: "1700000000"},
+			rateLimit: RateLimit{Limit: 100, Remaining: 42, Reset: time.Unix(1700000000, 0)},
+			ok:        true,
+		},
+		{
+			name:      "without reset",
+			header:    map[string]string{RateLimitLimitHeader: "100", RateLimitRemainingHeader: "0"},
+			rateLimit: RateLimit{Limit: 100, Remaining: 0},
+			ok:        true,
+		},
+		{
+			name:   "no headers",
+			header: map[string]string{},
+		},
+		{
+			name:   "invalid remaining",
+			header: map[string]string{RateLimitLimitHeader: "100", RateLimitRemainingHeader: "many"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tc.header {
+				header.Set(key, value)
+			}
+			rateLimit, ok := ParseRateLimit(header)
+			assert.Equal(t, ok, tc.ok)
+			assert.DeepEqual(t, rateLimit, tc.rateLimit)
+		})
+	}
+}
+
+func TestRateLimitBudget(t *testing.T) {
+	now := time.Now()
+	budget := NewRateLimitBudget()
+	_, _, ok := budget.Remaining(now)
+	assert.Assert(t, !ok)
+
+	budget.Observe(RateLimit{Limit: 10, Remaining: 2, Reset: now.Add(time.Minute)})
+	budget.Spend()
+	remaining, limit, ok := budget.Remaining(now)
+	assert.Assert(t, ok)
+	assert.Equal(t, remaining, 1)
+	assert.Equal(t, limit, 10)
+
+	budget.Spend()
+	budget.Spend()
+	remaining, _, _ = budget.Remaining(now)
+	assert.Equal(t, remaining, 0)
+
+	// The budget is renewed once the window ends
+	remaining, _, _ = budget.Remaining(now.Add(2 * time.Minute))
+	assert.Equal(t, remaining, 10)
+	assert.Equal(t, budget.Reset(), now.Add(time.Minute))
+}
+
+func TestQuayClient_OnRateLimit(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(204).
+		SetHeader(RateLimitLimitHeader, "100").
+		SetHeader(RateLimitRemainingHeader, "99")
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(204)
+
+	reported := []RateLimit{}
+	withoutRateLimit := 0
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	quayClient.OnRateLimit = func(rateLimit RateLimit) {
+		reported = append(reported, rateLimit)
+	}
+	quayClient.OnRequestWithoutRateLimit = func() {
+		withoutRateLimit++
+	}
+
+	_, err := quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	_, err = quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reported, []RateLimit{{Limit: 100, Remaining: 99}})
+	assert.Equal(t, withoutRateLimit, 1)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name       string
+		retryAfter string
+		expected   time.Duration
+	}{
+		{name: "seconds", retryAfter: "30", expected: 30 * time.Second},
+		{name: "http date", retryAfter: now.Add(2 * time.Minute).Format(http.TimeFormat), expected: 2 * time.Minute},
+		{name: "date in the past", retryAfter: now.Add(-time.Minute).Format(http.TimeFormat)},
+		{name: "negative seconds", retryAfter: "-5"},
+		{name: "invalid", retryAfter: "soon"},
+		{name: "missing"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			if tc.retryAfter != "" {
+				header.Set(RetryAfterHeader, tc.retryAfter)
+			}
+			assert.Equal(t, ParseRetryAfter(header, now), tc.expected)
+		})
+	}
+}
+
+func TestQuayClient_RegenerateRobotAccountTokenRateLimited(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		Post(fmt.Sprintf("organization/%s/robots/%s/regenerate", org, robotName)).
+		Reply(429).
+		SetHeader(RetryAfterHeader, "20").
+		BodyString("Too Many Requests")
+
+	quayClient := NewQuayClient(client, "authtoken", testQuayApiUrl)
+	robot, err := quayClient.RegenerateRobotAccountToken(org, robotName)
+	assert.Assert(t, robot == nil)
+	retryAfter, isRateLimited := GetRetryAfter(fmt.Errorf("failed to rotate token: %w", err))
+	assert.Assert(t, isRateLimited)
+	assert.Equal(t, retryAfter, 20*time.Second)
+
+	_, isRateLimited = GetRetryAfter(fmt.Errorf("not found"))
+	assert.Assert(t, !isRateLimited)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// RepositoryScopedQuayClient uses a token scoped to a single repository for day-2 operations
+// (visibility, notifications, tags) and the organization-wide client for everything else.
+// If the repository token is rejected, e.g. revoked, the call is retried with the organization-wide client.
+type RepositoryScopedQuayClient struct {
+	QuayService
+
+	repositoryClient QuayService
+	log              logr.Logger
+}
+
+var _ QuayService = (*RepositoryScopedQuayClient)(nil)
+
+func NewRepositoryScopedQuayClient(organizationClient, repositoryClient QuayService, log logr.Logger) *RepositoryScopedQuayClient {
+	return &RepositoryScopedQuayClient{
+		QuayService:      organizationClient,
+		repositoryClient: repositoryClient,
+		log:              log.WithName("QuayRepositoryScoped"),
+	}
+}
+
+// isUnauthorizedError checks if the error was caused by rejected token.
+func isUnauthorizedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	return strings.Contains(message, "Unauthorized") || strings.Contains(message, "Status code: 401") || strings.Contains(message, "Status code: 403")
+}
+
+func (c *RepositoryScopedQuayClient) fallback(operation string, err error) bool {
+	if !isUnauthorizedError(err) {
+		return false
+	}
+	c.log.Info("repository token rejected, falling back to organization token", "operation", operation, "error", err.Error())
+	return true
+}
+
+func (c *RepositoryScopedQuayClient) IsRepositoryPublic(organization, imageRepository string) (bool, error) {
+	isPublic, err := c.repositoryClient.IsRepositoryPublic(organization, imageRepository)
+	if c.fallback("IsRepositoryPublic", err) {
+		return c.QuayService.IsRepositoryPublic(organization, imageRepository)
+	}
+	return isPublic, err
+}
+
+func (c *RepositoryScopedQuayClient) GetRepository(organization, imageRepository string) (*Repository, error) {
+	repository, err := c.repositoryClient.GetRepository(organization, imageRepository)
+	if c.fallback("GetRepository", err) {
+		return c.QuayService.GetRepository(organization, imageRepository)
+	}
+	return repository, err
+}
+
+func (c *RepositoryScopedQuayClient) ChangeRepositoryVisibility(organization, imageRepository, visibility string) error {
+	err := c.repositoryClient.ChangeRepositoryVisibility(organization, imageRepository, visibility)
+	if c.fallback("ChangeRepositoryVisibility", err) {
+		return c.QuayService.ChangeRepositoryVisibility(organization, imageRepository, visibility)
+	}
+	return err
+}
+
+func (c *RepositoryScopedQuayClient) UpdateRepositoryDescription(organization, imageRepository, description string) error {
+	err := c.repositoryClient.UpdateRepositoryDescription(organization, imageRepository, description)
+	if c.fallback("UpdateRepositoryDescription", err) {
+		return c.QuayService.UpdateRepositoryDescription(organization, imageRepository, description)
+	}
+	return err
+}
+
+func (c *RepositoryScopedQuayClient) GetTagsFromPage(organization, repository string, page int) ([]Tag, bool, error) {
+	tags, hasAdditional, err := c.repositoryClient.GetTagsFromPage(organization, repository, page)
+	if c.fallback("GetTagsFromPage", err) {
+		return c.QuayService.GetTagsFromPage(organization, repository, page)
+	}
+	return tags, hasAdditional, err
+}
+
+func (c *RepositoryScopedQuayClient) DeleteTag(organization, repository, tag string) (bool, error) {
+	isDeleted, err := c.repositoryClient.DeleteTag(organization, repository, tag)
+	if c.fallback("DeleteTag", err) {
+		return c.QuayService.DeleteTag(organization, repository, tag)
+	}
+	return isDeleted, err
+}
+
+func (c *RepositoryScopedQuayClient) DeleteManifestByDigest(organization, repository, manifestDigest string) ([]string, error) {
+	deletedTagNames, err := c.repositoryClient.DeleteManifestByDigest(organization, repository, manifestDigest)
+	if c.fallback("DeleteManifestByDigest", err) {
+		return c.QuayService.DeleteManifestByDigest(organization, repository, manifestDigest)
+	}
+	return deletedTagNames, err
+}
+
+func (c *RepositoryScopedQuayClient) GetNotifications(organization, repository string) ([]Notification, error) {
+	notifications, err := c.repositoryClient.GetNotifications(organization, repository)
+	if c.fallback("GetNotifications", err) {
+		return c.QuayService.GetNotifications(organization, repository)
+	}
+	return notifications, err
+}
+
+func (c *RepositoryScopedQuayClient) CreateNotification(organization, repository string, notification Notification) (*Notification, error) {
+	createdNotification, err := c.repositoryClient.CreateNotification(organization, repository, notification)
+	if c.fallback("CreateNotification", err) {
+		return c.QuayService.CreateNotification(organization, repository, notification)
+	}
+	return createdNotification, err
+}
+
+func (c *RepositoryScopedQuayClient) DeleteNotification(organization, repository, notificationUUID string) (bool, error) {
+	isDeleted, err := c.repositoryClient.DeleteNotification(organization, repository, notificationUUID)
+	if c.fallback("DeleteNotification", err) {
+		return c.QuayService.DeleteNotification(organization, repository, notificationUUID)
+	}
+	return isDeleted, err
+}
+
+func (c *RepositoryScopedQuayClient) SetCorrelationID(correlationID string) {
+	c.QuayService.SetCorrelationID(correlationID)
+	c.repositoryClient.SetCorrelationID(correlationID)
+}
+
+func (c *RepositoryScopedQuayClient) SetRequestObserver(observer func(operation string)) {
+	c.QuayService.SetRequestObserver(observer)
+	c.repositoryClient.SetRequestObserver(observer)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"gotest.tools/v3/assert"
+)
+
+func TestRepositoryScopedQuayClient_UsesRepositoryToken(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer repotoken").
+		Post(fmt.Sprintf("repository/%s/%s/changevisibility", org, repo)).
+		Reply(200)
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer orgtoken").
+		Delete(fmt.Sprintf("repository/%s/%s", org, repo)).
+		Reply(204)
+
+	quayClient := NewRepositoryScopedQuayClient(
+		NewQuayClient(client, "orgtoken", testQuayApiUrl),
+		NewQuayClient(client, "repotoken", testQuayApiUrl),
+		logr.Discard())
+
+	assert.NilError(t, quayClient.ChangeRepositoryVisibility(org, repo, "private"))
+	isDeleted, err := quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestRepositoryScopedQuayClient_FallsBackToOrganizationToken(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer repotoken").
+		Post(fmt.Sprintf("repository/%s/%s/changevisibility", org, repo)).
+		Reply(401).
+		JSON(responseUnauthorized)
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer orgtoken").
+		Post(fmt.Sprintf("repository/%s/%s/changevisibility", org, repo)).
+		Reply(200)
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer repotoken").
+		Get(fmt.Sprintf("repository/%s/%s/notification/", org, repo)).
+		Reply(403)
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer orgtoken").
+		Get(fmt.Sprintf("repository/%s/%s/notification/", org, repo)).
+		Reply(200).
+		JSON(map[string][]Notification{"notifications": {{UUID: "uuid", Title: "title"}}})
+
+	quayClient := NewRepositoryScopedQuayClient(
+		NewQuayClient(client, "orgtoken", testQuayApiUrl),
+		NewQuayClient(client, "repotoken", testQuayApiUrl),
+		logr.Discard())
+
+	assert.NilError(t, quayClient.ChangeRepositoryVisibility(org, repo, "private"))
+	notifications, err := quayClient.GetNotifications(org, repo)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, notifications, []Notification{{UUID: "uuid", Title: "title"}})
+	assert.Assert(t, gock.IsDone())
+}
+
+func TestRepositoryScopedQuayClient_DoesNotFallBackOnOtherErrors(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer repotoken").
+		Post(fmt.Sprintf("repository/%s/%s/changevisibility", org, repo)).
+		Reply(402)
+
+	quayClient := NewRepositoryScopedQuayClient(
+		NewQuayClient(client, "orgtoken", testQuayApiUrl),
+		NewQuayClient(client, "repotoken", testQuayApiUrl),
+		logr.Discard())
+
+	err := quayClient.ChangeRepositoryVisibility(org, repo, "private")
+	assert.Error(t, err, "payment required")
+	assert.Assert(t, gock.IsDone())
+}
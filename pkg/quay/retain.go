@@ -0,0 +1,52 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"github.com/go-logr/logr"
+
+	l "github.com/konflux-ci/image-controller/pkg/logs"
+)
+
+// RetainRepositoriesQuayClient wraps a QuayService and skips all image repository deletions,
+// e.g. while the Quay organization is being migrated. Everything else is passed to the wrapped client.
+// Skipped deletions are logged and reported via OnRetain.
+type RetainRepositoriesQuayClient struct {
+	QuayService
+
+	log logr.Logger
+	// OnRetain is invoked for each skipped image repository deletion, e.g. for metrics.
+	OnRetain func()
+}
+
+var _ QuayService = (*RetainRepositoriesQuayClient)(nil)
+
+func NewRetainRepositoriesQuayClient(quayClient QuayService, log logr.Logger, onRetain func()) *RetainRepositoriesQuayClient {
+	return &RetainRepositoriesQuayClient{
+		QuayService: quayClient,
+		log:         log.WithName("QuayRetainRepositories"),
+		OnRetain:    onRetain,
+	}
+}
+
+// DeleteRepository keeps the image repository and reports that nothing was deleted.
+func (c *RetainRepositoriesQuayClient) DeleteRepository(organization, imageRepository string) (bool, error) {
+	c.log.Info("image repository deletion is paused, keeping the image repository", "Organization", organization, "Repository", imageRepository, l.Action, l.ActionDelete, l.Audit, "true")
+	if c.OnRetain != nil {
+		c.OnRetain()
+	}
+	return false, nil
+}
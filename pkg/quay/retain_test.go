@@ -0,0 +1,52 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"gotest.tools/v3/assert"
+)
+
+func TestRetainRepositoriesQuayClient(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	// Only robot account deletion is expected to reach the server
+	gock.New(testQuayApiUrl).
+		Delete(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		Reply(204)
+
+	retained := 0
+	quayClient := NewRetainRepositoriesQuayClient(NewQuayClient(client, "authtoken", testQuayApiUrl), logr.Discard(), func() {
+		retained++
+	})
+
+	isDeleted, err := quayClient.DeleteRepository(org, repo)
+	assert.NilError(t, err)
+	assert.Assert(t, !isDeleted)
+	assert.Equal(t, retained, 1)
+
+	isDeleted, err = quayClient.DeleteRobotAccount(org, robotName)
+	assert.NilError(t, err)
+	assert.Assert(t, isDeleted)
+	assert.Assert(t, gock.IsDone())
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package quay
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
 )
 
@@ -32,30 +34,91 @@ var _ QuayService = (*TestQuayClient)(nil)
 var (
 	CreateRepositoryFunc                          func(repository RepositoryRequest) (*Repository, error)
 	DeleteRepositoryFunc                          func(organization, imageRepository string) (bool, error)
+	IsRepositoryPublicFunc                        func(organization, imageRepository string) (bool, error)
+	GetRepositoryFunc                             func(organization, imageRepository string) (*Repository, error)
 	ChangeRepositoryVisibilityFunc                func(organization, imageRepository string, visibility string) error
+	UpdateRepositoryDescriptionFunc               func(organization, imageRepository, description string) error
+	ChangeRepositoryStateFunc                     func(organization, imageRepository, state string) error
+	GetRepositoryMirrorFunc                       func(organization, imageRepository string) (*RepositoryMirror, error)
+	CreateRepositoryMirrorFunc                    func(organization, imageRepository string, mirror RepositoryMirror) error
+	SyncRepositoryMirrorFunc                      func(organization, imageRepository string) error
+	ListManifestLabelsFunc                        func(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error)
+	AddManifestLabelFunc                          func(organization, imageRepository, manifestDigest, key, value string) error
 	GetRobotAccountFunc                           func(organization string, robotName string) (*RobotAccount, error)
 	CreateRobotAccountFunc                        func(organization string, robotName string) (*RobotAccount, error)
+	CreateRobotAccountWithDescriptionFunc         func(organization string, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error)
+	UpdateRobotAccountDescriptionFunc             func(organization string, robotName string, robotAccountRequest RobotAccountRequest) error
 	DeleteRobotAccountFunc                        func(organization string, robotName string) (bool, error)
 	AddPermissionsForRepositoryToRobotAccountFunc func(organization, imageRepository, robotAccountName string, isWrite bool) error
+	SetRepositoryUserPermissionFunc               func(organization, imageRepository, userName, role string) error
+	GetRepositoryUserPermissionFunc               func(organization, imageRepository, userName string) (string, error)
+	DeleteRepositoryUserPermissionFunc            func(organization, imageRepository, userName string) (bool, error)
 	RegenerateRobotAccountTokenFunc               func(organization string, robotName string) (*RobotAccount, error)
+	GetAllRepositoriesFunc                        func(organization string) ([]Repository, error)
+	ListRepositoriesFunc                          func(ctx context.Context, organization string, opts ListRepositoriesOptions, visit RepositoriesPageVisitor) error
+	GetRepositoriesMetadataFunc                   func(ctx context.Context, organization string) ([]RepositoryMetadata, error)
+	GetAllRobotAccountsFunc                       func(organization string) ([]RobotAccount, error)
+	GetRobotAccountPermissionsFunc                func(organization, robotName string) ([]RobotAccountPermission, error)
+	GetTagsFromPageFunc                           func(organization, repository string, page int) ([]Tag, bool, error)
+	DeleteManifestByDigestFunc                    func(organization, repository, manifestDigest string) ([]string, error)
 	GetNotificationsFunc                          func(organization, repository string) ([]Notification, error)
 	CreateNotificationFunc                        func(organization, repository string, notification Notification) (*Notification, error)
+	DeleteNotificationFunc                        func(organization, repository, notificationUUID string) (bool, error)
 )
 
+// newTestRobotAccount returns robot account in the shape returned by Quay, so credentials of it pass validation.
+func newTestRobotAccount(organization, robotName string) *RobotAccount {
+	return &RobotAccount{Name: organization + "+" + robotName, Token: "test-token"}
+}
+
 func ResetTestQuayClient() {
 	CreateRepositoryFunc = func(repository RepositoryRequest) (*Repository, error) { return &Repository{}, nil }
 	DeleteRepositoryFunc = func(organization, imageRepository string) (bool, error) { return true, nil }
+	IsRepositoryPublicFunc = func(organization, imageRepository string) (bool, error) { return false, nil }
+	GetRepositoryFunc = func(organization, imageRepository string) (*Repository, error) {
+		return &Repository{Namespace: organization, Name: imageRepository, State: RepositoryStateNormal}, nil
+	}
 	ChangeRepositoryVisibilityFunc = func(organization, imageRepository string, visibility string) error { return nil }
-	GetRobotAccountFunc = func(organization, robotName string) (*RobotAccount, error) { return &RobotAccount{}, nil }
-	CreateRobotAccountFunc = func(organization, robotName string) (*RobotAccount, error) { return &RobotAccount{}, nil }
+	UpdateRepositoryDescriptionFunc = func(organization, imageRepository, description string) error { return nil }
+	ChangeRepositoryStateFunc = func(organization, imageRepository, state string) error { return nil }
+	GetRepositoryMirrorFunc = func(organization, imageRepository string) (*RepositoryMirror, error) { return nil, nil }
+	CreateRepositoryMirrorFunc = func(organization, imageRepository string, mirror RepositoryMirror) error { return nil }
+	SyncRepositoryMirrorFunc = func(organization, imageRepository string) error { return nil }
+	ListManifestLabelsFunc = func(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error) { return nil, nil }
+	AddManifestLabelFunc = func(organization, imageRepository, manifestDigest, key, value string) error { return nil }
+	GetRobotAccountFunc = func(organization, robotName string) (*RobotAccount, error) {
+		return newTestRobotAccount(organization, robotName), nil
+	}
+	CreateRobotAccountFunc = func(organization, robotName string) (*RobotAccount, error) {
+		return newTestRobotAccount(organization, robotName), nil
+	}
+	// Tests overriding CreateRobotAccountFunc see also robot accounts created with description
+	CreateRobotAccountWithDescriptionFunc = func(organization, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error) {
+		return CreateRobotAccountFunc(organization, robotName)
+	}
+	UpdateRobotAccountDescriptionFunc = func(organization, robotName string, robotAccountRequest RobotAccountRequest) error { return nil }
 	DeleteRobotAccountFunc = func(organization, robotName string) (bool, error) { return true, nil }
 	AddPermissionsForRepositoryToRobotAccountFunc = func(organization, imageRepository, robotAccountName string, isWrite bool) error { return nil }
-	RegenerateRobotAccountTokenFunc = func(organization, robotName string) (*RobotAccount, error) { return &RobotAccount{}, nil }
+	RegenerateRobotAccountTokenFunc = func(organization, robotName string) (*RobotAccount, error) {
+		return newTestRobotAccount(organization, robotName), nil
+	}
+	GetAllRepositoriesFunc = func(organization string) ([]Repository, error) { return nil, nil }
+	ListRepositoriesFunc = func(ctx context.Context, organization string, opts ListRepositoriesOptions, visit RepositoriesPageVisitor) error {
+		return nil
+	}
+	GetRepositoriesMetadataFunc = func(ctx context.Context, organization string) ([]RepositoryMetadata, error) { return nil, nil }
+	GetAllRobotAccountsFunc = func(organization string) ([]RobotAccount, error) { return nil, nil }
+	GetRobotAccountPermissionsFunc = func(organization, robotName string) ([]RobotAccountPermission, error) { return nil, nil }
+	GetTagsFromPageFunc = func(organization, repository string, page int) ([]Tag, bool, error) { return nil, false, nil }
+	DeleteManifestByDigestFunc = func(organization, repository, manifestDigest string) ([]string, error) { return nil, nil }
 	GetNotificationsFunc = func(organization, repository string) ([]Notification, error) { return []Notification{}, nil }
 	CreateNotificationFunc = func(organization, repository string, notification Notification) (*Notification, error) {
 		return &Notification{}, nil
 	}
-
+	DeleteNotificationFunc = func(organization, repository, notificationUUID string) (bool, error) { return true, nil }
+	SetRepositoryUserPermissionFunc = func(organization, imageRepository, userName, role string) error { return nil }
+	GetRepositoryUserPermissionFunc = func(organization, imageRepository, userName string) (string, error) { return "", nil }
+	DeleteRepositoryUserPermissionFunc = func(organization, imageRepository, userName string) (bool, error) { return true, nil }
 }
 
 func ResetTestQuayClientToFails() {
@@ -74,6 +137,41 @@ func ResetTestQuayClientToFails() {
 		Fail("ChangeRepositoryVisibility invoked")
 		return nil
 	}
+	UpdateRepositoryDescriptionFunc = func(organization, imageRepository, description string) error {
+		defer GinkgoRecover()
+		Fail("UpdateRepositoryDescription invoked")
+		return nil
+	}
+	ChangeRepositoryStateFunc = func(organization, imageRepository, state string) error {
+		defer GinkgoRecover()
+		Fail("ChangeRepositoryState invoked")
+		return nil
+	}
+	GetRepositoryMirrorFunc = func(organization, imageRepository string) (*RepositoryMirror, error) {
+		defer GinkgoRecover()
+		Fail("GetRepositoryMirror invoked")
+		return nil, nil
+	}
+	CreateRepositoryMirrorFunc = func(organization, imageRepository string, mirror RepositoryMirror) error {
+		defer GinkgoRecover()
+		Fail("CreateRepositoryMirror invoked")
+		return nil
+	}
+	SyncRepositoryMirrorFunc = func(organization, imageRepository string) error {
+		defer GinkgoRecover()
+		Fail("SyncRepositoryMirror invoked")
+		return nil
+	}
+	ListManifestLabelsFunc = func(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error) {
+		defer GinkgoRecover()
+		Fail("ListManifestLabels invoked")
+		return nil, nil
+	}
+	AddManifestLabelFunc = func(organization, imageRepository, manifestDigest, key, value string) error {
+		defer GinkgoRecover()
+		Fail("AddManifestLabel invoked")
+		return nil
+	}
 	GetRobotAccountFunc = func(organization, robotName string) (*RobotAccount, error) {
 		defer GinkgoRecover()
 		Fail("GetRobotAccount invoked")
@@ -84,6 +182,10 @@ func ResetTestQuayClientToFails() {
 		Fail("CreateRobotAccount invoked")
 		return nil, nil
 	}
+	CreateRobotAccountWithDescriptionFunc = func(organization, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error) {
+		return CreateRobotAccountFunc(organization, robotName)
+	}
+	UpdateRobotAccountDescriptionFunc = func(organization, robotName string, robotAccountRequest RobotAccountRequest) error { return nil }
 	DeleteRobotAccountFunc = func(organization, robotName string) (bool, error) {
 		defer GinkgoRecover()
 		Fail("DeleteRobotAccount invoked")
@@ -94,6 +196,21 @@ func ResetTestQuayClientToFails() {
 		Fail("AddPermissionsForRepositoryToRobotAccount invoked")
 		return nil
 	}
+	SetRepositoryUserPermissionFunc = func(organization, imageRepository, userName, role string) error {
+		defer GinkgoRecover()
+		Fail("SetRepositoryUserPermission invoked")
+		return nil
+	}
+	GetRepositoryUserPermissionFunc = func(organization, imageRepository, userName string) (string, error) {
+		defer GinkgoRecover()
+		Fail("GetRepositoryUserPermission invoked")
+		return "", nil
+	}
+	DeleteRepositoryUserPermissionFunc = func(organization, imageRepository, userName string) (bool, error) {
+		defer GinkgoRecover()
+		Fail("DeleteRepositoryUserPermission invoked")
+		return true, nil
+	}
 	RegenerateRobotAccountTokenFunc = func(organization, robotName string) (*RobotAccount, error) {
 		defer GinkgoRecover()
 		Fail("RegenerateRobotAccountToken invoked")
@@ -109,6 +226,11 @@ func ResetTestQuayClientToFails() {
 		Fail("CreateNotification invoked")
 		return nil, nil
 	}
+	DeleteManifestByDigestFunc = func(organization, repository, manifestDigest string) ([]string, error) {
+		defer GinkgoRecover()
+		Fail("DeleteManifestByDigest invoked")
+		return nil, nil
+	}
 }
 
 func (c TestQuayClient) CreateRepository(repositoryRequest RepositoryRequest) (*Repository, error) {
@@ -117,35 +239,89 @@ func (c TestQuayClient) CreateRepository(repositoryRequest RepositoryRequest) (*
 func (c TestQuayClient) DeleteRepository(organization, imageRepository string) (bool, error) {
 	return DeleteRepositoryFunc(organization, imageRepository)
 }
+func (TestQuayClient) IsRepositoryPublic(organization, imageRepository string) (bool, error) {
+	return IsRepositoryPublicFunc(organization, imageRepository)
+}
+func (TestQuayClient) GetRepository(organization, imageRepository string) (*Repository, error) {
+	return GetRepositoryFunc(organization, imageRepository)
+}
 func (TestQuayClient) ChangeRepositoryVisibility(organization, imageRepository string, visibility string) error {
 	return ChangeRepositoryVisibilityFunc(organization, imageRepository, visibility)
 }
+func (TestQuayClient) UpdateRepositoryDescription(organization, imageRepository, description string) error {
+	return UpdateRepositoryDescriptionFunc(organization, imageRepository, description)
+}
+func (TestQuayClient) ChangeRepositoryState(organization, imageRepository, state string) error {
+	return ChangeRepositoryStateFunc(organization, imageRepository, state)
+}
+func (TestQuayClient) GetRepositoryMirror(organization, imageRepository string) (*RepositoryMirror, error) {
+	return GetRepositoryMirrorFunc(organization, imageRepository)
+}
+func (TestQuayClient) CreateRepositoryMirror(organization, imageRepository string, mirror RepositoryMirror) error {
+	return CreateRepositoryMirrorFunc(organization, imageRepository, mirror)
+}
+func (TestQuayClient) SyncRepositoryMirror(organization, imageRepository string) error {
+	return SyncRepositoryMirrorFunc(organization, imageRepository)
+}
+func (TestQuayClient) ListManifestLabels(organization, imageRepository, manifestDigest string) ([]ManifestLabel, error) {
+	return ListManifestLabelsFunc(organization, imageRepository, manifestDigest)
+}
+func (TestQuayClient) AddManifestLabel(organization, imageRepository, manifestDigest, key, value string) error {
+	return AddManifestLabelFunc(organization, imageRepository, manifestDigest, key, value)
+}
 func (c TestQuayClient) GetRobotAccount(organization string, robotName string) (*RobotAccount, error) {
 	return GetRobotAccountFunc(organization, robotName)
 }
 func (c TestQuayClient) CreateRobotAccount(organization string, robotName string) (*RobotAccount, error) {
 	return CreateRobotAccountFunc(organization, robotName)
 }
+func (c TestQuayClient) CreateRobotAccountWithDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) (*RobotAccount, error) {
+	return CreateRobotAccountWithDescriptionFunc(organization, robotName, robotAccountRequest)
+}
+func (c TestQuayClient) UpdateRobotAccountDescription(organization string, robotName string, robotAccountRequest RobotAccountRequest) error {
+	return UpdateRobotAccountDescriptionFunc(organization, robotName, robotAccountRequest)
+}
 func (c TestQuayClient) DeleteRobotAccount(organization string, robotName string) (bool, error) {
 	return DeleteRobotAccountFunc(organization, robotName)
 }
 func (c TestQuayClient) AddPermissionsForRepositoryToRobotAccount(organization, imageRepository, robotAccountName string, isWrite bool) error {
 	return AddPermissionsForRepositoryToRobotAccountFunc(organization, imageRepository, robotAccountName, isWrite)
 }
+func (c TestQuayClient) SetRepositoryUserPermission(organization, imageRepository, userName, role string) error {
+	return SetRepositoryUserPermissionFunc(organization, imageRepository, userName, role)
+}
+func (c TestQuayClient) GetRepositoryUserPermission(organization, imageRepository, userName string) (string, error) {
+	return GetRepositoryUserPermissionFunc(organization, imageRepository, userName)
+}
+func (c TestQuayClient) DeleteRepositoryUserPermission(organization, imageRepository, userName string) (bool, error) {
+	return DeleteRepositoryUserPermissionFunc(organization, imageRepository, userName)
+}
 func (c TestQuayClient) RegenerateRobotAccountToken(organization string, robotName string) (*RobotAccount, error) {
 	return RegenerateRobotAccountTokenFunc(organization, robotName)
 }
 func (c TestQuayClient) GetAllRepositories(organization string) ([]Repository, error) {
-	return nil, nil
+	return GetAllRepositoriesFunc(organization)
+}
+func (c TestQuayClient) ListRepositories(ctx context.Context, organization string, opts ListRepositoriesOptions, visit RepositoriesPageVisitor) error {
+	return ListRepositoriesFunc(ctx, organization, opts, visit)
+}
+func (c TestQuayClient) GetRepositoriesMetadata(ctx context.Context, organization string) ([]RepositoryMetadata, error) {
+	return GetRepositoriesMetadataFunc(ctx, organization)
 }
 func (c TestQuayClient) GetAllRobotAccounts(organization string) ([]RobotAccount, error) {
-	return nil, nil
+	return GetAllRobotAccountsFunc(organization)
+}
+func (c TestQuayClient) GetRobotAccountPermissions(organization, robotName string) ([]RobotAccountPermission, error) {
+	return GetRobotAccountPermissionsFunc(organization, robotName)
 }
 func (TestQuayClient) DeleteTag(organization string, repository string, tag string) (bool, error) {
 	return true, nil
 }
 func (TestQuayClient) GetTagsFromPage(organization string, repository string, page int) ([]Tag, bool, error) {
-	return nil, false, nil
+	return GetTagsFromPageFunc(organization, repository, page)
+}
+func (TestQuayClient) DeleteManifestByDigest(organization, repository, manifestDigest string) ([]string, error) {
+	return DeleteManifestByDigestFunc(organization, repository, manifestDigest)
 }
 func (TestQuayClient) GetNotifications(organization string, repository string) ([]Notification, error) {
 	return GetNotificationsFunc(organization, repository)
@@ -154,3 +330,57 @@ func (TestQuayClient) GetNotifications(organization string, repository string) (
 func (TestQuayClient) CreateNotification(organization, repository string, notification Notification) (*Notification, error) {
 	return CreateNotificationFunc(organization, repository, notification)
 }
+
+func (TestQuayClient) DeleteNotification(organization, repository, notificationUUID string) (bool, error) {
+	return DeleteNotificationFunc(organization, repository, notificationUUID)
+}
+
+func (TestQuayClient) ListOrganizationMembers(organization string) ([]OrganizationMember, error) {
+	return nil, nil
+}
+
+func (TestQuayClient) RemoveOrganizationMember(organization, memberName string) (bool, error) {
+	return true, nil
+}
+
+func (TestQuayClient) ListCollaborators(organization string) ([]Collaborator, error) {
+	return nil, nil
+}
+
+func (TestQuayClient) GetTeam(organization, teamName string) (*Team, error) {
+	return nil, nil
+}
+
+func (TestQuayClient) CreateTeam(organization, teamName, description string) error {
+	return nil
+}
+
+func (TestQuayClient) AddTeamMember(organization, teamName, memberName string) error {
+	return nil
+}
+
+func (TestQuayClient) GetOrganizationQuota(organization string) (*OrganizationQuota, error) {
+	return &OrganizationQuota{PrivateAllowed: true}, nil
+}
+
+func (TestQuayClient) GetOrganizationPlan(organization string) (*OrganizationPlan, error) {
+	return &OrganizationPlan{Plan: "free"}, nil
+}
+
+func (TestQuayClient) ListPermissionPrototypes(organization string) ([]PermissionPrototype, error) {
+	return nil, nil
+}
+
+func (TestQuayClient) CreatePermissionPrototype(organization string, prototype PermissionPrototype) (*PermissionPrototype, error) {
+	return &prototype, nil
+}
+
+func (TestQuayClient) DeletePermissionPrototype(organization, prototypeID string) (bool, error) {
+	return true, nil
+}
+
+func (TestQuayClient) SetCorrelationID(correlationID string) {
+}
+
+func (TestQuayClient) SetRequestObserver(observer func(operation string)) {
+}
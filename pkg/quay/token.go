@@ -0,0 +1,126 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quay
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+)
+
+// TokenProvider supplies the current Quay API token, so the token could be rotated without rebuilding clients.
+type TokenProvider interface {
+	Token() string
+}
+
+// FileTokenProvider keeps the Quay API token read from a file, e.g. a mounted Secret.
+// The token is read once and reloaded only when the file changes, see Start.
+type FileTokenProvider struct {
+	path string
+	log  logr.Logger
+
+	lock  sync.RWMutex
+	token string
+}
+
+// NewFileTokenProvider reads the token from the file.
+// Unreadable file is logged and leaves the token empty until the file appears.
+func NewFileTokenProvider(path string, log logr.Logger) *FileTokenProvider {
+	p := &FileTokenProvider{path: path, log: log}
+	if err := p.reload(); err != nil {
+		log.Error(err, "unable to read Quay token", "path", path)
+	}
+	return p
+}
+
+// Token returns the last successfully read token.
+func (p *FileTokenProvider) Token() string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.token
+}
+
+// Start watches the token file and reloads the token on each change until the context is done.
+// The parent directory is watched, because Kubernetes updates mounted Secrets by swapping a symlink.
+func (p *FileTokenProvider) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create Quay token watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(p.path)); err != nil {
+		return fmt.Errorf("failed to watch Quay token %s: %w", p.path, err)
+	}
+	// The file might have changed before the watch started
+	if err := p.reload(); err != nil {
+		p.log.Error(err, "unable to read Quay token", "path", p.path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			if err := p.reload(); err != nil {
+				// Keep the previous token, the file is likely being replaced
+				p.log.Error(err, "unable to reload Quay token, keeping the previous one", "path", p.path)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			p.log.Error(err, "Quay token watcher error", "path", p.path)
+		}
+	}
+}
+
+// NeedLeaderElection makes the token reloaded also by replicas that are not the leader.
+func (p *FileTokenProvider) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *FileTokenProvider) reload() error {
+	/* #nosec we are sure the input path is clean */
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return fmt.Errorf("token file %s is empty", p.path)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if token != p.token && p.token != "" {
+		p.log.Info("Reloaded rotated Quay token", "path", p.path)
+	}
+	p.token = token
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package quay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/h2non/gock"
+	"gotest.tools/v3/assert"
+)
+
+func TestFileTokenProvider(t *testing.T) {
+	tokenPath := filepath.Join(t.TempDir(), "quaytoken")
+	assert.NilError(t, os.WriteFile(tokenPath, []byte("first-token\n"), 0600))
+
+	tokenProvider := NewFileTokenProvider(tokenPath, logr.Discard())
+	assert.Equal(t, tokenProvider.Token(), "first-token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- tokenProvider.Start(ctx)
+	}()
+	defer func() {
+		cancel()
+		assert.NilError(t, <-done)
+	}()
+
+	// Empty file, e.g. while being rewritten, keeps the previous token
+	assert.NilError(t, os.WriteFile(tokenPath, []byte(""), 0600))
+	assert.NilError(t, os.WriteFile(tokenPath, []byte("second-token"), 0600))
+	deadline := time.Now().Add(5 * time.Second)
+	for tokenProvider.Token() != "second-token" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected rotated token to be reloaded, got %q", tokenProvider.Token())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQuayClient_TokenProvider(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	gock.InterceptClient(client)
+	defer gock.Off()
+
+	tokenPath := filepath.Join(t.TempDir(), "quaytoken")
+	assert.NilError(t, os.WriteFile(tokenPath, []byte("authtoken"), 0600))
+	quayClient := NewQuayClientWithTokenProvider(client, NewFileTokenProvider(tokenPath, logr.Discard()), testQuayApiUrl)
+
+	gock.New(testQuayApiUrl).
+		MatchHeader("Authorization", "Bearer authtoken").
+		Get(fmt.Sprintf("organization/%s/robots/%s", org, robotName)).
+		Reply(200).
+		JSON(map[string]string{"name": org + "+" + robotName, "token": "token"})
+
+	robotAccount, err := quayClient.GetRobotAccount(org, robotName)
+	assert.NilError(t, err)
+	assert.Equal(t, robotAccount.Token, "token")
+	assert.Assert(t, gock.IsDone())
+}